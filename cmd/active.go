@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/byterings/bgit/internal/config"
 	"github.com/byterings/bgit/internal/identity"
@@ -28,14 +29,9 @@ func runActive(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Load config
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
-	// Get effective identity (respects workspace/binding)
-	resolution, err := identity.GetEffectiveResolution(cfg)
+	// Get effective identity (respects workspace/binding). The resolution
+	// cache keeps this fast enough for shell prompts.
+	resolution, err := resolveActive()
 	if err != nil {
 		return fmt.Errorf("failed to resolve identity: %w", err)
 	}
@@ -49,23 +45,37 @@ func runActive(cmd *cobra.Command, args []string) error {
 	activeUser := resolution.User
 
 	// Show source of identity
-	sourceInfo := ""
-	switch resolution.Source {
-	case identity.SourceWorkspace:
-		sourceInfo = fmt.Sprintf(" (workspace: %s)", resolution.Path)
-	case identity.SourceBinding:
-		sourceInfo = " (bound repo)"
-	case identity.SourceGlobal:
-		sourceInfo = " (global)"
-	}
-
-	fmt.Printf("Active user: %s%s\n", resolution.Alias, sourceInfo)
+	fmt.Printf("Active user: %s %s\n", resolution.Alias, describeSource(resolution))
 	fmt.Printf("  Name: %s\n", activeUser.Name)
 	fmt.Printf("  Email: %s\n", activeUser.Email)
+	if activeUser.AuthorEmail != "" {
+		fmt.Printf("  Author email: %s\n", activeUser.AuthorEmail)
+	}
+	if activeUser.CommitterEmail != "" {
+		fmt.Printf("  Committer email: %s\n", activeUser.CommitterEmail)
+	}
 	fmt.Printf("  GitHub: %s\n", activeUser.GitHubUsername)
 	if activeUser.SSHKeyPath != "" {
 		fmt.Printf("  SSH Key: %s\n", activeUser.SSHKeyPath)
 	}
+	if activeUser.UsesIdentityAgent() {
+		fmt.Printf("  Agent: %s\n", activeUser.IdentityAgent)
+	}
 
 	return nil
 }
+
+// resolveActive resolves the identity for the working directory through the
+// resolution cache, falling back to the global active user if the working
+// directory is unavailable
+func resolveActive() (*identity.Resolution, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		return identity.GetEffectiveResolution(cfg)
+	}
+	return identity.ResolveCached(cwd)
+}
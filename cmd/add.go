@@ -1,22 +1,37 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/github"
 	"github.com/byterings/bgit/internal/platform"
 	"github.com/byterings/bgit/internal/ui"
 	"github.com/byterings/bgit/internal/user"
 )
 
 var (
-	addFlagAlias   string
-	addFlagName    string
-	addFlagEmail   string
-	addFlagGitHub  string
-	addFlagSSHKey  string
+	addFlagAlias      string
+	addFlagName       string
+	addFlagEmail      string
+	addFlagGitHub     string
+	addFlagSSHKey     string
+	addFlagAgent      string
+	addFlagKeyType    string
+	addFlagTemplate   string
+	addFlagTrailers   []string
+	addFlagTags       []string
+	addFlagAuthor     string
+	addFlagCommitter  string
+	addFlagFromGitHub string
+	addFlagAdoptKey   string
+	addFlagPreset     string
+	addFlagWorkspace  string
 )
 
 var addCmd = &cobra.Command{
@@ -27,7 +42,32 @@ var addCmd = &cobra.Command{
   bgit add
 
   # Using flags
-  bgit add --name "John Doe" --email "john@work.com" --github "john-work"`,
+  bgit add --name "John Doe" --email "john@work.com" --github "john-work"
+
+  # Prefill name and email from a GitHub profile
+  bgit add --from-github john-work
+
+  # Copy an existing key pair to ~/.ssh/bgit_john-work
+  bgit add --alias work --name "John Doe" --email "john@work.com" --github "john-work" \
+    --adopt-key ~/.ssh/id_ed25519
+
+  # Fill in a team preset (required key type, email domain, workspace, orgs)
+  bgit add --preset https://acme.example.com/bgit-preset.toml
+
+  # Key held by 1Password (the public key selects which agent key to offer)
+  bgit add --alias work --name "John Doe" --email "john@work.com" --github "john-work" \
+    --identity-agent ~/.1password/agent.sock --ssh-key ~/.ssh/work.pub
+
+  # Generate a FIDO2 resident key on a hardware security key
+  bgit add --alias work --name "John Doe" --email "john@work.com" --github "john-work" --key-type ed25519-sk
+
+  # Create ~/code/work and bind it to the new identity
+  bgit add --alias work --name "John Doe" --email "john@work.com" --github "john-work" \
+    --workspace ~/code/work
+
+  # Sign off every work commit
+  bgit add --alias work --name "John Doe" --email "john@work.com" --github "john-work" \
+    --trailer "Signed-off-by: John Doe <john@work.com>"`,
 	RunE: runAdd,
 }
 
@@ -39,6 +79,19 @@ func init() {
 	addCmd.Flags().StringVar(&addFlagEmail, "email", "", "Email address for Git commits")
 	addCmd.Flags().StringVar(&addFlagGitHub, "github", "", "GitHub username")
 	addCmd.Flags().StringVar(&addFlagSSHKey, "ssh-key", "", "Path to existing SSH private key")
+	addCmd.Flags().StringVar(&addFlagAgent, "identity-agent", "", "SSH agent socket holding the key (e.g. 1Password); --ssh-key may then be the public key")
+	addCmd.Flags().StringVar(&addFlagKeyType, "key-type", "", "Generate a new key of this type: ed25519, ed25519-sk, or ecdsa-sk (FIDO2 security key)")
+	addCmd.Flags().StringVar(&addFlagAdoptKey, "adopt-key", "", "Copy an existing key pair to ~/.ssh/bgit_<github-username> and use the copy")
+	addCmd.MarkFlagsMutuallyExclusive("key-type", "ssh-key", "adopt-key")
+	addCmd.Flags().StringVar(&addFlagTemplate, "commit-template", "", "Commit message template file used while this identity is active or bound")
+	addCmd.Flags().StringVar(&addFlagAuthor, "author-email", "", "Author email, if different from --email")
+	addCmd.Flags().StringVar(&addFlagCommitter, "committer-email", "", "Committer email, if different from --email (e.g. a corporate relay)")
+	addCmd.Flags().StringVar(&addFlagFromGitHub, "from-github", "", "Prefill name and email from this GitHub account's public profile")
+	addCmd.MarkFlagsMutuallyExclusive("from-github", "github")
+	addCmd.Flags().StringVar(&addFlagPreset, "preset", "", "Team preset file or URL setting the key type, email pattern, workspace, and organizations")
+	addCmd.Flags().StringArrayVar(&addFlagTrailers, "trailer", nil, "Trailer added to the commit template, e.g. \"Signed-off-by: Name <email>\" (repeatable)")
+	addCmd.Flags().StringVar(&addFlagWorkspace, "workspace", "", "Create this folder and bind it as the new identity's workspace")
+	addCmd.Flags().StringSliceVar(&addFlagTags, "tag", nil, "Tag for grouping identities, e.g. a client name (repeatable or comma-separated)")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
@@ -52,16 +105,75 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	var alias, name, email, githubUsername, sshKeyPath string
+	interactive := false
+
+	defaultName, defaultEmail, defaultGitHub := addFlagName, addFlagEmail, addFlagGitHub
+	if addFlagFromGitHub != "" {
+		profile, err := fetchGitHubProfile(addFlagFromGitHub)
+		if err != nil {
+			return err
+		}
+		defaultGitHub = profile.Login
+		if defaultName == "" {
+			defaultName = profile.Name
+			if defaultName == "" {
+				defaultName = profile.Login
+			}
+		}
+		if defaultEmail == "" {
+			defaultEmail = profile.CommitEmail()
+		}
+		ui.Success(fmt.Sprintf("Found GitHub user %s: %s <%s>", profile.Login, defaultName, defaultEmail))
+	}
+
+	var preset *config.Preset
+	defaultAlias := addFlagAlias
+	if addFlagPreset != "" {
+		preset, err = loadPreset(addFlagPreset)
+		if err != nil {
+			return err
+		}
+		if preset.Name != "" {
+			fmt.Printf("Preset: %s\n", preset.Name)
+		}
+		if preset.EmailPattern != "" {
+			fmt.Printf("  Email must match %s\n", preset.EmailPattern)
+		}
+		if preset.KeyType != "" {
+			fmt.Printf("  Key type: %s\n", preset.KeyType)
+		}
+		fmt.Println()
+		if defaultAlias == "" {
+			defaultAlias = preset.Alias
+		}
+		if err := applyPresetKeyType(preset); err != nil {
+			return err
+		}
+	}
 
-	if addFlagAlias == "" || addFlagName == "" || addFlagEmail == "" || addFlagGitHub == "" {
+	if addFlagFromGitHub != "" && addFlagAlias != "" {
+		// Flag mode, with anything not given taken from the profile
+		alias = addFlagAlias
+		name = defaultName
+		email = defaultEmail
+		githubUsername = defaultGitHub
+	} else if addFlagAlias == "" || addFlagName == "" || addFlagEmail == "" || addFlagGitHub == "" {
 		// Interactive mode
+		interactive = true
 		fmt.Println("Adding new user identity")
 		fmt.Println()
 
-		alias, name, email, githubUsername, err = ui.PromptUserInfo()
+		alias, name, email, githubUsername, err = ui.PromptUserInfoWithDefaults(defaultAlias, defaultName, defaultEmail, defaultGitHub)
 		if err != nil {
 			return fmt.Errorf("failed to get user info: %w", err)
 		}
+
+		// The username was verified above; check it again if it was edited
+		if addFlagFromGitHub != "" && !strings.EqualFold(githubUsername, defaultGitHub) {
+			if _, err := fetchGitHubProfile(githubUsername); err != nil {
+				return err
+			}
+		}
 	} else {
 		// Flag mode
 		alias = addFlagAlias
@@ -70,13 +182,45 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		githubUsername = addFlagGitHub
 	}
 
-	if addFlagSSHKey != "" && addFlagSSHKey != "skip" {
+	if preset != nil {
+		if !preset.MatchesEmail(email) {
+			return withExitCode(exitUsage, fmt.Errorf("email %s does not match the preset's pattern %s", email, preset.EmailPattern))
+		}
+		if len(addFlagTrailers) == 0 {
+			addFlagTrailers = preset.ExpandTrailers(name, email)
+		}
+	}
+
+	if addFlagAgent != "" {
+		warnMissingAgentSocket(addFlagAgent)
+	}
+	if addFlagTemplate != "" {
+		if err := validateCommitTemplate(addFlagTemplate); err != nil {
+			return err
+		}
+	}
+
+	if addFlagAdoptKey != "" {
+		if err := user.ValidateSSHKeyPath(addFlagAdoptKey); err != nil {
+			return err
+		}
+		sshKeyPath, _, err = user.AdoptKey(addFlagAdoptKey, githubUsername)
+		if err != nil {
+			return fmt.Errorf("failed to copy SSH key: %w", err)
+		}
+		ui.Success(fmt.Sprintf("Copied %s to %s", addFlagAdoptKey, sshKeyPath))
+	} else if addFlagSSHKey != "" && addFlagSSHKey != "skip" {
 		// Validate provided key path
 		if err := user.ValidateSSHKeyPath(addFlagSSHKey); err != nil {
 			return err
 		}
 		sshKeyPath = addFlagSSHKey
-	} else if addFlagSSHKey == "skip" {
+	} else if addFlagKeyType != "" {
+		sshKeyPath, err = generateKeyForAdd(githubUsername, addFlagKeyType)
+		if err != nil {
+			return err
+		}
+	} else if addFlagSSHKey == "skip" || addFlagAgent != "" {
 		// Skip SSH key setup when using flags
 		sshKeyPath = ""
 		ui.Info("Skipping SSH key setup")
@@ -87,27 +231,16 @@ func runAdd(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to get SSH key option: %w", err)
 		}
 
-		if strings.Contains(choice, "Generate new") {
-			// Generate new key using system ssh-keygen (more reliable)
-			privateKey, _, err := user.GenerateSSHKeySystem(githubUsername)
+		if strings.Contains(choice, "security key") {
+			sshKeyPath, err = generateKeyForAdd(githubUsername, "ed25519-sk")
 			if err != nil {
-				return fmt.Errorf("failed to generate SSH key: %w", err)
+				return err
 			}
-
-			sshKeyPath = privateKey
-			ui.Success(fmt.Sprintf("SSH key generated: %s", privateKey))
-
-			// Show public key content
-			pubKeyContent, err := user.GetPublicKeyContent(privateKey)
-			if err == nil {
-				fmt.Println("\n" + strings.Repeat("-", 70))
-				fmt.Println("Add this public key to your GitHub account:")
-				fmt.Println("https://github.com/settings/keys")
-				fmt.Println(strings.Repeat("-", 70))
-				fmt.Print(pubKeyContent)
-				fmt.Println(strings.Repeat("-", 70))
+		} else if strings.Contains(choice, "Generate new") {
+			sshKeyPath, err = generateKeyForAdd(githubUsername, "ed25519")
+			if err != nil {
+				return err
 			}
-
 		} else if strings.Contains(choice, "Import existing") {
 			// Import existing key
 			keyPath, err := ui.PromptExistingKeyPath()
@@ -133,26 +266,140 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if preset != nil && preset.KeyType != "" && sshKeyPath != "" {
+		if keyType, err := user.KeyType(sshKeyPath); err == nil && keyType != preset.KeyType {
+			return withExitCode(exitUsage, fmt.Errorf("the preset requires a %s key, but %s is %s", preset.KeyType, sshKeyPath, keyType))
+		}
+	}
+
+	if sshKeyPath != "" {
+		sshKeyPath, err = checkDuplicateKey(cfg, sshKeyPath, githubUsername, "")
+		if err != nil {
+			return err
+		}
+	}
+
 	newUser := config.User{
 		Alias:          alias,
 		Name:           name,
 		Email:          email,
 		GitHubUsername: githubUsername,
 		SSHKeyPath:     sshKeyPath,
+		IdentityAgent:  addFlagAgent,
+		CommitTemplate: addFlagTemplate,
+		Trailers:       addFlagTrailers,
+		AuthorEmail:    addFlagAuthor,
+		CommitterEmail: addFlagCommitter,
+		Tags:           addFlagTags,
 	}
 
 	if err := cfg.AddUser(newUser); err != nil {
+		if addFlagAdoptKey != "" && sshKeyPath != "" {
+			// Don't leave behind a copy no identity uses
+			os.Remove(sshKeyPath)
+			os.Remove(sshKeyPath + ".pub")
+		}
 		return fmt.Errorf("failed to add user: %w", err)
 	}
 
+	if preset != nil {
+		applyPresetLayout(cfg, preset, alias)
+	}
+
+	workspace := ""
+	if addFlagWorkspace != "" {
+		workspace = createIdentityWorkspace(cfg, addFlagWorkspace, alias)
+	} else if interactive && len(cfg.FindWorkspacesByUser(alias)) == 0 {
+		workspace, err = promptAddWorkspace(cfg, alias)
+		if err != nil {
+			return err
+		}
+	}
+
 	if err := config.SaveConfig(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
+	syncAllowedSigners(cfg)
 
 	fmt.Println()
 	ui.Success(fmt.Sprintf("User '%s' added successfully", alias))
 	fmt.Println()
-	fmt.Printf("Next: bgit use %s\n", alias)
+	if workspace != "" {
+		fmt.Printf("Next: clone into %s, or run 'bgit use %s' to make it the global identity\n", shortenPath(workspace), alias)
+	} else {
+		fmt.Printf("Next: bgit use %s\n", alias)
+	}
 
 	return nil
 }
+
+// promptAddWorkspace offers to create a workspace folder for a new identity,
+// defaulting to <current directory>/<alias> like 'bgit workspace' does, and
+// returns the workspace's path, or "" if none was added
+func promptAddWorkspace(cfg *config.Config, alias string) (string, error) {
+	fmt.Println()
+	create, err := ui.PromptConfirmation(fmt.Sprintf("Create a workspace folder for '%s'? Repos cloned into it use this identity", alias))
+	if err != nil || !create {
+		return "", err
+	}
+	defaultPath := alias
+	if cwd, err := os.Getwd(); err == nil {
+		defaultPath = filepath.Join(cwd, alias)
+	}
+	path, err := ui.PromptWorkspacePath(shortenPath(defaultPath))
+	if err != nil {
+		return "", err
+	}
+	return createIdentityWorkspace(cfg, path, alias), nil
+}
+
+// fetchGitHubProfile looks up a GitHub account, failing with exitNetwork if
+// GitHub can't be reached in time and exitUsage if the account doesn't exist
+func fetchGitHubProfile(username string) (*github.Profile, error) {
+	ui.Progress(fmt.Sprintf("Looking up %s on GitHub...", username))
+	ctx, cancel := networkContext()
+	defer cancel()
+	profile, err := github.FetchProfile(ctx, username)
+	ui.ClearProgress()
+	if err != nil {
+		if errors.Is(err, github.ErrUserNotFound) {
+			return nil, withExitCode(exitUsage, fmt.Errorf("GitHub user '%s' does not exist", username))
+		}
+		return nil, networkError(ctx, err)
+	}
+	return profile, nil
+}
+
+// generateKeyForAdd generates a key pair for a new identity and shows the
+// public key to add to GitHub
+func generateKeyForAdd(githubUsername, keyType string) (string, error) {
+	var privateKey string
+	var err error
+	switch {
+	case keyType == "ed25519":
+		// Generate new key using system ssh-keygen (more reliable)
+		privateKey, _, err = user.GenerateSSHKeySystem(githubUsername)
+	case user.IsSecurityKeyType(keyType):
+		privateKey, _, err = user.GenerateSecurityKey(githubUsername, keyType)
+	default:
+		return "", fmt.Errorf("unsupported key type '%s' (use ed25519, ed25519-sk, or ecdsa-sk)", keyType)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate SSH key: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("SSH key generated: %s", privateKey))
+
+	// Show public key content
+	pubKeyContent, err := user.GetPublicKeyContent(privateKey)
+	if err == nil {
+		fmt.Println("\n" + strings.Repeat("-", 70))
+		fmt.Println("Add this public key to your GitHub account:")
+		fmt.Println("https://github.com/settings/keys")
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Print(pubKeyContent)
+		fmt.Println(strings.Repeat("-", 70))
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/byterings/bgit/internal/user"
+	"github.com/spf13/cobra"
+)
+
+var applyDryRun bool
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Converge identities, workspaces, and rules to a spec file",
+	Long: `Read a declarative TOML spec and bring local state in line with it:
+identities are added or updated, missing SSH keys are generated, the SSH
+config is rewritten, workspace directories are created and registered, and
+rules are set. Running apply again with the same spec changes nothing, so it
+fits dotfiles repos and team onboarding scripts.
+
+apply only adds and updates; identities, workspaces, and rules that are not in
+the spec are left alone.
+
+Spec format:
+
+  [[identities]]
+  alias = "work"
+  name = "John Doe"
+  email = "john@work.com"
+  github_username = "john-work"
+  # ssh_key_path = "~/.ssh/id_work"   # default: ~/.ssh/bgit_<github_username>
+  # key_type = "ed25519"              # for generated keys; ed25519-sk, ecdsa-sk, or none
+
+  [[workspaces]]
+  path = "~/work"
+  user = "work"
+
+  [[rules]]
+  owner = "acme-corp"
+  user = "work"`,
+	Example: `  bgit apply ~/dotfiles/bgit.toml
+  bgit apply team.toml --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Show what would change without changing anything")
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	spec, err := config.LoadSpec(args[0])
+	if err != nil {
+		return withExitCode(exitUsage, err)
+	}
+
+	if err := autoInit(); err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if applyDryRun {
+		fmt.Println("Dry run - no changes will be made")
+		fmt.Println()
+	}
+
+	changes := 0
+	var updatedAliases []string
+	for _, id := range spec.Identities {
+		changed, err := applyIdentity(cfg, id)
+		if err != nil {
+			return fmt.Errorf("identity '%s': %w", id.Alias, err)
+		}
+		if changed {
+			changes++
+			updatedAliases = append(updatedAliases, id.Alias)
+		}
+	}
+	for _, ws := range spec.Workspaces {
+		changed, err := applyWorkspace(cfg, spec, ws)
+		if err != nil {
+			return fmt.Errorf("workspace %s: %w", ws.Path, err)
+		}
+		if changed {
+			changes++
+		}
+	}
+	for _, r := range spec.Rules {
+		changed, err := applyRule(cfg, spec, r)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", r.Owner, err)
+		}
+		if changed {
+			changes++
+		}
+	}
+
+	// Hosts for unchanged identities may still be missing from the SSH config;
+	// in a dry run, new identities aren't in cfg yet and are already counted
+	sshStale := len(updatedAliases) > 0
+	if !sshStale {
+		if current, proposed, err := ssh.PreviewManagedSection(cfg.Users); err == nil && current != proposed {
+			sshStale = true
+			changes++
+			if applyDryRun {
+				fmt.Println("  Would update the SSH config")
+			}
+		}
+	}
+
+	fmt.Println()
+	if changes == 0 {
+		ui.Success("Already up to date")
+		return nil
+	}
+	if applyDryRun {
+		fmt.Printf("%d change(s) would be made. Run without --dry-run to apply.\n", changes)
+		return nil
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if sshStale {
+		if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+			return fmt.Errorf("failed to update SSH config: %w", err)
+		}
+	}
+	if len(updatedAliases) > 0 {
+		syncAllowedSigners(cfg)
+
+		// Keep the global git config current if the active identity changed
+		if slices.Contains(updatedAliases, cfg.ActiveUser) {
+			if active := cfg.FindUserByAlias(cfg.ActiveUser); active != nil {
+				if err := setGlobalUser(active.Name, active.Email); err != nil {
+					ui.Warning(fmt.Sprintf("Could not update git config: %v", err))
+				}
+				if err := git.SetCommitEmails("", active.AuthorEmail, active.CommitterEmail); err != nil {
+					ui.Warning(fmt.Sprintf("Could not update git config: %v", err))
+				}
+				applyCommitTemplate(active, "")
+			}
+		}
+	}
+
+	ui.Success(fmt.Sprintf("Applied %d change(s) from %s", changes, args[0]))
+	return nil
+}
+
+// applyIdentity adds or updates one identity, generating its key if missing
+func applyIdentity(cfg *config.Config, id config.SpecIdentity) (bool, error) {
+	keyPath, generate, err := specKeyPath(id)
+	if err != nil {
+		return false, err
+	}
+
+	desired := config.User{
+		Alias:          id.Alias,
+		Name:           id.Name,
+		Email:          id.Email,
+		GitHubUsername: id.GitHubUsername,
+		SSHKeyPath:     keyPath,
+		IdentityAgent:  id.IdentityAgent,
+		CommitTemplate: id.CommitTemplate,
+		Trailers:       id.Trailers,
+		AuthorEmail:    id.AuthorEmail,
+		CommitterEmail: id.CommitterEmail,
+	}
+
+	existing := cfg.FindUserByAlias(id.Alias)
+	var fields []string
+	if existing != nil {
+		fields = changedUserFields(existing, &desired)
+		if len(fields) == 0 && !generate {
+			ui.Verbose(fmt.Sprintf("Identity '%s' is up to date", id.Alias))
+			return false, nil
+		}
+	}
+
+	if applyDryRun {
+		if generate {
+			fmt.Printf("  Would generate %s key %s\n", specKeyType(id), keyPath)
+		}
+		if existing == nil {
+			fmt.Printf("  Would add identity '%s' (%s)\n", id.Alias, id.Email)
+		} else if len(fields) > 0 {
+			fmt.Printf("  Would update identity '%s': %s\n", id.Alias, strings.Join(fields, ", "))
+		}
+		return true, nil
+	}
+
+	if generate {
+		generated, err := generateKeyForAdd(id.GitHubUsername, specKeyType(id))
+		if err != nil {
+			return false, err
+		}
+		desired.SSHKeyPath = generated
+	}
+
+	if existing == nil {
+		if err := cfg.AddUser(desired); err != nil {
+			return false, err
+		}
+		ui.Success(fmt.Sprintf("Added identity '%s' (%s)", id.Alias, id.Email))
+		return true, nil
+	}
+
+	if len(fields) > 0 {
+		for _, u := range cfg.Users {
+			if u.Alias == id.Alias {
+				continue
+			}
+			if u.Email == desired.Email || u.GitHubUsername == desired.GitHubUsername {
+				return false, fmt.Errorf("email or GitHub username is already used by '%s'", u.Alias)
+			}
+		}
+		desired.LastUsed = existing.LastUsed
+		*existing = desired
+		ui.Success(fmt.Sprintf("Updated identity '%s': %s", id.Alias, strings.Join(fields, ", ")))
+	}
+	return true, nil
+}
+
+// specKeyPath returns the key path an identity should use and whether the
+// key still has to be generated
+func specKeyPath(id config.SpecIdentity) (string, bool, error) {
+	if id.SSHKeyPath != "" {
+		if err := user.ValidateSSHKeyPath(id.SSHKeyPath); err != nil {
+			return "", false, err
+		}
+		return id.SSHKeyPath, false, nil
+	}
+	if id.IdentityAgent != "" || id.KeyType == "none" {
+		return "", false, nil
+	}
+
+	keyType := specKeyType(id)
+	if keyType != "ed25519" && !user.IsSecurityKeyType(keyType) {
+		return "", false, fmt.Errorf("unsupported key_type '%s' (use ed25519, ed25519-sk, ecdsa-sk, or none)", keyType)
+	}
+	sshDir, err := platform.GetSSHDir()
+	if err != nil {
+		return "", false, err
+	}
+	keyPath := filepath.Join(sshDir, fmt.Sprintf("bgit_%s", id.GitHubUsername))
+	_, err = os.Stat(keyPath)
+	return keyPath, os.IsNotExist(err), nil
+}
+
+// specKeyType returns the type of key generated for an identity
+func specKeyType(id config.SpecIdentity) string {
+	if id.KeyType == "" {
+		return "ed25519"
+	}
+	return id.KeyType
+}
+
+// changedUserFields lists the spec-managed fields that differ between two users
+func changedUserFields(current, desired *config.User) []string {
+	var fields []string
+	for _, f := range []struct {
+		name      string
+		old, want string
+	}{
+		{"name", current.Name, desired.Name},
+		{"email", current.Email, desired.Email},
+		{"github_username", current.GitHubUsername, desired.GitHubUsername},
+		{"ssh_key_path", current.SSHKeyPath, desired.SSHKeyPath},
+		{"identity_agent", current.IdentityAgent, desired.IdentityAgent},
+		{"commit_template", current.CommitTemplate, desired.CommitTemplate},
+		{"author_email", current.AuthorEmail, desired.AuthorEmail},
+		{"committer_email", current.CommitterEmail, desired.CommitterEmail},
+	} {
+		if f.old != f.want {
+			fields = append(fields, f.name)
+		}
+	}
+	if !slices.Equal(current.Trailers, desired.Trailers) {
+		fields = append(fields, "trailers")
+	}
+	return fields
+}
+
+// applyWorkspace creates a workspace directory and registers it for its user
+func applyWorkspace(cfg *config.Config, spec *config.Spec, ws config.Workspace) (bool, error) {
+	if !specHasUser(cfg, spec, ws.User) {
+		return false, fmt.Errorf("user '%s' not found", ws.User)
+	}
+	path, err := absWorkspacePath(ws.Path)
+	if err != nil {
+		return false, err
+	}
+
+	var existing *config.Workspace
+	if found := cfg.FindWorkspaceByPath(path); found != nil && platform.SamePath(found.Path, path) {
+		existing = found
+	}
+	_, statErr := os.Stat(path)
+	missingDir := os.IsNotExist(statErr)
+	sameSettings := existing != nil && existing.AutoFixRemotes == ws.AutoFixRemotes &&
+		existing.RequireSigning == ws.RequireSigning && existing.CloneProtocol == ws.CloneProtocol
+	if existing != nil && existing.User == ws.User && sameSettings && !missingDir {
+		ui.Verbose(fmt.Sprintf("Workspace %s is up to date", path))
+		return false, nil
+	}
+
+	if applyDryRun {
+		if missingDir {
+			fmt.Printf("  Would create %s\n", path)
+		}
+		switch {
+		case existing == nil:
+			ui.Printf("  Would add workspace %s/**  →  %s\n", path, ws.User)
+		case existing.User != ws.User:
+			fmt.Printf("  Would change workspace %s from %s to %s\n", path, existing.User, ws.User)
+		}
+		if !sameSettings && (existing != nil || len(ws.Settings()) > 0) {
+			fmt.Printf("  Would set workspace %s settings: %s\n", path, describeWorkspaceSettings(ws))
+		}
+		return true, nil
+	}
+
+	if missingDir {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return false, fmt.Errorf("failed to create directory: %w", err)
+		}
+		ui.Success(fmt.Sprintf("Created %s", path))
+	}
+	hadWorkspace := existing != nil
+	switch {
+	case existing == nil:
+		if err := cfg.AddWorkspace(path, ws.User); err != nil {
+			return false, err
+		}
+		existing = &cfg.Workspaces[len(cfg.Workspaces)-1]
+		ui.Success(fmt.Sprintf("Workspace added: %s/**  →  %s", path, ws.User))
+	case existing.User != ws.User:
+		ui.Success(fmt.Sprintf("Workspace %s changed from %s to %s", path, existing.User, ws.User))
+		existing.User = ws.User
+	}
+	if !sameSettings {
+		existing.AutoFixRemotes = ws.AutoFixRemotes
+		existing.RequireSigning = ws.RequireSigning
+		existing.CloneProtocol = ws.CloneProtocol
+		if hadWorkspace || len(ws.Settings()) > 0 {
+			ui.Success(fmt.Sprintf("Workspace %s settings: %s", path, describeWorkspaceSettings(ws)))
+		}
+	}
+	return true, nil
+}
+
+// applyRule maps a GitHub owner to its user
+func applyRule(cfg *config.Config, spec *config.Spec, r config.Rule) (bool, error) {
+	if !specHasUser(cfg, spec, r.User) {
+		return false, fmt.Errorf("user '%s' not found", r.User)
+	}
+	if existing := cfg.FindRuleByOwner(r.Owner); existing != nil && existing.User == r.User {
+		ui.Verbose(fmt.Sprintf("Rule %s is up to date", r.Owner))
+		return false, nil
+	}
+
+	if applyDryRun {
+		ui.Printf("  Would set rule %s  →  %s\n", r.Owner, r.User)
+		return true, nil
+	}
+	if err := cfg.AddRule(r.Owner, r.User); err != nil {
+		return false, err
+	}
+	ui.Success(fmt.Sprintf("Rule set: %s  →  %s", r.Owner, r.User))
+	return true, nil
+}
+
+// specHasUser reports whether an alias is configured or, in a dry run that
+// hasn't added them yet, declared in the spec
+func specHasUser(cfg *config.Config, spec *config.Spec, alias string) bool {
+	if cfg.FindUserByAlias(alias) != nil {
+		return true
+	}
+	for _, id := range spec.Identities {
+		if id.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
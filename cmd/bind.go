@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/history"
 	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/platform"
 	"github.com/byterings/bgit/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -15,12 +17,14 @@ var (
 	bindUser   string
 	bindForce  bool
 	bindRemove bool
+	bindList   bool
+	bindPrune  bool
 )
 
 var bindCmd = &cobra.Command{
-	Use:   "bind",
-	Short: "Bind current repository to an identity",
-	Long: `Bind the current repository to a specific identity.
+	Use:   "bind [path]",
+	Short: "Bind a repository to an identity",
+	Long: `Bind the current repository (or the repository at path) to a specific identity.
 
 The binding persists regardless of the global active user. When you work in a bound
 repository, bgit commands will use the bound identity.
@@ -28,8 +32,13 @@ repository, bgit commands will use the bound identity.
 Examples:
   bgit bind                  # Bind to current active user
   bgit bind --user work      # Bind to specific user
+  bgit bind ~/src/api -u work # Bind a repository by path
   bgit bind --force          # Override existing binding
-  bgit bind --remove         # Remove binding`,
+  bgit bind --remove         # Remove binding
+  bgit bind --list           # List all bindings
+  bgit bind --list -u work   # List bindings for one user
+  bgit bind --prune          # Drop bindings whose repos no longer exist`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runBind,
 }
 
@@ -38,6 +47,8 @@ func init() {
 	bindCmd.Flags().StringVarP(&bindUser, "user", "u", "", "User alias to bind to (default: active user)")
 	bindCmd.Flags().BoolVarP(&bindForce, "force", "f", false, "Override existing binding")
 	bindCmd.Flags().BoolVarP(&bindRemove, "remove", "r", false, "Remove binding for current repository")
+	bindCmd.Flags().BoolVarP(&bindList, "list", "l", false, "List bindings (filter with --user)")
+	bindCmd.Flags().BoolVar(&bindPrune, "prune", false, "Remove bindings whose repositories no longer exist")
 }
 
 func runBind(cmd *cobra.Command, args []string) error {
@@ -46,14 +57,37 @@ func runBind(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+	if bindList {
+		return listBindings(cfg, bindUser)
 	}
 
-	repoRoot := identity.FindGitRoot(cwd)
-	if repoRoot == "" {
-		return fmt.Errorf("not in a git repository. Run this command from inside a git repo.")
+	if bindPrune {
+		return pruneBindings(cfg)
+	}
+
+	var repoRoot string
+	if len(args) == 1 {
+		target, err := platform.ExpandTilde(args[0])
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(target); err != nil {
+			return fmt.Errorf("path does not exist: %s", target)
+		}
+		repoRoot = identity.FindGitRoot(target)
+		if repoRoot == "" {
+			return fmt.Errorf("%s is not inside a git repository", target)
+		}
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		repoRoot = identity.FindGitRoot(cwd)
+		if repoRoot == "" {
+			return fmt.Errorf("not in a git repository. Run this command from inside a git repo.")
+		}
 	}
 
 	repoRoot, err = filepath.Abs(repoRoot)
@@ -61,6 +95,12 @@ func runBind(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
+	// Bindings belong to the main repository so every linked worktree shares them
+	if mainRoot := identity.MainRepoRoot(repoRoot); mainRoot != repoRoot {
+		ui.Info(fmt.Sprintf("Linked worktree of %s; using the main repository's binding", mainRoot))
+		repoRoot = mainRoot
+	}
+
 	if bindRemove {
 		return removeBind(cfg, repoRoot)
 	}
@@ -105,10 +145,16 @@ func runBind(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to add binding: %w", err)
 	}
 
+	undo := beginUndo("bind", fmt.Sprintf("bind %s to %s", shortenPath(repoRoot), userAlias), repoRoot)
 	if err := config.SaveConfig(cfg); err != nil {
+		undo.Discard()
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
+	recordHistory(history.ActionBind, userAlias, repoRoot, "")
+	applyCommitTemplate(user, repoRoot)
+	commitUndo(undo)
+
 	ui.Success(fmt.Sprintf("Bound repository to '%s' (%s)", userAlias, user.GitHubUsername))
 	fmt.Printf("  Path: %s\n", repoRoot)
 	fmt.Printf("  Email: %s\n", user.Email)
@@ -124,14 +170,80 @@ func removeBind(cfg *config.Config, repoRoot string) error {
 	}
 
 	previousUser := binding.User
+	if cfg.IsSystemBinding(repoRoot) {
+		return config.ErrSystemEntry("binding", shortenPath(repoRoot))
+	}
 
 	if cfg.RemoveBinding(repoRoot) {
+		undo := beginUndo("unbind", fmt.Sprintf("unbind %s from %s", shortenPath(repoRoot), previousUser), repoRoot)
 		if err := config.SaveConfig(cfg); err != nil {
+			undo.Discard()
 			return fmt.Errorf("failed to save config: %w", err)
 		}
+		recordHistory(history.ActionUnbind, previousUser, repoRoot, "")
+		clearCommitTemplate(repoRoot)
+		commitUndo(undo)
 		ui.Success(fmt.Sprintf("Removed binding for '%s'", previousUser))
 		ui.Info("Repository will now use workspace identity (if inside one) or global active user.")
 	}
 
 	return nil
 }
+
+func listBindings(cfg *config.Config, userAlias string) error {
+	if userAlias != "" && cfg.FindUserByAlias(userAlias) == nil {
+		return fmt.Errorf("user '%s' not found", userAlias)
+	}
+
+	var bindings []config.Binding
+	for _, b := range cfg.GetBindings() {
+		if userAlias == "" || b.User == userAlias {
+			bindings = append(bindings, b)
+		}
+	}
+
+	if len(bindings) == 0 {
+		fmt.Println("No bindings configured.")
+		fmt.Println("\nBind a repository with: bgit bind")
+		return nil
+	}
+
+	fmt.Println("\nBound repositories:")
+	fmt.Println()
+
+	missing := 0
+	for _, b := range bindings {
+		status := "✓"
+		if !config.BindingExists(b) {
+			status = "✗ (missing)"
+			missing++
+		}
+		ui.Printf("  %s %-20s → %s\n", status, b.User, b.Path)
+	}
+
+	fmt.Println()
+	if missing > 0 {
+		ui.Info(fmt.Sprintf("%d binding(s) point to missing repositories. Run: bgit bind --prune", missing))
+	}
+	return nil
+}
+
+func pruneBindings(cfg *config.Config) error {
+	removed := cfg.PruneBindings()
+	if len(removed) == 0 {
+		ui.Info("All bindings point to existing repositories. Nothing to prune.")
+		return nil
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	for _, b := range removed {
+		ui.Success(fmt.Sprintf("Removed binding: %s → %s", b.Path, b.User))
+	}
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Pruned %d binding(s)", len(removed)))
+
+	return nil
+}
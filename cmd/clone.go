@@ -2,15 +2,21 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"regexp"
-	"runtime"
 	"strings"
+	"time"
 
+	"github.com/byterings/bgit/internal/agent"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/hooks"
 	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/remote"
+	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +26,22 @@ var cloneCmd = &cobra.Command{
 	Long: `Clone a GitHub repository using the active user's SSH configuration.
 
 Accepts any GitHub URL format (HTTPS or SSH) and automatically converts it
-to use the correct SSH host alias for the active user.`,
+to use the correct SSH host alias for the active user.
+
+With --https, it clones over HTTPS instead, authenticating as the identity's
+GitHub account through 'bgit credential' (see 'bgit remote fix --https'), for
+environments where SSH is unavailable. Inside a workspace with
+clone_protocol = "https" this is the default; pass --https=false for SSH.
+
+--no-agent skips loading the identity's key into the SSH agent. With
+--no-ssh-config, bgit expects your own SSH config to define the
+github.com-<username> host it clones through, and warns if it doesn't.
+
+When a clone over SSH fails, bgit explains why (the network blocks port 22,
+the agent holds no key, GitHub doesn't know the key) and offers to retry
+through ssh.github.com:443 or over HTTPS with the identity's token. A
+repository cloned through port 443 keeps using it. --fallback retries without
+asking.`,
 	Example: `  # Clone using HTTPS URL
   bgit clone https://github.com/user/repo.git
 
@@ -28,13 +49,38 @@ to use the correct SSH host alias for the active user.`,
   bgit clone git@github.com:user/repo.git
 
   # Clone to specific directory
-  bgit clone https://github.com/user/repo.git my-folder`,
+  bgit clone https://github.com/user/repo.git my-folder
+
+  # Clone over HTTPS with the identity's token
+  bgit clone --https https://github.com/user/repo.git
+
+  # Clone and install the identity-check hook
+  bgit clone --hook git@github.com:user/repo.git
+
+  # Retry through port 443 or HTTPS if SSH is blocked
+  bgit clone --fallback git@github.com:user/repo.git
+
+  # Leave the SSH agent and config to you
+  bgit clone --no-agent --no-ssh-config git@github.com:user/repo.git`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: runClone,
 }
 
+var (
+	cloneHook         bool
+	cloneHTTPS        bool
+	cloneNoAgent      bool
+	cloneNoSSHConfig  bool
+	cloneFallbackAuto bool
+)
+
 func init() {
 	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().BoolVar(&cloneHook, "hook", false, "Install the bgit post-checkout identity hook in the cloned repository")
+	cloneCmd.Flags().BoolVar(&cloneHTTPS, "https", false, "Clone over HTTPS using the identity's token via bgit's credential helper")
+	cloneCmd.Flags().BoolVar(&cloneNoAgent, "no-agent", false, "Don't load the identity's key into the SSH agent")
+	cloneCmd.Flags().BoolVar(&cloneNoSSHConfig, "no-ssh-config", false, "Rely on your own SSH config for the github.com-<username> host")
+	cloneCmd.Flags().BoolVar(&cloneFallbackAuto, "fallback", false, "If SSH fails, retry through ssh.github.com:443 or HTTPS without asking")
 }
 
 func runClone(cmd *cobra.Command, args []string) error {
@@ -55,8 +101,16 @@ func runClone(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Resolve effective identity (workspace > binding > global)
+	// Resolve effective identity (workspace > binding > rule > global)
 	resolution, err := identity.GetEffectiveResolution(cfg)
+
+	// Owner rules for the URL being cloned apply when no workspace or binding does
+	if err != nil || resolution == nil || resolution.Source == identity.SourceGlobal || resolution.Source == identity.SourceRule {
+		if ruleResolution := identity.ResolveRule(cfg, url); ruleResolution != nil {
+			resolution, err = ruleResolution, nil
+		}
+	}
+
 	if err != nil || resolution == nil || resolution.User == nil {
 		// Fall back to checking global active user
 		if cfg.ActiveUser == "" {
@@ -76,76 +130,261 @@ func runClone(cmd *cobra.Command, args []string) error {
 
 	// Show identity source if not global
 	if resolution.Source != identity.SourceGlobal {
-		sourceInfo := ""
-		switch resolution.Source {
-		case identity.SourceWorkspace:
-			sourceInfo = fmt.Sprintf(" (workspace: %s)", resolution.Path)
-		case identity.SourceBinding:
-			sourceInfo = " (bound repo)"
+		ui.Info(fmt.Sprintf("Using identity from %s %s", resolution.Source, describeSource(resolution)))
+	}
+
+	// A workspace can default the protocol; --https or --https=false overrides it
+	useHTTPS := cloneHTTPS
+	if !cmd.Flags().Changed("https") {
+		if cwd, err := os.Getwd(); err == nil {
+			if ws := cfg.FindWorkspaceByPath(cwd); ws != nil && ws.CloneProtocol != "" {
+				if err := ws.ValidateSettings(); err != nil {
+					return withExitCode(exitUsage, err)
+				}
+				useHTTPS = ws.CloneProtocol == config.CloneProtocolHTTPS
+				ui.Verbose(fmt.Sprintf("Workspace %s clones over %s", ws.Path, ws.CloneProtocol))
+			}
 		}
-		ui.Info(fmt.Sprintf("Using identity from %s%s", resolution.Source, sourceInfo))
 	}
 
-	// Check if SSH key is configured
-	if activeUser.SSHKeyPath == "" {
-		ui.Warning("No SSH key configured for this user")
-		fmt.Println("Clone may fail. Run: bgit update " + activeUser.Alias + " --ssh-key <path>")
-		fmt.Println()
+	var attempt cloneAttempt
+	if useHTTPS {
+		attempt, err = httpsCloneAttempt(activeUser, url)
+		if err != nil {
+			return err
+		}
 	} else {
-		// Ensure SSH agent has the key loaded
-		ensureSSHAgentForClone(activeUser)
+		// Check if SSH key is configured
+		if !activeUser.HasSSHHost() {
+			ui.Warning("No SSH key configured for this user")
+			fmt.Println("Clone may fail. Run: bgit update " + activeUser.Alias + " --ssh-key <path>")
+			fmt.Println()
+		} else if !activeUser.UsesIdentityAgent() && !platform.IsCI() && !cloneNoAgent {
+			// Ensure SSH agent has the key loaded
+			ensureSSHAgentForClone(activeUser)
+		}
+		if cloneNoSSHConfig {
+			warnUndefinedSSHHost(ssh.GetHostForUser(activeUser.GitHubUsername))
+		}
+
+		// Convert URL to bgit format (uses GitHub username for SSH host)
+		convertedURL, err := convertToBgitURL(url, activeUser.GitHubUsername)
+		if err != nil {
+			return err
+		}
+		attempt = cloneAttempt{url: convertedURL}
 	}
 
-	// Convert URL to bgit format (uses GitHub username for SSH host)
-	convertedURL, err := convertToBgitURL(url, activeUser.GitHubUsername)
+	fmt.Printf("Cloning as: %s\n", activeUser.Alias)
+	fmt.Printf("URL: %s\n\n", attempt.url)
+
+	start := time.Now()
+	stats, messages, err := runGitClone(attempt.gitArgs(directory))
+	if err != nil && !attempt.https {
+		attempt, stats, err = cloneFallback(activeUser, url, directory, messages, err)
+	}
 	if err != nil {
-		return err
+		// Almost always GitHub being unreachable or rejecting the key
+		return withExitCode(exitNetwork, fmt.Errorf("git clone failed: %w", err))
 	}
 
-	fmt.Printf("Cloning as: %s\n", activeUser.Alias)
-	fmt.Printf("URL: %s\n\n", convertedURL)
+	cloneDir := directory
+	if cloneDir == "" {
+		if parsed, err := remote.Parse(attempt.url); err == nil {
+			cloneDir = parsed.Repo
+		}
+	}
 
-	// Build git clone command
-	gitArgs := []string{"clone", convertedURL}
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Cloned into %s/ %s", cloneDir, stats.summary(time.Since(start))))
+
+	if attempt.sshCommand != "" {
+		// Fetch and push need the same route as the clone
+		if err := git.SetSSHCommand(cloneDir, attempt.sshCommand); err != nil {
+			ui.Warning(fmt.Sprintf("Failed to set core.sshCommand: %v", err))
+		} else {
+			ui.Success(fmt.Sprintf("This repository connects through %s:%s", ssh.GitHubSSHOver443Host, ssh.GitHubSSHOver443Port))
+		}
+	}
+
+	if attempt.https {
+		// Keep using the identity's credentials for fetch and push
+		if err := git.SetCredentialHelper(cloneDir, credentialHelperCommand(activeUser.Alias), activeUser.GitHubUsername); err != nil {
+			ui.Warning(fmt.Sprintf("Failed to configure credential helper: %v", err))
+		} else {
+			ui.Success(fmt.Sprintf("HTTPS credentials set to %s via bgit", activeUser.GitHubUsername))
+		}
+	}
+
+	if cloneHook {
+		if err := hooks.Install(cloneDir, hooks.PostCheckout, false); err != nil {
+			ui.Warning(fmt.Sprintf("Failed to install post-checkout hook: %v", err))
+		} else {
+			ui.Success("Installed post-checkout identity hook")
+		}
+	}
+
+	return nil
+}
+
+// cloneStats is what git reported about a clone's transfer
+type cloneStats struct {
+	objects int
+	size    string // e.g. "12.40 MiB"
+}
+
+// summary describes the transfer for the final message, e.g.
+// "(1234 objects, 12.40 MiB, 3.2s)"
+func (s cloneStats) summary(elapsed time.Duration) string {
+	var parts []string
+	if s.objects > 0 {
+		parts = append(parts, fmt.Sprintf("%d objects", s.objects))
+	}
+	if s.size != "" {
+		parts = append(parts, s.size)
+	}
+	parts = append(parts, elapsed.Round(100*time.Millisecond).String())
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// cloneAttempt is one way of running a clone
+type cloneAttempt struct {
+	url        string
+	config     []string // -c options for git
+	https      bool     // authenticates through bgit's credential helper
+	sshCommand string   // core.sshCommand for the clone, kept in the new repository
+}
+
+// gitArgs returns the arguments of git clone for the attempt
+func (a cloneAttempt) gitArgs(directory string) []string {
+	args := append([]string{}, a.config...)
+	if a.sshCommand != "" {
+		args = append(args, "-c", "core.sshCommand="+a.sshCommand)
+	}
+	args = append(args, "clone", a.url)
 	if directory != "" {
-		gitArgs = append(gitArgs, directory)
+		args = append(args, directory)
+	}
+	return args
+}
+
+// httpsCloneAttempt clones url over HTTPS as user's GitHub account
+func httpsCloneAttempt(user *config.User, url string) (cloneAttempt, error) {
+	parsed, err := remote.Parse(url)
+	if err != nil {
+		return cloneAttempt{}, fmt.Errorf("unrecognized URL format: %s\nExpected GitHub HTTPS or SSH URL", url)
+	}
+	if token, _ := userpkg.LoadToken(user.GitHubUsername); token == "" {
+		ui.Info(fmt.Sprintf("No saved token for %s; enter a personal access token as the password and bgit will save it", user.GitHubUsername))
+	}
+	return cloneAttempt{
+		url: parsed.HTTPSURL(),
+		// Only bgit's helper answers during the clone, as the identity's account
+		config: []string{
+			"-c", "credential.https://github.com.helper=",
+			"-c", "credential.https://github.com.helper=" + credentialHelperCommand(user.Alias),
+			"-c", "credential.https://github.com.username=" + user.GitHubUsername,
+		},
+		https: true,
+	}, nil
+}
+
+// runGitClone runs git clone, which takes as long as the transfer takes and
+// can be interrupted, and returns git's messages other than progress for
+// diagnosing a failure. On a terminal git's progress is drawn as one bar that
+// replaces itself per phase; git's other messages are passed through. With
+// --verbose, or when stderr isn't a terminal, git's output is passed through
+// unchanged.
+func runGitClone(gitArgs []string) (cloneStats, []string, error) {
+	var stats cloneStats
+	var messages []string
+	if ui.IsVerbose() || !ui.ShowsProgress() {
+		if ui.GetLevel() == ui.LevelQuiet {
+			gitArgs = append(gitArgs, "--quiet")
+		} else if ui.ShowsProgress() {
+			// git only draws progress on its own terminal
+			gitArgs = append(gitArgs, "--progress")
+		}
+		capture := git.NewProgressWriter(func(git.Progress) {}, func(line string) {
+			messages = append(messages, line)
+		})
+		gitCmd := ui.Command("git", gitArgs...).WithTimeout(0)
+		gitCmd.Stdout = os.Stdout
+		gitCmd.Stderr = io.MultiWriter(os.Stderr, capture)
+		gitCmd.Stdin = os.Stdin
+		err := gitCmd.Run()
+		capture.Flush()
+		return stats, messages, err
 	}
 
-	// Execute git clone
-	gitCmd := exec.Command("git", gitArgs...)
+	progress := git.NewProgressWriter(func(p git.Progress) {
+		if p.Phase == "Receiving objects" {
+			stats.objects = p.Total
+			if size, _, ok := strings.Cut(p.Detail, "|"); ok {
+				stats.size = strings.TrimSpace(size)
+			}
+		}
+		ui.Progress(formatCloneProgress(p))
+	}, func(line string) {
+		messages = append(messages, line)
+		// git announces the directory and the server its pack statistics;
+		// the summary covers both
+		if strings.HasPrefix(line, "Cloning into") || strings.HasPrefix(line, "remote: Total ") {
+			return
+		}
+		ui.ClearProgress()
+		fmt.Fprintln(os.Stderr, line)
+	})
+
+	gitCmd := ui.Command("git", append(gitArgs, "--progress")...).WithTimeout(0)
 	gitCmd.Stdout = os.Stdout
-	gitCmd.Stderr = os.Stderr
+	gitCmd.Stderr = progress
 	gitCmd.Stdin = os.Stdin
+	err := gitCmd.Run()
+	progress.Flush()
+	ui.ClearProgress()
+	return stats, messages, err
+}
 
-	if err := gitCmd.Run(); err != nil {
-		return fmt.Errorf("git clone failed: %w", err)
+// formatCloneProgress renders one progress update of a clone, e.g.
+// "Receiving objects  [██████░░░░░░░░░░░░░░]  30%  1.20 MiB | 3.40 MiB/s"
+func formatCloneProgress(p git.Progress) string {
+	if p.Percent < 0 {
+		return fmt.Sprintf("%-20s %d", p.Phase, p.Current)
 	}
+	line := fmt.Sprintf("%-20s %s %3d%%", p.Phase, ui.ProgressBar(p.Percent, 20), p.Percent)
+	if p.Detail != "" {
+		line += "  " + p.Detail
+	}
+	return line
+}
 
-	fmt.Println()
-	ui.Success("Repository cloned successfully!")
-
-	return nil
+// warnUndefinedSSHHost warns when the user's SSH config doesn't point a
+// bgit host alias at GitHub, which a clone through it needs
+func warnUndefinedSSHHost(host string) {
+	effective, err := ssh.GetEffectiveHost(host)
+	if err != nil || effective.HostName == "github.com" {
+		return
+	}
+	ui.Warning(fmt.Sprintf("Your SSH config has no '%s' host; the clone will likely fail", host))
+	fmt.Printf("Add a 'Host %s' entry with 'HostName github.com', or run 'bgit sync' to let bgit write it\n\n", host)
 }
 
 // ensureSSHAgentForClone ensures SSH key is loaded for cloning
 func ensureSSHAgentForClone(user *config.User) {
-	if runtime.GOOS == "windows" {
+	if platform.UsesAgentService() {
 		// Start ssh-agent service silently
-		startCmd := exec.Command("powershell", "-Command", "Start-Service ssh-agent")
+		startCmd := ui.Command("powershell", "-Command", "Start-Service ssh-agent")
 		startCmd.Run()
 
 		// Set to automatic startup
-		autoCmd := exec.Command("powershell", "-Command", "Set-Service -Name ssh-agent -StartupType Automatic")
+		autoCmd := ui.Command("powershell", "-Command", "Set-Service -Name ssh-agent -StartupType Automatic")
 		autoCmd.Run()
 	}
 
-	// Check if key is already loaded
-	listCmd := exec.Command("ssh-add", "-l")
-	output, _ := listCmd.Output()
-
 	// If key not in agent, add it
-	if user.SSHKeyPath != "" && !strings.Contains(string(output), user.SSHKeyPath) {
-		addCmd := exec.Command("ssh-add", user.SSHKeyPath)
+	if user.SSHKeyPath != "" && !keyInAgent(user.SSHKeyPath) {
+		addCmd := ui.Command(platform.SSHAddCommand(), agent.AddKeyArgs(user.SSHKeyPath, agentLifetime(user))...).WithTimeout(0)
 		addCmd.Run()
 	}
 }
@@ -153,34 +392,11 @@ func ensureSSHAgentForClone(user *config.User) {
 // convertToBgitURL converts any GitHub URL to bgit's SSH format
 // sshHostUser is the GitHub username used for the SSH host (github.com-<sshHostUser>)
 func convertToBgitURL(url string, sshHostUser string) (string, error) {
-	// Pattern for HTTPS: https://github.com/user/repo.git
-	httpsPattern := regexp.MustCompile(`^https?://github\.com/([^/]+)/(.+?)(?:\.git)?$`)
-
-	// Pattern for SSH: git@github.com:user/repo.git
-	sshPattern := regexp.MustCompile(`^git@github\.com:([^/]+)/(.+?)(?:\.git)?$`)
-
-	// Pattern for already converted: git@github.com-user:user/repo.git
-	bgitPattern := regexp.MustCompile(`^git@github\.com-([^:]+):([^/]+)/(.+?)(?:\.git)?$`)
-
-	var repoOwner, repoName string
-
-	if matches := httpsPattern.FindStringSubmatch(url); matches != nil {
-		repoOwner = matches[1]
-		repoName = matches[2]
-	} else if matches := sshPattern.FindStringSubmatch(url); matches != nil {
-		repoOwner = matches[1]
-		repoName = matches[2]
-	} else if matches := bgitPattern.FindStringSubmatch(url); matches != nil {
-		// Already in bgit format, update host user if different
-		repoOwner = matches[2]
-		repoName = matches[3]
-	} else {
+	parsed, err := remote.Parse(url)
+	if err != nil {
 		return "", fmt.Errorf("unrecognized URL format: %s\nExpected GitHub HTTPS or SSH URL", url)
 	}
 
-	// Remove .git suffix if present
-	repoName = strings.TrimSuffix(repoName, ".git")
-
 	// sshHostUser is the GitHub username that matches SSH config: Host github.com-<sshHostUser>
-	return fmt.Sprintf("git@github.com-%s:%s/%s.git", sshHostUser, repoOwner, repoName), nil
+	return parsed.BgitURL(sshHostUser), nil
 }
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/agent"
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/remote"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
+)
+
+// cloneFailure is why a clone over SSH failed
+type cloneFailure int
+
+const (
+	cloneFailureUnknown     cloneFailure = iota
+	cloneFailureBlocked                  // github.com:22 unreachable, usually a firewall
+	cloneFailureOffline                  // github.com doesn't resolve
+	cloneFailureNoHost                   // the SSH config doesn't define the bgit host alias
+	cloneFailureKeyMissing               // the identity's key file is gone
+	cloneFailureAgentEmpty               // the agent that should offer the key doesn't
+	cloneFailureKeyRejected              // GitHub doesn't know the key
+	cloneFailureNoAccess                 // authenticated, but the account can't see the repository
+	cloneFailureHostKey                  // ssh couldn't verify GitHub's host key
+)
+
+// blockedMarkers are ssh errors for a connection that never reached GitHub
+var blockedMarkers = []string{
+	"connection timed out",
+	"operation timed out",
+	"connection refused",
+	"network is unreachable",
+	"no route to host",
+	"kex_exchange_identification",
+	"connection reset by peer",
+}
+
+// diagnoseCloneFailure works out from git's messages why a clone over SSH as
+// user failed, and explains it
+func diagnoseCloneFailure(user *config.User, messages []string) (cloneFailure, []string) {
+	output := strings.ToLower(strings.Join(messages, "\n"))
+	keyPath := shortenPath(user.SSHKeyPath)
+	host := ssh.GetHostForUser(user.GitHubUsername)
+
+	switch {
+	case strings.Contains(output, "could not resolve hostname "+strings.ToLower(host)+":"):
+		return cloneFailureNoHost, []string{
+			fmt.Sprintf("Your SSH config has no '%s' host, so ssh looked it up as a hostname.", host),
+			"Let bgit write it with: bgit sync --fix",
+		}
+	case strings.Contains(output, "could not resolve hostname"):
+		return cloneFailureOffline, []string{
+			"github.com could not be resolved; check your network connection.",
+		}
+	case containsAny(output, blockedMarkers):
+		return cloneFailureBlocked, []string{
+			"Could not connect to github.com on port 22; the network probably blocks SSH.",
+			fmt.Sprintf("GitHub also accepts SSH on %s port %s.", ssh.GitHubSSHOver443Host, ssh.GitHubSSHOver443Port),
+		}
+	case strings.Contains(output, "host key verification failed"):
+		return cloneFailureHostKey, []string{
+			"ssh could not verify GitHub's host key.",
+			"Compare ~/.ssh/known_hosts with https://docs.github.com/en/authentication/keeping-your-account-and-data-secure/githubs-ssh-key-fingerprints",
+		}
+	case strings.Contains(output, "repository not found"):
+		return cloneFailureNoAccess, []string{
+			fmt.Sprintf("%s can't see this repository, or it doesn't exist.", user.GitHubUsername),
+			"If another identity has access, clone inside its workspace or add a rule: bgit rule add <owner> <alias>",
+		}
+	case !strings.Contains(output, "permission denied (publickey"):
+		return cloneFailureUnknown, nil
+	}
+
+	// GitHub turned every key away; find out why ours wasn't among them
+	if user.UsesIdentityAgent() {
+		socket, err := platform.ExpandTilde(user.IdentityAgent)
+		if err != nil {
+			socket = user.IdentityAgent
+		}
+		if _, err := os.Stat(socket); err != nil {
+			return cloneFailureAgentEmpty, []string{
+				fmt.Sprintf("The agent at %s isn't running, so no key was offered.", user.IdentityAgent),
+			}
+		}
+		return cloneFailureAgentEmpty, []string{
+			fmt.Sprintf("The agent at %s offered no key GitHub accepts for %s.", user.IdentityAgent, user.GitHubUsername),
+			"Unlock it, and check that it holds the key for this account.",
+		}
+	}
+	if user.SSHKeyPath == "" {
+		return cloneFailureKeyMissing, []string{
+			fmt.Sprintf("'%s' has no SSH key. Set one with: bgit update %s --ssh-key <path>", user.Alias, user.Alias),
+		}
+	}
+	if _, err := os.Stat(user.SSHKeyPath); err != nil {
+		return cloneFailureKeyMissing, []string{
+			fmt.Sprintf("The key %s doesn't exist. Set another with: bgit update %s --ssh-key <path>", keyPath, user.Alias),
+		}
+	}
+
+	fingerprint, _ := userpkg.GetFingerprint(user.SSHKeyPath)
+	if os.Getenv("SSH_AUTH_SOCK") != "" && fingerprint != "" {
+		if keys, err := agent.ListKeys(); err == nil && !agent.HasFingerprint(keys, fingerprint) && cloneNoAgent {
+			return cloneFailureAgentEmpty, []string{
+				fmt.Sprintf("%s isn't loaded in the SSH agent (--no-agent), and ssh couldn't use it directly.", keyPath),
+				fmt.Sprintf("Load it with: ssh-add %s", keyPath),
+			}
+		}
+	}
+	explanation := []string{
+		fmt.Sprintf("GitHub rejected %s: it is probably not added to %s's account.", keyPath, user.GitHubUsername),
+	}
+	if fingerprint != "" {
+		explanation = append(explanation, fmt.Sprintf("Its fingerprint is %s; compare it with https://github.com/settings/keys", fingerprint))
+	}
+	return cloneFailureKeyRejected, explanation
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneFallback explains why a clone over SSH failed and, where another
+// route can work, retries through ssh.github.com:443 or HTTPS with the
+// identity's token, asking first unless --fallback is given. It returns the
+// attempt that succeeded, or the last error.
+func cloneFallback(user *config.User, url, directory string, messages []string, cloneErr error) (cloneAttempt, cloneStats, error) {
+	failure, explanation := diagnoseCloneFailure(user, messages)
+	if failure == cloneFailureUnknown {
+		return cloneAttempt{}, cloneStats{}, cloneErr
+	}
+
+	fmt.Println()
+	ui.Warning(explanation[0])
+	for _, line := range explanation[1:] {
+		fmt.Printf("  %s\n", line)
+	}
+
+	var fallbacks []string
+	switch failure {
+	case cloneFailureBlocked:
+		fallbacks = []string{"443", "https"}
+	case cloneFailureNoHost, cloneFailureKeyMissing, cloneFailureAgentEmpty, cloneFailureKeyRejected:
+		fallbacks = []string{"https"}
+	}
+
+	for _, fallback := range fallbacks {
+		var attempt cloneAttempt
+		var question string
+		switch fallback {
+		case "443":
+			converted, err := convertToBgitURL(url, user.GitHubUsername)
+			if err != nil {
+				return cloneAttempt{}, cloneStats{}, err
+			}
+			attempt = cloneAttempt{url: converted, sshCommand: ssh.SSHOver443Command()}
+			question = fmt.Sprintf("Retry over SSH through %s:%s?", ssh.GitHubSSHOver443Host, ssh.GitHubSSHOver443Port)
+		case "https":
+			question = fmt.Sprintf("Retry over HTTPS with %s's personal access token?", user.GitHubUsername)
+		}
+
+		retry, err := confirmCloneFallback(question)
+		if err != nil {
+			return cloneAttempt{}, cloneStats{}, err
+		}
+		if !retry {
+			continue
+		}
+		if fallback == "https" {
+			if attempt, err = httpsCloneAttempt(user, url); err != nil {
+				return cloneAttempt{}, cloneStats{}, err
+			}
+		}
+
+		fmt.Println()
+		fmt.Printf("URL: %s\n\n", attempt.url)
+		stats, _, err := runGitClone(attempt.gitArgs(directory))
+		if err == nil {
+			if fallback == "443" {
+				printPort443Hint(user)
+			}
+			return attempt, stats, nil
+		}
+		cloneErr = err
+	}
+
+	if !cloneFallbackAuto && !ui.IsInteractive() && len(fallbacks) > 0 {
+		fmt.Println()
+		fmt.Println("To retry automatically, run:")
+		fmt.Printf("  bgit clone --fallback %s\n", url)
+		if parsed, err := remote.Parse(url); err == nil {
+			fmt.Printf("  bgit clone --https %s\n", parsed.HTTPSURL())
+		}
+		fmt.Println()
+	}
+	return cloneAttempt{}, cloneStats{}, cloneErr
+}
+
+// confirmCloneFallback asks whether to retry a clone another way; --fallback
+// answers yes, and without a terminal the answer is no
+func confirmCloneFallback(question string) (bool, error) {
+	if cloneFallbackAuto {
+		ui.Info(strings.TrimSuffix(question, "?") + ": retrying (--fallback)")
+		return true, nil
+	}
+	if !ui.IsInteractive() {
+		return false, nil
+	}
+	fmt.Println()
+	return ui.PromptConfirmation(question)
+}
+
+// printPort443Hint tells the user how to send all of an identity's SSH traffic
+// through port 443, since only the new repository is set up for it
+func printPort443Hint(user *config.User) {
+	fmt.Println()
+	ui.Info("To use port 443 for every repository of this identity, add to ~/.bgit/ssh_overrides.toml:")
+	fmt.Printf("  [%s]\n", user.Alias)
+	fmt.Printf("  HostName = %q\n", ssh.GitHubSSHOver443Host)
+	fmt.Printf("  Port = %s\n", ssh.GitHubSSHOver443Port)
+	fmt.Println("  and run 'bgit sync --fix'")
+}
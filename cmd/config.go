@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var configEncryptKeychain bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage how bgit stores its config",
+}
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Store config.toml encrypted",
+	Long: `Encrypt ~/.bgit/config.toml, which holds your identities' emails and key
+paths. bgit decrypts it in memory whenever it loads the config.
+
+By default the key is derived from a passphrase you choose. bgit asks for it
+once per command, or reads it from BGIT_CONFIG_PASSPHRASE. With --keychain a
+random key is stored in the OS keychain (macOS Keychain, or the Secret
+Service via secret-tool on Linux) and no passphrase is needed.
+
+Run it again to change the passphrase or switch methods. Shell prompts that
+run 'bgit active' need the keychain or BGIT_CONFIG_PASSPHRASE, since they
+can't prompt.`,
+	Example: `  bgit config encrypt
+  bgit config encrypt --keychain`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runConfigEncrypt,
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:          "decrypt",
+	Short:        "Store config.toml as plain TOML again",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runConfigDecrypt,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configEncryptCmd)
+	configCmd.AddCommand(configDecryptCmd)
+	configEncryptCmd.Flags().BoolVar(&configEncryptKeychain, "keychain", false, "Keep the key in the OS keychain instead of using a passphrase")
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) error {
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	previous := cfg.EncryptionMethod()
+
+	method := config.EncryptionPassphrase
+	if configEncryptKeychain {
+		method = config.EncryptionKeychain
+	}
+	if err := cfg.Encrypt(method); err != nil {
+		return err
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	removeUnusedKeychainKey(previous, method)
+
+	// The resolution cache would keep identities in plain text
+	_ = identity.ClearCache()
+
+	if method == config.EncryptionKeychain {
+		ui.Success("Config encrypted with a key stored in the OS keychain")
+	} else {
+		ui.Success("Config encrypted with your passphrase")
+		ui.Info(fmt.Sprintf("bgit will ask for it, or read it from %s", config.PassphraseEnv))
+	}
+	if previous == "" {
+		ui.Info("Copies made before encryption in ~/.bgit/backups and the undo journal are still plain text; remove them if needed.")
+	}
+	return nil
+}
+
+func runConfigDecrypt(cmd *cobra.Command, args []string) error {
+	if !config.IsEncrypted() {
+		ui.Info("Config is not encrypted")
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	previous := cfg.EncryptionMethod()
+
+	cfg.Decrypt()
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	removeUnusedKeychainKey(previous, "")
+
+	ui.Success("Config decrypted")
+	return nil
+}
+
+// removeUnusedKeychainKey deletes the keychain key once the saved config no
+// longer needs it
+func removeUnusedKeychainKey(previous, current string) {
+	if previous != config.EncryptionKeychain || current == config.EncryptionKeychain {
+		return
+	}
+	if err := config.RemoveKeychainKey(); err != nil {
+		ui.Warning(fmt.Sprintf("Could not remove the old config key from the keychain: %v", err))
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/user"
+	"github.com/spf13/cobra"
+)
+
+var credentialUser string
+
+var credentialCmd = &cobra.Command{
+	Use:   "credential <get|store|erase>",
+	Short: "Git credential helper for HTTPS remotes",
+	Long: `A git credential helper that answers for one identity's GitHub account.
+'bgit remote fix --https' configures it per repository; git runs it, not you.
+
+On get, it returns the identity's GitHub username and its saved token, if any.
+Without a saved token git prompts for one (use a personal access token), and
+after it works git asks the helper to store it under ~/.bgit/credentials.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"get", "store", "erase"},
+	Hidden:    true,
+	RunE:      runCredential,
+}
+
+func init() {
+	rootCmd.AddCommand(credentialCmd)
+	credentialCmd.Flags().StringVar(&credentialUser, "user", "", "Identity alias whose GitHub account to use")
+	credentialCmd.MarkFlagRequired("user")
+}
+
+func runCredential(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	u := cfg.FindUserByAlias(credentialUser)
+	if u == nil {
+		return fmt.Errorf("user '%s' not found", credentialUser)
+	}
+
+	attrs := readCredentialAttributes(os.Stdin)
+	if attrs["protocol"] != "https" || !strings.EqualFold(attrs["host"], "github.com") {
+		// Not ours; let other helpers or the prompt handle it
+		return nil
+	}
+	if username := attrs["username"]; username != "" && !strings.EqualFold(username, u.GitHubUsername) {
+		return nil
+	}
+
+	switch args[0] {
+	case "get":
+		token, err := user.LoadToken(u.GitHubUsername)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("username=%s\n", u.GitHubUsername)
+		if token != "" {
+			fmt.Printf("password=%s\n", token)
+		}
+	case "store":
+		if attrs["password"] != "" {
+			return user.SaveToken(u.GitHubUsername, attrs["password"])
+		}
+	case "erase":
+		return user.DeleteToken(u.GitHubUsername)
+	}
+	// Unknown actions are ignored, as the credential protocol requires
+	return nil
+}
+
+// readCredentialAttributes reads git credential protocol key=value lines up to
+// a blank line or EOF
+func readCredentialAttributes(f *os.File) map[string]string {
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			attrs[key] = value
+		}
+	}
+	return attrs
+}
+
+// credentialHelperCommand returns the credential.helper value that runs this
+// bgit binary as the helper for an identity
+func credentialHelperCommand(alias string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "bgit"
+	}
+	// The leading ! makes git run the value as a shell command
+	return fmt.Sprintf("!'%s' credential --user %s", strings.ReplaceAll(exe, "'", `'\''`), alias)
+}
+
+// isBgitCredentialHelper reports whether a credential.helper value is one
+// bgit configured
+func isBgitCredentialHelper(helper string) bool {
+	return strings.HasPrefix(helper, "!") && strings.Contains(helper, " credential --user ")
+}
+
+// credentialHelperAlias returns the identity alias a bgit credential.helper
+// value answers for, or "" for other helpers
+func credentialHelperAlias(helper string) string {
+	if !isBgitCredentialHelper(helper) {
+		return ""
+	}
+	_, alias, _ := strings.Cut(helper, " credential --user ")
+	return strings.TrimSpace(alias)
+}
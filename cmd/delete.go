@@ -3,25 +3,53 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/history"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/remote"
 	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
 )
 
+var (
+	deleteForce    bool
+	deleteKeepKeys bool
+)
+
 var deleteCmd = &cobra.Command{
 	Use:   "delete <alias>",
 	Short: "Delete a user identity",
-	Long:  `Remove a user identity from bgit configuration and optionally delete SSH keys.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Remove a user identity from bgit configuration, together with everything
+that points at it: its workspaces, bindings, and rules, and its host entry
+(github.com-<username>) in the SSH config. bgit lists all of it before asking
+for confirmation, then asks whether to delete the key files too. Key files
+another identity also uses are always kept.
+
+Repositories bound to the identity or inside its workspaces whose remotes still
+go through its host alias stop working once the host entry is gone; bgit lists
+them and offers to restore their standard GitHub URLs.
+
+--force deletes without asking: it also deletes the key files, unless
+--keep-keys is given, and restores the remotes. Without a terminal, --force is
+required. 'bgit undo' restores the config and SSH config, but not deleted key
+files.`,
+	Args: cobra.ExactArgs(1),
 	Example: `  bgit delete work
-  bgit delete personal`,
+  bgit delete personal --keep-keys
+  bgit delete old-client --force --keep-keys   # In scripts`,
 	RunE: runDelete,
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Flags().BoolVarP(&deleteForce, "force", "f", false, "Delete without asking, including the key files unless --keep-keys is given")
+	deleteCmd.Flags().BoolVar(&deleteKeepKeys, "keep-keys", false, "Keep the identity's SSH key files")
 }
 
 func runDelete(cmd *cobra.Command, args []string) error {
@@ -40,66 +68,255 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	if user == nil {
 		return fmt.Errorf("user '%s' not found", identifier)
 	}
+	if cfg.IsSystemUser(user.Alias) {
+		return config.ErrSystemEntry("identity", user.Alias)
+	}
+	if !deleteForce && !ui.IsInteractive() {
+		return withExitCode(exitUsage, fmt.Errorf("cannot confirm deleting '%s': not running interactively (pass --force)", user.Alias))
+	}
+	deleted := *user
 
-	confirmed, err := ui.PromptConfirmation(fmt.Sprintf("Delete user '%s' (%s)?", user.Alias, user.Email))
-	if err != nil {
-		return err
+	// Everything that points at the identity
+	var workspaces, systemWorkspaces []config.Workspace
+	for _, ws := range cfg.FindWorkspacesByUser(deleted.Alias) {
+		if cfg.IsSystemWorkspace(ws.Path) {
+			systemWorkspaces = append(systemWorkspaces, ws)
+		} else {
+			workspaces = append(workspaces, ws)
+		}
 	}
+	var bindings []config.Binding
+	for _, b := range cfg.GetBindings() {
+		if b.User == deleted.Alias && !cfg.IsSystemBinding(b.Path) {
+			bindings = append(bindings, b)
+		}
+	}
+	var rules []config.Rule
+	for _, r := range cfg.Rules {
+		if r.User == deleted.Alias && !cfg.IsSystemRule(r.Owner) {
+			rules = append(rules, r)
+		}
+	}
+	keyFiles, keySharedWith := identityKeyFiles(cfg, &deleted)
+	remotes := hostAliasRemotes(cfg, &deleted)
 
-	if !confirmed {
-		fmt.Println("Operation cancelled.")
-		return nil
+	var removes []string
+	for _, ws := range workspaces {
+		removes = append(removes, "workspace "+shortenPath(ws.Path))
+	}
+	for _, b := range bindings {
+		removes = append(removes, "binding "+shortenPath(b.Path))
 	}
+	for _, r := range rules {
+		removes = append(removes, "rule "+r.Owner)
+	}
+	if deleted.HasSSHHost() {
+		removes = append(removes, "SSH host "+ssh.GetHostForUser(deleted.GitHubUsername))
+	}
+	if deleteForce && !deleteKeepKeys {
+		for _, path := range keyFiles {
+			removes = append(removes, "key file "+shortenPath(path))
+		}
+	}
+	if len(removes) == 0 {
+		fmt.Printf("Deleting '%s' (%s)\n", deleted.Alias, deleted.Email)
+	} else {
+		fmt.Printf("Deleting '%s' (%s) also removes:\n", deleted.Alias, deleted.Email)
+		for _, line := range removes {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	if keySharedWith != "" {
+		ui.Info(fmt.Sprintf("Keeping the key files: '%s' uses the same key", keySharedWith))
+	}
+	if deleteKeepKeys && len(keyFiles) > 0 {
+		ui.Info(fmt.Sprintf("Keeping the key files (--keep-keys): %s", strings.Join(shortenPaths(keyFiles), ", ")))
+	}
+	for _, ws := range systemWorkspaces {
+		ui.Warning(fmt.Sprintf("Workspace %s is set in the system config and stays", shortenPath(ws.Path)))
+	}
+	if len(remotes) > 0 {
+		fmt.Println()
+		ui.Warning(fmt.Sprintf("%d remote(s) still go through %s and will stop working:", len(remotes), ssh.GetHostForUser(deleted.GitHubUsername)))
+		for _, c := range remotes {
+			ui.Printf("  • %s\n", c.description)
+		}
+	}
+	fmt.Println()
 
-	deleteKeys := false
-	if user.SSHKeyPath != "" {
-		deleteKeys, err = ui.PromptConfirmation(fmt.Sprintf("Also delete SSH key files (%s)?", user.SSHKeyPath))
+	if !deleteForce {
+		confirmed, err := ui.PromptConfirmation(fmt.Sprintf("Delete user '%s' (%s)?", deleted.Alias, deleted.Email))
 		if err != nil {
 			return err
 		}
+		if !confirmed {
+			fmt.Println("Operation cancelled.")
+			return nil
+		}
 	}
 
+	deleteKeys := !deleteKeepKeys && len(keyFiles) > 0 && deleteForce
+	if !deleteKeepKeys && len(keyFiles) > 0 && !deleteForce {
+		deleteKeys, err = ui.PromptConfirmation(fmt.Sprintf("Also delete the key files (%s)? This can't be undone", strings.Join(shortenPaths(keyFiles), ", ")))
+		if err != nil {
+			return err
+		}
+	}
+	restoreRemotes := len(remotes) > 0 && deleteForce
+	if len(remotes) > 0 && !deleteForce {
+		restoreRemotes, err = ui.PromptConfirmation("Restore the remotes to standard GitHub URLs?")
+		if err != nil {
+			return err
+		}
+	}
+
+	undo := beginUndo("delete", fmt.Sprintf("delete user '%s'", deleted.Alias), "")
 	newUsers := []config.User{}
 	for _, u := range cfg.Users {
-		if u.Alias != user.Alias {
+		if u.Alias != deleted.Alias {
 			newUsers = append(newUsers, u)
 		}
 	}
 	cfg.Users = newUsers
+	for _, ws := range workspaces {
+		cfg.RemoveWorkspaceByPath(ws.Path)
+	}
+	for _, b := range bindings {
+		cfg.RemoveBinding(b.Path)
+	}
+	for _, r := range rules {
+		cfg.RemoveRule(r.Owner)
+	}
 
-	if cfg.ActiveUser == user.Alias {
+	if cfg.ActiveUser == deleted.Alias {
 		cfg.ActiveUser = ""
 		ui.Info("Active user cleared")
 	}
 
-	if deleteKeys && user.SSHKeyPath != "" {
-		if err := os.Remove(user.SSHKeyPath); err != nil {
-			ui.Warning(fmt.Sprintf("Could not delete private key: %v", err))
-		} else {
-			ui.Success(fmt.Sprintf("Deleted: %s", user.SSHKeyPath))
-		}
-
-		pubKeyPath := user.SSHKeyPath + ".pub"
-		if err := os.Remove(pubKeyPath); err != nil {
-			ui.Warning(fmt.Sprintf("Could not delete public key: %v", err))
-		} else {
-			ui.Success(fmt.Sprintf("Deleted: %s", pubKeyPath))
-		}
-	}
-
 	if err := config.SaveConfig(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
+	syncAllowedSigners(cfg)
 
 	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
-		ui.Info("Warning: Failed to update SSH config")
+		ui.Warning(fmt.Sprintf("Could not update SSH config: %v", err))
 	}
+	commitUndo(undo)
 
-	ui.Success(fmt.Sprintf("User '%s' deleted", user.Alias))
+	ui.Success(fmt.Sprintf("User '%s' deleted", deleted.Alias))
+	if len(workspaces)+len(bindings)+len(rules) > 0 {
+		ui.Success(fmt.Sprintf("Removed %d workspace(s), %d binding(s), and %d rule(s)", len(workspaces), len(bindings), len(rules)))
+	}
+
+	failed := 0
+	if deleteKeys {
+		for _, path := range keyFiles {
+			if err := os.Remove(path); err != nil {
+				ui.Error(fmt.Sprintf("Could not delete %s: %v", shortenPath(path), err))
+				failed++
+			} else {
+				ui.Success(fmt.Sprintf("Deleted: %s", shortenPath(path)))
+			}
+		}
+	}
+
+	if restoreRemotes {
+		for _, c := range remotes {
+			if err := c.apply(); err != nil {
+				ui.Error(fmt.Sprintf("%s: %v", c.description, err))
+				failed++
+				continue
+			}
+			ui.Success(c.description)
+		}
+	} else if len(remotes) > 0 {
+		ui.Info("Restore them later with: bgit remote restore --all")
+	}
 
 	if len(cfg.Users) == 0 {
 		fmt.Println("\nNo users remaining. Add one with: bgit add")
 	}
 
+	if failed > 0 {
+		exit(exitPartialFix)
+	}
 	return nil
 }
+
+// identityKeyFiles returns the key files of u that exist: the private key and
+// its .pub. An identity whose key lives in an external agent has only the
+// .pub on disk. A key pair another identity in cfg also uses is left out, and
+// sharedWith names that identity.
+func identityKeyFiles(cfg *config.Config, u *config.User) (files []string, sharedWith string) {
+	if u.SSHKeyPath == "" {
+		return nil, ""
+	}
+	base := keyPairBase(u.SSHKeyPath)
+	for _, other := range cfg.Users {
+		if other.Alias != u.Alias && other.SSHKeyPath != "" && keyPairBase(other.SSHKeyPath) == base {
+			return nil, other.Alias
+		}
+	}
+
+	for _, path := range keyFiles(base) {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files, ""
+}
+
+// keyPairBase returns the private key path of the pair path belongs to, with
+// ~ expanded, so two spellings of the same key compare equal
+func keyPairBase(path string) string {
+	expanded, err := platform.ExpandTilde(path)
+	if err != nil {
+		expanded = path
+	}
+	return strings.TrimSuffix(filepath.Clean(expanded), ".pub")
+}
+
+// hostAliasRemotes plans restoring the remotes of u's repositories (bound to
+// it or inside its workspaces) that go through its bgit host alias to their
+// standard GitHub URLs
+func hostAliasRemotes(cfg *config.Config, u *config.User) changePlan {
+	var plan changePlan
+	for _, repoPath := range identityRepos(cfg, u.Alias) {
+		remotes, err := git.ListRemotes(repoPath)
+		if err != nil {
+			ui.Verbose(fmt.Sprintf("%s: %v", shortenPath(repoPath), err))
+			continue
+		}
+		names := make([]string, 0, len(remotes))
+		for name := range remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			oldURL := remotes[name]
+			parsed, err := remote.Parse(oldURL)
+			if err != nil || parsed.Kind != remote.KindBgit || !strings.EqualFold(parsed.HostUser, u.GitHubUsername) {
+				continue
+			}
+			repoPath, name, newURL := repoPath, name, parsed.StandardURL()
+			plan.add(fmt.Sprintf("%s: set %s URL: %s → %s", shortenPath(repoPath), name, oldURL, newURL), func() error {
+				if err := git.SetRemoteURL(repoPath, name, newURL); err != nil {
+					return err
+				}
+				recordHistory(history.ActionRemoteRestore, u.Alias, repoPath, newURL)
+				return nil
+			})
+		}
+	}
+	return plan
+}
+
+// shortenPaths applies shortenPath to each path
+func shortenPaths(paths []string) []string {
+	short := make([]string, len(paths))
+	for i, path := range paths {
+		short[i] = shortenPath(path)
+	}
+	return short
+}
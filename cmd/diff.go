@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/byterings/bgit/internal/agent"
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/remote"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how the system differs from bgit's config",
+	Long: `Compare the state bgit's config describes with the actual state of the
+system and print the differences as a unified diff, from actual ("-") to
+expected ("+"):
+
+  global git config   user.name, user.email, and author/committer emails of
+                      the active identity
+  ssh config          the bgit section of ~/.ssh/config
+  ssh agent           which configured identities' keys are loaded
+  repository          the current repository's effective git user and origin
+                      remote, when run inside one
+
+'bgit sync --fix' (and 'bgit sync --repo --fix' in a repository) applies the
+expected side. Exits with status 4 when there are differences, like diff.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+// stateSection is one part of the system bgit manages, rendered as lines
+type stateSection struct {
+	name     string
+	actual   string
+	expected string
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var sections []stateSection
+	if section, ok := globalGitState(cfg); ok {
+		sections = append(sections, section)
+	}
+
+	current, proposed, err := ssh.PreviewManagedSection(cfg.Users)
+	if err != nil {
+		return err
+	}
+	sections = append(sections, stateSection{name: "ssh config", actual: current, expected: proposed})
+
+	resolution, _ := identity.GetEffectiveResolution(cfg)
+	if section, ok := agentState(cfg, resolution); ok {
+		sections = append(sections, section)
+	}
+	if section, ok := repoState(resolution); ok {
+		sections = append(sections, section)
+	}
+
+	differences := 0
+	for _, s := range sections {
+		hunks := unifiedDiff(s.actual, s.expected, 3)
+		if len(hunks) == 0 {
+			ui.Verbose(fmt.Sprintf("%s: no differences", s.name))
+			continue
+		}
+		differences++
+		printUnifiedDiff(s.name, hunks)
+	}
+
+	if differences == 0 {
+		ui.Success("No differences: the system matches bgit's config")
+		return nil
+	}
+	exit(exitMismatch)
+	return nil
+}
+
+// printUnifiedDiff prints a section's hunks with diff-style headers and colors
+func printUnifiedDiff(name string, hunks []string) {
+	fmt.Println(ui.Cyan("diff " + name))
+	fmt.Println(ui.Red("--- actual/" + name))
+	fmt.Println(ui.Green("+++ expected/" + name))
+	for _, line := range hunks {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(ui.Cyan(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(ui.Red(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(ui.Green(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+}
+
+// configLines renders git config keys with their values, skipping unset ones
+func configLines(keys []string, values map[string]string) string {
+	var b strings.Builder
+	for _, key := range keys {
+		if v := values[key]; v != "" {
+			fmt.Fprintf(&b, "%s = %s\n", key, v)
+		}
+	}
+	return b.String()
+}
+
+// identityConfigKeys are the git config keys that record an identity
+var identityConfigKeys = []string{"user.name", "user.email", "author.email", "committer.email"}
+
+// expectedIdentityConfig returns the values of identityConfigKeys for u
+func expectedIdentityConfig(u *config.User) map[string]string {
+	return map[string]string{
+		"user.name":       u.Name,
+		"user.email":      u.Email,
+		"author.email":    u.AuthorEmail,
+		"committer.email": u.CommitterEmail,
+	}
+}
+
+// globalGitState compares the global git config with the active identity
+func globalGitState(cfg *config.Config) (stateSection, bool) {
+	active := cfg.FindUserByAlias(cfg.ActiveUser)
+	if active == nil {
+		return stateSection{}, false
+	}
+	actual := make(map[string]string)
+	for _, key := range identityConfigKeys {
+		actual[key], _ = git.GetGlobalConfig(key)
+	}
+	return stateSection{
+		name:     "global git config",
+		actual:   configLines(identityConfigKeys, actual),
+		expected: configLines(identityConfigKeys, expectedIdentityConfig(active)),
+	}, true
+}
+
+// agentState compares the configured identities' keys loaded in the SSH
+// agent with the effective identity's key alone. Keys that belong to no
+// identity are left out; bgit doesn't manage them.
+func agentState(cfg *config.Config, resolution *identity.Resolution) (stateSection, bool) {
+	if os.Getenv("SSH_AUTH_SOCK") == "" || resolution == nil || resolution.User == nil {
+		return stateSection{}, false
+	}
+	keys, err := agent.ListKeys()
+	if err != nil {
+		return stateSection{}, false
+	}
+
+	owners := make(map[string]string) // fingerprint → alias
+	for _, u := range cfg.Users {
+		if u.SSHKeyPath == "" || u.UsesIdentityAgent() {
+			continue
+		}
+		if fp, err := userpkg.GetFingerprint(u.SSHKeyPath); err == nil {
+			owners[fp] = u.Alias
+		}
+	}
+
+	var actual []string
+	for _, k := range keys {
+		if alias, ok := owners[k.Fingerprint]; ok {
+			actual = append(actual, fmt.Sprintf("%s (%s)", k.Fingerprint, alias))
+		}
+	}
+	sort.Strings(actual)
+
+	var expected []string
+	for fp, alias := range owners {
+		if alias == resolution.User.Alias {
+			expected = append(expected, fmt.Sprintf("%s (%s)", fp, alias))
+		}
+	}
+	if len(actual) == 0 && len(expected) == 0 {
+		return stateSection{}, false
+	}
+	return stateSection{
+		name:     "ssh agent",
+		actual:   strings.Join(actual, "\n"),
+		expected: strings.Join(expected, "\n"),
+	}, true
+}
+
+// repoState compares the current repository's effective git user and origin
+// remote with the identity that applies there, as 'bgit sync --repo' does
+func repoState(resolution *identity.Resolution) (stateSection, bool) {
+	cwd, err := os.Getwd()
+	if err != nil || resolution == nil || resolution.User == nil {
+		return stateSection{}, false
+	}
+	repoRoot := identity.FindGitRoot(cwd)
+	if repoRoot == "" {
+		return stateSection{}, false
+	}
+	u := resolution.User
+
+	actual := make(map[string]string)
+	for _, key := range identityConfigKeys {
+		actual[key], _ = git.GetRepoConfig(repoRoot, key)
+	}
+	expected := expectedIdentityConfig(u)
+
+	keys := append([]string{}, identityConfigKeys...)
+	if url, err := git.GetRemoteURL(repoRoot, "origin"); err == nil && url != "" {
+		keys = append(keys, "remote.origin.url")
+		actual["remote.origin.url"] = url
+		expected["remote.origin.url"] = url
+		if parsed, err := remote.Parse(url); err == nil && u.HasSSHHost() &&
+			(parsed.Kind == remote.KindBgit || resolution.Source != identity.SourceGlobal) {
+			expected["remote.origin.url"] = parsed.BgitURL(u.GitHubUsername)
+		}
+	}
+
+	return stateSection{
+		name:     "repository " + shortenPath(repoRoot),
+		actual:   configLines(keys, actual),
+		expected: configLines(keys, expected),
+	}, true
+}
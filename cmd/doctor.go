@@ -1,21 +1,31 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
+	"github.com/byterings/bgit/internal/agent"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
 	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
 	"github.com/spf13/cobra"
 )
 
 var (
 	doctorNetwork bool
 	doctorFix     bool
+	doctorJSON    bool
+	doctorReport  string
 )
 
 var doctorCmd = &cobra.Command{
@@ -25,22 +35,30 @@ var doctorCmd = &cobra.Command{
 
 Runs checks on:
 - Config file validity
+- git and OpenSSH versions
 - SSH key existence and permissions
-- SSH config entries
+- SSH config entries and conflicting unmanaged Host entries
 - SSH agent status
 - Git config alignment
 
 Examples:
   bgit doctor              # Run basic diagnostics
   bgit doctor --network    # Include GitHub connectivity tests
-  bgit doctor --fix        # Auto-fix permission issues`,
+  bgit doctor --fix        # Repair permissions, SSH config, git config, and agent keys
+  bgit doctor --json       # Machine-readable output
+  bgit doctor --report bgit-report.zip  # Sanitized bundle for bug reports
+
+Exit codes: 0 when all checks pass, 1 when there are only warnings,
+2 when any check fails, including when bgit is not initialized.`,
 	RunE: runDoctor,
 }
 
 func init() {
 	rootCmd.AddCommand(doctorCmd)
 	doctorCmd.Flags().BoolVarP(&doctorNetwork, "network", "n", false, "Test GitHub SSH connectivity")
-	doctorCmd.Flags().BoolVarP(&doctorFix, "fix", "f", false, "Auto-fix permission issues")
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Print results as JSON")
+	doctorCmd.Flags().StringVar(&doctorReport, "report", "", "Write a sanitized diagnostic bundle (zip) to this path")
+	doctorCmd.Flags().BoolVarP(&doctorFix, "fix", "f", false, "Auto-fix permissions, SSH config, git user, and agent keys")
 }
 
 type checkResult struct {
@@ -49,93 +67,143 @@ type checkResult struct {
 	fix     string // Suggested fix command
 }
 
-func runDoctor(cmd *cobra.Command, args []string) error {
-	fmt.Println()
-	fmt.Println("Checking bgit configuration...")
-	fmt.Println()
+// doctorSection groups the results of one area of checks
+type doctorSection struct {
+	name    string
+	results []checkResult
+	strict  bool // every failure is an error, even when a fix is suggested
+}
 
-	errors := 0
-	warnings := 0
-	fixed := 0
+// severity classifies a check result as "ok", "warning", or "error"
+func (s doctorSection) severity(r checkResult) string {
+	switch {
+	case r.passed:
+		return "ok"
+	case s.strict || r.fix == "":
+		return "error"
+	default:
+		return "warning"
+	}
+}
 
-	fmt.Println("Config")
-	fmt.Println("──────")
+// Doctor exit codes, so scripts can gate on bgit health. They predate and
+// differ from bgit's general exit codes: 2 here is a failed check, not a
+// usage error.
+const (
+	doctorExitClean    = 0
+	doctorExitWarnings = 1
+	doctorExitErrors   = 2
+)
+
+// doctorExitCode returns the exit code for the number of failed checks and
+// warnings
+func doctorExitCode(errors, warnings int) int {
+	switch {
+	case errors > 0:
+		return doctorExitErrors
+	case warnings > 0:
+		return doctorExitWarnings
+	default:
+		return doctorExitClean
+	}
+}
 
-	configResults := checkConfig()
-	for _, r := range configResults {
-		printCheckResult(r)
-		if !r.passed {
-			errors++
+func runDoctor(cmd *cobra.Command, args []string) error {
+	fixed := 0
+	sections := []doctorSection{{name: "Config", results: checkConfig(), strict: true}}
+	if exists, _ := config.ConfigExists(); exists {
+		result, permFixed := checkConfigPermissions(doctorFix)
+		sections[0].results = append(sections[0].results, result)
+		if permFixed {
+			fixed++
 		}
 	}
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		fmt.Println()
-		ui.Error(fmt.Sprintf("Cannot continue: %v", err))
-		return nil
-	}
+		sections[0].results = append(sections[0].results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("Cannot continue: %v", err),
+		})
+	} else {
+		sshResults, sshFixed := checkSSH(cfg, doctorFix)
+		agentResults, agentFixed := checkSSHAgent(cfg, doctorFix)
+		gitResults, gitFixed := checkGitConfig(cfg, doctorFix)
+		fixed += sshFixed + agentFixed + gitFixed
 
-	fmt.Println()
-	fmt.Println("SSH Setup")
-	fmt.Println("─────────")
+		sections = append(sections,
+			doctorSection{name: "Identities", results: checkDuplicateIdentities(cfg)},
+			doctorSection{name: "Tools", results: checkToolVersions()},
+			doctorSection{name: "SSH Setup", results: sshResults},
+			doctorSection{name: "SSH Host Entries", results: checkSSHHostConflicts(cfg)},
+			doctorSection{name: "SSH Agent", results: agentResults},
+			doctorSection{name: "Git Config", results: gitResults},
+		)
 
-	sshResults, sshFixed := checkSSH(cfg, doctorFix)
-	for _, r := range sshResults {
-		printCheckResult(r)
-		if !r.passed && r.fix == "" {
-			errors++
-		} else if !r.passed {
-			warnings++
+		if skResults := checkSecurityKeys(cfg); len(skResults) > 0 {
+			sections = append(sections, doctorSection{name: "Security Keys", results: skResults})
 		}
-	}
-	fixed += sshFixed
 
-	fmt.Println()
-	fmt.Println("SSH Agent")
-	fmt.Println("─────────")
+		if doctorNetwork {
+			sections = append(sections, doctorSection{name: "GitHub Connectivity", results: checkGitHubConnectivity(cfg), strict: true})
+		}
+	}
 
-	agentResults := checkSSHAgent()
-	for _, r := range agentResults {
-		printCheckResult(r)
-		if !r.passed && r.fix == "" {
-			errors++
-		} else if !r.passed {
-			warnings++
+	errorCount := 0
+	warnings := 0
+	for _, section := range sections {
+		for _, r := range section.results {
+			switch section.severity(r) {
+			case "error":
+				errorCount++
+			case "warning":
+				warnings++
+			}
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("Git Config")
-	fmt.Println("──────────")
+	exitCode := doctorExitCode(errorCount, warnings)
 
-	gitResults := checkGitConfig(cfg)
-	for _, r := range gitResults {
-		printCheckResult(r)
-		if !r.passed && r.fix == "" {
-			errors++
-		} else if !r.passed {
-			warnings++
+	if doctorJSON {
+		if err := printDoctorJSON(sections, errorCount, warnings, fixed, exitCode); err != nil {
+			return err
 		}
+	} else {
+		printDoctorReport(sections, errorCount, warnings, fixed)
 	}
 
-	if doctorNetwork {
-		fmt.Println()
-		fmt.Println("GitHub Connectivity")
-		fmt.Println("───────────────────")
+	if doctorReport != "" {
+		if err := writeDiagnosticBundle(doctorReport, cfg, buildDoctorJSON(sections, errorCount, warnings, fixed, exitCode)); err != nil {
+			return err
+		}
+		if !doctorJSON {
+			ui.Success(fmt.Sprintf("Diagnostic bundle written to %s", doctorReport))
+			fmt.Println("  Review it before attaching to a bug report")
+		}
+	}
+
+	if exitCode != doctorExitClean {
+		exit(exitCode)
+	}
+	return nil
+}
 
-		netResults := checkGitHubConnectivity(cfg)
-		for _, r := range netResults {
+func printDoctorReport(sections []doctorSection, errors, warnings, fixed int) {
+	fmt.Println()
+	fmt.Println("Checking bgit configuration...")
+
+	for _, section := range sections {
+		fmt.Println()
+		fmt.Println(section.name)
+		ui.Println(strings.Repeat("─", len([]rune(section.name))))
+		for _, r := range section.results {
 			printCheckResult(r)
-			if !r.passed {
-				errors++
-			}
 		}
 	}
 
 	// Summary
 	fmt.Println()
-	fmt.Println("─────────")
+	ui.Println("─────────")
 
 	if fixed > 0 {
 		ui.Success(fmt.Sprintf("Auto-fixed %d issue(s)", fixed))
@@ -148,18 +216,73 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	} else {
 		ui.Error(fmt.Sprintf("%d error(s), %d warning(s)", errors, warnings))
 	}
+}
+
+type doctorJSONCheck struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+type doctorJSONSection struct {
+	Name   string            `json:"name"`
+	Checks []doctorJSONCheck `json:"checks"`
+}
+
+type doctorJSONReport struct {
+	Sections []doctorJSONSection `json:"sections"`
+	Errors   int                 `json:"errors"`
+	Warnings int                 `json:"warnings"`
+	Fixed    int                 `json:"fixed"`
+	ExitCode int                 `json:"exit_code"`
+}
+
+func printDoctorJSON(sections []doctorSection, errors, warnings, fixed, exitCode int) error {
+	return writeDoctorJSON(os.Stdout, buildDoctorJSON(sections, errors, warnings, fixed, exitCode))
+}
+
+func buildDoctorJSON(sections []doctorSection, errors, warnings, fixed, exitCode int) doctorJSONReport {
+	report := doctorJSONReport{
+		Sections: []doctorJSONSection{},
+		Errors:   errors,
+		Warnings: warnings,
+		Fixed:    fixed,
+		ExitCode: exitCode,
+	}
+
+	for _, section := range sections {
+		js := doctorJSONSection{Name: section.name, Checks: []doctorJSONCheck{}}
+		for _, r := range section.results {
+			js.Checks = append(js.Checks, doctorJSONCheck{
+				Status:  section.severity(r),
+				Message: r.message,
+				Fix:     r.fix,
+			})
+		}
+		report.Sections = append(report.Sections, js)
+	}
 
+	return report
+}
+
+func writeDoctorJSON(w io.Writer, report doctorJSONReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
 	return nil
 }
 
 func printCheckResult(r checkResult) {
 	if r.passed {
-		fmt.Printf("  ✓ %s\n", r.message)
+		ui.Printf("  %s %s\n", ui.Green("✓"), r.message)
 	} else if r.fix != "" {
-		fmt.Printf("  ⚠ %s\n", r.message)
-		fmt.Printf("    → %s\n", r.fix)
+		ui.Printf("  %s %s\n", ui.Yellow("⚠"), r.message)
+		ui.Printf("    → %s\n", r.fix)
 	} else {
-		fmt.Printf("  ✗ %s\n", r.message)
+		ui.Printf("  %s %s\n", ui.Red("✗"), r.message)
 	}
 }
 
@@ -203,6 +326,20 @@ func checkConfig() []checkResult {
 		message: "Config file valid",
 	})
 
+	if method := cfg.EncryptionMethod(); method != "" {
+		results = append(results, checkResult{
+			passed:  true,
+			message: fmt.Sprintf("Config file encrypted (%s)", method),
+		})
+	}
+
+	if cfg.HasSystemConfig() {
+		results = append(results, checkResult{
+			passed:  true,
+			message: fmt.Sprintf("System config merged from %s", config.GetSystemConfigPath()),
+		})
+	}
+
 	if len(cfg.Users) == 0 {
 		results = append(results, checkResult{
 			passed:  false,
@@ -240,6 +377,174 @@ func checkConfig() []checkResult {
 	return results
 }
 
+// checkDuplicateIdentities flags identities that share an SSH key, email, or
+// GitHub username, which make the generated SSH host entries ambiguous
+func checkDuplicateIdentities(cfg *config.Config) []checkResult {
+	var results []checkResult
+
+	byKey := make(map[string][]string)
+	byFingerprint := make(map[string][]string)
+	byEmail := make(map[string][]string)
+	byHost := make(map[string][]string)
+
+	for _, user := range cfg.Users {
+		if user.SSHKeyPath != "" {
+			keyPath, err := platform.ExpandTilde(user.SSHKeyPath)
+			if err != nil {
+				keyPath = user.SSHKeyPath
+			}
+			keyPath = filepath.Clean(keyPath)
+			byKey[keyPath] = append(byKey[keyPath], user.Alias)
+
+			if fingerprint, err := userpkg.GetFingerprint(keyPath); err == nil {
+				byFingerprint[fingerprint] = append(byFingerprint[fingerprint], user.Alias)
+			}
+		}
+		if user.Email != "" {
+			email := strings.ToLower(user.Email)
+			byEmail[email] = append(byEmail[email], user.Alias)
+		}
+		if user.GitHubUsername != "" {
+			host := strings.ToLower(ssh.GetHostForUser(user.GitHubUsername))
+			byHost[host] = append(byHost[host], user.Alias)
+		}
+	}
+
+	duplicates := 0
+	report := func(groups map[string][]string, what, fix string) {
+		values := make([]string, 0, len(groups))
+		for value := range groups {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+
+		for _, value := range values {
+			aliases := groups[value]
+			if len(aliases) < 2 {
+				continue
+			}
+			duplicates++
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s shared by %s: %s", what, strings.Join(aliases, ", "), value),
+				fix:     fix,
+			})
+		}
+	}
+
+	report(byKey, "SSH key path", "GitHub rejects one key on two accounts. Run: bgit update <alias> --ssh-key <new-key>")
+	keyPathDuplicates := duplicates
+	if keyPathDuplicates == 0 {
+		// The same key copied to different paths is just as ambiguous
+		report(byFingerprint, "SSH key (same fingerprint)", "GitHub rejects one key on two accounts. Run: bgit update <alias> --ssh-key <new-key>")
+	}
+	report(byEmail, "Email", fmt.Sprintf("Edit %s so each identity has its own email", platform.GetConfigFilePath()))
+	report(byHost, "SSH host alias", fmt.Sprintf("Edit %s so each identity has its own GitHub username", platform.GetConfigFilePath()))
+
+	if duplicates == 0 && len(cfg.Users) > 0 {
+		results = append(results, checkResult{
+			passed:  true,
+			message: "No identities share a key, email, or host alias",
+		})
+	}
+
+	return results
+}
+
+// toolFeature is a bgit feature that requires a minimum tool version
+type toolFeature struct {
+	major, minor int
+	feature      string
+}
+
+var gitFeatures = []toolFeature{
+	{2, 10, "core.sshCommand"},
+	{2, 13, "includeIf conditional config"},
+	{2, 34, "SSH commit signing"},
+}
+
+var sshFeatures = []toolFeature{
+	{6, 5, "ed25519 keys"},
+	{8, 0, "ssh-keygen -Y signing"},
+	{8, 2, "FIDO2 security keys (ed25519-sk)"},
+}
+
+// checkToolVersions reports the git and OpenSSH versions and which bgit features they lack
+func checkToolVersions() []checkResult {
+	var results []checkResult
+
+	if v, err := git.GetVersion(); err != nil {
+		results = append(results, checkResult{
+			passed:  false,
+			message: "git not found or version unreadable",
+		})
+	} else {
+		results = append(results, checkVersionFeatures("git", v, gitFeatures, "Upgrade git: https://git-scm.com/downloads")...)
+	}
+
+	if v, err := ssh.GetClientVersion(); err != nil {
+		results = append(results, checkResult{
+			passed:  false,
+			message: "OpenSSH client not found or version unreadable",
+		})
+	} else {
+		results = append(results, checkVersionFeatures("OpenSSH", v, sshFeatures, "Upgrade your OpenSSH client")...)
+	}
+
+	results = append(results, checkSSHStack())
+
+	return results
+}
+
+// checkSSHStack reports which ssh client git runs and where it reads its
+// config; bgit's host aliases only work with an OpenSSH client
+func checkSSHStack() checkResult {
+	env := platform.DetectSSHEnvironment()
+	if env.Stack == platform.SSHStackCustom {
+		fix := "Point git at OpenSSH: git config --global core.sshCommand ssh"
+		if env.Source == "GIT_SSH_COMMAND" || env.Source == "GIT_SSH" {
+			fix = fmt.Sprintf("Unset %s so git uses OpenSSH", env.Source)
+		}
+		return checkResult{
+			passed:  false,
+			message: fmt.Sprintf("git uses a non-OpenSSH client (%s, via %s), which ignores bgit's SSH config", env.SSH, env.Source),
+			fix:     fix,
+		}
+	}
+
+	sshDir, _ := platform.GetSSHDir()
+	return checkResult{
+		passed:  true,
+		message: fmt.Sprintf("git uses %s (%s, via %s); SSH config in %s", env.Stack, env.SSH, env.Source, sshDir),
+	}
+}
+
+func checkVersionFeatures(tool string, v platform.Version, features []toolFeature, fix string) []checkResult {
+	var missing []string
+	for _, f := range features {
+		if !v.AtLeast(f.major, f.minor) {
+			missing = append(missing, fmt.Sprintf("%s (needs %d.%d+)", f.feature, f.major, f.minor))
+		}
+	}
+
+	if len(missing) == 0 {
+		return []checkResult{{
+			passed:  true,
+			message: fmt.Sprintf("%s %s", tool, v),
+		}}
+	}
+
+	var results []checkResult
+	for _, m := range missing {
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("%s %s: %s unavailable", tool, v, m),
+			fix:     fix,
+		})
+	}
+	return results
+}
+
 func checkSSH(cfg *config.Config, autoFix bool) ([]checkResult, int) {
 	var results []checkResult
 	fixed := 0
@@ -254,6 +559,16 @@ func checkSSH(cfg *config.Config, autoFix bool) ([]checkResult, int) {
 	}
 
 	info, err := os.Stat(sshDir)
+	if os.IsNotExist(err) && autoFix {
+		if err := platform.MkdirSecure(sshDir); err == nil {
+			results = append(results, checkResult{
+				passed:  true,
+				message: "SSH directory created (700)",
+			})
+			fixed++
+			info, err = os.Stat(sshDir)
+		}
+	}
 	if os.IsNotExist(err) {
 		results = append(results, checkResult{
 			passed:  false,
@@ -296,6 +611,10 @@ func checkSSH(cfg *config.Config, autoFix bool) ([]checkResult, int) {
 	}
 
 	for _, user := range cfg.Users {
+		if user.UsesIdentityAgent() {
+			results = append(results, checkIdentityAgent(user))
+			continue
+		}
 		if user.SSHKeyPath == "" {
 			results = append(results, checkResult{
 				passed:  false,
@@ -348,43 +667,168 @@ func checkSSH(cfg *config.Config, autoFix bool) ([]checkResult, int) {
 				})
 			}
 		} else {
-			results = append(results, checkResult{
-				passed:  true,
-				message: fmt.Sprintf("SSH key '%s' exists", user.Alias),
-			})
+			result, aclFixed := checkKeyACL(user.Alias, keyPath, autoFix)
+			results = append(results, result)
+			if aclFixed {
+				fixed++
+			}
 		}
+
+		results = append(results, checkKeyPair(cfg, user)...)
 	}
 
 	sshConfigPath, _ := platform.GetSSHConfigPath()
+	problem, fix := "", "Run: bgit sync --fix"
 	if _, err := os.Stat(sshConfigPath); os.IsNotExist(err) {
+		problem = "SSH config file not found"
+	} else if content, err := os.ReadFile(sshConfigPath); err == nil {
+		report := ssh.FindManagedSections(string(content))
+		switch {
+		case len(report.Sections) == 0:
+			problem = "SSH config missing bgit entries"
+		case report.NeedsMigration():
+			problem = fmt.Sprintf("SSH config has %s", describeMarkerProblems(report))
+			fix = "Run: bgit migrate-ssh"
+		}
+	}
+
+	switch {
+	case problem == "":
+		results = append(results, checkResult{
+			passed:  true,
+			message: "SSH config has bgit entries",
+		})
+	case autoFix && len(cfg.Users) > 0:
+		if err := ssh.UpdateSSHConfig(cfg.Users); err == nil {
+			results = append(results, checkResult{
+				passed:  true,
+				message: "SSH config bgit entries regenerated",
+			})
+			fixed++
+		} else {
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s (regenerating failed: %v)", problem, err),
+				fix:     fix,
+			})
+		}
+	default:
 		results = append(results, checkResult{
 			passed:  false,
-			message: "SSH config file not found",
-			fix:     "Run: bgit sync --fix",
+			message: problem,
+			fix:     fix,
 		})
-	} else {
-		content, err := os.ReadFile(sshConfigPath)
-		if err == nil {
-			if strings.Contains(string(content), "BEGIN BRGIT MANAGED") {
+	}
+
+	return results, fixed
+}
+
+// checkSSHHostConflicts finds Host entries outside the managed section that also
+// apply to bgit's host aliases, and asks ssh which key it would actually offer
+func checkSSHHostConflicts(cfg *config.Config) []checkResult {
+	var results []checkResult
+
+	sshConfigPath, err := platform.GetSSHConfigPath()
+	if err != nil {
+		return results
+	}
+	content, err := os.ReadFile(sshConfigPath)
+	if err != nil {
+		return results
+	}
+	entries := ssh.FindUnmanagedHostEntries(string(content))
+
+	for _, e := range entries {
+		for _, pattern := range e.Patterns {
+			if strings.EqualFold(pattern, "github.com") && len(e.IdentityFiles) > 0 {
 				results = append(results, checkResult{
-					passed:  true,
-					message: "SSH config has bgit entries",
+					passed:  false,
+					message: fmt.Sprintf("Unmanaged 'Host github.com' (line %d) uses %s", e.Line, e.IdentityFiles[0]),
+					fix:     "Repos with standard github.com URLs authenticate with this key. Run: bgit remote fix",
 				})
-			} else {
+			}
+		}
+	}
+
+	canQuerySSH := platform.HasCommand(platform.SSHCommand())
+	for _, user := range cfg.Users {
+		if !user.HasSSHHost() {
+			continue
+		}
+		host := ssh.GetHostForUser(user.GitHubUsername)
+
+		conflict := false
+		for _, e := range entries {
+			if !e.Matches(host) {
+				continue
+			}
+			where := fmt.Sprintf("line %d (Host %s)", e.Line, strings.Join(e.Patterns, " "))
+			if e.Line == 0 {
+				where = "top-level options"
+			}
+			if len(e.IdentityFiles) > 0 {
+				conflict = true
 				results = append(results, checkResult{
 					passed:  false,
-					message: "SSH config missing bgit entries",
-					fix:     "Run: bgit sync --fix",
+					message: fmt.Sprintf("%s: %s adds IdentityFile %s before bgit's entry", host, where, strings.Join(e.IdentityFiles, ", ")),
+					fix:     fmt.Sprintf("Remove the IdentityFile or exclude the alias: Host ... !%s", host),
+				})
+			}
+			for _, opt := range []string{"hostname", "user", "port"} {
+				if v, ok := e.Options[opt]; ok {
+					conflict = true
+					results = append(results, checkResult{
+						passed:  false,
+						message: fmt.Sprintf("%s: %s overrides %s=%s", host, where, opt, v),
+						fix:     fmt.Sprintf("Remove it from %s; the first value in ~/.ssh/config wins", where),
+					})
+				}
+			}
+		}
+
+		if !canQuerySSH {
+			if !conflict {
+				results = append(results, checkResult{
+					passed:  true,
+					message: fmt.Sprintf("%s: no conflicting entries", host),
+				})
+			}
+			continue
+		}
+
+		eh, err := ssh.GetEffectiveHost(host)
+		if err != nil || len(eh.IdentityFiles) == 0 {
+			continue
+		}
+		files := eh.IdentityFiles
+		if filepath.Clean(files[0]) == filepath.Clean(user.SSHKeyPath) {
+			if !conflict {
+				results = append(results, checkResult{
+					passed:  true,
+					message: fmt.Sprintf("%s: ssh offers %s first", host, user.SSHKeyPath),
 				})
 			}
+		} else {
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s: ssh would offer %s first (expected %s)", host, files[0], user.SSHKeyPath),
+				fix:     "Check the entries above, then run: ssh -G " + host + " | grep identityfile",
+			})
 		}
 	}
 
-	return results, fixed
+	return results
 }
 
-func checkSSHAgent() []checkResult {
+func checkSSHAgent(cfg *config.Config, autoFix bool) ([]checkResult, int) {
 	var results []checkResult
+	fixed := 0
+
+	// CI runners load keys per job, if at all, so there is no agent to check
+	if ci := platform.CIName(); ci != "" {
+		results = append(results, checkResult{passed: true, message: fmt.Sprintf("Skipped in %s", ci)})
+		return results, fixed
+	}
 
 	authSock := os.Getenv("SSH_AUTH_SOCK")
 	if authSock == "" {
@@ -393,7 +837,7 @@ func checkSSHAgent() []checkResult {
 			message: "SSH agent not running (SSH_AUTH_SOCK not set)",
 			fix:     "Run: eval $(ssh-agent)",
 		})
-		return results
+		return results, fixed
 	}
 
 	if _, err := os.Stat(authSock); os.IsNotExist(err) {
@@ -402,7 +846,7 @@ func checkSSHAgent() []checkResult {
 			message: "SSH agent socket missing",
 			fix:     "Run: eval $(ssh-agent)",
 		})
-		return results
+		return results, fixed
 	}
 
 	results = append(results, checkResult{
@@ -410,45 +854,165 @@ func checkSSHAgent() []checkResult {
 		message: "SSH agent running",
 	})
 
-	cmd := exec.Command("ssh-add", "-l")
-	output, err := cmd.CombinedOutput()
+	keys, err := agent.ListKeys()
 	if err != nil {
-		if strings.Contains(string(output), "no identities") {
+		results = append(results, checkResult{
+			passed:  false,
+			message: "Could not list SSH agent keys",
+		})
+		return results, fixed
+	}
+
+	// Map each loaded fingerprint to the identity that owns it
+	// Identities with their own IdentityAgent don't use this agent
+	owners := make(map[string]string)
+	for _, user := range cfg.Users {
+		if user.SSHKeyPath == "" || user.UsesIdentityAgent() {
+			continue
+		}
+		fingerprint, err := userpkg.GetFingerprint(user.SSHKeyPath)
+		if err != nil {
+			continue
+		}
+		owners[fingerprint] = user.Alias
+
+		if agent.HasFingerprint(keys, fingerprint) {
 			results = append(results, checkResult{
-				passed:  false,
-				message: "No keys loaded in SSH agent",
-				fix:     "Run: ssh-add ~/.ssh/bgit_*",
+				passed:  true,
+				message: fmt.Sprintf("'%s' key loaded (%s)", user.Alias, fingerprint),
 			})
+		} else if autoFix && !platform.IsCI() && agent.AddKey(user.SSHKeyPath, agentLifetime(&user)) == nil {
+			results = append(results, checkResult{
+				passed:  true,
+				message: fmt.Sprintf("'%s' key added to agent (%s)", user.Alias, fingerprint),
+			})
+			fixed++
 		} else {
 			results = append(results, checkResult{
 				passed:  false,
-				message: "Could not list SSH agent keys",
+				message: fmt.Sprintf("'%s' key not loaded in agent", user.Alias),
+				fix:     fmt.Sprintf("Run: ssh-add %s", user.SSHKeyPath),
 			})
 		}
-	} else {
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	}
+
+	if len(keys) == 0 {
+		return results, fixed
+	}
+
+	unmanaged := 0
+	for _, k := range keys {
+		if _, ok := owners[k.Fingerprint]; !ok {
+			unmanaged++
+		}
+	}
+	if unmanaged > 0 {
 		results = append(results, checkResult{
 			passed:  true,
-			message: fmt.Sprintf("%d key(s) loaded in agent", len(lines)),
+			message: fmt.Sprintf("%d other key(s) loaded that belong to no bgit identity", unmanaged),
 		})
 	}
 
+	if len(keys) > githubAuthAttempts {
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("%d keys loaded in the agent; GitHub disconnects after %d failed keys, so connections that offer every agent key (plain git@github.com URLs, hosts without IdentitiesOnly) can fail with 'Too many authentication failures'", len(keys), githubAuthAttempts),
+			fix:     "Unload keys you don't need with 'ssh-add -d <key>', or 'ssh-add -D' and then 'bgit use <alias>'",
+		})
+	}
+
+	// Without IdentitiesOnly, ssh offers every agent key, so another account's key may authenticate first
+	if !platform.HasCommand(platform.SSHCommand()) {
+		return results, fixed
+	}
+	results = append(results, checkAgentKeyOrder(cfg, keys, owners)...)
+
+	return results, fixed
+}
+
+// githubAuthAttempts is how many keys GitHub lets a connection offer before it
+// disconnects with "Too many authentication failures" (sshd's MaxAuthTries)
+const githubAuthAttempts = 6
+
+// checkAgentKeyOrder warns about identity hosts without IdentitiesOnly, where
+// ssh offers the agent's keys in order: a key of another identity ahead of
+// the identity's own authenticates as the wrong account, and too many keys
+// ahead of it use up GitHub's attempts, both ending in "Permission denied" or
+// a clone of the wrong account's view. owners maps agent fingerprints to
+// aliases.
+func checkAgentKeyOrder(cfg *config.Config, keys []agent.Key, owners map[string]string) []checkResult {
+	var results []checkResult
+	for _, user := range cfg.Users {
+		if user.SSHKeyPath == "" || user.UsesIdentityAgent() {
+			continue
+		}
+		host := ssh.GetHostForUser(user.GitHubUsername)
+		eh, err := ssh.GetEffectiveHost(host)
+		if err != nil || eh.IdentitiesOnly {
+			continue
+		}
+
+		// Keys ssh offers before the identity's own: all of them if it isn't loaded
+		ahead := keys
+		for i, k := range keys {
+			if owners[k.Fingerprint] == user.Alias {
+				ahead = keys[:i]
+				break
+			}
+		}
+
+		wrongOwner := ""
+		for _, k := range ahead {
+			if owner, ok := owners[k.Fingerprint]; ok && owner != user.Alias {
+				wrongOwner = owner
+				break
+			}
+		}
+		switch {
+		case wrongOwner != "":
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s: IdentitiesOnly is off and the agent offers '%s' key before '%s', so GitHub authenticates as the wrong account (\"Permission denied\" or \"Repository not found\" for %s's repositories)", host, wrongOwner, user.Alias, user.GitHubUsername),
+				fix:     "Run: bgit sync --fix (managed entries set IdentitiesOnly yes; check for overrides above)",
+			})
+		case len(ahead) >= githubAuthAttempts:
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s: IdentitiesOnly is off and the agent offers %d keys before '%s', more than GitHub's %d attempts, so it fails with 'Too many authentication failures'", host, len(ahead), user.Alias, githubAuthAttempts),
+				fix:     "Run: bgit sync --fix (managed entries set IdentitiesOnly yes), or unload unused keys with 'ssh-add -d <key>'",
+			})
+		}
+	}
 	return results
 }
 
-func checkGitConfig(cfg *config.Config) []checkResult {
+func checkGitConfig(cfg *config.Config, autoFix bool) ([]checkResult, int) {
 	var results []checkResult
+	fixed := 0
 
 	if cfg.ActiveUser == "" {
-		return results
+		return results, fixed
 	}
 
 	user := cfg.FindUserByAlias(cfg.ActiveUser)
 	if user == nil {
-		return results
+		return results, fixed
 	}
 
-	cmd := exec.Command("git", "config", "--global", "user.name")
+	if autoFix {
+		name, email, err := git.GetGlobalUser()
+		if err == nil && (name != user.Name || email != user.Email) {
+			if err := setGlobalUser(user.Name, user.Email); err == nil {
+				results = append(results, checkResult{
+					passed:  true,
+					message: fmt.Sprintf("Git user set to active identity '%s'", user.Alias),
+				})
+				fixed++
+			}
+		}
+	}
+
+	cmd := ui.Command("git", "config", "--global", "user.name")
 	output, err := cmd.Output()
 	if err != nil {
 		results = append(results, checkResult{
@@ -471,7 +1035,7 @@ func checkGitConfig(cfg *config.Config) []checkResult {
 		}
 	}
 
-	cmd = exec.Command("git", "config", "--global", "user.email")
+	cmd = ui.Command("git", "config", "--global", "user.email")
 	output, err = cmd.Output()
 	if err != nil {
 		results = append(results, checkResult{
@@ -494,6 +1058,194 @@ func checkGitConfig(cfg *config.Config) []checkResult {
 		}
 	}
 
+	return results, fixed
+}
+
+// checkKeyACL checks that only the owner, SYSTEM, and Administrators can
+// access a private key on Windows, which OpenSSH for Windows requires, and
+// tightens the ACL with icacls if autoFix is set, reporting whether it did
+func checkKeyACL(alias, keyPath string, autoFix bool) (checkResult, bool) {
+	broad, err := platform.BroadACLPrincipals(keyPath)
+	if err != nil {
+		return checkResult{
+			passed:  false,
+			message: fmt.Sprintf("Cannot read ACL of SSH key '%s': %v", alias, err),
+		}, false
+	}
+	if len(broad) == 0 {
+		return checkResult{
+			passed:  true,
+			message: fmt.Sprintf("SSH key '%s' exists with correct permissions", alias),
+		}, false
+	}
+
+	if autoFix {
+		if err := platform.FixFilePermissions(keyPath); err == nil {
+			return checkResult{
+				passed:  true,
+				message: fmt.Sprintf("SSH key '%s' ACL fixed (owner only)", alias),
+			}, true
+		}
+	}
+	return checkResult{
+		passed:  false,
+		message: fmt.Sprintf("SSH key '%s' is accessible to %s; OpenSSH will refuse it", alias, strings.Join(broad, ", ")),
+		fix:     platform.GetPermissionFixCommand(keyPath),
+	}, false
+}
+
+// checkKeyPair checks that an identity's .pub file belongs to its private
+// key and that its comment doesn't name another configured account
+func checkKeyPair(cfg *config.Config, user config.User) []checkResult {
+	var results []checkResult
+	publicKeyPath := user.SSHKeyPath + ".pub"
+
+	var mismatch *userpkg.KeyPairMismatchError
+	err := userpkg.VerifyKeyPair(user.SSHKeyPath, false)
+	switch {
+	case errors.As(err, &mismatch):
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("SSH key '%s': %s does not match the private key (private %s, .pub %s)", user.Alias, shortenPath(publicKeyPath), mismatch.PrivateFingerprint, mismatch.PublicFingerprint),
+			fix:     fmt.Sprintf("Run: ssh-keygen -y -f %s > %s", user.SSHKeyPath, publicKeyPath),
+		})
+	case err == nil:
+		if fingerprint, err := userpkg.GetFingerprint(user.SSHKeyPath); err == nil {
+			results = append(results, checkResult{
+				passed:  true,
+				message: fmt.Sprintf("SSH key '%s' is %s (compare with github.com/settings/keys)", user.Alias, fingerprint),
+			})
+		}
+	case errors.Is(err, userpkg.ErrPublicKeyUnknown):
+		ui.Verbose(fmt.Sprintf("Skipping key pair check for '%s': %v", user.Alias, err))
+	case err != nil:
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("Cannot check SSH key pair for '%s': %v", user.Alias, err),
+		})
+	}
+
+	_, comment, err := userpkg.ReadPublicKeyFile(publicKeyPath)
+	if err != nil || comment == "" || userpkg.CommentNames(comment, &user) {
+		return results
+	}
+	for i := range cfg.Users {
+		other := &cfg.Users[i]
+		if other.Alias == user.Alias || !userpkg.CommentNames(comment, other) {
+			continue
+		}
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("SSH key '%s' is labeled '%s', which looks like '%s' (%s); check the key is uploaded to %s's GitHub account", user.Alias, comment, other.Alias, other.GitHubUsername, user.GitHubUsername),
+			fix:     fmt.Sprintf("Run: bgit update %s --ssh-key <path>  (or bgit key comment %s if the label is just stale)", user.Alias, user.Alias),
+		})
+		break
+	}
+	return results
+}
+
+// checkConfigPermissions flags a config file other users can read: it holds
+// every identity's emails and key paths
+func checkConfigPermissions(autoFix bool) (checkResult, bool) {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return checkResult{passed: false, message: fmt.Sprintf("Cannot determine config path: %v", err)}, false
+	}
+	ok, err := platform.CheckFilePermissions(configPath)
+	if err != nil {
+		return checkResult{passed: false, message: fmt.Sprintf("Cannot check config permissions: %v", err)}, false
+	}
+	if ok {
+		return checkResult{passed: true, message: "Config file readable only by you"}, false
+	}
+
+	if autoFix {
+		if err := platform.FixFilePermissions(configPath); err == nil {
+			return checkResult{passed: true, message: "Config file permissions fixed (owner only)"}, true
+		}
+	}
+	return checkResult{
+		passed:  false,
+		message: "Config file is readable by other users",
+		fix:     platform.GetPermissionFixCommand(configPath),
+	}, false
+}
+
+// checkIdentityAgent checks that an external agent socket exists; its keys
+// and permissions are managed by the agent, not bgit
+func checkIdentityAgent(user config.User) checkResult {
+	socket, err := platform.ExpandTilde(user.IdentityAgent)
+	if err != nil {
+		socket = user.IdentityAgent
+	}
+	if _, err := os.Stat(socket); err != nil {
+		return checkResult{
+			passed:  false,
+			message: fmt.Sprintf("IdentityAgent socket for '%s' not found: %s", user.Alias, user.IdentityAgent),
+			fix:     "Start the agent (e.g. enable the SSH agent in 1Password) or run: bgit update " + user.Alias + " --identity-agent <socket>",
+		}
+	}
+	return checkResult{
+		passed:  true,
+		message: fmt.Sprintf("'%s' uses IdentityAgent %s", user.Alias, user.IdentityAgent),
+	}
+}
+
+// checkSecurityKeys checks that the ssh client can use the FIDO2 keys some
+// identities rely on: OpenSSH 8.2+ with sk-* support and a middleware to reach
+// the authenticator. It returns nothing if no identity uses a security key.
+func checkSecurityKeys(cfg *config.Config) []checkResult {
+	var aliases []string
+	for _, u := range cfg.Users {
+		if u.SSHKeyPath != "" && !u.UsesIdentityAgent() && userpkg.IsSecurityKey(u.SSHKeyPath) {
+			aliases = append(aliases, u.Alias)
+		}
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	var results []checkResult
+	users := strings.Join(aliases, ", ")
+
+	if v, err := ssh.GetClientVersion(); err == nil && !v.AtLeast(8, 2) {
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("OpenSSH %s cannot use security keys (needs 8.2+), used by: %s", v, users),
+			fix:     "Upgrade your OpenSSH client",
+		})
+	} else if !ssh.SupportsSecurityKeys() {
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("ssh client was built without FIDO2 support, needed by: %s", users),
+			fix:     "Install an OpenSSH build with security key support (e.g. a distro package or Homebrew openssh)",
+		})
+	} else {
+		results = append(results, checkResult{
+			passed:  true,
+			message: fmt.Sprintf("ssh client supports security keys (%s)", users),
+		})
+	}
+
+	if middleware, ok := ssh.SecurityKeyMiddleware(); ok {
+		results = append(results, checkResult{
+			passed:  true,
+			message: fmt.Sprintf("FIDO2 middleware: %s", middleware),
+		})
+	} else if middleware != "" {
+		results = append(results, checkResult{
+			passed:  false,
+			message: fmt.Sprintf("SSH_SK_PROVIDER points to a missing library: %s", middleware),
+			fix:     "Set SSH_SK_PROVIDER to an installed FIDO2 middleware library",
+		})
+	} else {
+		results = append(results, checkResult{
+			passed:  false,
+			message: "No FIDO2 middleware found (ssh-sk-helper or SSH_SK_PROVIDER)",
+			fix:     "Install libfido2 with your OpenSSH package, or set SSH_SK_PROVIDER",
+		})
+	}
+
 	return results
 }
 
@@ -501,31 +1253,59 @@ func checkGitHubConnectivity(cfg *config.Config) []checkResult {
 	var results []checkResult
 
 	for _, user := range cfg.Users {
-		if user.SSHKeyPath == "" {
+		if !user.HasSSHHost() {
 			continue
 		}
 
-		host := fmt.Sprintf("github.com-%s", user.GitHubUsername)
-		cmd := exec.Command("ssh", "-T", "-o", "StrictHostKeyChecking=no", "-o", "ConnectTimeout=10", fmt.Sprintf("git@%s", host))
-		output, _ := cmd.CombinedOutput()
-		outputStr := string(output)
-		if strings.Contains(outputStr, "successfully authenticated") || strings.Contains(outputStr, "Hi ") {
+		ctx, cancel := networkContext()
+		result := ssh.TestConnection(ctx, ssh.GetHostForUser(user.GitHubUsername))
+		cancel()
+		if result.Status == ssh.ConnectionCanceled {
 			results = append(results, checkResult{
-				passed:  true,
-				message: fmt.Sprintf("%s: authenticated as %s", user.Alias, user.GitHubUsername),
+				passed:  false,
+				message: "Connectivity checks interrupted",
 			})
-		} else if strings.Contains(outputStr, "Permission denied") {
+			break
+		}
+
+		switch result.Status {
+		case ssh.ConnectionAuthenticated:
+			if strings.EqualFold(result.Account, user.GitHubUsername) {
+				results = append(results, checkResult{
+					passed:  true,
+					message: fmt.Sprintf("%s: authenticated as %s", user.Alias, result.Account),
+				})
+			} else {
+				results = append(results, checkResult{
+					passed:  false,
+					message: fmt.Sprintf("%s: key authenticates as %s, not %s", user.Alias, result.Account, user.GitHubUsername),
+					fix:     fmt.Sprintf("Add the key to %s's GitHub account, or remove it from %s's", user.GitHubUsername, result.Account),
+				})
+			}
+		case ssh.ConnectionPermissionDenied:
 			results = append(results, checkResult{
 				passed:  false,
 				message: fmt.Sprintf("%s: permission denied", user.Alias),
 				fix:     "Check SSH key is added to GitHub account",
 			})
-		} else if strings.Contains(outputStr, "Connection refused") || strings.Contains(outputStr, "Connection timed out") {
+		case ssh.ConnectionHostKeyFailed:
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s: github.com host key not verified", user.Alias),
+				fix:     "Run 'bgit ssh-test " + user.Alias + "' for the fingerprints to check",
+			})
+		case ssh.ConnectionTimedOut:
+			results = append(results, checkResult{
+				passed:  false,
+				message: fmt.Sprintf("%s: no answer within %s", user.Alias, networkTimeout),
+				fix:     "Check your network, or allow more time with --timeout",
+			})
+		case ssh.ConnectionFailed:
 			results = append(results, checkResult{
 				passed:  false,
 				message: fmt.Sprintf("%s: connection failed", user.Alias),
 			})
-		} else {
+		default:
 			results = append(results, checkResult{
 				passed:  false,
 				message: fmt.Sprintf("%s: unknown response", user.Alias),
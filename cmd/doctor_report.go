@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/redact"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// reportGitConfigPrefixes limits the git config values included in a
+// diagnostic bundle to those that affect identity and authentication
+var reportGitConfigPrefixes = []string{
+	"user.",
+	"core.sshcommand",
+	"commit.gpgsign",
+	"tag.gpgsign",
+	"gpg.",
+	"url.",
+	"credential.",
+}
+
+// reportLogLines is how much of the newest debug log a diagnostic bundle
+// includes
+const reportLogLines = 200
+
+// writeDiagnosticBundle writes a zip archive with sanitized configuration, the
+// doctor results, and the end of the newest --debug log. Secrets and emails are masked, names dropped, and the
+// home directory replaced with ~ so the bundle can be attached to a public
+// issue.
+func writeDiagnosticBundle(path string, cfg *config.Config, report doctorJSONReport) error {
+	files := map[string][]byte{}
+
+	files["platform.txt"] = []byte(reportPlatformInfo())
+
+	if cfg != nil {
+		data, err := sanitizedConfig(cfg)
+		if err != nil {
+			return err
+		}
+		files["config.toml"] = data
+	}
+
+	if sshConfigPath, err := platform.GetSSHConfigPath(); err == nil {
+		if content, err := os.ReadFile(sshConfigPath); err == nil {
+			files["ssh_config_managed.txt"] = []byte(sanitizeReportText(ssh.ExtractManagedSection(string(content))))
+		}
+	}
+
+	files["gitconfig.txt"] = []byte(reportGitConfig())
+
+	var doctorJSONBuf bytes.Buffer
+	if err := writeDoctorJSON(&doctorJSONBuf, report); err != nil {
+		return err
+	}
+	// Check messages and logged commands quote configured and global git
+	// names; drop them too
+	var names []string
+	if cfg != nil {
+		for _, u := range cfg.Users {
+			names = append(names, u.Name)
+		}
+	}
+	if name, _, err := git.GetGlobalUser(); err == nil {
+		names = append(names, name)
+	}
+	files["doctor.json"] = []byte(sanitizeReportText(dropNames(doctorJSONBuf.String(), names)))
+
+	if log, ok := recentLog(); ok {
+		files["recent.log"] = []byte(sanitizeReportText(dropNames(log, names)))
+	}
+
+	ui.Debugf("write %s", path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"platform.txt", "config.toml", "ssh_config_managed.txt", "gitconfig.txt", "doctor.json", "recent.log"} {
+		data, ok := files[name]
+		if !ok {
+			continue
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// dropNames replaces each name of at least three characters in s
+func dropNames(s string, names []string) string {
+	for _, name := range names {
+		if len(name) >= 3 {
+			s = strings.ReplaceAll(s, name, "<redacted>")
+		}
+	}
+	return s
+}
+
+// recentLog returns the last reportLogLines lines of the newest log in
+// ~/.bgit/logs, written by --debug. Log names sort by date.
+func recentLog() (string, bool) {
+	dir, err := ui.LogDir()
+	if err != nil {
+		return "", false
+	}
+	logs, _ := filepath.Glob(filepath.Join(dir, "bgit-*.log"))
+	if len(logs) == 0 {
+		return "", false
+	}
+	sort.Strings(logs)
+	data, err := os.ReadFile(logs[len(logs)-1])
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) > reportLogLines {
+		lines = lines[len(lines)-reportLogLines:]
+	}
+	return strings.Join(lines, "\n") + "\n", true
+}
+
+// reportPlatformInfo describes the bgit build, OS, and tool versions
+func reportPlatformInfo() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "bgit: %s\n", version)
+	fmt.Fprintf(&b, "os: %s/%s (%s)\n", runtime.GOOS, runtime.GOARCH, platform.GetPlatformName())
+
+	if v, err := git.GetVersion(); err == nil {
+		fmt.Fprintf(&b, "git: %s\n", v)
+	} else {
+		fmt.Fprintf(&b, "git: unavailable (%v)\n", err)
+	}
+
+	if v, err := ssh.GetClientVersion(); err == nil {
+		fmt.Fprintf(&b, "openssh: %s\n", v)
+	} else {
+		fmt.Fprintf(&b, "openssh: unavailable (%v)\n", err)
+	}
+
+	fmt.Fprintf(&b, "ssh agent: %t\n", os.Getenv("SSH_AUTH_SOCK") != "")
+	if ci := platform.CIName(); ci != "" {
+		fmt.Fprintf(&b, "ci: %s\n", ci)
+	}
+	if _, err := os.Stat(config.GetSystemConfigPath()); err == nil {
+		fmt.Fprintf(&b, "system config: %s\n", config.GetSystemConfigPath())
+	}
+	return b.String()
+}
+
+// sanitizedConfig encodes a copy of the config with names removed and emails
+// and home paths masked
+func sanitizedConfig(cfg *config.Config) ([]byte, error) {
+	clean := *cfg
+	clean.Users = make([]config.User, len(cfg.Users))
+	for i, u := range cfg.Users {
+		u.Name = "<redacted>"
+		clean.Users[i] = u
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(clean); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	return []byte(sanitizeReportText(buf.String())), nil
+}
+
+// reportGitConfig returns the identity-related global git config values
+func reportGitConfig() string {
+	output, err := ui.Command("git", "config", "--global", "--list").Output()
+	if err != nil {
+		return fmt.Sprintf("unavailable: %v\n", err)
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.ToLower(key)
+
+		relevant := false
+		for _, prefix := range reportGitConfigPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				relevant = true
+				break
+			}
+		}
+		if !relevant {
+			continue
+		}
+
+		if key == "user.name" {
+			value = "<redacted>"
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+	return sanitizeReportText(b.String())
+}
+
+// sanitizeReportText masks secrets and email addresses, whether or not
+// --redact-emails is set, and replaces the home directory with ~
+func sanitizeReportText(s string) string {
+	if home, err := os.UserHomeDir(); err == nil && home != "" && home != "/" {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	return redact.Emails(redact.Secrets(s))
+}
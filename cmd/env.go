@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/spf13/cobra"
+)
+
+var envShell string
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Print git identity environment variables for the current directory",
+	Long: `Print GIT_AUTHOR_* and GIT_COMMITTER_* variables for the effective identity,
+for scripts, CI jobs, and tools that don't read git config.
+
+Author and committer emails differ when the identity sets author_email or
+committer_email.`,
+	Example: `  eval "$(bgit env)"
+  bgit env --shell fish | source
+  bgit env --shell powershell | Invoke-Expression`,
+	Args: cobra.NoArgs,
+	RunE: runEnv,
+}
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.Flags().StringVar(&envShell, "shell", "sh", "Output syntax: sh, fish, or powershell")
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	var format string
+	switch envShell {
+	case "sh":
+		format = "export %s='%s'\n"
+	case "fish":
+		format = "set -gx %s '%s'\n"
+	case "powershell":
+		format = "$env:%s = '%s'\n"
+	default:
+		return withExitCode(exitUsage, fmt.Errorf("unknown shell '%s' (use sh, fish, or powershell)", envShell))
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	resolution, err := identity.GetEffectiveResolution(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to resolve identity: %w", err)
+	}
+	if resolution == nil {
+		return fmt.Errorf("no active user set. Run: bgit use <alias>")
+	}
+	u := resolution.User
+
+	vars := [][2]string{
+		{"GIT_AUTHOR_NAME", u.Name},
+		{"GIT_AUTHOR_EMAIL", u.EffectiveAuthorEmail()},
+		{"GIT_COMMITTER_NAME", u.Name},
+		{"GIT_COMMITTER_EMAIL", u.EffectiveCommitterEmail()},
+	}
+	for _, v := range vars {
+		fmt.Printf(format, v[0], quoteEnvValue(v[1], envShell))
+	}
+	return nil
+}
+
+// quoteEnvValue escapes single quotes for use inside a single-quoted string
+func quoteEnvValue(value, shell string) string {
+	switch shell {
+	case "sh":
+		return strings.ReplaceAll(value, "'", `'\''`)
+	case "fish":
+		return strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", `\'`)
+	default:
+		return strings.ReplaceAll(value, "'", "''")
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// Exit codes, so wrappers and shell hooks can branch on results without
+// parsing output
+const (
+	exitOK            = 0   // Success, nothing to report
+	exitError         = 1   // Unexpected failure
+	exitUsage         = 2   // Invalid flags or arguments
+	exitConfigMissing = 3   // bgit is not initialized
+	exitMismatch      = 4   // Identity or configuration problems found and left unfixed
+	exitNetwork       = 5   // GitHub could not be reached or rejected the key
+	exitPartialFix    = 6   // Some fixes were applied but at least one failed
+	exitInterrupted   = 130 // A network operation was canceled with Ctrl-C
+)
+
+// exitCodeError carries an exit code out of a command's RunE
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode attaches an exit code to an error returned from a command
+func withExitCode(code int, err error) error {
+	return &exitCodeError{code: code, err: err}
+}
+
+// exitCodeFor maps an error returned by a command to the process exit code
+func exitCodeFor(err error) int {
+	var coded *exitCodeError
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, config.ErrNotInitialized):
+		return exitConfigMissing
+	default:
+		return exitError
+	}
+}
+
+// exit ends the process with code once a command has printed its results
+func exit(code int) {
+	ui.CloseLog()
+	ui.RestoreConsole()
+	os.Exit(code)
+}
@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcDryRun bool
+	gcForce  bool
+)
+
+const (
+	// gcRetention is the age after which backups and debug logs are stale
+	gcRetention = 30 * 24 * time.Hour
+	// gcKeepNewest is how many backups of each kind are kept whatever their age
+	gcKeepNewest = 3
+	// gcLeftoverAge is the age after which a lock or temporary file is assumed
+	// to be left over from an interrupted run, as platform.LockFile assumes
+	gcLeftoverAge = 10 * time.Minute
+)
+
+// gcBackupKinds are the name patterns of the copies bgit keeps in
+// ~/.bgit/backups: SSH configs from before each rewrite, and configs from
+// before a format migration
+var gcBackupKinds = []string{"ssh_config-*", "config-*.toml"}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove orphaned keys, stale backups, and dead workspaces and bindings",
+	Long: `Find what bgit left behind and clean it up, reporting each item:
+
+  workspaces and bindings   whose folders or repositories no longer exist
+  orphaned keys             bgit_* key pairs in ~/.ssh no identity uses, e.g.
+                            the old keys 'bgit key rotate' keeps
+  stale backups and logs    backups and --debug logs older than 30 days,
+                            keeping the 3 newest backups of each kind
+  leftover files            lock and temporary files from interrupted runs
+
+Dead workspaces and bindings are removed from the config ('bgit undo'
+restores them). Files are deleted only after asking, one key at a time and
+once for the rest; --force deletes them without asking, and without a
+terminal they are only reported. Other commands only report dead paths
+('bgit status' marks them with ✗).`,
+	Example: `  bgit gc --dry-run   # Report what would be removed
+  bgit gc
+  bgit gc --force     # Delete without asking`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runGC,
+}
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Report what would be removed without changing anything")
+	gcCmd.Flags().BoolVar(&gcForce, "force", false, "Delete files without asking")
+}
+
+// gcFile is a file gc can delete, with the files that go with it (a key's
+// .pub half)
+type gcFile struct {
+	paths  []string
+	reason string
+}
+
+// gcFileGroup is one kind of file gc cleans up
+type gcFileGroup struct {
+	title   string
+	files   []gcFile
+	askEach bool // ask about each file instead of once for the group
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	if gcDryRun && gcForce {
+		return withExitCode(exitUsage, fmt.Errorf("--dry-run cannot be combined with --force"))
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	groups := []gcFileGroup{
+		{title: "Orphaned keys", files: orphanedKeys(cfg), askEach: true},
+		{title: "Stale backups and logs", files: staleBackups()},
+		{title: "Leftover files", files: leftoverFiles()},
+	}
+	workspaces := cfg.PruneWorkspaces()
+	bindings := cfg.PruneBindings()
+
+	found := len(workspaces) + len(bindings)
+	for _, g := range groups {
+		found += len(g.files)
+	}
+	if found == 0 {
+		ui.Success("Nothing to clean up")
+		return nil
+	}
+
+	if len(workspaces)+len(bindings) > 0 {
+		fmt.Println("Dead workspaces and bindings:")
+		for _, ws := range workspaces {
+			ui.Printf("  workspace %s → %s (folder no longer exists)\n", shortenPath(ws.Path), ws.User)
+		}
+		for _, b := range bindings {
+			ui.Printf("  binding %s → %s (repository no longer exists)\n", shortenPath(b.Path), b.User)
+		}
+		fmt.Println()
+	}
+	for _, g := range groups {
+		if len(g.files) == 0 {
+			continue
+		}
+		fmt.Printf("%s:\n", g.title)
+		for _, f := range g.files {
+			fmt.Printf("  %s (%s)\n", describeGCFile(f), f.reason)
+		}
+		fmt.Println()
+	}
+
+	if gcDryRun {
+		ui.Info("Dry run: nothing was removed")
+		return nil
+	}
+
+	if len(workspaces)+len(bindings) > 0 {
+		undo := beginUndo("gc", fmt.Sprintf("remove %d workspace(s) and %d binding(s)", len(workspaces), len(bindings)), "")
+		if err := config.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		commitUndo(undo)
+		ui.Success(fmt.Sprintf("Removed %d workspace(s) and %d binding(s) from the config", len(workspaces), len(bindings)))
+	}
+
+	deleted, skipped, failed := 0, 0, 0
+	for _, g := range groups {
+		if len(g.files) == 0 {
+			continue
+		}
+		if !gcForce && !ui.IsInteractive() {
+			skipped += len(g.files)
+			continue
+		}
+
+		if !gcForce && !g.askEach {
+			ok, err := ui.PromptConfirmation(fmt.Sprintf("Delete the %d %s listed above?", len(g.files), strings.ToLower(g.title)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				skipped += len(g.files)
+				continue
+			}
+		}
+		for _, f := range g.files {
+			if !gcForce && g.askEach {
+				ok, err := ui.PromptConfirmation(fmt.Sprintf("Delete %s (%s)?", describeGCFile(f), f.reason))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					skipped++
+					continue
+				}
+			}
+			if err := deleteGCFile(f); err != nil {
+				ui.Error(fmt.Sprintf("Failed to delete %s: %v", describeGCFile(f), err))
+				failed++
+				continue
+			}
+			deleted++
+		}
+	}
+
+	if deleted > 0 {
+		ui.Success(fmt.Sprintf("Deleted %d file(s)", deleted))
+	}
+	if skipped > 0 {
+		if ui.IsInteractive() || gcForce {
+			ui.Info(fmt.Sprintf("Kept %d file(s)", skipped))
+		} else {
+			ui.Info(fmt.Sprintf("Kept %d file(s): run 'bgit gc' in a terminal to choose, or 'bgit gc --force' to delete them", skipped))
+		}
+	}
+	if failed > 0 {
+		return withExitCode(exitPartialFix, fmt.Errorf("failed to delete %d file(s)", failed))
+	}
+	return nil
+}
+
+// describeGCFile names a file and its companions, e.g. "~/.ssh/bgit_old (+ .pub)"
+func describeGCFile(f gcFile) string {
+	name := shortenPath(f.paths[0])
+	for _, extra := range f.paths[1:] {
+		name += " (+ " + strings.TrimPrefix(extra, f.paths[0]) + ")"
+	}
+	return name
+}
+
+// deleteGCFile removes a file and its companions
+func deleteGCFile(f gcFile) error {
+	var errs []error
+	for _, path := range f.paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// orphanedKeys returns the bgit_* key pairs in the SSH directory that no
+// identity uses
+func orphanedKeys(cfg *config.Config) []gcFile {
+	sshDir, err := platform.GetSSHDir()
+	if err != nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(sshDir, "bgit_*"))
+
+	used := make(map[string]bool)
+	for _, u := range cfg.Users {
+		if u.SSHKeyPath == "" {
+			continue
+		}
+		path, err := platform.ExpandTilde(u.SSHKeyPath)
+		if err != nil {
+			path = u.SSHKeyPath
+		}
+		used[strings.TrimSuffix(filepath.Clean(path), ".pub")] = true
+	}
+
+	seen := make(map[string]bool)
+	var files []gcFile
+	for _, match := range matches {
+		base := strings.TrimSuffix(match, ".pub")
+		if seen[base] || used[base] {
+			continue
+		}
+		seen[base] = true
+		if info, err := os.Stat(match); err != nil || info.IsDir() {
+			continue
+		}
+
+		var f gcFile
+		for _, path := range []string{base, base + ".pub"} {
+			if _, err := os.Stat(path); err == nil {
+				f.paths = append(f.paths, path)
+			}
+		}
+		f.reason = "used by no identity"
+		if fingerprint, err := userpkg.GetFingerprint(base); err == nil {
+			f.reason += ", " + fingerprint
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// staleBackups returns backups older than gcRetention beyond the newest
+// gcKeepNewest of each kind, and --debug logs older than gcRetention
+func staleBackups() []gcFile {
+	var files []gcFile
+	if backupDir, err := config.GetBackupDir(); err == nil {
+		for _, kind := range gcBackupKinds {
+			files = append(files, staleFiles(filepath.Join(backupDir, kind), gcKeepNewest)...)
+		}
+	}
+	if logDir, err := ui.LogDir(); err == nil {
+		files = append(files, staleFiles(filepath.Join(logDir, "bgit-*.log"), 0)...)
+	}
+	return files
+}
+
+// staleFiles returns the files matching pattern older than gcRetention,
+// except the keep newest
+func staleFiles(pattern string, keep int) []gcFile {
+	type dated struct {
+		path    string
+		modTime time.Time
+	}
+	matches, _ := filepath.Glob(pattern)
+	var all []dated
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			all = append(all, dated{match, info.ModTime()})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].modTime.After(all[j].modTime) })
+
+	var files []gcFile
+	for i, d := range all {
+		if i < keep || time.Since(d.modTime) < gcRetention {
+			continue
+		}
+		files = append(files, gcFile{
+			paths:  []string{d.path},
+			reason: fmt.Sprintf("%d days old", int(time.Since(d.modTime).Hours()/24)),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].paths[0] < files[j].paths[0] })
+	return files
+}
+
+// leftoverFiles returns lock files and temporary files that interrupted runs
+// left in ~/.bgit and next to the SSH config
+func leftoverFiles() []gcFile {
+	var patterns []string
+	if configDir, err := config.GetConfigDir(); err == nil {
+		patterns = append(patterns,
+			filepath.Join(configDir, "*.lock"),
+			filepath.Join(configDir, identity.CacheFileName+".*"))
+	}
+	if sshConfigPath, err := platform.GetSSHConfigPath(); err == nil {
+		patterns = append(patterns, sshConfigPath+".lock")
+	}
+
+	var files []gcFile
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() || time.Since(info.ModTime()) < gcLeftoverAge {
+				continue
+			}
+			reason := "temporary file from an interrupted run"
+			if strings.HasSuffix(match, ".lock") {
+				reason = "lock from an interrupted run"
+			}
+			files = append(files, gcFile{paths: []string{match}, reason: reason})
+		}
+	}
+	return files
+}
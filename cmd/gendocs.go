@@ -0,0 +1,129 @@
+//go:build gendocs
+
+// gen-docs is only built with -tags gendocs, so the packages it needs stay
+// out of release binaries:
+//
+//	go run -tags gendocs . gen-docs dist/docs
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// docFormats are the kinds of files gen-docs writes, each into its own
+// subdirectory of the output directory
+var docFormats = []string{"man", "markdown", "completions"}
+
+var genDocsFormats []string
+
+var genDocsCmd = &cobra.Command{
+	Use:   "gen-docs [dir]",
+	Short: "Generate man pages, Markdown reference, and shell completions",
+	Long: `Write documentation generated from bgit's command definitions into dir
+(default "docs"), for packagers:
+
+  man/          man pages, one per command (bgit.1, bgit-clone.1, ...)
+  markdown/     a Markdown reference, one file per command
+  completions/  bash, zsh, fish, and PowerShell completion scripts
+
+Pages carry no "auto generated" footer. Man pages are dated from
+SOURCE_DATE_EPOCH when it is set, for reproducible builds, and today
+otherwise.`,
+	Example: `  bgit gen-docs dist/docs
+  bgit gen-docs --format man /usr/share/man/man1`,
+	Args:         cobra.MaximumNArgs(1),
+	Hidden:       true,
+	SilenceUsage: true,
+	RunE:         runGenDocs,
+}
+
+func init() {
+	rootCmd.AddCommand(genDocsCmd)
+	genDocsCmd.Flags().StringSliceVar(&genDocsFormats, "format", docFormats, "What to generate: man, markdown, completions")
+}
+
+func runGenDocs(cmd *cobra.Command, args []string) error {
+	dir := "docs"
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	for _, format := range genDocsFormats {
+		if !slices.Contains(docFormats, format) {
+			return withExitCode(exitUsage, fmt.Errorf("unknown format '%s' (use man, markdown, or completions)", format))
+		}
+	}
+
+	root := cmd.Root()
+	root.DisableAutoGenTag = true
+
+	// With a single format, dir is where its files go
+	single := len(genDocsFormats) == 1
+	for _, format := range genDocsFormats {
+		out := dir
+		if !single {
+			out = filepath.Join(dir, format)
+		}
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", out, err)
+		}
+
+		var err error
+		switch format {
+		case "man":
+			err = genManPages(root, out)
+		case "markdown":
+			err = doc.GenMarkdownTree(root, out)
+		case "completions":
+			err = genCompletions(root, out)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate %s: %w", format, err)
+		}
+		ui.Success(fmt.Sprintf("Wrote %s to %s", format, out))
+	}
+	return nil
+}
+
+// genManPages writes a section 1 man page for every command
+func genManPages(root *cobra.Command, dir string) error {
+	header := &doc.GenManHeader{
+		Title:   "BGIT",
+		Section: "1",
+		Source:  "bgit " + version,
+		Manual:  "bgit Manual",
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid SOURCE_DATE_EPOCH '%s'", epoch)
+		}
+		date := time.Unix(seconds, 0).UTC()
+		header.Date = &date
+	}
+	return doc.GenManTree(root, header, dir)
+}
+
+// genCompletions writes the completion script for each shell cobra supports
+func genCompletions(root *cobra.Command, dir string) error {
+	name := root.Name()
+	if err := root.GenBashCompletionFileV2(filepath.Join(dir, name+".bash"), true); err != nil {
+		return err
+	}
+	if err := root.GenZshCompletionFile(filepath.Join(dir, "_"+name)); err != nil {
+		return err
+	}
+	if err := root.GenFishCompletionFile(filepath.Join(dir, name+".fish"), true); err != nil {
+		return err
+	}
+	return root.GenPowerShellCompletionFileWithDesc(filepath.Join(dir, name+".ps1"))
+}
@@ -1,7 +1,21 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/history"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/journal"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/byterings/bgit/internal/user"
 )
 
 // autoInit initializes bgit automatically if not already initialized
@@ -29,3 +43,412 @@ func autoInit() error {
 
 	return nil
 }
+
+// setGlobalUser sets the global git user, first recording the values it
+// replaces so uninstall can put them back
+func setGlobalUser(name, email string) error {
+	if currentName, currentEmail, err := git.GetGlobalUser(); err == nil {
+		if err := config.SaveOriginalGitUser(config.GitUser{Name: currentName, Email: currentEmail}); err != nil {
+			return err
+		}
+	}
+	return git.SetGlobalUser(name, email)
+}
+
+// applyCommitTemplate points commit.template at the user's generated template,
+// globally (empty repoPath) or in a repository. If the user has no template, a
+// template bgit set for another identity is removed; one the user set is kept.
+// Failures are reported but never fail the command.
+func applyCommitTemplate(u *config.User, repoPath string) {
+	path, err := user.WriteCommitTemplate(u)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not write commit template: %v", err))
+		return
+	}
+
+	if path == "" {
+		clearCommitTemplate(repoPath)
+		return
+	}
+	if err := git.SetCommitTemplate(repoPath, path); err != nil {
+		ui.Warning(fmt.Sprintf("Could not set commit.template: %v", err))
+		return
+	}
+	ui.Verbose(fmt.Sprintf("Set commit.template to %s", path))
+}
+
+// syncAllowedSigners keeps bgit's allowed_signers file current when git signs
+// with SSH keys, and points gpg.ssh.allowedSignersFile at it so
+// 'git log --show-signature' verifies every identity's commits locally.
+// Failures are reported but never fail the command.
+func syncAllowedSigners(cfg *config.Config) {
+	sc, err := git.GetSigningConfig("")
+	if err != nil || sc.Format != "ssh" {
+		return
+	}
+
+	path, err := user.WriteAllowedSigners(cfg.Users)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not update allowed signers: %v", err))
+		return
+	}
+	ui.Verbose(fmt.Sprintf("Updated allowed signers: %s", path))
+
+	current, _ := git.GetGlobalConfig("gpg.ssh.allowedSignersFile")
+	if current != "" {
+		if expanded, err := platform.ExpandTilde(current); err == nil && filepath.Clean(expanded) == path {
+			return
+		}
+		ui.Warning(fmt.Sprintf("gpg.ssh.allowedSignersFile is %s; bgit's signers are in %s", current, path))
+		return
+	}
+	if err := git.SetGlobalConfig("gpg.ssh.allowedSignersFile", path); err != nil {
+		ui.Warning(fmt.Sprintf("Could not set gpg.ssh.allowedSignersFile: %v", err))
+	}
+}
+
+// validateCommitTemplate checks that a commit template file can be read
+func validateCommitTemplate(path string) error {
+	expanded, err := platform.ExpandTilde(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.ReadFile(expanded); err != nil {
+		return fmt.Errorf("cannot read commit template: %w", err)
+	}
+	return nil
+}
+
+// clearCommitTemplate removes commit.template if bgit set it
+func clearCommitTemplate(repoPath string) {
+	var current string
+	if repoPath == "" {
+		current, _ = git.GetGlobalConfig("commit.template")
+	} else {
+		current, _ = git.GetLocalConfig(repoPath, "commit.template")
+	}
+	if !user.IsManagedTemplate(current) {
+		return
+	}
+	if err := git.UnsetCommitTemplate(repoPath); err != nil {
+		ui.Warning(fmt.Sprintf("Could not unset commit.template: %v", err))
+	}
+}
+
+// warnMissingAgentSocket warns when an IdentityAgent socket doesn't exist yet,
+// which is normal if the agent (e.g. 1Password) isn't running
+func warnMissingAgentSocket(socket string) {
+	path, err := platform.ExpandTilde(socket)
+	if err != nil {
+		path = socket
+	}
+	if _, err := os.Stat(path); err != nil {
+		ui.Warning(fmt.Sprintf("Agent socket not found: %s (is the agent running?)", socket))
+	}
+}
+
+// findKeyOwner returns another identity that already uses the key at keyPath,
+// matched by path or by public key fingerprint, or nil
+func findKeyOwner(cfg *config.Config, keyPath, exceptAlias string) *config.User {
+	normalize := func(path string) string {
+		if expanded, err := platform.ExpandTilde(path); err == nil {
+			path = expanded
+		}
+		return strings.TrimSuffix(filepath.Clean(path), ".pub")
+	}
+	target := normalize(keyPath)
+	fingerprint, _ := user.GetFingerprint(keyPath)
+
+	for i := range cfg.Users {
+		u := &cfg.Users[i]
+		if u.Alias == exceptAlias || u.SSHKeyPath == "" {
+			continue
+		}
+		if normalize(u.SSHKeyPath) == target {
+			return u
+		}
+		if fingerprint != "" {
+			if fp, err := user.GetFingerprint(u.SSHKeyPath); err == nil && fp == fingerprint {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+// checkDuplicateKey warns when another identity already uses keyPath, since
+// GitHub accepts a key on only one account, and offers to generate a fresh
+// key instead. It returns the key path to use.
+func checkDuplicateKey(cfg *config.Config, keyPath, githubUsername, exceptAlias string) (string, error) {
+	owner := findKeyOwner(cfg, keyPath, exceptAlias)
+	if owner == nil {
+		return keyPath, nil
+	}
+
+	ui.Warning(fmt.Sprintf("%s is already the key for '%s' (%s)", keyPath, owner.Alias, owner.GitHubUsername))
+	fmt.Println("  GitHub rejects a key that is already registered on another account,")
+	fmt.Println("  so one of these identities will fail to authenticate.")
+	if !ui.IsInteractive() {
+		return keyPath, nil
+	}
+
+	generate, err := ui.PromptConfirmation("Generate a new key for this identity instead?")
+	if err != nil {
+		return "", err
+	}
+	if !generate {
+		return keyPath, nil
+	}
+	return generateKeyForAdd(githubUsername, "ed25519")
+}
+
+// recordHistory appends an entry to the history log. A failure to record is
+// reported but never fails the command that made the change.
+func recordHistory(action, userAlias, path, detail string) {
+	entry := history.Entry{Action: action, User: userAlias, Path: path, Detail: detail}
+	if err := history.Record(entry); err != nil {
+		ui.Warning(fmt.Sprintf("Could not update history log: %v", err))
+	}
+}
+
+// describeSource returns a short parenthesized description of where a resolution came from
+func describeSource(resolution *identity.Resolution) string {
+	switch resolution.Source {
+	case identity.SourceWorkspace:
+		return fmt.Sprintf("(workspace: %s)", resolution.Path)
+	case identity.SourceBinding:
+		return "(bound repo)"
+	case identity.SourceRepoFile:
+		return fmt.Sprintf("(repo file: %s)", resolution.Path)
+	case identity.SourceRule:
+		return fmt.Sprintf("(rule: owner %s)", resolution.Owner)
+	case identity.SourceOwner:
+		return fmt.Sprintf("(owner: %s)", resolution.Owner)
+	default:
+		return "(global)"
+	}
+}
+
+// diffLine is one line of a line diff: ' ' kept, '-' only in old, '+' only
+// in new
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines compares old and new line by line, using a longest common
+// subsequence so unchanged lines are matched up
+func diffLines(old, new string) []diffLine {
+	a := strings.Split(strings.TrimRight(old, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(new, "\n"), "\n")
+	if old == "" {
+		a = nil
+	}
+	if new == "" {
+		b = nil
+	}
+
+	// lcs[i][j] is the common subsequence length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// lineDiff returns the lines removed from old ("- ") and added in new ("+ "),
+// in order, skipping unchanged lines
+func lineDiff(old, new string) []string {
+	var changes []string
+	for _, l := range diffLines(old, new) {
+		if l.op != ' ' {
+			changes = append(changes, string(l.op)+" "+l.text)
+		}
+	}
+	return changes
+}
+
+// unifiedDiff returns the hunks of a unified diff from old to new, with
+// context unchanged lines around each change, or nil if they are equal
+func unifiedDiff(old, new string, context int) []string {
+	lines := diffLines(old, new)
+
+	// Mark the lines shown: every change and its context
+	show := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+			show[k] = true
+		}
+	}
+
+	var out []string
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(lines); {
+		if !show[i] {
+			if lines[i].op != '+' {
+				oldLine++
+			}
+			if lines[i].op != '-' {
+				newLine++
+			}
+			i++
+			continue
+		}
+		var hunk []string
+		oldStart, newStart, oldCount, newCount := oldLine, newLine, 0, 0
+		for ; i < len(lines) && show[i]; i++ {
+			hunk = append(hunk, string(lines[i].op)+lines[i].text)
+			if lines[i].op != '+' {
+				oldLine++
+				oldCount++
+			}
+			if lines[i].op != '-' {
+				newLine++
+				newCount++
+			}
+		}
+		// An empty side starts at the line before, as diff -u writes it
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount))
+		out = append(out, hunk...)
+	}
+	return out
+}
+
+// beginUndo snapshots the files an operation may change so 'bgit undo' can
+// restore them: bgit's config, the SSH config, the global git config, the
+// allowed signers file, and, for repository operations, the repository's git
+// config. Failing to snapshot only costs the ability to undo.
+func beginUndo(operation, description, repoRoot string) *journal.Entry {
+	var paths []string
+	if path, err := config.GetConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	if path, err := ssh.GetSSHConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	if path, err := git.GetGlobalConfigPath(); err == nil {
+		paths = append(paths, path)
+	}
+	if path, err := user.AllowedSignersPath(); err == nil {
+		paths = append(paths, path)
+	}
+	if repoRoot != "" {
+		if path, err := git.GetRepoConfigPath(repoRoot); err == nil {
+			paths = append(paths, path)
+		}
+	}
+
+	entry, err := journal.Begin(operation, description, paths...)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not record undo information: %v", err))
+		return nil
+	}
+	return entry
+}
+
+// commitUndo makes an operation started with beginUndo undoable
+func commitUndo(entry *journal.Entry) {
+	if err := entry.Commit(); err != nil {
+		ui.Warning(fmt.Sprintf("Could not record undo information: %v", err))
+	}
+}
+
+// identityEnv returns the BGIT_* variables plugins and hooks receive: bgit's
+// version, config directory, and executable, and the identity described by
+// resolution. Identity variables are always set, empty for a nil resolution,
+// so values inherited from an outer bgit process can't leak through.
+func identityEnv(resolution *identity.Resolution) []string {
+	vars := [][2]string{{"BGIT_VERSION", version}}
+	if dir, err := config.GetConfigDir(); err == nil {
+		vars = append(vars, [2]string{"BGIT_CONFIG_DIR", dir})
+	}
+	if exe, err := os.Executable(); err == nil {
+		vars = append(vars, [2]string{"BGIT_BIN", exe})
+	}
+
+	names := []string{"BGIT_ALIAS", "BGIT_NAME", "BGIT_EMAIL", "BGIT_AUTHOR_EMAIL", "BGIT_COMMITTER_EMAIL",
+		"BGIT_GITHUB_USER", "BGIT_SSH_KEY", "BGIT_SSH_HOST", "BGIT_IDENTITY_SOURCE", "BGIT_IDENTITY_PATH"}
+	values := make(map[string]string)
+	if resolution != nil && resolution.User != nil {
+		u := resolution.User
+		values["BGIT_ALIAS"] = resolution.Alias
+		values["BGIT_NAME"] = u.Name
+		values["BGIT_EMAIL"] = u.Email
+		values["BGIT_AUTHOR_EMAIL"] = u.EffectiveAuthorEmail()
+		values["BGIT_COMMITTER_EMAIL"] = u.EffectiveCommitterEmail()
+		values["BGIT_GITHUB_USER"] = u.GitHubUsername
+		if !u.UsesIdentityAgent() {
+			values["BGIT_SSH_KEY"] = u.SSHKeyPath
+		}
+		if u.HasSSHHost() {
+			values["BGIT_SSH_HOST"] = ssh.GetHostForUser(u.GitHubUsername)
+		}
+		values["BGIT_IDENTITY_SOURCE"] = string(resolution.Source)
+		values["BGIT_IDENTITY_PATH"] = resolution.Path
+	}
+	for _, name := range names {
+		vars = append(vars, [2]string{name, values[name]})
+	}
+
+	env := make([]string, len(vars))
+	for i, v := range vars {
+		env[i] = v[0] + "=" + v[1]
+	}
+	return env
+}
+
+// systemSuffix marks list entries that come from the system config
+func systemSuffix(system bool) string {
+	if system {
+		return "  (system)"
+	}
+	return ""
+}
+
+// agentLifetime returns how long the SSH agent should keep u's key, warning
+// about and ignoring an agent_lifetime bgit can't read
+func agentLifetime(u *config.User) time.Duration {
+	lifetime, err := u.KeyLifetime(time.Now())
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Ignoring agent_lifetime of '%s': %v", u.Alias, err))
+		return 0
+	}
+	return lifetime
+}
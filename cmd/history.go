@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/history"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show when and where identities were used",
+	Long: `Show the identity history log, newest first.
+
+bgit records every identity switch (bgit use), repository bind and unbind, and
+remote fix or restore in an append-only log at ~/.bgit/history.log. Use it to
+audit when an identity was active and which repositories it touched.
+
+Examples:
+  bgit history                 # Last 20 entries
+  bgit history --user work     # Only entries for 'work'
+  bgit history --path .        # Only entries in this directory tree
+  bgit history --limit 0       # Everything`,
+	RunE: runHistory,
+}
+
+var (
+	historyUser  string
+	historyPath  string
+	historyLimit int
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().StringVarP(&historyUser, "user", "u", "", "Only show entries for this identity")
+	historyCmd.Flags().StringVarP(&historyPath, "path", "p", "", "Only show entries at or below this directory")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Maximum entries to show (0 for all)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	var pathFilter string
+	if historyPath != "" {
+		pathFilter, err = filepath.Abs(historyPath)
+		if err != nil {
+			return fmt.Errorf("invalid path: %w", err)
+		}
+	}
+
+	var shown []history.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if historyUser != "" && e.User != historyUser {
+			continue
+		}
+		if pathFilter != "" && !isPathWithin(e.Path, pathFilter) {
+			continue
+		}
+		shown = append(shown, e)
+		if historyLimit > 0 && len(shown) == historyLimit {
+			break
+		}
+	}
+
+	if len(shown) == 0 {
+		ui.Info("No history recorded yet")
+		return nil
+	}
+
+	home, _ := os.UserHomeDir()
+	for _, e := range shown {
+		path := e.Path
+		if home != "" && strings.HasPrefix(path, home) {
+			path = "~" + strings.TrimPrefix(path, home)
+		}
+		userAlias := e.User
+		if userAlias == "" {
+			userAlias = "-"
+		}
+
+		fmt.Printf("%s  %-14s %-12s %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Action, userAlias, path)
+		if e.Detail != "" {
+			fmt.Printf("%s  %s\n", strings.Repeat(" ", 16), e.Detail)
+		}
+	}
+
+	return nil
+}
+
+// isPathWithin reports whether path is dir or inside it
+func isPathWithin(path, dir string) bool {
+	if path == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/hooks"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var hookForce bool
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage bgit git hooks in the current repository",
+	Long: `Install or remove optional git hooks that check identity automatically.
+
+Available hooks:
+  post-checkout  Runs 'bgit verify --quiet' after checkout and clone, warning
+                 when the repository's git email doesn't match the bgit identity
+  pre-push       Runs 'bgit verify-push', blocking pushes whose URL would
+                 authenticate as a different GitHub account than the
+                 repository's identity ('git push --no-verify' skips it)`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install [hook]",
+	Short: "Install a bgit hook (default: post-checkout)",
+	Example: `  bgit hook install
+  bgit hook install pre-push
+  bgit hook install post-checkout --force`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runHookInstall,
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall [hook]",
+	Short: "Remove a bgit hook (default: post-checkout)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runHookUninstall,
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookInstallCmd)
+	hookCmd.AddCommand(hookUninstallCmd)
+	hookInstallCmd.Flags().BoolVarP(&hookForce, "force", "f", false, "Replace an existing hook not created by bgit")
+}
+
+func runHookInstall(cmd *cobra.Command, args []string) error {
+	repoRoot, name, err := hookTarget(args)
+	if err != nil {
+		return err
+	}
+
+	if err := hooks.Install(repoRoot, name, hookForce); err != nil {
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Installed %s hook in %s", name, shortenPath(repoRoot)))
+	return nil
+}
+
+func runHookUninstall(cmd *cobra.Command, args []string) error {
+	repoRoot, name, err := hookTarget(args)
+	if err != nil {
+		return err
+	}
+
+	removed, err := hooks.Uninstall(repoRoot, name)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		ui.Info(fmt.Sprintf("No bgit %s hook installed", name))
+		return nil
+	}
+
+	ui.Success(fmt.Sprintf("Removed %s hook from %s", name, shortenPath(repoRoot)))
+	return nil
+}
+
+// hookTarget returns the current repository root and the requested hook name
+func hookTarget(args []string) (string, string, error) {
+	name := hooks.PostCheckout
+	if len(args) == 1 {
+		name = args[0]
+	}
+
+	known := false
+	for _, n := range hooks.Names() {
+		if n == name {
+			known = true
+		}
+	}
+	if !known {
+		return "", "", fmt.Errorf("unknown hook '%s' (available: %s)", name, strings.Join(hooks.Names(), ", "))
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	repoRoot := identity.FindGitRoot(cwd)
+	if repoRoot == "" {
+		return "", "", fmt.Errorf("not in a git repository")
+	}
+
+	return repoRoot, name, nil
+}
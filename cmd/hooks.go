@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// Switch hooks are the [hooks] pre_use and post_use commands in config.toml.
+// They run in a shell with the terminal attached, so they can prompt, and get
+// the identity being switched to in the same BGIT_* variables plugins get,
+// plus BGIT_HOOK and BGIT_PREVIOUS_ALIAS.
+
+// Hook names as they appear in config.toml and BGIT_HOOK
+const (
+	hookPreUse  = "pre_use"
+	hookPostUse = "post_use"
+)
+
+// addUseHooks adds the hooks configured for name to a 'bgit use' plan.
+// A failing pre_use hook stops the plan before anything else changes; a
+// failing post_use hook is reported without undoing the switch.
+func addUseHooks(plan *changePlan, name string, commands []string, user *config.User, previousAlias string) {
+	env := append(identityEnv(&identity.Resolution{User: user, Alias: user.Alias, Source: identity.SourceGlobal}),
+		"BGIT_HOOK="+name,
+		"BGIT_PREVIOUS_ALIAS="+previousAlias)
+
+	for _, command := range commands {
+		command := command
+		plan.add(fmt.Sprintf("Run %s hook: %s", name, command), func() error {
+			err := runHook(command, env)
+			if err == nil {
+				return nil
+			}
+			if name == hookPreUse {
+				return fmt.Errorf("%s hook '%s' failed: %w", name, command, err)
+			}
+			ui.Warning(fmt.Sprintf("%s hook '%s' failed: %v", name, command, err))
+			return nil
+		})
+	}
+}
+
+// runHook runs one hook command line in the platform shell
+func runHook(command string, env []string) error {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "powershell", "-Command"
+	}
+
+	// Hooks may prompt or take a while, so they run without a deadline
+	hook := ui.Command(shell, flag, command).WithTimeout(0)
+	hook.Stdin = os.Stdin
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(), env...)
+	return hook.Run()
+}
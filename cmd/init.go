@@ -2,20 +2,38 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/byterings/bgit/internal/user"
 )
 
+var initFromGit bool
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize bgit configuration",
-	Long:  `Initialize bgit by creating the configuration directory. This is optional - bgit will auto-initialize on first use.`,
-	RunE:  runInit,
+	Long: `Initialize bgit by creating the configuration directory. This is optional - bgit will auto-initialize on first use.
+
+With --from-git, bgit also reads the user.name/user.email in your global git
+config and in the files it includes (include and includeIf), and offers to
+create an identity from each. An includeIf "gitdir:..." directory can become a
+workspace for its identity.`,
+	Example: `  bgit init
+  bgit init --from-git`,
+	RunE: runInit,
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().BoolVar(&initFromGit, "from-git", false, "Offer to create identities from the existing git config")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
@@ -28,6 +46,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if exists {
 		configDir, _ := config.GetConfigDir()
 		fmt.Printf("bgit is already initialized at: %s\n", configDir)
+		if initFromGit {
+			return importFromGit()
+		}
 		return nil
 	}
 
@@ -48,8 +69,170 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	configDir, _ := config.GetConfigDir()
-	fmt.Printf("✓ bgit initialized at: %s\n", configDir)
+	ui.Printf("✓ bgit initialized at: %s\n", configDir)
+	if initFromGit {
+		return importFromGit()
+	}
 	fmt.Println("\nNext: bgit add user")
 
 	return nil
 }
+
+// importFromGit offers to create an identity from each user found in the
+// global git config and its includes
+func importFromGit() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	found, err := git.FindConfigIdentities()
+	if err != nil {
+		return fmt.Errorf("failed to read git config: %w", err)
+	}
+
+	var candidates []git.ConfigIdentity
+	for _, id := range found {
+		if existing := cfg.FindUserByCommitEmail(id.Email); existing != nil {
+			ui.Info(fmt.Sprintf("%s is already identity '%s'", id.Email, existing.Alias))
+			continue
+		}
+		candidates = append(candidates, id)
+	}
+	if len(candidates) == 0 {
+		fmt.Println()
+		ui.Info("No new identities found in git config")
+		fmt.Println("\nNext: bgit add")
+		return nil
+	}
+
+	var added []string
+	for _, id := range candidates {
+		fmt.Println()
+		fmt.Printf("Found in %s: %s <%s>\n", describeGitSource(id.Source), id.Name, id.Email)
+		if id.GitDir != "" {
+			fmt.Printf("  Applies to repositories under %s\n", id.GitDir)
+		}
+		confirmed, err := ui.PromptConfirmation("Create an identity from it?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			continue
+		}
+
+		alias, githubUsername, err := ui.PromptImportedUser(suggestAlias(id), suggestGitHubUsername(id.Email))
+		if err != nil {
+			return fmt.Errorf("failed to get user info: %w", err)
+		}
+
+		sshKeyPath := ""
+		if id.SSHKeyPath != "" {
+			if err := user.ValidateSSHKeyPath(id.SSHKeyPath); err != nil {
+				ui.Warning(fmt.Sprintf("Ignoring key from core.sshCommand: %v", err))
+			} else {
+				sshKeyPath = id.SSHKeyPath
+				ui.Success(fmt.Sprintf("Using key from core.sshCommand: %s", sshKeyPath))
+			}
+		}
+
+		newUser := config.User{
+			Alias:          alias,
+			Name:           id.Name,
+			Email:          id.Email,
+			GitHubUsername: githubUsername,
+			SSHKeyPath:     sshKeyPath,
+		}
+		if err := cfg.AddUser(newUser); err != nil {
+			ui.Error(fmt.Sprintf("Failed to add user: %v", err))
+			continue
+		}
+		ui.Success(fmt.Sprintf("User '%s' added", alias))
+		added = append(added, alias)
+
+		if id.GitDir != "" {
+			offerImportedWorkspace(cfg, id.GitDir, alias)
+		}
+	}
+
+	if len(added) == 0 {
+		fmt.Println("\nNext: bgit add")
+		return nil
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	syncAllowedSigners(cfg)
+
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Imported %d identity(ies) from git config", len(added)))
+	for _, alias := range added {
+		if u := cfg.FindUserByAlias(alias); u != nil && !u.HasSSHHost() {
+			fmt.Printf("  Add an SSH key later: bgit update %s --ssh-key <path>\n", alias)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Next: bgit use %s\n", added[0])
+
+	return nil
+}
+
+// offerImportedWorkspace offers to make an includeIf gitdir directory a
+// workspace for the imported identity
+func offerImportedWorkspace(cfg *config.Config, dir, alias string) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return
+	}
+	if ws := cfg.FindWorkspaceByPath(dir); ws != nil && platform.SamePath(ws.Path, dir) {
+		return
+	}
+
+	confirmed, err := ui.PromptConfirmation(fmt.Sprintf("Make %s a workspace for '%s'?", dir, alias))
+	if err != nil || !confirmed {
+		return
+	}
+	if err := cfg.AddWorkspace(dir, alias); err != nil {
+		ui.Warning(fmt.Sprintf("Failed to add workspace: %v", err))
+		return
+	}
+	ui.Success(fmt.Sprintf("Workspace added: %s/**  →  %s", dir, alias))
+}
+
+// describeGitSource returns a short label for where a git identity was found
+func describeGitSource(source string) string {
+	if source == "global" {
+		return "global git config"
+	}
+	return shortenPath(source)
+}
+
+// suggestAlias proposes an alias: the includeIf directory name, "personal"
+// for the global identity, or the email's domain name
+func suggestAlias(id git.ConfigIdentity) string {
+	if id.GitDir != "" {
+		return strings.ToLower(filepath.Base(id.GitDir))
+	}
+	if id.Source == "global" {
+		return "personal"
+	}
+	_, domain, ok := strings.Cut(id.Email, "@")
+	if !ok {
+		return ""
+	}
+	label, _, _ := strings.Cut(domain, ".")
+	return strings.ToLower(label)
+}
+
+// suggestGitHubUsername extracts the username from a GitHub noreply email
+// (12345+octocat@users.noreply.github.com)
+func suggestGitHubUsername(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || !strings.EqualFold(domain, "users.noreply.github.com") {
+		return ""
+	}
+	if _, username, ok := strings.Cut(local, "+"); ok {
+		return username
+	}
+	return local
+}
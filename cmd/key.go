@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/byterings/bgit/internal/agent"
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
+	"github.com/spf13/cobra"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Inspect and manage identities' SSH keys",
+}
+
+var keyListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List each identity's SSH key with its SHA256 fingerprint",
+	Long: `List the SSH key of every identity with its type, SHA256 fingerprint, and
+whether the SSH agent has it loaded.
+
+The fingerprints are the ones GitHub shows under Settings → SSH and GPG keys,
+so you can check which account each key is uploaded to.`,
+	Args: cobra.NoArgs,
+	RunE: runKeyList,
+}
+
+var keyCommentCmd = &cobra.Command{
+	Use:   "comment <alias>",
+	Short: "Set a key's comment to alias@hostname (bgit)",
+	Long: `Rewrite the comment of an identity's key pair to the canonical
+alias@hostname (bgit), so keys are easy to tell apart in 'ssh-add -l' and on
+GitHub's SSH keys page. A key already loaded in the agent is re-added so the
+agent shows the new comment.
+
+ssh-keygen asks for the passphrase of a protected key. For keys held by an
+external agent (1Password) only the .pub file can be changed.
+
+The comment on GitHub is set when the key is uploaded; re-upload the .pub file
+to change it there.`,
+	Example:      `  bgit key comment work`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runKeyComment,
+}
+
+var keyPasswdCmd = &cobra.Command{
+	Use:   "passwd <alias>",
+	Short: "Add, change, or remove the passphrase of an identity's key",
+	Long: `Run 'ssh-keygen -p' on an identity's private key to add, change, or remove
+its passphrase (leave the new passphrase empty to remove it), then load the
+key into the SSH agent again with the new passphrase. On macOS this also
+updates the passphrase stored in the keychain.`,
+	Example:      `  bgit key passwd work`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runKeyPasswd,
+}
+
+var (
+	keyRotateTag    string
+	keyRotateDryRun bool
+)
+
+var keyRotateCmd = &cobra.Command{
+	Use:   "rotate [alias...]",
+	Short: "Replace identities' SSH keys with new ed25519 keys",
+	Long: `Generate a new ed25519 key for each named identity, or for every identity
+with --tag, and switch the identity to it: bgit's config, the SSH config, and
+the allowed signers file are updated and the key is loaded into the SSH agent.
+
+New keys are written to ~/.ssh/bgit_<username>_<YYYYMMDD> without a
+passphrase; add one with 'bgit key passwd'. The old key files are left in
+place, so pushes keep working until the new public key is uploaded to GitHub.
+Delete the old key there and on disk afterwards.
+
+Identities whose key lives in an external agent (1Password) or on a security
+key are skipped. 'bgit undo' switches the identities back to their old keys.`,
+	Example: `  bgit key rotate work
+  bgit key rotate --tag client-a --dry-run`,
+	SilenceUsage: true,
+	RunE:         runKeyRotate,
+}
+
+func init() {
+	rootCmd.AddCommand(keyCmd)
+	keyCmd.AddCommand(keyListCmd)
+	keyCmd.AddCommand(keyCommentCmd)
+	keyCmd.AddCommand(keyPasswdCmd)
+	keyCmd.AddCommand(keyRotateCmd)
+
+	keyRotateCmd.Flags().StringVar(&keyRotateTag, "tag", "", "Rotate the keys of every identity with this tag")
+	keyRotateCmd.Flags().BoolVar(&keyRotateDryRun, "dry-run", false, "List the keys that would be replaced without changing anything")
+}
+
+func runKeyList(cmd *cobra.Command, args []string) error {
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if len(cfg.Users) == 0 {
+		fmt.Println(ui.T("No users configured yet."))
+		return nil
+	}
+
+	keys, agentErr := agent.ListKeys()
+	agentRunning := os.Getenv("SSH_AUTH_SOCK") != "" && agentErr == nil
+
+	fmt.Println()
+	fmt.Printf("  %-16s %-12s %-52s %-10s %s\n", "IDENTITY", "TYPE", "FINGERPRINT", "IN AGENT", "PATH")
+	for _, user := range cfg.Users {
+		if user.SSHKeyPath == "" {
+			fmt.Printf("  %-16s %s\n", user.Alias, "(no key)")
+			continue
+		}
+
+		keyType, err := userpkg.KeyType(user.SSHKeyPath)
+		if err != nil {
+			keyType = "?"
+		}
+		fingerprint, err := userpkg.GetFingerprint(user.SSHKeyPath)
+		if err != nil {
+			fingerprint = "(unreadable key)"
+		}
+
+		inAgent := "-"
+		switch {
+		case user.UsesIdentityAgent():
+			inAgent = "external"
+		case agentRunning && err == nil:
+			inAgent = "no"
+			if agent.HasFingerprint(keys, fingerprint) {
+				inAgent = "yes"
+			}
+		}
+		fmt.Printf("  %-16s %-12s %-52s %-10s %s\n", user.Alias, keyType, fingerprint, inAgent, shortenPath(user.SSHKeyPath))
+	}
+	fmt.Println()
+	return nil
+}
+
+func runKeyComment(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	user := cfg.FindUserByAlias(args[0])
+	if user == nil {
+		return fmt.Errorf("user '%s' not found", args[0])
+	}
+	if user.SSHKeyPath == "" {
+		return fmt.Errorf("'%s' has no SSH key", user.Alias)
+	}
+
+	comment := userpkg.CanonicalKeyComment(user.Alias)
+	if _, current, err := userpkg.ReadPublicKeyFile(userpkg.PublicKeyPath(user.SSHKeyPath)); err == nil && current == comment {
+		ui.Info(fmt.Sprintf("Key for '%s' is already labeled '%s'", user.Alias, comment))
+		return nil
+	}
+
+	// Only refresh the agent's copy if it already holds the key
+	fingerprint, _ := userpkg.GetFingerprint(user.SSHKeyPath)
+	keys, _ := agent.ListKeys()
+	inAgent := fingerprint != "" && agent.HasFingerprint(keys, fingerprint) && !user.UsesIdentityAgent()
+
+	if err := userpkg.SetKeyComment(user.SSHKeyPath, comment); err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Key for '%s' is now labeled '%s'", user.Alias, comment))
+
+	if inAgent {
+		if err := agent.AddKey(user.SSHKeyPath, agentLifetime(user)); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update the agent: %v", err))
+		} else {
+			ui.Success("Agent entry updated")
+		}
+	}
+	if user.UsesIdentityAgent() {
+		ui.Info(fmt.Sprintf("Only %s changed; %s keeps its own name for the key", shortenPath(user.SSHKeyPath), user.IdentityAgent))
+	}
+	ui.Info("GitHub keeps the title given at upload; re-add the key there to rename it")
+	return nil
+}
+
+func runKeyPasswd(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	user := cfg.FindUserByAlias(args[0])
+	if user == nil {
+		return fmt.Errorf("user '%s' not found", args[0])
+	}
+	if user.SSHKeyPath == "" {
+		return fmt.Errorf("'%s' has no SSH key", user.Alias)
+	}
+	if user.UsesIdentityAgent() {
+		return fmt.Errorf("the key for '%s' is held by %s; change its passphrase there", user.Alias, user.IdentityAgent)
+	}
+	if !ui.IsInteractive() {
+		return ui.ErrNotInteractive
+	}
+
+	if err := userpkg.ChangePassphrase(user.SSHKeyPath); err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Passphrase updated for '%s'", user.Alias))
+
+	if os.Getenv("SSH_AUTH_SOCK") == "" {
+		return nil
+	}
+	if err := agent.AddKey(user.SSHKeyPath, agentLifetime(user)); err != nil {
+		ui.Warning(fmt.Sprintf("Could not load the key into the agent: %v", err))
+		return nil
+	}
+	ui.Success("Key loaded into the SSH agent")
+	return nil
+}
+
+func runKeyRotate(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && keyRotateTag == "" {
+		return withExitCode(exitUsage, fmt.Errorf("name the identities to rotate or pass --tag"))
+	}
+	if len(args) > 0 && keyRotateTag != "" {
+		return withExitCode(exitUsage, fmt.Errorf("pass either aliases or --tag, not both"))
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var users []*config.User
+	if keyRotateTag != "" {
+		users = cfg.UsersWithTag(keyRotateTag)
+		if len(users) == 0 {
+			return fmt.Errorf("no identities tagged '%s'", keyRotateTag)
+		}
+	} else {
+		for _, alias := range args {
+			user := cfg.FindUserByAlias(alias)
+			if user == nil {
+				return fmt.Errorf("user '%s' not found", alias)
+			}
+			users = append(users, user)
+		}
+	}
+
+	var rotate []*config.User
+	for _, user := range users {
+		switch {
+		case user.UsesIdentityAgent():
+			ui.Warning(fmt.Sprintf("Skipping '%s': its key is held by %s; rotate it there", user.Alias, user.IdentityAgent))
+		case user.SSHKeyPath != "" && userpkg.IsSecurityKey(user.SSHKeyPath):
+			ui.Warning(fmt.Sprintf("Skipping '%s': generate a new security key with ssh-keygen and set it with 'bgit update %s --ssh-key'", user.Alias, user.Alias))
+		default:
+			rotate = append(rotate, user)
+		}
+	}
+	if len(rotate) == 0 {
+		return fmt.Errorf("no keys to rotate")
+	}
+
+	if keyRotateDryRun {
+		sshDir, err := platform.GetSSHDir()
+		if err != nil {
+			return err
+		}
+		fmt.Println("Dry run: the following keys would be replaced")
+		for _, user := range rotate {
+			old := "(no key)"
+			if user.SSHKeyPath != "" {
+				old = shortenPath(user.SSHKeyPath)
+			}
+			ui.Printf("  %-16s %s → %s\n", user.Alias, old, shortenPath(userpkg.RotatedKeyPath(sshDir, user.GitHubUsername, time.Now())))
+		}
+		return nil
+	}
+
+	type rotation struct {
+		user    *config.User
+		oldPath string
+		oldFP   string
+	}
+	undo := beginUndo("key rotate", fmt.Sprintf("rotate keys of %d identity(ies)", len(rotate)), "")
+	var rotated []rotation
+	for _, user := range rotate {
+		privPath, _, err := userpkg.GenerateRotatedKey(user.GitHubUsername, userpkg.CanonicalKeyComment(user.Alias))
+		if err != nil {
+			ui.Error(fmt.Sprintf("'%s': %v", user.Alias, err))
+			continue
+		}
+		r := rotation{user: user, oldPath: user.SSHKeyPath}
+		if r.oldPath != "" {
+			r.oldFP, _ = userpkg.GetFingerprint(r.oldPath)
+		}
+		user.SSHKeyPath = privPath
+		rotated = append(rotated, r)
+	}
+	if len(rotated) == 0 {
+		return fmt.Errorf("no keys were rotated")
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+		ui.Warning(fmt.Sprintf("Could not update SSH config: %v", err))
+	}
+	syncAllowedSigners(cfg)
+	commitUndo(undo)
+
+	for _, r := range rotated {
+		fmt.Println()
+		ui.Success(fmt.Sprintf("New key for '%s': %s", r.user.Alias, shortenPath(r.user.SSHKeyPath)))
+		if os.Getenv("SSH_AUTH_SOCK") != "" {
+			if err := agent.AddKey(r.user.SSHKeyPath, agentLifetime(r.user)); err != nil {
+				ui.Warning(fmt.Sprintf("Could not load the key into the agent: %v", err))
+			}
+		}
+		if pubKey, err := userpkg.GetPublicKeyContent(r.user.SSHKeyPath); err == nil {
+			fmt.Printf("\nAdd this key to %s's account at https://github.com/settings/keys:\n\n", r.user.GitHubUsername)
+			fmt.Println(strings.TrimSpace(pubKey))
+		}
+		if r.oldPath != "" {
+			fmt.Println()
+			if r.oldFP != "" {
+				fmt.Printf("Then delete the old key (%s) from GitHub and remove %s\n", r.oldFP, shortenPath(r.oldPath))
+			} else {
+				fmt.Printf("Then delete the old key from GitHub and remove %s\n", shortenPath(r.oldPath))
+			}
+		}
+	}
+
+	if len(rotated) < len(rotate) {
+		exit(exitPartialFix)
+	}
+	return nil
+}
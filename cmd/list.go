@@ -2,22 +2,41 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
+	"github.com/byterings/bgit/internal/agent"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
 )
 
+var listTag string
+
 var listCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List all configured user identities",
-	Long:    `Display all configured Git user identities and highlight the active one.`,
-	RunE:    runList,
+	Long: `Display all configured Git user identities and highlight the active one.
+
+With --verbose, also show each identity's SSH host alias, key fingerprint,
+whether the key is loaded in the agent, workspace and binding counts, and when
+it was last activated.
+
+With --tag, list only the identities carrying that tag (see 'bgit update --tag').`,
+	Example: `  bgit list
+  bgit list --tag client-a --verbose`,
+	RunE: runList,
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVar(&listTag, "tag", "", "Only list identities with this tag")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -32,8 +51,128 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	users := cfg.Users
+	if listTag != "" {
+		users = nil
+		for _, u := range cfg.UsersWithTag(listTag) {
+			users = append(users, *u)
+		}
+		if len(users) == 0 {
+			fmt.Println(ui.T("No identities tagged '%s'.", listTag))
+			return nil
+		}
+	}
+
 	// Print users
-	ui.PrintUsersList(cfg.Users, cfg.ActiveUser)
+	if ui.IsVerbose() && len(users) > 0 {
+		printUsersVerbose(cfg, users)
+		return nil
+	}
+	fingerprints := make(map[string]string)
+	for _, user := range users {
+		if user.SSHKeyPath == "" {
+			continue
+		}
+		if fingerprint, err := userpkg.GetFingerprint(user.SSHKeyPath); err == nil {
+			fingerprints[user.Alias] = fingerprint
+		}
+	}
+	ui.PrintUsersList(users, cfg.ActiveUser, fingerprints)
 
 	return nil
 }
+
+// verboseLabels are the field labels of bgit list -v
+var verboseLabels = []string{"GitHub:", "Host alias:", "Tags:", "SSH key:", "Fingerprint:", "In agent:", "Workspaces:", "Bindings:", "Last used:"}
+
+// printUsersVerbose prints each identity with its key, agent, and usage details
+func printUsersVerbose(cfg *config.Config, users []config.User) {
+	keys, agentErr := agent.ListKeys()
+	agentRunning := os.Getenv("SSH_AUTH_SOCK") != "" && agentErr == nil
+
+	fmt.Println("\n" + ui.T("Configured users:"))
+
+	// Field labels are padded to the longest translation so values line up
+	width := 0
+	for _, label := range verboseLabels {
+		width = max(width, utf8.RuneCountInString(ui.T(label)))
+	}
+	field := func(label, value string) {
+		fmt.Printf("    %-*s %s\n", width, ui.T(label), value)
+	}
+
+	for _, user := range users {
+		indicator := " "
+		if user.Alias == cfg.ActiveUser {
+			indicator = ui.ActiveMarker()
+		}
+
+		fmt.Println()
+		fmt.Printf("%s %s  %s <%s>\n", indicator, user.Alias, user.Name, user.Email)
+		field("GitHub:", user.GitHubUsername)
+		field("Host alias:", ssh.GetHostForUser(user.GitHubUsername))
+		if len(user.Tags) > 0 {
+			field("Tags:", strings.Join(user.Tags, ", "))
+		}
+
+		if user.SSHKeyPath == "" && user.UsesIdentityAgent() {
+			field("SSH key:", ui.T("(any key in %s)", user.IdentityAgent))
+		} else if user.SSHKeyPath == "" {
+			field("SSH key:", ui.T("(none)"))
+		} else {
+			field("SSH key:", shortenPath(user.SSHKeyPath))
+			fingerprint, err := userpkg.GetFingerprint(user.SSHKeyPath)
+			if err != nil {
+				field("Fingerprint:", ui.T("(unreadable public key)"))
+			} else {
+				field("Fingerprint:", fingerprint)
+			}
+
+			inAgent := ui.T("agent not running")
+			if user.UsesIdentityAgent() {
+				inAgent = ui.T("external agent (%s)", user.IdentityAgent)
+			} else if agentRunning && err == nil {
+				inAgent = ui.T("no")
+				if agent.HasFingerprint(keys, fingerprint) {
+					inAgent = ui.T("yes")
+				}
+			}
+			field("In agent:", inAgent)
+		}
+
+		bindings := 0
+		for _, b := range cfg.GetBindings() {
+			if b.User == user.Alias {
+				bindings++
+			}
+		}
+		field("Workspaces:", strconv.Itoa(len(cfg.FindWorkspacesByUser(user.Alias))))
+		field("Bindings:", strconv.Itoa(bindings))
+
+		lastUsed := ui.T("never")
+		if !user.LastUsed.IsZero() {
+			lastUsed = fmt.Sprintf("%s (%s)", user.LastUsed.Local().Format("2006-01-02 15:04"), timeAgo(user.LastUsed))
+		}
+		field("Last used:", lastUsed)
+	}
+
+	fmt.Println()
+	if cfg.ActiveUser == "" {
+		fmt.Println(ui.T("No active user set. Use 'bgit use <alias>' to set one."))
+	}
+}
+
+// timeAgo describes how long ago t was in coarse units
+func timeAgo(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return ui.T("just now")
+	case d < time.Hour:
+		return ui.T("%d min ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return ui.T("%d hours ago", int(d.Hours()))
+	default:
+		return ui.T("%d days ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/agent"
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// legacyKeyPrefix names keys generated before bgit was renamed from brgit
+	legacyKeyPrefix = "brgit_"
+	// keyPrefix names the keys bgit generates
+	keyPrefix = "bgit_"
+)
+
+var migrateKeysDryRun bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Bring files left by older versions of bgit up to date",
+}
+
+var migrateKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Rename legacy brgit_* keys to the bgit_* prefix",
+	Long: `Rename the key files of identities that still use keys generated under
+bgit's old name (~/.ssh/brgit_<username>) to ~/.ssh/bgit_<username>, and
+update everything that refers to them in one pass: bgit's config, the SSH
+config's host entries, the allowed signers file, a global user.signingkey
+pointing at the old file, and the SSH agent, which reloads the key under its
+new path.
+
+The keys themselves don't change, so nothing needs updating on GitHub. A key
+is skipped if a file already has its new name. 'bgit undo' restores the
+configs; renamed files keep their new names.`,
+	Example: `  bgit migrate keys --dry-run   # Show the renames
+  bgit migrate keys`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMigrateKeys,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateKeysCmd)
+	migrateKeysCmd.Flags().BoolVar(&migrateKeysDryRun, "dry-run", false, "Show the renames without changing anything")
+}
+
+// keyRename is one identity's legacy key moving to its new name
+type keyRename struct {
+	user     *config.User
+	oldPath  string // as configured: the private key, or the .pub for an identity agent
+	newPath  string
+	inAgent  bool
+	conflict string // a file already at the new name
+}
+
+func runMigrateKeys(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var renames []keyRename
+	for i := range cfg.Users {
+		u := &cfg.Users[i]
+		if u.SSHKeyPath == "" || !strings.HasPrefix(filepath.Base(u.SSHKeyPath), legacyKeyPrefix) {
+			continue
+		}
+		oldPath, err := platform.ExpandTilde(u.SSHKeyPath)
+		if err != nil {
+			oldPath = u.SSHKeyPath
+		}
+		r := keyRename{
+			user:    u,
+			oldPath: oldPath,
+			newPath: filepath.Join(filepath.Dir(oldPath), keyPrefix+strings.TrimPrefix(filepath.Base(oldPath), legacyKeyPrefix)),
+		}
+		for _, path := range keyFiles(r.newPath) {
+			if _, err := os.Stat(path); err == nil {
+				r.conflict = path
+				break
+			}
+		}
+		if !u.UsesIdentityAgent() {
+			r.inAgent = keyInAgent(oldPath)
+		}
+		renames = append(renames, r)
+	}
+
+	if len(renames) == 0 {
+		ui.Success(fmt.Sprintf("No identity uses a %s* key", legacyKeyPrefix))
+		return nil
+	}
+
+	if migrateKeysDryRun {
+		fmt.Println("Dry run: the following keys would be renamed")
+		for _, r := range renames {
+			note := ""
+			switch {
+			case r.conflict != "":
+				note = fmt.Sprintf(" (skipped: %s exists)", shortenPath(r.conflict))
+			case r.inAgent:
+				note = " (reloaded in the SSH agent)"
+			}
+			ui.Printf("  %-16s %s → %s%s\n", r.user.Alias, shortenPath(r.oldPath), shortenPath(r.newPath), note)
+		}
+		return nil
+	}
+
+	undo := beginUndo("migrate keys", fmt.Sprintf("rename %d legacy key(s)", len(renames)), "")
+	var renamed []keyRename
+	failed := 0
+	for _, r := range renames {
+		if r.conflict != "" {
+			ui.Error(fmt.Sprintf("'%s': %s already exists; leaving %s as it is", r.user.Alias, shortenPath(r.conflict), shortenPath(r.oldPath)))
+			failed++
+			continue
+		}
+		if err := renameKeyFiles(r.oldPath, r.newPath); err != nil {
+			ui.Error(fmt.Sprintf("'%s': %v", r.user.Alias, err))
+			failed++
+			continue
+		}
+		r.user.SSHKeyPath = r.newPath
+		renamed = append(renamed, r)
+	}
+	if len(renamed) == 0 {
+		return fmt.Errorf("no keys were renamed")
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+		ui.Warning(fmt.Sprintf("Could not update SSH config: %v", err))
+	}
+	syncAllowedSigners(cfg)
+	for _, r := range renamed {
+		updateSigningKeyPath(r.oldPath, r.newPath)
+	}
+	commitUndo(undo)
+
+	for _, r := range renamed {
+		ui.Success(fmt.Sprintf("'%s': %s → %s", r.user.Alias, shortenPath(r.oldPath), shortenPath(r.newPath)))
+		if !r.inAgent {
+			continue
+		}
+		// The agent labels a key with the path it was loaded from
+		agent.RemoveKey(r.newPath)
+		if err := agent.AddKey(r.newPath, agentLifetime(r.user)); err != nil {
+			ui.Warning(fmt.Sprintf("Could not reload the key into the agent: %v", err))
+		}
+	}
+
+	if failed > 0 {
+		exit(exitPartialFix)
+	}
+	return nil
+}
+
+// keyFiles returns a key pair's two files from either one's path
+func keyFiles(path string) []string {
+	private := strings.TrimSuffix(path, ".pub")
+	return []string{private, private + ".pub"}
+}
+
+// renameKeyFiles moves a key pair's files from oldPath's names to newPath's,
+// skipping a half that doesn't exist, and puts them back if one move fails
+func renameKeyFiles(oldPath, newPath string) error {
+	from, to := keyFiles(oldPath), keyFiles(newPath)
+	var moved []int
+	for i := range from {
+		if _, err := os.Stat(from[i]); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(from[i], to[i]); err != nil {
+			for _, j := range moved {
+				os.Rename(to[j], from[j])
+			}
+			return fmt.Errorf("failed to rename %s: %w", shortenPath(from[i]), err)
+		}
+		moved = append(moved, i)
+	}
+	if len(moved) == 0 {
+		return fmt.Errorf("%s does not exist", shortenPath(oldPath))
+	}
+	return nil
+}
+
+// updateSigningKeyPath points a global user.signingkey naming either file of
+// the old key pair at the same file under its new name
+func updateSigningKeyPath(oldPath, newPath string) {
+	current, _ := git.GetGlobalConfig("user.signingkey")
+	if current == "" {
+		return
+	}
+	expanded, err := platform.ExpandTilde(current)
+	if err != nil {
+		return
+	}
+	from, to := keyFiles(oldPath), keyFiles(newPath)
+	for i := range from {
+		if filepath.Clean(expanded) == from[i] {
+			if err := git.SetGlobalConfig("user.signingkey", to[i]); err != nil {
+				ui.Warning(fmt.Sprintf("Could not update user.signingkey: %v", err))
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var migrateSSHDryRun bool
+
+var migrateSSHCmd = &cobra.Command{
+	Use:   "migrate-ssh",
+	Short: "Consolidate bgit's SSH config blocks into one managed block",
+	Long: `Find every block bgit has written to ~/.ssh/config, including ones with the
+older "BRGIT MANAGED" markers, blocks that lost their END marker, and stray
+END markers, and replace them with a single block with the current markers,
+generated from your identities.
+
+Each block and the hosts in it are reported. Hosts no identity generates any
+more are dropped; the previous file is kept in ~/.bgit/backups.`,
+	Example: `  bgit migrate-ssh --dry-run   # Show what would be merged
+  bgit migrate-ssh`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runMigrateSSH,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateSSHCmd)
+	migrateSSHCmd.Flags().BoolVar(&migrateSSHDryRun, "dry-run", false, "Report what would be merged without changing the SSH config")
+}
+
+func runMigrateSSH(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	sshConfigPath, err := ssh.GetSSHConfigPath()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(sshConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	report := ssh.FindManagedSections(string(content))
+	if !report.NeedsMigration() {
+		if len(report.Sections) == 0 {
+			ui.Info(fmt.Sprintf("%s has no bgit block; 'bgit sync' writes one", shortenPath(sshConfigPath)))
+		} else {
+			ui.Success(fmt.Sprintf("%s already has a single bgit block", shortenPath(sshConfigPath)))
+		}
+		return nil
+	}
+
+	fmt.Printf("Found %s in %s:\n", describeMarkerProblems(report), shortenPath(sshConfigPath))
+	for _, s := range report.Sections {
+		fmt.Printf("  lines %d-%d: %s\n", s.Start, s.End, describeSection(s))
+	}
+	for _, line := range report.StrayEnds {
+		fmt.Printf("  line %d: END marker with no BEGIN\n", line)
+	}
+
+	// Hosts in the old blocks that the new one keeps or loses
+	generated := make(map[string]bool)
+	for _, host := range ssh.ManagedHosts(cfg.Users) {
+		generated[host] = true
+	}
+	var merged, dropped []string
+	seen := make(map[string]bool)
+	for _, s := range report.Sections {
+		for _, host := range s.Hosts {
+			if seen[host] {
+				continue
+			}
+			seen[host] = true
+			if generated[host] {
+				merged = append(merged, host)
+			} else {
+				dropped = append(dropped, host)
+			}
+		}
+	}
+
+	fmt.Println()
+	if len(merged) > 0 {
+		fmt.Printf("Merged into one block: %s\n", strings.Join(merged, ", "))
+	}
+	if len(dropped) > 0 {
+		fmt.Printf("Dropped (no identity generates them): %s\n", strings.Join(dropped, ", "))
+	}
+
+	if migrateSSHDryRun {
+		fmt.Println()
+		ui.Info("Dry run: the SSH config was not changed")
+		return nil
+	}
+
+	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+		return fmt.Errorf("failed to update SSH config: %w", err)
+	}
+	fmt.Println()
+	ui.Success(fmt.Sprintf("%s now has one bgit block (the previous version is in ~/.bgit/backups)", shortenPath(sshConfigPath)))
+	return nil
+}
+
+// describeMarkerProblems summarizes what keeps an SSH config from having a
+// single current bgit block, e.g. "2 bgit blocks, legacy BRGIT markers"
+func describeMarkerProblems(report ssh.MarkerReport) string {
+	var parts []string
+	if len(report.Sections) > 1 {
+		parts = append(parts, fmt.Sprintf("%d bgit blocks", len(report.Sections)))
+	}
+	legacy, unterminated := false, false
+	for _, s := range report.Sections {
+		if s.Legacy() {
+			legacy = true
+		}
+		if !s.Terminated() {
+			unterminated = true
+		}
+	}
+	if legacy {
+		parts = append(parts, "legacy BRGIT markers")
+	}
+	if unterminated {
+		parts = append(parts, "a block without its END marker")
+	}
+	if n := len(report.StrayEnds); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d stray END marker(s)", n))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "nonstandard bgit markers")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeSection describes one bgit block for migrate-ssh's report
+func describeSection(s ssh.ManagedSection) string {
+	var notes []string
+	switch {
+	case s.Legacy():
+		notes = append(notes, "legacy BRGIT markers")
+	case s.Terminated() && !s.Canonical():
+		notes = append(notes, fmt.Sprintf("nonstandard markers '%s' ... '%s'", s.StartMarker, s.EndMarker))
+	default:
+		notes = append(notes, "current markers")
+	}
+	if !s.Terminated() {
+		notes = append(notes, "no END marker")
+	}
+	if len(s.Hosts) == 0 {
+		notes = append(notes, "no hosts")
+	} else {
+		notes = append(notes, "hosts "+strings.Join(s.Hosts, ", "))
+	}
+	return strings.Join(notes, "; ")
+}
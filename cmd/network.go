@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// defaultNetworkTimeout bounds a network operation when --timeout isn't given
+const defaultNetworkTimeout = 10 * time.Second
+
+// networkTimeout is how long one network operation may take (--timeout)
+var networkTimeout = defaultNetworkTimeout
+
+// errInterrupted is returned when Ctrl-C cancels a network operation
+var errInterrupted = errors.New("interrupted")
+
+// networkContext returns the context for one network operation: a GitHub API
+// request or an SSH connectivity check. It ends after --timeout, or when
+// Ctrl-C is pressed, which cancels the operation so bgit can report it instead
+// of being killed in the middle. Call the CancelFunc once the operation is
+// done; Ctrl-C then interrupts bgit as usual again.
+func networkContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := context.WithTimeout(ctx, networkTimeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// interrupted reports whether ctx was canceled by Ctrl-C rather than its
+// deadline
+func interrupted(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// networkError attaches an exit code to err from a network operation run
+// under ctx: exitInterrupted after Ctrl-C, and exitNetwork otherwise, naming
+// the timeout when that is what ended it
+func networkError(ctx context.Context, err error) error {
+	switch {
+	case interrupted(ctx):
+		return withExitCode(exitInterrupted, errInterrupted)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return withExitCode(exitNetwork, fmt.Errorf("timed out after %s (raise the limit with --timeout)", networkTimeout))
+	default:
+		return withExitCode(exitNetwork, err)
+	}
+}
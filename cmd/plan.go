@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byterings/bgit/internal/journal"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// plannedChange is one git, SSH, or file modification a command will make.
+// Commands collect their changes first so --dry-run prints exactly what a
+// real run would do.
+type plannedChange struct {
+	description string
+	details     []string // Extra lines shown by a dry run, e.g. an SSH config diff
+	apply       func() error
+	unattended  bool // Approved in advance, e.g. by workspace auto_fix_remotes, so applied without asking
+}
+
+// changePlan is an ordered list of planned changes
+type changePlan []plannedChange
+
+// add appends a change to the plan
+func (p *changePlan) add(description string, apply func() error, details ...string) {
+	*p = append(*p, plannedChange{description: description, details: details, apply: apply})
+}
+
+// unattended returns the changes applied without asking
+func (p changePlan) unattended() changePlan {
+	var changes changePlan
+	for _, c := range p {
+		if c.unattended {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// print shows the plan for --dry-run, ending with the command that applies it
+func (p changePlan) print(applyCommand string) {
+	if len(p) == 0 {
+		fmt.Println("Dry run: nothing would change")
+		return
+	}
+	fmt.Println("Dry run: the following changes would be made")
+	for _, c := range p {
+		ui.Printf("  • %s\n", c.description)
+		for _, line := range c.details {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("No changes made. Run '%s' to apply.\n", applyCommand)
+}
+
+// run applies the changes in order, stopping at the first failure
+func (p changePlan) run() error {
+	for _, c := range p {
+		if err := c.apply(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runTransaction applies the changes like run, treating them as one unit: if
+// a step fails, the files snapshotted in undo (see beginUndo) are restored so
+// the git, SSH, and bgit configs never disagree. On success the snapshot
+// becomes the operation's undo entry. Without a snapshot (beginUndo returns
+// nil when a file can't be read), a failure is reported as not rolled back.
+func (p changePlan) runTransaction(undo *journal.Entry) error {
+	err := p.run()
+	if err == nil {
+		commitUndo(undo)
+		return nil
+	}
+	if undo == nil {
+		ui.Warning("Changes made before the failure could not be rolled back")
+		return err
+	}
+
+	if len(undo.Pending()) == 0 {
+		// The failing step was the first to change anything
+		undo.Discard()
+		return err
+	}
+	if rollbackErr := undo.Restore(); rollbackErr != nil {
+		// Keep the snapshot so 'bgit undo' can retry
+		commitUndo(undo)
+		return fmt.Errorf("%w\nrollback failed: %v\nRun 'bgit undo --force' to retry", err, rollbackErr)
+	}
+	undo.Discard()
+	ui.Warning("Rolled back the changes made before the failure")
+	return err
+}
@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// Plugins are executables named bgit-<name> on PATH, run as 'bgit <name>'
+// the way git and kubectl run theirs. bgit passes the effective identity in
+// BGIT_* environment variables so plugins don't have to parse its config.
+const pluginPrefix = "bgit-"
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List plugins (bgit-<name> executables on PATH)",
+	Long: `List the plugins bgit can run. Any executable named bgit-<name> on PATH runs
+as 'bgit <name> [args...]', unless <name> is a built-in command.
+
+Plugins receive the identity effective in the current directory:
+
+  BGIT_ALIAS            Identity alias
+  BGIT_NAME             Git user name
+  BGIT_EMAIL            Git user email
+  BGIT_AUTHOR_EMAIL     Email recorded as the commit author
+  BGIT_COMMITTER_EMAIL  Email recorded as the committer
+  BGIT_GITHUB_USER      GitHub username
+  BGIT_SSH_KEY          SSH private key path (empty when using an external agent)
+  BGIT_SSH_HOST         SSH host alias, e.g. github.com-octocat
+  BGIT_IDENTITY_SOURCE  Why this identity applies: workspace, binding, repo-file, rule, owner, or global
+  BGIT_IDENTITY_PATH    The workspace, binding, or repo file that matched
+
+and always BGIT_VERSION, BGIT_CONFIG_DIR, and BGIT_BIN (the bgit executable).
+Identity variables are empty when no identity applies.`,
+	Example: `  bgit plugins
+  bgit report --since 30d   # Runs bgit-report --since 30d`,
+	Args: cobra.NoArgs,
+	RunE: runPlugins,
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
+
+func runPlugins(cmd *cobra.Command, args []string) error {
+	plugins := listPlugins()
+	if len(plugins) == 0 {
+		ui.Info("No plugins found. Put an executable named bgit-<name> on PATH to add 'bgit <name>'.")
+		return nil
+	}
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		suffix := ""
+		if isBuiltinCommand(name) {
+			suffix = "  (shadowed by the built-in command)"
+		}
+		fmt.Printf("%-16s %s%s\n", name, shortenPath(plugins[name]), suffix)
+	}
+	return nil
+}
+
+// listPlugins maps plugin names to the first matching executable on PATH
+func listPlugins() map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || plugins[name] != "" {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if lookedUp, err := exec.LookPath(path); err == nil {
+				plugins[name] = lookedUp
+			}
+		}
+	}
+	return plugins
+}
+
+// pluginName returns the command name for a bgit-<name> file, dropping the
+// executable extension on Windows
+func pluginName(file string) (string, bool) {
+	if !strings.HasPrefix(file, pluginPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(file, pluginPrefix)
+	if runtime.GOOS == "windows" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name, name != ""
+}
+
+// isBuiltinCommand reports whether name is a bgit command or alias,
+// including the help and completion commands cobra adds at run time
+func isBuiltinCommand(name string) bool {
+	if name == "help" || name == "completion" {
+		return true
+	}
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// findPlugin returns the plugin to run for bgit's arguments and the
+// arguments to pass it. bgit's global flags may come before the plugin's name
+// (bgit --debug <name>) and are returned in flags for bgit to apply. The name
+// must not be a built-in command.
+func findPlugin(args []string) (path string, flags, pluginArgs []string, ok bool) {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		n := globalFlagArgs(args[i])
+		if n == 0 {
+			return "", nil, nil, false
+		}
+		i += n
+	}
+	if i >= len(args) {
+		return "", nil, nil, false
+	}
+	name := args[i]
+	if strings.ContainsAny(name, `/\`) || isBuiltinCommand(name) {
+		return "", nil, nil, false
+	}
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return "", nil, nil, false
+	}
+	return path, args[:i], args[i+1:], true
+}
+
+// globalFlagArgs returns how many arguments the global flag in arg takes up:
+// 1, or 2 when its value is the next argument. Anything else, such as "--" or
+// a command's own flag, returns 0 and is left to cobra. Combined shorthands
+// (-qv) are recognized when they are all switches.
+func globalFlagArgs(arg string) int {
+	var name string
+	var hasValue bool
+	flags := rootCmd.PersistentFlags()
+	switch {
+	case arg == "--" || arg == "-":
+		return 0
+	case strings.HasPrefix(arg, "--"):
+		name, _, hasValue = strings.Cut(arg[2:], "=")
+	case len(arg) == 2:
+		f := flags.ShorthandLookup(arg[1:])
+		if f == nil {
+			return 0
+		}
+		name = f.Name
+	default:
+		for _, short := range arg[1:] {
+			if f := flags.ShorthandLookup(string(short)); f == nil || f.NoOptDefVal == "" {
+				return 0
+			}
+		}
+		return 1
+	}
+
+	f := flags.Lookup(name)
+	switch {
+	case f == nil:
+		return 0
+	case hasValue || f.NoOptDefVal != "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// runPlugin applies bgit's global flags, then runs a plugin with its
+// arguments and bgit's environment, and returns its exit code
+func runPlugin(path string, flags, args []string) int {
+	if err := rootCmd.PersistentFlags().Parse(flags); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if err := rootCmd.PersistentPreRunE(rootCmd, nil); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeFor(err)
+	}
+
+	plugin := ui.Command(path, args...).WithTimeout(0)
+	plugin.Stdin = os.Stdin
+	plugin.Stdout = os.Stdout
+	plugin.Stderr = os.Stderr
+	resolution, _ := resolveActive()
+	plugin.Env = append(os.Environ(), identityEnv(resolution)...)
+
+	// Ctrl-C reaches the plugin directly; bgit waits for it to exit
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	if err := plugin.Run(); err != nil {
+		if code := execx.ExitCode(err); code > 0 {
+			return code
+		}
+		fmt.Fprintf(os.Stderr, "failed to run plugin %s: %v\n", filepath.Base(path), err)
+		return exitError
+	}
+	return exitOK
+}
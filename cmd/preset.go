@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/byterings/bgit/internal/user"
+)
+
+// maxPresetSize bounds how much of a preset URL is read
+const maxPresetSize = 64 * 1024
+
+// loadPreset reads a team preset from a file or an http(s) URL
+func loadPreset(source string) (*config.Preset, error) {
+	var data []byte
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		ctx, cancel := networkContext()
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, withExitCode(exitUsage, fmt.Errorf("invalid preset URL: %w", err))
+		}
+		ui.Debugf("GET %s", source)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, networkError(ctx, fmt.Errorf("failed to fetch preset: %w", err))
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, withExitCode(exitNetwork, fmt.Errorf("failed to fetch preset: %s returned %s", source, resp.Status))
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxPresetSize))
+		if err != nil {
+			return nil, networkError(ctx, fmt.Errorf("failed to fetch preset: %w", err))
+		}
+	} else {
+		path, err := platform.ExpandTilde(source)
+		if err != nil {
+			return nil, err
+		}
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, withExitCode(exitUsage, fmt.Errorf("failed to read preset: %w", err))
+		}
+	}
+
+	preset, err := config.ParsePreset(data, source)
+	if err != nil {
+		return nil, withExitCode(exitUsage, err)
+	}
+	return preset, nil
+}
+
+// applyPresetKeyType makes add generate the preset's key type unless the user
+// chose a key another way, and rejects a conflicting --key-type
+func applyPresetKeyType(preset *config.Preset) error {
+	if preset.KeyType == "" {
+		return nil
+	}
+	if preset.KeyType != "ed25519" && !user.IsSecurityKeyType(preset.KeyType) {
+		return withExitCode(exitUsage, fmt.Errorf("preset key_type '%s' is not supported (use ed25519, ed25519-sk, or ecdsa-sk)", preset.KeyType))
+	}
+	if addFlagKeyType != "" && addFlagKeyType != preset.KeyType {
+		return withExitCode(exitUsage, fmt.Errorf("the preset requires a %s key, not %s", preset.KeyType, addFlagKeyType))
+	}
+	if addFlagSSHKey == "" && addFlagAdoptKey == "" && addFlagAgent == "" {
+		addFlagKeyType = preset.KeyType
+	}
+	return nil
+}
+
+// applyPresetLayout creates the preset's workspace and routes its
+// organizations to the new identity. Failures are reported but never fail
+// the add.
+func applyPresetLayout(cfg *config.Config, preset *config.Preset, alias string) {
+	if preset.Workspace != "" {
+		createIdentityWorkspace(cfg, preset.Workspace, alias)
+	}
+
+	for _, owner := range preset.Owners {
+		if err := cfg.AddRule(owner, alias); err != nil {
+			ui.Warning(fmt.Sprintf("Could not add rule for %s: %v", owner, err))
+			continue
+		}
+		ui.Success(fmt.Sprintf("Rule set: %s  →  %s", owner, alias))
+	}
+}
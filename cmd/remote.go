@@ -1,17 +1,28 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os/exec"
-	"regexp"
-	"strings"
+	"os"
+	"sort"
 
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/history"
 	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/remote"
+	"github.com/byterings/bgit/internal/scanner"
 	"github.com/byterings/bgit/internal/ui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	remoteDryRun    bool
+	remoteHTTPS     bool
+	remoteRecursive bool
+	remoteAll       bool
+)
+
 var remoteCmd = &cobra.Command{
 	Use:   "remote",
 	Short: "Manage git remotes for bgit",
@@ -23,25 +34,48 @@ var remoteFixCmd = &cobra.Command{
 	Short: "Convert remote URL to use active user's SSH config",
 	Long: `Convert the current repository's origin remote URL to use the active user's SSH host alias.
 
-This allows git push/pull to work with the correct SSH key.`,
+This allows git push/pull to work with the correct SSH key.
+
+With --https, the remote stays (or becomes) an HTTPS URL instead, and the
+repository's GitHub credentials come from 'bgit credential' for the identity's
+account. Use this where SSH is blocked.`,
 	Example: `  # Fix current repo's remote
   bgit use work
   bgit remote fix
 
-  # Now git push works with the work identity`,
+  # Now git push works with the work identity
+
+  # Keep HTTPS, authenticating as the identity's GitHub account
+  bgit remote fix --https
+
+  # Preview the change
+  bgit remote fix --dry-run`,
 	RunE: runRemoteFix,
 }
 
 var remoteRestoreCmd = &cobra.Command{
-	Use:   "restore",
+	Use:   "restore [path]",
 	Short: "Restore remote URL to standard GitHub format",
 	Long: `Convert the current repository's origin remote URL back to standard GitHub format.
 
-Use this before uninstalling bgit or if you want to use standard git SSH.`,
+Use this before uninstalling bgit or if you want to use standard git SSH.
+
+With --recursive, every repository under path (the current directory by
+default) is searched for remotes using a bgit host alias, and each one is
+restored; with --all, the repositories in your workspaces, scan_roots, and the
+common home directories are, as 'bgit scan' finds them. Nothing else changes:
+unlike 'bgit uninstall', the SSH config, keys, and bgit's config stay.`,
 	Example: `  # Restore current repo's remote
   bgit remote restore
 
-  # Remote is now: git@github.com:user/repo.git`,
+  # Remote is now: git@github.com:user/repo.git
+
+  # Restore every repository under ~/code
+  bgit remote restore --recursive ~/code
+
+  # Preview restoring every repository bgit knows about
+  bgit remote restore --all --dry-run`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runRemoteRestore,
 }
 
@@ -49,6 +83,11 @@ func init() {
 	rootCmd.AddCommand(remoteCmd)
 	remoteCmd.AddCommand(remoteFixCmd)
 	remoteCmd.AddCommand(remoteRestoreCmd)
+	remoteFixCmd.Flags().BoolVar(&remoteDryRun, "dry-run", false, "Show the remote change without making it")
+	remoteFixCmd.Flags().BoolVar(&remoteHTTPS, "https", false, "Keep an HTTPS remote and use bgit as its credential helper")
+	remoteRestoreCmd.Flags().BoolVar(&remoteDryRun, "dry-run", false, "Show the remote change without making it")
+	remoteRestoreCmd.Flags().BoolVarP(&remoteRecursive, "recursive", "r", false, "Restore the remotes of every repository under path")
+	remoteRestoreCmd.Flags().BoolVar(&remoteAll, "all", false, "Restore the remotes of every repository in workspaces and scan roots")
 }
 
 func runRemoteFix(cmd *cobra.Command, args []string) error {
@@ -81,17 +120,31 @@ func runRemoteFix(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// With no workspace, binding, or rule, propose an identity from the remote owner
+	if resolution.Source == identity.SourceGlobal {
+		if url, err := getRemoteURL("origin"); err == nil {
+			suggestion := identity.SuggestIdentity(cfg, url)
+			if suggestion != nil && suggestion.Alias != resolution.Alias && remoteDryRun {
+				ui.Info(fmt.Sprintf("Repository owner '%s' matches identity '%s'; a real run would offer to use it", suggestion.Owner, suggestion.Alias))
+			} else if suggestion != nil && suggestion.Alias != resolution.Alias {
+				ui.Info(fmt.Sprintf("Repository owner '%s' matches identity '%s' (%s)", suggestion.Owner, suggestion.Alias, suggestion.User.GitHubUsername))
+				confirmed, err := ui.PromptConfirmation(fmt.Sprintf("Use '%s' instead of global '%s'?", suggestion.Alias, resolution.Alias))
+				if err != nil {
+					return err
+				}
+				if confirmed {
+					resolution = suggestion
+					fmt.Printf("Tip: run 'bgit bind --user %s' to remember this choice\n", suggestion.Alias)
+				}
+				fmt.Println()
+			}
+		}
+	}
+
 	activeUser := resolution.User
 
 	if resolution.Source != identity.SourceGlobal {
-		sourceInfo := ""
-		switch resolution.Source {
-		case identity.SourceWorkspace:
-			sourceInfo = fmt.Sprintf(" (workspace: %s)", resolution.Path)
-		case identity.SourceBinding:
-			sourceInfo = " (bound repo)"
-		}
-		ui.Info(fmt.Sprintf("Using identity from %s%s", resolution.Source, sourceInfo))
+		ui.Info(fmt.Sprintf("Using identity from %s %s", resolution.Source, describeSource(resolution)))
 	}
 
 	currentURL, err := getRemoteURL("origin")
@@ -107,41 +160,116 @@ func runRemoteFix(cmd *cobra.Command, args []string) error {
 	if existingUsername != "" && existingUsername != activeUser.GitHubUsername {
 		ui.Warning(fmt.Sprintf("This repo is configured for GitHub user '%s' but effective user is '%s' (%s)", existingUsername, activeUser.Alias, activeUser.GitHubUsername))
 
-		confirmed, err := ui.PromptConfirmation("Continue anyway?")
+		if !remoteDryRun {
+			confirmed, err := ui.PromptConfirmation("Continue anyway?")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("Operation cancelled.")
+				return nil
+			}
+		}
+		fmt.Println()
+	}
+
+	var newURL string
+	if remoteHTTPS {
+		parsed, err := remote.Parse(currentURL)
 		if err != nil {
-			return err
+			return fmt.Errorf("unrecognized URL format: %s\nExpected GitHub HTTPS or SSH URL", currentURL)
 		}
-		if !confirmed {
-			fmt.Println("Operation cancelled.")
-			return nil
+		newURL = parsed.HTTPSURL()
+	} else {
+		newURL, err = convertToBgitURL(currentURL, activeUser.GitHubUsername)
+		if err != nil {
+			return err
 		}
-		fmt.Println()
 	}
 
-	newURL, err := convertToBgitURL(currentURL, activeUser.GitHubUsername)
-	if err != nil {
-		return err
+	var plan changePlan
+	if currentURL != newURL {
+		plan.add(fmt.Sprintf("Set origin URL: %s → %s", currentURL, newURL), func() error {
+			if err := setRemoteURL("origin", newURL); err != nil {
+				return fmt.Errorf("failed to update remote: %w", err)
+			}
+			return nil
+		})
 	}
+	planCredentialHelper(&plan, activeUser, remoteHTTPS)
 
-	if currentURL == newURL {
+	if len(plan) == 0 {
 		ui.Info("Remote URL already configured for " + activeUser.Alias)
 		return nil
 	}
-
-	if err := setRemoteURL("origin", newURL); err != nil {
-		return fmt.Errorf("failed to update remote: %w", err)
+	if remoteDryRun {
+		applyCommand := "bgit remote fix"
+		if remoteHTTPS {
+			applyCommand += " --https"
+		}
+		plan.print(applyCommand)
+		return nil
+	}
+	undo := beginUndo("remote fix", "fix origin for "+activeUser.Alias, currentRepoRoot())
+	if err := plan.runTransaction(undo); err != nil {
+		return err
 	}
 
-	fmt.Printf("Remote 'origin' updated:\n")
-	fmt.Printf("  Old: %s\n", currentURL)
-	fmt.Printf("  New: %s\n", newURL)
-	fmt.Println()
+	if currentURL != newURL {
+		fmt.Printf("Remote 'origin' updated:\n")
+		fmt.Printf("  Old: %s\n", currentURL)
+		fmt.Printf("  New: %s\n", newURL)
+		fmt.Println()
+	}
+	if remoteHTTPS {
+		fmt.Printf("HTTPS credentials for this repo now come from bgit as %s.\n", activeUser.GitHubUsername)
+		fmt.Println("On the first push, enter a personal access token as the password; bgit saves it.")
+		fmt.Println()
+	}
 	ui.Success(fmt.Sprintf("Remote fixed for user '%s'", activeUser.Alias))
+	recordHistory(history.ActionRemoteFix, activeUser.Alias, currentRepoRoot(), newURL)
 
 	return nil
 }
 
+// planCredentialHelper adds the credential helper changes for remote fix and
+// restore: in HTTPS mode the repo's GitHub credentials come from 'bgit
+// credential' for the identity (u); otherwise a helper bgit set is removed
+func planCredentialHelper(plan *changePlan, u *config.User, https bool) {
+	repoRoot := currentRepoRoot()
+	current := git.GetCredentialHelper(repoRoot)
+	username, _ := git.GetLocalConfig(repoRoot, "credential.https://github.com.username")
+
+	if !https {
+		if isBgitCredentialHelper(current) {
+			plan.add("Remove bgit's HTTPS credential helper", func() error {
+				return git.UnsetCredentialHelper(repoRoot)
+			})
+		}
+		return
+	}
+
+	helper := credentialHelperCommand(u.Alias)
+	if current == helper && username == u.GitHubUsername {
+		return
+	}
+	plan.add(fmt.Sprintf("Use 'bgit credential' as %s for github.com HTTPS credentials", u.GitHubUsername), func() error {
+		return git.SetCredentialHelper(repoRoot, helper, u.GitHubUsername)
+	})
+}
+
 func runRemoteRestore(cmd *cobra.Command, args []string) error {
+	switch {
+	case remoteRecursive && remoteAll:
+		return withExitCode(exitUsage, fmt.Errorf("--recursive cannot be combined with --all"))
+	case remoteAll && len(args) > 0:
+		return withExitCode(exitUsage, fmt.Errorf("--all searches the configured roots; use --recursive to restore under %s", args[0]))
+	case remoteRecursive || remoteAll:
+		return runBulkRemoteRestore(args)
+	case len(args) > 0:
+		return withExitCode(exitUsage, fmt.Errorf("a path needs --recursive"))
+	}
+
 	if !isGitRepo() {
 		return fmt.Errorf("not a git repository\nRun this command inside a git repository")
 	}
@@ -160,75 +288,182 @@ func runRemoteRestore(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if currentURL == newURL {
+	var plan changePlan
+	if currentURL != newURL {
+		plan.add(fmt.Sprintf("Set origin URL: %s → %s", currentURL, newURL), func() error {
+			if err := setRemoteURL("origin", newURL); err != nil {
+				return fmt.Errorf("failed to update remote: %w", err)
+			}
+			return nil
+		})
+	}
+	planCredentialHelper(&plan, nil, false)
+
+	if len(plan) == 0 {
 		ui.Info("Remote URL is already in standard format")
 		return nil
 	}
-
-	if err := setRemoteURL("origin", newURL); err != nil {
-		return fmt.Errorf("failed to update remote: %w", err)
+	if remoteDryRun {
+		plan.print("bgit remote restore")
+		return nil
+	}
+	undo := beginUndo("remote restore", "restore origin to "+newURL, currentRepoRoot())
+	if err := plan.runTransaction(undo); err != nil {
+		return err
 	}
 
-	fmt.Printf("Remote 'origin' restored:\n")
-	fmt.Printf("  Old: %s\n", currentURL)
-	fmt.Printf("  New: %s\n", newURL)
-	fmt.Println()
+	if currentURL != newURL {
+		fmt.Printf("Remote 'origin' restored:\n")
+		fmt.Printf("  Old: %s\n", currentURL)
+		fmt.Printf("  New: %s\n", newURL)
+		fmt.Println()
+	}
 	ui.Success("Remote restored to standard GitHub format")
+	recordHistory(history.ActionRemoteRestore, extractAliasFromURL(currentURL), currentRepoRoot(), newURL)
 
 	return nil
 }
 
+// runBulkRemoteRestore restores every remote with a bgit host alias in the
+// repositories under args[0] (--recursive) or the default scan roots (--all),
+// continuing past repositories it can't change
+func runBulkRemoteRestore(args []string) error {
+	var roots, ignore []string
+	cfg, err := config.LoadConfig()
+	if err == nil {
+		ignore = cfg.ScanIgnore
+	}
+	if remoteAll {
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		roots = defaultScanRoots(cfg)
+	} else {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if roots, err = resolveScanPaths([]string{path}); err != nil {
+			return withExitCode(exitUsage, err)
+		}
+	}
+
+	fmt.Println("Scanning for repositories...")
+	repos, err := findRepos(roots, nil, ignore, scanner.DefaultTimeout)
+	if err != nil && !errors.Is(err, scanner.ErrTimeout) {
+		return err
+	}
+
+	var plan changePlan
+	restored := make(map[string]bool)
+	for _, repoPath := range repos {
+		remotes, err := git.ListRemotes(repoPath)
+		if err != nil {
+			ui.Verbose(fmt.Sprintf("%s: %v", shortenPath(repoPath), err))
+			continue
+		}
+		names := make([]string, 0, len(remotes))
+		for name := range remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			oldURL := remotes[name]
+			parsed, err := remote.Parse(oldURL)
+			if err != nil || parsed.Kind != remote.KindBgit {
+				continue
+			}
+			repoPath, name, newURL := repoPath, name, parsed.StandardURL()
+			plan.add(fmt.Sprintf("%s: set %s URL: %s → %s", shortenPath(repoPath), name, oldURL, newURL), func() error {
+				if err := git.SetRemoteURL(repoPath, name, newURL); err != nil {
+					return err
+				}
+				restored[repoPath] = true
+				recordHistory(history.ActionRemoteRestore, extractAliasFromURL(oldURL), repoPath, newURL)
+				return nil
+			})
+		}
+	}
+
+	if len(plan) == 0 {
+		ui.Info(fmt.Sprintf("No bgit remote URLs in %d repo(s)", len(repos)))
+		return nil
+	}
+	if remoteDryRun {
+		applyCommand := "bgit remote restore --all"
+		if remoteRecursive {
+			applyCommand = "bgit remote restore --recursive"
+			if len(args) > 0 {
+				applyCommand += " " + args[0]
+			}
+		}
+		plan.print(applyCommand)
+		return nil
+	}
+
+	failed := 0
+	for _, c := range plan {
+		if err := c.apply(); err != nil {
+			ui.Error(fmt.Sprintf("%s: %v", c.description, err))
+			failed++
+			continue
+		}
+		ui.Printf("  %s %s\n", ui.Green("✓"), c.description)
+	}
+	fmt.Println()
+	if failed > 0 {
+		ui.Warning(fmt.Sprintf("Restored %d of %d remote(s) in %d repo(s)", len(plan)-failed, len(plan), len(restored)))
+		return withExitCode(exitPartialFix, fmt.Errorf("failed to restore %d remote(s)", failed))
+	}
+	ui.Success(fmt.Sprintf("Restored %d remote(s) in %d repo(s) to standard GitHub format", len(plan), len(restored)))
+	return nil
+}
+
 // isGitRepo checks if current directory is a git repository
 func isGitRepo() bool {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	return cmd.Run() == nil
+	return git.IsRepo(".")
 }
 
-// getRemoteURL gets the URL of a remote
-func getRemoteURL(remote string) (string, error) {
-	cmd := exec.Command("git", "remote", "get-url", remote)
-	output, err := cmd.Output()
+// currentRepoRoot returns the root of the repository containing the working directory
+func currentRepoRoot() string {
+	cwd, err := os.Getwd()
 	if err != nil {
-		return "", err
+		return ""
 	}
-	return strings.TrimSpace(string(output)), nil
+	return identity.FindGitRoot(cwd)
+}
+
+// getRemoteURL gets the URL of a remote
+func getRemoteURL(remote string) (string, error) {
+	return git.GetRemoteURL(".", remote)
 }
 
 // setRemoteURL sets the URL of a remote
 func setRemoteURL(remote, url string) error {
-	cmd := exec.Command("git", "remote", "set-url", remote, url)
-	return cmd.Run()
+	return git.SetRemoteURL(".", remote, url)
 }
 
 // convertToStandardURL converts bgit URL back to standard GitHub SSH URL
 func convertToStandardURL(url string) (string, error) {
-	// Pattern for bgit format: git@github.com-alias:user/repo.git
-	bgitPattern := regexp.MustCompile(`^git@github\.com-[^:]+:([^/]+)/(.+?)(?:\.git)?$`)
-
-	// Pattern for standard SSH (already standard)
-	sshPattern := regexp.MustCompile(`^git@github\.com:([^/]+)/(.+?)(?:\.git)?$`)
-
-	// Pattern for HTTPS (already standard)
-	httpsPattern := regexp.MustCompile(`^https?://github\.com/`)
+	parsed, err := remote.Parse(url)
+	if err != nil {
+		return "", err
+	}
 
-	if matches := bgitPattern.FindStringSubmatch(url); matches != nil {
-		user := matches[1]
-		repo := strings.TrimSuffix(matches[2], ".git")
-		return fmt.Sprintf("git@github.com:%s/%s.git", user, repo), nil
-	} else if sshPattern.MatchString(url) || httpsPattern.MatchString(url) {
-		// Already in standard format
-		return url, nil
+	if parsed.Kind == remote.KindBgit {
+		return parsed.StandardURL(), nil
 	}
 
-	return "", fmt.Errorf("unrecognized URL format: %s", url)
+	// Already in standard format
+	return url, nil
 }
 
 // extractAliasFromURL extracts the bgit alias from a URL if present
 func extractAliasFromURL(url string) string {
-	// Pattern for bgit format: git@github.com-alias:user/repo.git
-	bgitPattern := regexp.MustCompile(`^git@github\.com-([^:]+):`)
-	if matches := bgitPattern.FindStringSubmatch(url); matches != nil {
-		return matches[1]
+	parsed, err := remote.Parse(url)
+	if err != nil || parsed.Kind != remote.KindBgit {
+		return ""
 	}
-	return ""
+	return parsed.HostUser
 }
@@ -3,7 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/redact"
+	"github.com/byterings/bgit/internal/ui"
 	"github.com/spf13/cobra"
 )
 
@@ -15,14 +21,90 @@ var rootCmd = &cobra.Command{
 	Long: `bgit is a simple, safe, and transparent way to manage multiple Git identities
 on one system without changing how you normally use git.`,
 	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyOutputFlags(); err != nil {
+			return err
+		}
+		if networkTimeout <= 0 {
+			return withExitCode(exitUsage, fmt.Errorf("--timeout must be positive"))
+		}
+		return nil
+	},
 }
 
+var (
+	verboseFlag      bool
+	debugFlag        bool
+	quietFlag        bool
+	colorFlag        string
+	traceFlag        bool
+	redactEmailsFlag bool
+	asciiFlag        bool
+)
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ui.SetupConsole()
+	if path, flags, args, ok := findPlugin(os.Args[1:]); ok {
+		exit(runPlugin(path, flags, args))
+	}
+
+	err := rootCmd.Execute()
+	ui.CloseLog()
+	ui.RestoreConsole()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCodeFor(err))
 	}
 }
 
 func init() {
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return withExitCode(exitUsage, err)
+	})
+	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Show more detail about what bgit is doing")
+	rootCmd.PersistentFlags().BoolVar(&debugFlag, "debug", false, "Log external commands and file writes to stderr and ~/.bgit/logs")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Only print errors and requested output")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", ui.ColorAuto, "Color output: auto, always, or never (auto honors NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&asciiFlag, "ascii", false, "Print ASCII instead of symbols such as ✓ and → (automatic on non-UTF-8 terminals)")
+	rootCmd.PersistentFlags().DurationVar(&networkTimeout, "timeout", defaultNetworkTimeout, "Give up on each GitHub request or SSH connectivity check after this long")
+	rootCmd.PersistentFlags().BoolVar(&traceFlag, "show-commands", false, "Print every external command with its arguments and exit status (also BGIT_TRACE=1)")
+	rootCmd.PersistentFlags().BoolVar(&redactEmailsFlag, "redact-emails", false, "Mask email addresses in --debug and --show-commands output (also BGIT_REDACT_EMAILS=1)")
+
+	// An encrypted config asks for its passphrase when first loaded
+	config.PassphraseFunc = ui.PromptPassphrase
+}
+
+// applyOutputFlags sets the ui output level from --verbose, --debug, and
+// --quiet, the color mode from --color, ASCII symbols from --ascii, command
+// tracing from --show-commands, and masking emails from --redact-emails
+func applyOutputFlags() error {
+	if traceFlag {
+		execx.SetTracing(true)
+	}
+	if redactEmailsFlag {
+		redact.SetEmails(true)
+	}
+	if err := ui.SetColorMode(colorFlag); err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	if asciiFlag {
+		ui.SetASCII(true)
+	}
+	if quietFlag && (verboseFlag || debugFlag) {
+		return withExitCode(exitUsage, fmt.Errorf("--quiet cannot be combined with --verbose or --debug"))
+	}
+
+	switch {
+	case debugFlag:
+		ui.SetLevel(ui.LevelDebug)
+		ui.Debugf("bgit %s: %s", version, strings.Join(os.Args, " "))
+		if ci := platform.CIName(); ci != "" {
+			ui.Debugf("running in %s: prompts, colors, and SSH agent changes are disabled", ci)
+		}
+	case verboseFlag:
+		ui.SetLevel(ui.LevelVerbose)
+	case quietFlag:
+		ui.SetLevel(ui.LevelQuiet)
+	}
+	return nil
 }
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var ruleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Map GitHub owners and organizations to identities",
+	Long: `Manage rules that map a repository owner (GitHub user or organization)
+to an identity.
+
+Rules are consulted when no workspace or binding applies, so repositories are
+matched to identities by who owns them rather than where they live on disk.
+They are used by identity resolution, clone, and remote fix.`,
+}
+
+var ruleAddCmd = &cobra.Command{
+	Use:   "add <owner> <alias>",
+	Short: "Add or update a rule",
+	Example: `  bgit rule add mycompany work
+  bgit rule add my-oss-org personal`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRuleAdd,
+}
+
+var ruleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List configured rules",
+	RunE:    runRuleList,
+}
+
+var ruleRemoveCmd = &cobra.Command{
+	Use:   "remove <owner>",
+	Short: "Remove the rule for an owner",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRuleRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(ruleCmd)
+	ruleCmd.AddCommand(ruleAddCmd)
+	ruleCmd.AddCommand(ruleListCmd)
+	ruleCmd.AddCommand(ruleRemoveCmd)
+}
+
+func runRuleAdd(cmd *cobra.Command, args []string) error {
+	owner, alias := args[0], args[1]
+
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := cfg.AddRule(owner, alias); err != nil {
+		return err
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Repositories owned by '%s' will use '%s'", owner, alias))
+	return nil
+}
+
+func runRuleList(cmd *cobra.Command, args []string) error {
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(cfg.Rules) == 0 {
+		fmt.Println("No rules configured.")
+		fmt.Println("\nAdd one with: bgit rule add <owner> <alias>")
+		return nil
+	}
+
+	fmt.Println("\nConfigured rules:")
+	fmt.Println()
+
+	for _, r := range cfg.Rules {
+		status := "✓"
+		if cfg.FindUserByAlias(r.User) == nil {
+			status = "✗ (unknown user)"
+		}
+		ui.Printf("  %s %-20s → %s%s\n", status, r.Owner, r.User, systemSuffix(cfg.IsSystemRule(r.Owner)))
+	}
+
+	fmt.Println()
+	return nil
+}
+
+func runRuleRemove(cmd *cobra.Command, args []string) error {
+	owner := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if cfg.IsSystemRule(owner) {
+		return config.ErrSystemEntry("rule", owner)
+	}
+	if !cfg.RemoveRule(owner) {
+		return fmt.Errorf("no rule found for owner '%s'", owner)
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Removed rule for '%s'", owner))
+	return nil
+}
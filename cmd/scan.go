@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/scanner"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [path]",
+	Short: "Scan repositories for identity mismatches",
+	Long: `Find git repositories and report, for each one:
+- The SSH host alias used by the origin remote
+- The identity bgit resolves for it (workspace, binding, or global)
+- The git user.email commits will be made with
+- Whether these agree
+
+Without a path, scans configured workspaces, the scan_roots listed in
+config.toml, and common code directories under your home directory.
+
+Directories matching scan_ignore patterns in config.toml, or listed in a
+.bgitignore file in any scanned directory, are skipped.`,
+	Example: `  bgit scan                            # Scan workspaces and common directories
+  bgit scan ~/code                     # Scan a specific directory
+  bgit scan --path /data/src --path ~/deep/tree`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScan,
+}
+
+var (
+	scanPaths   []string
+	scanSkip    []string
+	scanTimeout time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+	scanCmd.Flags().StringArrayVarP(&scanPaths, "path", "p", nil, "Directory to scan instead of the defaults (repeatable)")
+	scanCmd.Flags().StringArrayVar(&scanSkip, "skip", nil, "Directory name to skip while scanning (repeatable)")
+	scanCmd.Flags().DurationVar(&scanTimeout, "scan-timeout", scanner.DefaultTimeout, "Stop scanning after this long and report what was found")
+}
+
+// repoReport describes the identity state of a single repository
+type repoReport struct {
+	path       string
+	remoteURL  string
+	hostUser   string // GitHub username from the bgit host alias, empty if not a bgit URL
+	resolution *identity.Resolution
+	email      string
+	problems   []string
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var roots []string
+	if paths := append(args, scanPaths...); len(paths) > 0 {
+		roots, err = resolveScanPaths(paths)
+		if err != nil {
+			return err
+		}
+	} else {
+		roots = defaultScanRoots(cfg)
+	}
+
+	fmt.Println("Scanning for repositories...")
+	repos, _ := findRepos(roots, scanSkip, cfg.ScanIgnore, scanTimeout)
+	if len(repos) == 0 {
+		fmt.Println()
+		ui.Info("No git repositories found")
+		return nil
+	}
+
+	mismatched := 0
+	for _, repoPath := range repos {
+		report := inspectRepo(cfg, repoPath)
+		printRepoReport(report)
+		if len(report.problems) > 0 {
+			mismatched++
+		}
+	}
+
+	fmt.Println()
+	ui.Println("─────────")
+	if mismatched == 0 {
+		ui.Success(fmt.Sprintf("%d repo(s) scanned, all consistent", len(repos)))
+	} else {
+		ui.Warning(fmt.Sprintf("%d of %d repo(s) have mismatches", mismatched, len(repos)))
+	}
+
+	return nil
+}
+
+// defaultScanRoots returns workspace paths and configured scan_roots followed
+// by the common home directories
+func defaultScanRoots(cfg *config.Config) []string {
+	var roots []string
+	for _, ws := range cfg.GetWorkspaces() {
+		if _, err := os.Stat(ws.Path); err == nil {
+			roots = append(roots, ws.Path)
+		}
+	}
+	for _, root := range cfg.ScanRoots {
+		path, err := platform.ExpandTilde(root)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			roots = append(roots, path)
+		} else {
+			ui.Warning(fmt.Sprintf("scan_roots entry not found: %s", root))
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		roots = append(roots, scanner.DefaultRoots(home)...)
+	}
+	return roots
+}
+
+// findRepos runs a parallel repository scan, showing progress on the terminal
+// and warning when the timeout cut the scan short (scanner.ErrTimeout is returned
+// with the partial results)
+func findRepos(roots, skip, ignore []string, timeout time.Duration) ([]string, error) {
+	repos, err := scanner.Find(roots, scanner.Options{
+		Timeout: timeout,
+		Skip:    skip,
+		Ignore:  ignore,
+		Progress: func(dirs, repos int) {
+			ui.Progress(fmt.Sprintf("  %d directories scanned, %d repositories found", dirs, repos))
+		},
+	})
+	ui.ClearProgress()
+
+	if errors.Is(err, scanner.ErrTimeout) {
+		ui.Warning(fmt.Sprintf("Scan stopped after %s; results may be incomplete (use --scan-timeout or --path)", timeout))
+	}
+	return repos, err
+}
+
+// resolveScanPaths expands and validates directories given on the command line
+func resolveScanPaths(paths []string) ([]string, error) {
+	var roots []string
+	for _, p := range paths {
+		expanded, err := platform.ExpandTilde(p)
+		if err != nil {
+			return nil, err
+		}
+		root, err := filepath.Abs(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("path does not exist or is not a directory: %s", root)
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// inspectRepo compares a repository's remote and git email against its resolved identity
+func inspectRepo(cfg *config.Config, repoPath string) repoReport {
+	report := repoReport{path: repoPath}
+
+	report.remoteURL, _ = getRepoRemoteURL(repoPath)
+	report.hostUser = extractAliasFromURL(report.remoteURL)
+	report.email, _ = git.GetRepoConfig(repoPath, "user.email")
+	report.resolution, _ = identity.ResolveIdentity(cfg, repoPath)
+
+	rf, err := config.LoadRepoFile(repoPath)
+	if err != nil {
+		report.problems = append(report.problems, err.Error())
+	} else if rf != nil {
+		resolvedEmail := ""
+		if report.resolution != nil && report.resolution.User != nil {
+			resolvedEmail = report.resolution.User.Email
+		}
+		report.problems = append(report.problems, checkRepoFile(cfg, rf, report.email, resolvedEmail)...)
+	}
+
+	if ws := cfg.FindWorkspaceByPath(repoPath); ws != nil && ws.RequireSigning {
+		if sc, err := git.GetSigningConfig(repoPath); err == nil && !sc.Enabled {
+			report.problems = append(report.problems, "commit signing is not enabled (required by workspace)")
+		}
+	}
+
+	if report.resolution == nil || report.resolution.User == nil {
+		if report.hostUser != "" {
+			report.problems = append(report.problems, "remote uses a bgit host alias but no identity applies")
+		}
+		return report
+	}
+
+	user := report.resolution.User
+	if report.hostUser != "" && report.hostUser != user.GitHubUsername {
+		report.problems = append(report.problems, fmt.Sprintf("remote uses '%s' but identity is '%s'", report.hostUser, user.GitHubUsername))
+	}
+	if report.hostUser == "" && report.remoteURL != "" && report.resolution.Source != identity.SourceGlobal {
+		report.problems = append(report.problems, "remote does not use a bgit host alias (run: bgit remote fix)")
+	}
+	if report.email != "" && report.email != user.Email {
+		report.problems = append(report.problems, fmt.Sprintf("git email '%s' does not match '%s'", report.email, user.Email))
+	}
+
+	return report
+}
+
+func printRepoReport(r repoReport) {
+	status := ui.Green("✓")
+	if len(r.problems) > 0 {
+		status = ui.Red("✗")
+	}
+
+	fmt.Println()
+	ui.Printf("%s %s\n", status, shortenPath(r.path))
+
+	remote := r.hostUser
+	if remote == "" {
+		remote = "(none)"
+		if r.remoteURL != "" {
+			remote = "(standard URL)"
+		}
+	} else {
+		remote = "github.com-" + remote
+	}
+	fmt.Printf("    Remote:   %s\n", remote)
+
+	if r.resolution != nil {
+		fmt.Printf("    Identity: %s (%s)\n", r.resolution.Alias, r.resolution.Source)
+	} else {
+		fmt.Println("    Identity: (none)")
+	}
+
+	email := r.email
+	if email == "" {
+		email = "(not set)"
+	}
+	fmt.Printf("    Email:    %s\n", email)
+
+	for _, p := range r.problems {
+		ui.Printf("    → %s\n", p)
+	}
+}
+
+// checkRepoFile compares a repo identity file's declarations against the config and git email
+// Git email mismatches already reported against the resolved identity are not repeated
+func checkRepoFile(cfg *config.Config, rf *config.RepoFile, gitEmail, resolvedEmail string) []string {
+	var problems []string
+	name := filepath.Base(rf.Path)
+
+	user := cfg.FindUserForRepoFile(rf)
+	if user == nil {
+		problems = append(problems, fmt.Sprintf("%s declares an identity that is not configured (user=%q email=%q)", name, rf.User, rf.Email))
+		return problems
+	}
+
+	if rf.Email != "" && user.Email != rf.Email {
+		problems = append(problems, fmt.Sprintf("%s expects email '%s' but '%s' uses '%s'", name, rf.Email, user.Alias, user.Email))
+	}
+	expected := rf.Email
+	if expected == "" {
+		expected = user.Email
+	}
+	if gitEmail != "" && gitEmail != expected && expected != resolvedEmail {
+		problems = append(problems, fmt.Sprintf("%s expects email '%s' but git uses '%s'", name, expected, gitEmail))
+	}
+
+	return problems
+}
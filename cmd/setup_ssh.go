@@ -2,13 +2,15 @@ package cmd
 
 import (
 	"fmt"
-	"os/exec"
 	"runtime"
 	"strings"
 
-	"github.com/spf13/cobra"
+	"github.com/byterings/bgit/internal/agent"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/platform"
 	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
 )
 
 var setupSSHCmd = &cobra.Command{
@@ -43,8 +45,9 @@ func runSetupSSH(cmd *cobra.Command, args []string) error {
 	fmt.Println("Setting up SSH agent...")
 	fmt.Println()
 
-	// Windows-specific setup
-	if runtime.GOOS == "windows" {
+	// The Windows ssh-agent service only serves Windows OpenSSH; Git for
+	// Windows' bundled ssh uses an agent started from Git Bash like on Unix
+	if platform.UsesAgentService() {
 		if err := setupWindowsSSH(cfg); err != nil {
 			return err
 		}
@@ -72,7 +75,7 @@ func setupWindowsSSH(cfg *config.Config) error {
 	fmt.Println("1. Starting ssh-agent service...")
 
 	// Start ssh-agent service
-	startCmd := exec.Command("powershell", "-Command", "Start-Service ssh-agent")
+	startCmd := ui.Command("powershell", "-Command", "Start-Service ssh-agent")
 	if err := startCmd.Run(); err != nil {
 		ui.Info("Could not start ssh-agent service automatically")
 		fmt.Println("   Please run as Administrator:")
@@ -84,7 +87,7 @@ func setupWindowsSSH(cfg *config.Config) error {
 	}
 
 	// Set ssh-agent to automatic startup
-	autoCmd := exec.Command("powershell", "-Command", "Set-Service -Name ssh-agent -StartupType Automatic")
+	autoCmd := ui.Command("powershell", "-Command", "Set-Service -Name ssh-agent -StartupType Automatic")
 	autoCmd.Run() // Ignore errors
 
 	// Add keys to ssh-agent
@@ -93,13 +96,13 @@ func setupWindowsSSH(cfg *config.Config) error {
 
 	addedCount := 0
 	for _, user := range cfg.Users {
-		if user.SSHKeyPath == "" {
+		if user.SSHKeyPath == "" || user.UsesIdentityAgent() {
 			continue
 		}
 
 		fmt.Printf("   Adding key: %s\n", user.SSHKeyPath)
 
-		addCmd := exec.Command("ssh-add", user.SSHKeyPath)
+		addCmd := ui.Command(platform.SSHAddCommand(), agent.AddKeyArgs(user.SSHKeyPath, agentLifetime(&user))...).WithTimeout(0) // May prompt for a passphrase
 		output, err := addCmd.CombinedOutput()
 
 		if err != nil {
@@ -117,7 +120,7 @@ func setupWindowsSSH(cfg *config.Config) error {
 	// List loaded keys
 	fmt.Println()
 	fmt.Println("3. Verifying loaded keys...")
-	listCmd := exec.Command("ssh-add", "-l")
+	listCmd := ui.Command(platform.SSHAddCommand(), "-l")
 	output, err := listCmd.Output()
 	if err != nil {
 		ui.Info("No keys currently loaded in ssh-agent")
@@ -128,13 +131,27 @@ func setupWindowsSSH(cfg *config.Config) error {
 	return nil
 }
 
+// isNoIdentitiesError reports whether ssh-add -l failed only because the
+// agent holds no keys (exit status 1), as opposed to no agent (status 2)
+func isNoIdentitiesError(err error) bool {
+	return execx.ExitCode(err) == 1
+}
+
 func setupUnixSSH(cfg *config.Config) error {
-	fmt.Println("Unix/Linux SSH Setup:")
+	var agentRunning bool
+	if runtime.GOOS == "windows" {
+		fmt.Printf("%s Setup:\n", platform.DetectSSHEnvironment().Stack)
+		// No pgrep outside Git Bash; the agent is reachable only via SSH_AUTH_SOCK
+		err := ui.Command(platform.SSHAddCommand(), "-l").Run()
+		agentRunning = err == nil || isNoIdentitiesError(err)
+	} else {
+		fmt.Println("Unix/Linux SSH Setup:")
+		agentRunning = ui.Command("pgrep", "ssh-agent").Run() == nil
+	}
 	fmt.Println()
 
 	// Check if ssh-agent is running
-	agentCheck := exec.Command("pgrep", "ssh-agent")
-	if err := agentCheck.Run(); err != nil {
+	if !agentRunning {
 		fmt.Println("1. Starting ssh-agent...")
 		fmt.Println("   Run: eval $(ssh-agent)")
 		fmt.Println()
@@ -148,13 +165,13 @@ func setupUnixSSH(cfg *config.Config) error {
 
 	addedCount := 0
 	for _, user := range cfg.Users {
-		if user.SSHKeyPath == "" {
+		if user.SSHKeyPath == "" || user.UsesIdentityAgent() {
 			continue
 		}
 
 		fmt.Printf("   Adding key: %s\n", user.SSHKeyPath)
 
-		addCmd := exec.Command("ssh-add", user.SSHKeyPath)
+		addCmd := ui.Command(platform.SSHAddCommand(), agent.AddKeyArgs(user.SSHKeyPath, agentLifetime(&user))...).WithTimeout(0) // May prompt for a passphrase
 		output, err := addCmd.CombinedOutput()
 
 		if err != nil {
@@ -172,7 +189,7 @@ func setupUnixSSH(cfg *config.Config) error {
 	// List loaded keys
 	fmt.Println()
 	fmt.Println("3. Verifying loaded keys...")
-	listCmd := exec.Command("ssh-add", "-l")
+	listCmd := ui.Command(platform.SSHAddCommand(), "-l")
 	output, err := listCmd.Output()
 	if err != nil {
 		ui.Info("No keys currently loaded in ssh-agent")
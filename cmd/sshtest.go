@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/ssh"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var sshTestCmd = &cobra.Command{
+	Use:   "ssh-test <alias>",
+	Short: "Test SSH authentication to GitHub for one identity",
+	Long: `Run an authenticated 'ssh -T' against the identity's host alias and show
+which GitHub account accepted the key.
+
+github.com's host key must already be in known_hosts; an unknown or changed
+key fails the test instead of being accepted. Exits with status 5 when GitHub
+can't be reached or rejects the key, and 4 when the key belongs to a
+different account than the identity's. The test gives up after --timeout
+(default 10s) or when Ctrl-C is pressed.`,
+	Example: `  bgit ssh-test work
+  bgit ssh-test personal --timeout 5s`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runSSHTest,
+}
+
+func init() {
+	rootCmd.AddCommand(sshTestCmd)
+}
+
+func runSSHTest(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	u := cfg.FindUser(args[0])
+	if u == nil {
+		return withExitCode(exitUsage, fmt.Errorf("user '%s' not found", args[0]))
+	}
+	if !u.HasSSHHost() {
+		return withExitCode(exitUsage, fmt.Errorf("'%s' has no SSH key configured\nRun: bgit update %s --ssh-key <path>", u.Alias, u.Alias))
+	}
+
+	host := ssh.GetHostForUser(u.GitHubUsername)
+	fmt.Printf("Testing %s (git@%s)...\n", u.Alias, host)
+	ctx, cancel := networkContext()
+	result := ssh.TestConnection(ctx, host)
+	cancel()
+	ui.Verbose(result.Output)
+
+	switch result.Status {
+	case ssh.ConnectionAuthenticated:
+		if !strings.EqualFold(result.Account, u.GitHubUsername) {
+			ui.Warning(fmt.Sprintf("GitHub authenticated the key as '%s', not '%s'", result.Account, u.GitHubUsername))
+			fmt.Printf("  The key is registered to %s. Add %s.pub to %s's account instead.\n", result.Account, u.SSHKeyPath, u.GitHubUsername)
+			return withExitCode(exitMismatch, fmt.Errorf("key for '%s' belongs to GitHub account '%s'", u.Alias, result.Account))
+		}
+		ui.Success(fmt.Sprintf("Authenticated as %s", result.Account))
+		return nil
+	case ssh.ConnectionPermissionDenied:
+		ui.Error("GitHub rejected the key")
+		fmt.Printf("  Add %s.pub at https://github.com/settings/keys (signed in as %s)\n", u.SSHKeyPath, u.GitHubUsername)
+		return withExitCode(exitNetwork, fmt.Errorf("permission denied for '%s'", u.Alias))
+	case ssh.ConnectionHostKeyFailed:
+		ui.Error("github.com's host key is not in known_hosts or does not match it")
+		fmt.Println("  Connect once with 'ssh -T git@" + host + "' and accept only if the fingerprint is one of:")
+		for _, fp := range ssh.GitHubHostKeyFingerprints {
+			fmt.Println("    " + fp)
+		}
+		return withExitCode(exitNetwork, fmt.Errorf("host key verification failed"))
+	case ssh.ConnectionTimedOut:
+		ui.Error(fmt.Sprintf("No answer from GitHub within %s", networkTimeout))
+		return withExitCode(exitNetwork, fmt.Errorf("connection timed out"))
+	case ssh.ConnectionCanceled:
+		return withExitCode(exitInterrupted, errInterrupted)
+	case ssh.ConnectionFailed:
+		ui.Error("Could not connect to GitHub")
+		printSSHOutput(result.Output)
+		return withExitCode(exitNetwork, fmt.Errorf("connection failed"))
+	default:
+		ui.Error("Unexpected response from ssh")
+		printSSHOutput(result.Output)
+		return withExitCode(exitNetwork, fmt.Errorf("ssh test failed for '%s'", u.Alias))
+	}
+}
+
+// printSSHOutput shows ssh's own messages, indented under a failure
+func printSSHOutput(output string) {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Println("  " + line)
+		}
+	}
+}
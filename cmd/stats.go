@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/scanner"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize commits per identity across bound repos and workspaces",
+	Long: `Count commits made with each identity's email in bound repositories and
+repositories inside workspaces, over a time window.
+
+Only commits authored with a configured identity's email are counted, so
+collaborators' commits are ignored. A commit is flagged as unexpected when it
+was made with one identity's email in a repository that resolves to another
+identity.`,
+	Example: `  bgit stats                        # Last 30 days
+  bgit stats --since "1 year ago"
+  bgit stats --user work`,
+	RunE: runStats,
+}
+
+var (
+	statsSince string
+	statsUser  string
+)
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().StringVar(&statsSince, "since", "30 days ago", "Only count commits newer than this (any git date)")
+	statsCmd.Flags().StringVarP(&statsUser, "user", "u", "", "Only show this identity")
+}
+
+// identityStats accumulates commit counts for one identity
+type identityStats struct {
+	commits int
+	repos   int
+}
+
+// unexpectedCommits records commits made with the wrong identity in a repository
+type unexpectedCommits struct {
+	repo     string
+	email    string
+	actual   string // Alias owning the email
+	expected string // Alias the repository resolves to
+	count    int
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if len(cfg.Users) == 0 {
+		ui.Info("No identities configured")
+		return nil
+	}
+	if statsUser != "" && cfg.FindUserByAlias(statsUser) == nil {
+		return fmt.Errorf("user '%s' not found", statsUser)
+	}
+
+	repos := statsRepos(cfg)
+	if len(repos) == 0 {
+		ui.Info("No bound repositories or workspace repositories found")
+		fmt.Println("Bind repos with 'bgit bind' or create workspaces with 'bgit workspace'.")
+		return nil
+	}
+
+	byEmail := make(map[string]*config.User)
+	for i := range cfg.Users {
+		byEmail[strings.ToLower(cfg.Users[i].Email)] = &cfg.Users[i]
+	}
+
+	totals := make(map[string]*identityStats)
+	for i := range cfg.Users {
+		totals[cfg.Users[i].Alias] = &identityStats{}
+	}
+
+	var unexpected []unexpectedCommits
+	for _, repoPath := range repos {
+		counts, err := git.CommitEmailCounts(repoPath, statsSince)
+		if err != nil {
+			ui.Warning(fmt.Sprintf("Skipping %s: %v", shortenPath(repoPath), err))
+			continue
+		}
+
+		expected := ""
+		if resolution, _ := identity.ResolveIdentity(cfg, repoPath); resolution != nil {
+			expected = resolution.Alias
+		}
+
+		for email, count := range counts {
+			user := byEmail[email]
+			if user == nil {
+				continue
+			}
+			totals[user.Alias].commits += count
+			totals[user.Alias].repos++
+
+			if expected != "" && user.Alias != expected {
+				unexpected = append(unexpected, unexpectedCommits{
+					repo:     repoPath,
+					email:    email,
+					actual:   user.Alias,
+					expected: expected,
+					count:    count,
+				})
+			}
+		}
+	}
+
+	fmt.Printf("Commits since %s across %d repo(s):\n\n", statsSince, len(repos))
+	for _, u := range cfg.Users {
+		if statsUser != "" && u.Alias != statsUser {
+			continue
+		}
+		s := totals[u.Alias]
+		fmt.Printf("  %-14s %-30s %5d commit(s) in %d repo(s)\n", u.Alias, u.Email, s.commits, s.repos)
+	}
+
+	sort.Slice(unexpected, func(i, j int) bool {
+		if unexpected[i].repo != unexpected[j].repo {
+			return unexpected[i].repo < unexpected[j].repo
+		}
+		return unexpected[i].email < unexpected[j].email
+	})
+
+	shown := 0
+	for _, u := range unexpected {
+		if statsUser != "" && u.actual != statsUser && u.expected != statsUser {
+			continue
+		}
+		if shown == 0 {
+			fmt.Println()
+			ui.Warning("Commits under unexpected emails:")
+		}
+		shown++
+		ui.Printf("  %s %s: %d commit(s) as %s (%s), expected '%s'\n", ui.Red("✗"), shortenPath(u.repo), u.count, u.email, u.actual, u.expected)
+	}
+
+	fmt.Println()
+	if shown == 0 {
+		ui.Success("No commits under unexpected emails")
+	} else {
+		fmt.Println("Run 'bgit sync --repo' in those repositories to correct their git user.")
+	}
+
+	return nil
+}
+
+// statsRepos returns bound repositories and repositories inside workspaces,
+// each once
+func statsRepos(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			repos = append(repos, path)
+		}
+	}
+
+	for _, b := range cfg.GetBindings() {
+		if info, err := os.Stat(b.Path); err == nil && info.IsDir() {
+			add(b.Path)
+		}
+	}
+
+	var roots []string
+	for _, ws := range cfg.GetWorkspaces() {
+		if _, err := os.Stat(ws.Path); err == nil {
+			roots = append(roots, ws.Path)
+		}
+	}
+	if len(roots) > 0 {
+		found, _ := findRepos(roots, nil, cfg.ScanIgnore, scanner.DefaultTimeout)
+		for _, repo := range found {
+			add(repo)
+		}
+	}
+
+	sort.Strings(repos)
+	return repos
+}
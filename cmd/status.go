@@ -6,8 +6,11 @@ import (
 	"path/filepath"
 
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
 	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/remote"
 	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
 	"github.com/spf13/cobra"
 )
 
@@ -17,9 +20,12 @@ var statusCmd = &cobra.Command{
 	Long: `Display the current identity status including:
 - Active global identity
 - Current repository binding (if in a git repo)
+- Whether the origin remote's account matches the effective identity
 - Effective identity for current location
-- Configured workspaces and bindings
+- Commit signing configuration and whether its key matches the identity
+- Configured workspaces and bindings, marking ones whose folders are gone
 
+Status only reads; remove dead workspaces and bindings with 'bgit gc'.
 This helps you understand which identity will be used for git operations.`,
 	RunE: runStatus,
 }
@@ -34,13 +40,6 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	removed := cfg.CleanupInvalidPaths()
-	if removed > 0 {
-		if err := config.SaveConfig(cfg); err != nil {
-			ui.Warning(fmt.Sprintf("Failed to save config after cleanup: %v", err))
-		}
-	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
 		cwd = ""
@@ -53,6 +52,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	printActiveIdentity(cfg, resolution)
 	printCurrentRepo(cfg, cwd, resolution)
+	printSigning(cwd, resolution)
 	printWorkspaces(cfg)
 	printBindings(cfg)
 
@@ -62,7 +62,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 func printActiveIdentity(cfg *config.Config, resolution *identity.Resolution) {
 	fmt.Println()
 	fmt.Println("Active Identity")
-	fmt.Println("───────────────")
+	ui.Println("───────────────")
 
 	if cfg.ActiveUser == "" {
 		fmt.Println("  No active user set")
@@ -89,13 +89,24 @@ func printActiveIdentity(cfg *config.Config, resolution *identity.Resolution) {
 	} else {
 		sshStatus = "⚠ (not configured)"
 	}
-	fmt.Printf("  SSH Key:  %s %s\n", user.SSHKeyPath, sshStatus)
+	ui.Printf("  SSH Key:  %s %s\n", user.SSHKeyPath, sshStatus)
+	if user.SSHKeyPath != "" {
+		// Matches the fingerprint on GitHub's SSH keys settings page
+		if fingerprint, err := userpkg.GetFingerprint(user.SSHKeyPath); err == nil {
+			fmt.Printf("            %s\n", fingerprint)
+		}
+	}
+	if user.UsesIdentityAgent() {
+		fmt.Printf("  Agent:    %s\n", user.IdentityAgent)
+	} else if user.AgentLifetime != "" {
+		fmt.Printf("  Lifetime: %s in the SSH agent\n", user.AgentLifetime)
+	}
 }
 
 func printCurrentRepo(cfg *config.Config, cwd string, resolution *identity.Resolution) {
 	fmt.Println()
 	fmt.Println("Current Location")
-	fmt.Println("────────────────")
+	ui.Println("────────────────")
 
 	if cwd == "" {
 		fmt.Println("  Could not determine current directory")
@@ -109,24 +120,15 @@ func printCurrentRepo(cfg *config.Config, cwd string, resolution *identity.Resol
 		fmt.Println("  Not inside a git repository")
 	} else {
 		fmt.Printf("  Path: %s\n", repoRoot)
+		printRemoteAgreement(repoRoot, resolution)
 	}
 
 	if resolution != nil {
 		fmt.Println()
 		fmt.Println("Effective Identity")
-		fmt.Println("──────────────────")
-
-		sourceStr := ""
-		switch resolution.Source {
-		case identity.SourceWorkspace:
-			sourceStr = fmt.Sprintf("(workspace: %s)", resolution.Path)
-		case identity.SourceBinding:
-			sourceStr = fmt.Sprintf("(bound repo)")
-		case identity.SourceGlobal:
-			sourceStr = "(global)"
-		}
+		ui.Println("──────────────────")
 
-		fmt.Printf("  Using: %s %s\n", resolution.Alias, sourceStr)
+		fmt.Printf("  Using: %s %s\n", resolution.Alias, describeSource(resolution))
 
 		if resolution.User != nil {
 			fmt.Printf("  Email: %s\n", resolution.User.Email)
@@ -143,6 +145,82 @@ func printCurrentRepo(cfg *config.Config, cwd string, resolution *identity.Resol
 	}
 }
 
+// printRemoteAgreement shows the account implied by origin and whether it matches the effective identity
+func printRemoteAgreement(repoRoot string, resolution *identity.Resolution) {
+	url, err := git.GetRemoteURL(repoRoot, "origin")
+	if err != nil || url == "" {
+		fmt.Println("  Remote: (no origin)")
+		return
+	}
+	fmt.Printf("  Remote: %s\n", url)
+
+	if resolution == nil || resolution.User == nil {
+		return
+	}
+	expected := resolution.User.GitHubUsername
+
+	parsed, err := remote.Parse(url)
+	if err != nil {
+		fmt.Println("  Account: (not a GitHub URL)")
+		return
+	}
+
+	switch {
+	case parsed.Kind != remote.KindBgit:
+		ui.Printf("  Account: ✗ MISMATCH (default SSH key, expected %s)\n", expected)
+		ui.Println("    → Fix: bgit remote fix")
+	case parsed.HostUser == expected:
+		ui.Printf("  Account: ✓ OK (%s)\n", parsed.HostUser)
+	default:
+		ui.Printf("  Account: ✗ MISMATCH (%s, expected %s)\n", parsed.HostUser, expected)
+		ui.Println("    → Fix: bgit remote fix")
+	}
+}
+
+func printSigning(cwd string, resolution *identity.Resolution) {
+	sc, err := git.GetSigningConfig(cwd)
+	if err != nil {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Commit Signing")
+	ui.Println("──────────────")
+
+	if !sc.Enabled {
+		fmt.Println("  Enabled:  no")
+		if sc.Key != "" {
+			fmt.Printf("  Key:      %s (configured but unused)\n", sc.Key)
+		}
+		return
+	}
+
+	fmt.Printf("  Enabled:  yes (%s)\n", sc.Format)
+
+	if sc.Key == "" {
+		ui.Println("  Key:      ⚠ (not set, git will pick a default)")
+		return
+	}
+
+	if resolution == nil || resolution.User == nil {
+		fmt.Printf("  Key:      %s\n", sc.Key)
+		return
+	}
+
+	belongs, known := userpkg.SigningKeyBelongsTo(sc, resolution.User)
+	switch {
+	case !known:
+		fmt.Printf("  Key:      %s (could not verify owner)\n", sc.Key)
+	case belongs:
+		ui.Printf("  Key:      %s ✓ (belongs to %s)\n", sc.Key, resolution.Alias)
+	default:
+		ui.Printf("  Key:      %s ✗\n", sc.Key)
+		fmt.Println()
+		ui.Warning(fmt.Sprintf("Signing key does not belong to '%s' (%s)", resolution.Alias, resolution.User.Email))
+		ui.Info("Commits here will be signed with another identity's key.")
+	}
+}
+
 func printWorkspaces(cfg *config.Config) {
 	workspaces := cfg.GetWorkspaces()
 	if len(workspaces) == 0 {
@@ -151,14 +229,21 @@ func printWorkspaces(cfg *config.Config) {
 
 	fmt.Println()
 	fmt.Println("Workspaces")
-	fmt.Println("──────────")
+	ui.Println("──────────")
 
+	missing := 0
 	for _, ws := range workspaces {
 		status := "✓"
 		if _, err := os.Stat(ws.Path); os.IsNotExist(err) {
 			status = "✗"
+			missing++
 		}
-		fmt.Printf("  %s %s → %s\n", status, shortenPath(ws.Path), ws.User)
+		ui.Printf("  %s %s → %s\n", status, shortenPath(ws.Path), ws.User)
+	}
+
+	if missing > 0 {
+		fmt.Println()
+		ui.Info(fmt.Sprintf("%d workspace folder(s) no longer exist. Run: bgit gc", missing))
 	}
 }
 
@@ -170,14 +255,21 @@ func printBindings(cfg *config.Config) {
 
 	fmt.Println()
 	fmt.Println("Bound Repositories")
-	fmt.Println("──────────────────")
+	ui.Println("──────────────────")
 
+	missing := 0
 	for _, b := range bindings {
 		status := "✓"
-		if _, err := os.Stat(b.Path); os.IsNotExist(err) {
+		if !config.BindingExists(b) {
 			status = "✗"
+			missing++
 		}
-		fmt.Printf("  %s %s → %s\n", status, shortenPath(b.Path), b.User)
+		ui.Printf("  %s %s → %s\n", status, shortenPath(b.Path), b.User)
+	}
+
+	if missing > 0 {
+		fmt.Println()
+		ui.Info(fmt.Sprintf("%d binding(s) point to missing repositories. Run: bgit gc", missing))
 	}
 }
 
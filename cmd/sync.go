@@ -6,15 +6,20 @@ import (
 
 	"github.com/byterings/bgit/internal/config"
 	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/hooks"
 	"github.com/byterings/bgit/internal/identity"
 	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/remote"
 	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	autoFix bool
+	autoFix    bool
+	syncDryRun bool
+	syncRepo   bool
+	syncTag    string
 )
 
 var syncCmd = &cobra.Command{
@@ -27,23 +32,44 @@ The effective user is determined by:
 2. Binding (if repo is bound to a user)
 3. Global active user (fallback)
 
-Optionally fix any mismatches found.`,
+Optionally fix any mismatches found, or preview the fixes with --dry-run.
+
+With --repo, checks the current repository instead of global state: its
+effective git user.name/email, the origin remote's SSH host alias, and any
+bgit-managed hooks.
+
+With --tag, checks the SSH key files of every identity carrying the tag
+instead of the effective identity, and the bgit section of the SSH config.`,
+	Example: `  bgit sync --dry-run
+  bgit sync --repo --fix
+  bgit sync --tag client-a --fix`,
 	RunE: runSync,
 }
 
 func init() {
 	rootCmd.AddCommand(syncCmd)
 	syncCmd.Flags().BoolVarP(&autoFix, "fix", "f", false, "Automatically fix issues without prompting")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Show what --fix would change without changing anything")
+	syncCmd.Flags().BoolVar(&syncRepo, "repo", false, "Check the current repository's git config, remote, and hooks")
+	syncCmd.Flags().StringVar(&syncTag, "tag", "", "Check the keys of every identity with this tag")
+	syncCmd.MarkFlagsMutuallyExclusive("repo", "tag")
 }
 
 func runSync(cmd *cobra.Command, args []string) error {
+	if syncDryRun && autoFix {
+		return withExitCode(exitUsage, fmt.Errorf("--dry-run and --fix cannot be used together"))
+	}
+	if syncRepo && !isGitRepo() {
+		return fmt.Errorf("not a git repository\nRun 'bgit sync --repo' inside a git repository")
+	}
+
 	// Check if bgit is initialized
 	exists, err := config.ConfigExists()
 	if err != nil {
 		return err
 	}
 	if !exists {
-		return fmt.Errorf("bgit not initialized. Run 'bgit init' first")
+		return fmt.Errorf("%w. Run 'bgit init' first", config.ErrNotInitialized)
 	}
 
 	// Load config
@@ -52,6 +78,10 @@ func runSync(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	if syncTag != "" {
+		return runSyncTag(cfg, syncTag)
+	}
+
 	// Get effective identity (respects workspace/binding)
 	resolution, err := identity.GetEffectiveResolution(cfg)
 	if err != nil {
@@ -68,16 +98,27 @@ func runSync(cmd *cobra.Command, args []string) error {
 
 	// Show context info
 	sourceInfo := ""
-	switch resolution.Source {
-	case identity.SourceWorkspace:
-		sourceInfo = fmt.Sprintf(" (workspace: %s)", resolution.Path)
-	case identity.SourceBinding:
-		sourceInfo = " (bound repo)"
-	case identity.SourceGlobal:
-		sourceInfo = ""
+	if resolution.Source != identity.SourceGlobal {
+		sourceInfo = " " + describeSource(resolution)
 	}
 
 	fmt.Printf("Validating identity: %s%s\n", resolution.Alias, sourceInfo)
+
+	// With no workspace, binding, or rule, propose an identity from the remote owner
+	if resolution.Source == identity.SourceGlobal && isGitRepo() {
+		if url, err := getRemoteURL("origin"); err == nil {
+			suggestion := identity.SuggestIdentity(cfg, url)
+			if suggestion != nil && suggestion.Alias != resolution.Alias {
+				ui.Warning(fmt.Sprintf("Repository owner '%s' matches identity '%s', not '%s'", suggestion.Owner, suggestion.Alias, resolution.Alias))
+				fmt.Printf("  To use it here: bgit bind --user %s\n", suggestion.Alias)
+			}
+		}
+	}
+
+	if syncRepo {
+		return runSyncRepo(cfg, resolution)
+	}
+
 	fmt.Printf("Checking configuration for: %s (%s)\n\n", activeUser.GitHubUsername, activeUser.Email)
 
 	issues := []string{}
@@ -104,35 +145,24 @@ func runSync(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Check SSH key
-	if activeUser.SSHKeyPath != "" {
-		fmt.Println("\nChecking SSH key...")
-		if _, err := os.Stat(activeUser.SSHKeyPath); os.IsNotExist(err) {
-			ui.Error(fmt.Sprintf("SSH key not found: %s", activeUser.SSHKeyPath))
-			issues = append(issues, "ssh_key_missing")
-		} else {
-			ui.Success("SSH key exists")
-
-			// Check permissions (Unix only)
-			ok, err := platform.CheckFilePermissions(activeUser.SSHKeyPath)
-			if err == nil && !ok {
-				info, _ := os.Stat(activeUser.SSHKeyPath)
-				mode := info.Mode()
-				ui.Error(fmt.Sprintf("SSH key has insecure permissions: %s", mode))
-				issues = append(issues, "ssh_key_permissions")
-			} else if err == nil {
-				ui.Success("SSH key permissions OK")
-			}
+	authorEmail, _ := git.GetGlobalConfig("author.email")
+	committerEmail, _ := git.GetGlobalConfig("committer.email")
+	if authorEmail != activeUser.AuthorEmail || committerEmail != activeUser.CommitterEmail {
+		if authorEmail != activeUser.AuthorEmail {
+			ui.Error(fmt.Sprintf("Git author.email mismatch: got %s, expected %s", orUnset(authorEmail), orUnset(activeUser.AuthorEmail)))
 		}
-
-		// Check public key
-		pubKeyPath := activeUser.SSHKeyPath + ".pub"
-		if _, err := os.Stat(pubKeyPath); os.IsNotExist(err) {
-			ui.Error(fmt.Sprintf("SSH public key not found: %s", pubKeyPath))
-			issues = append(issues, "ssh_pubkey_missing")
-		} else {
-			ui.Success("SSH public key exists")
+		if committerEmail != activeUser.CommitterEmail {
+			ui.Error(fmt.Sprintf("Git committer.email mismatch: got %s, expected %s", orUnset(committerEmail), orUnset(activeUser.CommitterEmail)))
 		}
+		issues = append(issues, "git_commit_emails_mismatch")
+	} else if activeUser.AuthorEmail != "" || activeUser.CommitterEmail != "" {
+		ui.Success("Git author.email and committer.email match")
+	}
+
+	// Check SSH key
+	if activeUser.SSHKeyPath != "" && !activeUser.UsesIdentityAgent() {
+		fmt.Println("\nChecking SSH key...")
+		issues = append(issues, checkSyncSSHKey(activeUser)...)
 	}
 
 	fmt.Println()
@@ -143,11 +173,18 @@ func runSync(cmd *cobra.Command, args []string) error {
 	}
 
 	// Issues found
-	fmt.Printf("\033[31mFound %d issue(s)\033[0m\n\n", len(issues))
+	fmt.Printf("%s\n\n", ui.Red(fmt.Sprintf("Found %d issue(s)", len(issues))))
+
+	if syncDryRun {
+		if err := printSyncPlan(cfg, activeUser, issues, gitName, gitEmail); err != nil {
+			return err
+		}
+		exit(exitMismatch)
+	}
 
 	// Determine if we should fix
 	fix := autoFix
-	if !autoFix {
+	if !autoFix && ui.IsInteractive() {
 		// Ask if user wants to fix
 		prompted, err := ui.PromptConfirmation("Fix these issues automatically?")
 		if err != nil {
@@ -158,39 +195,477 @@ func runSync(cmd *cobra.Command, args []string) error {
 
 	if !fix {
 		fmt.Println("\nNo changes made. Run 'bgit sync --fix' to auto-fix.")
-		return nil
+		exit(exitMismatch)
 	}
 
 	// Apply fixes
 	fmt.Println("\nApplying fixes...")
 
+	undo := beginUndo("sync --fix", "sync global config to "+activeUser.Alias, "")
+	failed := 0
 	for _, issue := range issues {
 		switch issue {
 		case "git_name_mismatch", "git_email_mismatch", "git_config_error":
-			if err := git.SetGlobalUser(activeUser.Name, activeUser.Email); err != nil {
+			if err := setGlobalUser(activeUser.Name, activeUser.Email); err != nil {
 				ui.Error(fmt.Sprintf("Failed to fix Git config: %v", err))
+				failed++
 			} else {
 				ui.Success("Fixed Git config")
 			}
 
+		case "git_commit_emails_mismatch":
+			if err := git.SetCommitEmails("", activeUser.AuthorEmail, activeUser.CommitterEmail); err != nil {
+				ui.Error(fmt.Sprintf("Failed to fix author/committer emails: %v", err))
+				failed++
+			} else {
+				ui.Success("Fixed author/committer emails")
+			}
+
 		case "ssh_key_permissions":
 			if err := platform.FixFilePermissions(activeUser.SSHKeyPath); err != nil {
 				ui.Error(fmt.Sprintf("Failed to fix SSH key permissions: %v", err))
+				failed++
 			} else {
 				ui.Success("Fixed SSH key permissions")
 			}
+
+		case "ssh_key_missing", "ssh_pubkey_missing":
+			// Keys can't be recreated; 'bgit update' replaces them
+			failed++
 		}
 	}
 
 	// Update SSH config
 	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
 		ui.Error(fmt.Sprintf("Failed to update SSH config: %v", err))
+		failed++
 	} else {
 		ui.Success("Updated SSH config")
 	}
+	commitUndo(undo)
+
+	fmt.Println()
+	if failed > 0 {
+		ui.Warning(fmt.Sprintf("%d issue(s) could not be fixed", failed))
+		exit(exitPartialFix)
+	}
+	ui.Success("Sync complete!")
+
+	return nil
+}
+
+// checkSyncSSHKey checks that a user's private and public key files exist and
+// the private key has safe permissions, printing each result, and returns the
+// issues found
+func checkSyncSSHKey(user *config.User) []string {
+	var issues []string
+	if _, err := os.Stat(user.SSHKeyPath); os.IsNotExist(err) {
+		ui.Error(fmt.Sprintf("SSH key not found: %s", user.SSHKeyPath))
+		issues = append(issues, "ssh_key_missing")
+	} else {
+		ui.Success("SSH key exists")
+
+		// Check permissions (Unix only)
+		ok, err := platform.CheckFilePermissions(user.SSHKeyPath)
+		if err == nil && !ok {
+			info, _ := os.Stat(user.SSHKeyPath)
+			mode := info.Mode()
+			ui.Error(fmt.Sprintf("SSH key has insecure permissions: %s", mode))
+			issues = append(issues, "ssh_key_permissions")
+		} else if err == nil {
+			ui.Success("SSH key permissions OK")
+		}
+	}
+
+	// Check public key
+	pubKeyPath := user.SSHKeyPath + ".pub"
+	if _, err := os.Stat(pubKeyPath); os.IsNotExist(err) {
+		ui.Error(fmt.Sprintf("SSH public key not found: %s", pubKeyPath))
+		issues = append(issues, "ssh_pubkey_missing")
+	} else {
+		ui.Success("SSH public key exists")
+	}
+	return issues
+}
+
+// runSyncTag checks the key files of every identity carrying tag and the bgit
+// section of the SSH config, fixing key permissions and regenerating the
+// section with --fix
+func runSyncTag(cfg *config.Config, tag string) error {
+	users := cfg.UsersWithTag(tag)
+	if len(users) == 0 {
+		return fmt.Errorf("no identities tagged '%s'\nTag one with: bgit update <alias> --tag %s", tag, tag)
+	}
+	fmt.Printf("Checking %d identity(ies) tagged '%s'\n", len(users), tag)
+
+	type keyIssue struct {
+		user  *config.User
+		issue string
+	}
+	var issues []keyIssue
+	for _, user := range users {
+		fmt.Printf("\n%s (%s)\n", user.Alias, user.GitHubUsername)
+		if user.SSHKeyPath == "" || user.UsesIdentityAgent() {
+			ui.Info("No key file to check")
+			continue
+		}
+		for _, issue := range checkSyncSSHKey(user) {
+			issues = append(issues, keyIssue{user, issue})
+		}
+	}
+
+	current, proposed, err := ssh.PreviewManagedSection(cfg.Users)
+	if err != nil {
+		return err
+	}
+	sshConfigStale := current != proposed
+	fmt.Println()
+	if sshConfigStale {
+		ui.Error("The bgit section of the SSH config is out of date")
+	} else {
+		ui.Success("SSH config is up to date")
+	}
+
+	fmt.Println()
+	if len(issues) == 0 && !sshConfigStale {
+		ui.Success(fmt.Sprintf("All identities tagged '%s' are in sync.", tag))
+		return nil
+	}
+	total := len(issues)
+	if sshConfigStale {
+		total++
+	}
+	fmt.Printf("%s\n\n", ui.Red(fmt.Sprintf("Found %d issue(s)", total)))
+
+	if syncDryRun {
+		fmt.Println("Dry run: the following changes would be made")
+		for _, ki := range issues {
+			if ki.issue != "ssh_key_permissions" {
+				continue
+			}
+			if info, err := os.Stat(ki.user.SSHKeyPath); err == nil {
+				ui.Printf("  %s: %s → %s\n", shortenPath(ki.user.SSHKeyPath), info.Mode().Perm(), os.FileMode(0600))
+			}
+		}
+		for _, line := range lineDiff(current, proposed) {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Printf("\nNo changes made. Run 'bgit sync --tag %s --fix' to apply.\n", tag)
+		exit(exitMismatch)
+	}
+
+	fix := autoFix
+	if !autoFix && ui.IsInteractive() {
+		prompted, err := ui.PromptConfirmation("Fix these issues automatically?")
+		if err != nil {
+			return err
+		}
+		fix = prompted
+	}
+	if !fix {
+		fmt.Printf("\nNo changes made. Run 'bgit sync --tag %s --fix' to auto-fix.\n", tag)
+		exit(exitMismatch)
+	}
+
+	fmt.Println("\nApplying fixes...")
+	undo := beginUndo("sync --fix", fmt.Sprintf("sync identities tagged '%s'", tag), "")
+	failed := 0
+	for _, ki := range issues {
+		switch ki.issue {
+		case "ssh_key_permissions":
+			if err := platform.FixFilePermissions(ki.user.SSHKeyPath); err != nil {
+				ui.Error(fmt.Sprintf("Failed to fix permissions of %s: %v", ki.user.SSHKeyPath, err))
+				failed++
+			} else {
+				ui.Success(fmt.Sprintf("Fixed SSH key permissions for '%s'", ki.user.Alias))
+			}
+		default:
+			// Keys can't be recreated; 'bgit update' or 'bgit key rotate' replaces them
+			ui.Warning(fmt.Sprintf("'%s': replace the key with 'bgit key rotate %s'", ki.user.Alias, ki.user.Alias))
+			failed++
+		}
+	}
+	if sshConfigStale {
+		if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+			ui.Error(fmt.Sprintf("Failed to update SSH config: %v", err))
+			failed++
+		} else {
+			ui.Success("Updated SSH config")
+		}
+	}
+	commitUndo(undo)
 
 	fmt.Println()
+	if failed > 0 {
+		ui.Warning(fmt.Sprintf("%d issue(s) could not be fixed", failed))
+		exit(exitPartialFix)
+	}
 	ui.Success("Sync complete!")
+	return nil
+}
+
+// printSyncPlan lists the changes 'bgit sync --fix' would make for the given issues
+func printSyncPlan(cfg *config.Config, activeUser *config.User, issues []string, gitName, gitEmail string) error {
+	fmt.Println("Dry run: the following changes would be made")
+	fmt.Println()
+
+	gitPlanned := false
+	for _, issue := range issues {
+		switch issue {
+		case "git_name_mismatch", "git_email_mismatch", "git_config_error":
+			if gitPlanned {
+				continue
+			}
+			gitPlanned = true
+			fmt.Println("Git config (global):")
+			ui.Printf("  user.name:  '%s' → '%s'\n", gitName, activeUser.Name)
+			ui.Printf("  user.email: '%s' → '%s'\n", gitEmail, activeUser.Email)
+			fmt.Println()
+
+		case "git_commit_emails_mismatch":
+			authorEmail, _ := git.GetGlobalConfig("author.email")
+			committerEmail, _ := git.GetGlobalConfig("committer.email")
+			fmt.Println("Git config (global):")
+			ui.Printf("  author.email:    %s → %s\n", orUnset(authorEmail), orUnset(activeUser.AuthorEmail))
+			ui.Printf("  committer.email: %s → %s\n", orUnset(committerEmail), orUnset(activeUser.CommitterEmail))
+			fmt.Println()
 
+		case "ssh_key_permissions":
+			info, err := os.Stat(activeUser.SSHKeyPath)
+			if err != nil {
+				continue
+			}
+			fmt.Println("File permissions:")
+			ui.Printf("  %s: %s → %s\n", activeUser.SSHKeyPath, info.Mode().Perm(), os.FileMode(0600))
+			fmt.Println()
+		}
+	}
+
+	current, proposed, err := ssh.PreviewManagedSection(cfg.Users)
+	if err != nil {
+		return err
+	}
+	sshConfigPath, _ := ssh.GetSSHConfigPath()
+	changes := lineDiff(current, proposed)
+	fmt.Printf("SSH config (%s):\n", sshConfigPath)
+	if len(changes) == 0 {
+		fmt.Println("  No changes to the bgit-managed section")
+	}
+	for _, line := range changes {
+		fmt.Printf("  %s\n", line)
+	}
+
+	fmt.Println()
+	fmt.Println("No changes made. Run 'bgit sync --fix' to apply.")
+	return nil
+}
+
+// orUnset quotes a git config value for display, or describes it as unset
+func orUnset(value string) string {
+	if value == "" {
+		return "(unset)"
+	}
+	return "'" + value + "'"
+}
+
+// runSyncRepo validates the current repository against the effective identity
+// and applies, previews, or offers fixes the same way global sync does
+func runSyncRepo(cfg *config.Config, resolution *identity.Resolution) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	repoRoot := identity.FindGitRoot(cwd)
+	activeUser := resolution.User
+	workspace := cfg.FindWorkspaceByPath(repoRoot)
+
+	fmt.Printf("Checking repository: %s\n\n", shortenPath(repoRoot))
+
+	var fixes changePlan
+
+	// Effective git user (local config layered over global)
+	fmt.Println("Checking Git config...")
+	name, _ := git.GetRepoConfig(repoRoot, "user.name")
+	email, _ := git.GetRepoConfig(repoRoot, "user.email")
+	if name != activeUser.Name || email != activeUser.Email {
+		if name != activeUser.Name {
+			ui.Error(fmt.Sprintf("Git user.name mismatch: got '%s', expected '%s'", name, activeUser.Name))
+		}
+		if email != activeUser.Email {
+			ui.Error(fmt.Sprintf("Git user.email mismatch: got '%s', expected '%s'", email, activeUser.Email))
+		}
+		fixes = append(fixes, plannedChange{
+			description: fmt.Sprintf("Set local git user to '%s <%s>'", activeUser.Name, activeUser.Email),
+			apply: func() error {
+				return git.SetRepoUser(repoRoot, activeUser.Name, activeUser.Email)
+			},
+		})
+	} else {
+		ui.Success("Git user.name and user.email match")
+	}
+
+	// author.email/committer.email override user.email; a global value left by
+	// another identity has to be overridden locally
+	wantAuthor, wantCommitter := activeUser.EffectiveAuthorEmail(), activeUser.EffectiveCommitterEmail()
+	authorEmail, _ := git.GetRepoConfig(repoRoot, "author.email")
+	committerEmail, _ := git.GetRepoConfig(repoRoot, "committer.email")
+	authorOK := authorEmail == wantAuthor || (authorEmail == "" && activeUser.AuthorEmail == "")
+	committerOK := committerEmail == wantCommitter || (committerEmail == "" && activeUser.CommitterEmail == "")
+	if !authorOK || !committerOK {
+		if !authorOK {
+			ui.Error(fmt.Sprintf("Git author.email mismatch: got '%s', expected '%s'", authorEmail, wantAuthor))
+		}
+		if !committerOK {
+			ui.Error(fmt.Sprintf("Git committer.email mismatch: got '%s', expected '%s'", committerEmail, wantCommitter))
+		}
+		fixes = append(fixes, plannedChange{
+			description: fmt.Sprintf("Set local author/committer emails to '%s' / '%s'", wantAuthor, wantCommitter),
+			apply: func() error {
+				return git.SetCommitEmails(repoRoot, wantAuthor, wantCommitter)
+			},
+		})
+	} else if activeUser.AuthorEmail != "" || activeUser.CommitterEmail != "" {
+		ui.Success("Git author.email and committer.email match")
+	}
+
+	// Origin remote host alias
+	fmt.Println("\nChecking remote...")
+	currentURL, err := git.GetRemoteURL(repoRoot, "origin")
+	if err != nil || currentURL == "" {
+		ui.Info("No 'origin' remote")
+	} else if parsed, err := remote.Parse(currentURL); err != nil {
+		ui.Info("Origin is not a GitHub remote; skipping")
+	} else {
+		wantBgit := parsed.Kind == remote.KindBgit || resolution.Source != identity.SourceGlobal
+		newURL := parsed.BgitURL(activeUser.GitHubUsername)
+		switch {
+		case !wantBgit:
+			ui.Success("Origin uses a standard URL (global identity)")
+		case !activeUser.HasSSHHost():
+			ui.Warning(fmt.Sprintf("'%s' has no SSH key; cannot use a bgit host alias", activeUser.Alias))
+		case currentURL == newURL:
+			ui.Success(fmt.Sprintf("Origin uses github.com-%s", activeUser.GitHubUsername))
+		default:
+			if parsed.Kind == remote.KindBgit {
+				ui.Error(fmt.Sprintf("Origin uses github.com-%s, expected github.com-%s", parsed.HostUser, activeUser.GitHubUsername))
+			} else {
+				ui.Error("Origin does not use a bgit host alias")
+			}
+			change := plannedChange{
+				description: fmt.Sprintf("Set origin URL: %s → %s", currentURL, newURL),
+				apply: func() error {
+					return setRemoteURL("origin", newURL)
+				},
+			}
+			// The workspace opted in to fixing remotes without asking
+			if workspace != nil && workspace.AutoFixRemotes {
+				change.description += " (workspace auto_fix_remotes)"
+				change.unattended = true
+			}
+			fixes = append(fixes, change)
+		}
+	}
+
+	// Commit signing, when the workspace requires it. bgit doesn't configure
+	// signing keys, so this is reported rather than fixed.
+	signingRequired := false
+	if workspace != nil && workspace.RequireSigning {
+		fmt.Println("\nChecking commit signing...")
+		if sc, err := git.GetSigningConfig(repoRoot); err == nil && sc.Enabled {
+			ui.Success(fmt.Sprintf("Commits are signed (%s)", sc.Format))
+		} else {
+			ui.Error(fmt.Sprintf("Commit signing is not enabled; workspace %s requires it", shortenPath(workspace.Path)))
+			fmt.Println("  Enable it: git config commit.gpgsign true (and set gpg.format / user.signingkey)")
+			signingRequired = true
+		}
+	}
+
+	// bgit-managed hooks
+	fmt.Println("\nChecking hooks...")
+	installed := 0
+	for _, name := range hooks.Names() {
+		if !hooks.IsInstalled(repoRoot, name) {
+			continue
+		}
+		installed++
+		if hooks.IsCurrent(repoRoot, name) {
+			ui.Success(fmt.Sprintf("%s hook up to date", name))
+			continue
+		}
+		ui.Error(fmt.Sprintf("%s hook is outdated", name))
+		hookName := name
+		fixes = append(fixes, plannedChange{
+			description: fmt.Sprintf("Reinstall %s hook", hookName),
+			apply: func() error {
+				return hooks.Install(repoRoot, hookName, false)
+			},
+		})
+	}
+	if installed == 0 {
+		ui.Info("No bgit hooks installed (optional: bgit hook install)")
+	}
+
+	fmt.Println()
+
+	if len(fixes) == 0 && signingRequired {
+		exit(exitMismatch)
+	}
+	if len(fixes) == 0 {
+		ui.Success("Repository is in sync.")
+		return nil
+	}
+
+	fmt.Printf("%s\n\n", ui.Red(fmt.Sprintf("Found %d issue(s)", len(fixes))))
+
+	if syncDryRun {
+		fixes.print("bgit sync --repo --fix")
+		exit(exitMismatch)
+	}
+
+	unattended := fixes.unattended()
+	fix := autoFix || len(unattended) == len(fixes)
+	if !fix && ui.IsInteractive() {
+		prompted, err := ui.PromptConfirmation("Fix these issues automatically?")
+		if err != nil {
+			return err
+		}
+		fix = prompted
+	}
+
+	// Without approval, only the unattended fixes are applied
+	apply := fixes
+	if !fix {
+		apply = unattended
+	}
+	failed := 0
+	if len(apply) > 0 {
+		fmt.Println("\nApplying fixes...")
+		undo := beginUndo("sync --fix", "sync "+shortenPath(repoRoot)+" to "+activeUser.Alias, repoRoot)
+		for _, f := range apply {
+			if err := f.apply(); err != nil {
+				ui.Error(fmt.Sprintf("%s: %v", f.description, err))
+				failed++
+			} else {
+				ui.Success(f.description)
+			}
+		}
+		commitUndo(undo)
+	}
+
+	if !fix {
+		if len(apply) > 0 {
+			fmt.Println("\nNo other changes made. Run 'bgit sync --repo --fix' to auto-fix.")
+		} else {
+			fmt.Println("\nNo changes made. Run 'bgit sync --repo --fix' to auto-fix.")
+		}
+		exit(exitMismatch)
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		ui.Warning(fmt.Sprintf("%d of %d fix(es) failed", failed, len(fixes)))
+		exit(exitPartialFix)
+	}
+	ui.Success("Repository sync complete!")
 	return nil
 }
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/byterings/bgit/internal/history"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/journal"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	undoForce  bool
+	undoList   bool
+	undoDryRun bool
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the most recent identity change",
+	Long: `Revert the most recent mutating operation (use, bind, remote fix or
+restore, sync --fix, key rotate, migrate keys, delete, gc) by restoring the
+files it changed: ~/.bgit/config.toml, ~/.ssh/config, the global git config,
+the allowed signers file, and the repository's git config. Key files are never
+deleted.
+
+bgit keeps the last 20 operations; run undo again to step further back. If a
+file was changed after the operation (by hand or by another tool), undo stops
+instead of overwriting it unless --force is given.`,
+	Example: `  bgit undo
+  bgit undo --list      # Show what can be undone
+  bgit undo --dry-run   # Show what would be restored`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolVarP(&undoForce, "force", "f", false, "Restore even if files changed after the operation")
+	undoCmd.Flags().BoolVarP(&undoList, "list", "l", false, "List the operations that can be undone, newest first")
+	undoCmd.Flags().BoolVar(&undoDryRun, "dry-run", false, "Show what would be restored without changing anything")
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	if undoList {
+		return listUndo()
+	}
+
+	entry, err := journal.Latest()
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		ui.Info("Nothing to undo")
+		return nil
+	}
+
+	fmt.Printf("Last operation: %s (%s) at %s\n", entry.Operation, entry.Description, entry.Time.Local().Format("2006-01-02 15:04:05"))
+
+	modified := entry.ModifiedSince()
+	if len(modified) > 0 {
+		ui.Warning("Changed since then:")
+		for _, path := range modified {
+			fmt.Printf("  %s\n", shortenPath(path))
+		}
+		if !undoForce && !undoDryRun {
+			return withExitCode(exitMismatch, fmt.Errorf("files changed after '%s'; run 'bgit undo --force' to restore them anyway", entry.Operation))
+		}
+	}
+
+	if undoDryRun {
+		fmt.Println("\nWould restore:")
+		for _, f := range entry.Pending() {
+			if f.Existed {
+				fmt.Printf("  %s\n", shortenPath(f.Path))
+			} else {
+				fmt.Printf("  %s (remove; it didn't exist before)\n", shortenPath(f.Path))
+			}
+		}
+		return nil
+	}
+
+	if err := entry.Restore(); err != nil {
+		return err
+	}
+	if err := entry.Discard(); err != nil {
+		ui.Warning(fmt.Sprintf("Could not remove undo entry: %v", err))
+	}
+
+	ui.Success(fmt.Sprintf("Undid %s (%s)", entry.Operation, entry.Description))
+	recordHistory(history.ActionUndo, "", "", fmt.Sprintf("%s (%s)", entry.Operation, entry.Description))
+
+	// Restoring config.toml may change which identity applies here
+	if resolution, err := identity.ResolveCached("."); err == nil && resolution != nil {
+		fmt.Printf("Effective identity: %s %s\n", resolution.Alias, describeSource(resolution))
+	}
+	return nil
+}
+
+// listUndo prints the undoable operations, newest first
+func listUndo() error {
+	entries, err := journal.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		ui.Info("Nothing to undo")
+		return nil
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		fmt.Printf("%s  %-14s %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Operation, e.Description)
+	}
+	return nil
+}
@@ -3,15 +3,20 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/byterings/bgit/internal/backup"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
 	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/scanner"
+	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
 	"github.com/spf13/cobra"
 )
 
@@ -20,14 +25,31 @@ var uninstallCmd = &cobra.Command{
 	Short: "Safely uninstall bgit and restore all repositories",
 	Long: `Safely uninstall bgit by:
 1. Finding all git repositories with bgit remote URLs
-2. Restoring them to standard GitHub format
-3. Removing bgit SSH config entries
-4. Removing bgit configuration
-
-This ensures your repositories continue to work after bgit is removed.`,
+2. Backing up ~/.bgit, the SSH config, and bgit-generated keys
+3. Restoring repositories to standard GitHub format
+4. Removing bgit SSH config entries
+5. Removing bgit-generated SSH keys (unless --keep-keys)
+6. Reverting git config bgit changed (global user, repo-local users, includes,
+   allowed signers)
+7. Removing bgit configuration
+
+This ensures your repositories continue to work after bgit is removed.
+The backup archive can be replayed with --undo to reverse the uninstall.`,
 	Example: `  # Uninstall bgit safely
   bgit uninstall
 
+  # List everything uninstall would change, without changing it
+  bgit uninstall --dry-run
+
+  # Keep the SSH keys bgit generated
+  bgit uninstall --keep-keys
+
+  # Also search repositories outside the default locations
+  bgit uninstall --path /data/src
+
+  # Reverse an uninstall from its backup archive
+  bgit uninstall --undo ~/bgit-backup-20250101-120000.zip
+
   # After running this command, manually delete:
   # Linux/macOS: sudo rm /usr/local/bin/bgit
   # Windows: Remove from Add/Remove Programs or delete the install folder`,
@@ -37,25 +59,51 @@ This ensures your repositories continue to work after bgit is removed.`,
 var (
 	uninstallSkipRepos bool
 	uninstallForce     bool
+	uninstallKeepKeys  bool
+	uninstallUndo      string
+	uninstallPaths     []string
+	uninstallSkip      []string
+	uninstallTimeout   time.Duration
+	uninstallDryRun    bool
 )
 
 func init() {
 	rootCmd.AddCommand(uninstallCmd)
 	uninstallCmd.Flags().BoolVar(&uninstallSkipRepos, "skip-repos", false, "Skip scanning and fixing repositories")
 	uninstallCmd.Flags().BoolVar(&uninstallForce, "force", false, "Skip confirmation prompt")
+	uninstallCmd.Flags().BoolVar(&uninstallKeepKeys, "keep-keys", false, "Keep SSH keys generated by bgit")
+	uninstallCmd.Flags().StringVar(&uninstallUndo, "undo", "", "Restore bgit from an uninstall backup archive")
+	uninstallCmd.Flags().StringArrayVarP(&uninstallPaths, "path", "p", nil, "Additional directory to search for repositories (repeatable)")
+	uninstallCmd.Flags().StringArrayVar(&uninstallSkip, "skip", nil, "Directory name to skip while scanning (repeatable)")
+	uninstallCmd.Flags().DurationVar(&uninstallTimeout, "scan-timeout", scanner.DefaultTimeout, "Stop scanning for repositories after this long")
+	uninstallCmd.Flags().BoolVar(&uninstallDryRun, "dry-run", false, "List the repositories, SSH config lines, and files uninstall would change, without changing anything")
 }
 
 func runUninstall(cmd *cobra.Command, args []string) error {
+	if uninstallUndo != "" {
+		if uninstallDryRun {
+			return withExitCode(exitUsage, fmt.Errorf("--dry-run cannot be combined with --undo"))
+		}
+		return runUninstallUndo(uninstallUndo)
+	}
+
 	fmt.Println("bgit Uninstall")
 	fmt.Println("==============")
 	fmt.Println()
 
-	if !uninstallForce {
+	if !uninstallForce && !uninstallDryRun {
 		fmt.Println("This will:")
 		fmt.Println("  1. Scan for repositories with bgit remote URLs")
-		fmt.Println("  2. Restore them to standard GitHub format")
-		fmt.Println("  3. Remove bgit SSH config entries")
-		fmt.Println("  4. Remove bgit configuration (~/.bgit)")
+		fmt.Println("  2. Back up ~/.bgit, the SSH config, and bgit-generated keys")
+		fmt.Println("  3. Restore repositories to standard GitHub format")
+		fmt.Println("  4. Remove bgit SSH config entries")
+		if uninstallKeepKeys {
+			fmt.Println("  5. Keep SSH keys generated by bgit (--keep-keys)")
+		} else {
+			fmt.Println("  5. Remove SSH keys generated by bgit")
+		}
+		fmt.Println("  6. Revert git config bgit changed (global user, repo-local users, includes)")
+		fmt.Println("  7. Remove bgit configuration (~/.bgit)")
 		fmt.Println()
 
 		confirmed, err := ui.PromptConfirmation("Continue?")
@@ -69,16 +117,36 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	var fixedRepos []string
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	extraRoots, err := resolveScanPaths(uninstallPaths)
+	if err != nil {
+		return err
+	}
+	cfg, _ := config.LoadConfig()
+
+	var changes []backup.RepoChange
+	var localUsers []backup.RepoUser
 	var failedRepos []string
 
 	if !uninstallSkipRepos {
 		fmt.Println("Step 1: Scanning for repositories...")
-		homeDir, err := os.UserHomeDir()
+		roots := scanner.DefaultRoots(homeDir)
+		var ignore []string
+		if cfg != nil {
+			roots = defaultScanRoots(cfg)
+			ignore = cfg.ScanIgnore
+		}
+		changes, localUsers, failedRepos, err = planRepoRestores(append(roots, extraRoots...), ignore)
 		if err != nil {
-			ui.Error("Failed to get home directory")
-		} else {
-			fixedRepos, failedRepos = scanAndFixRepos(homeDir)
+			return fmt.Errorf("%w\nNothing was changed. Re-run with a longer --scan-timeout, or --skip large directories", err)
+		}
+		ui.Info(fmt.Sprintf("%d repo(s) to restore", len(changes)))
+		if len(localUsers) > 0 {
+			ui.Info(fmt.Sprintf("%d repo(s) with a bgit-managed local git user", len(localUsers)))
 		}
 		fmt.Println()
 	} else {
@@ -86,7 +154,55 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	fmt.Println("Step 2: Removing SSH config entries...")
+	var keys []string
+	if !uninstallKeepKeys && cfg != nil {
+		keys = bgitGeneratedKeys(cfg)
+	}
+	if uninstallDryRun {
+		printUninstallDryRun(changes, localUsers, failedRepos, keys)
+		return nil
+	}
+
+	fmt.Println("Step 2: Creating backup...")
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return err
+	}
+	sshConfigPath, _ := platform.GetSSHConfigPath()
+	globalName, globalEmail, _ := git.GetGlobalUser()
+	manifest := &backup.Manifest{
+		CreatedAt:     time.Now(),
+		BgitVersion:   version,
+		ConfigDir:     configDir,
+		SSHConfigPath: sshConfigPath,
+		Keys:          keys,
+		Repos:         changes,
+		GlobalName:    globalName,
+		GlobalEmail:   globalEmail,
+		LocalUsers:    localUsers,
+	}
+	backupPath := backup.DefaultPath(homeDir, manifest.CreatedAt)
+	if err := backup.Write(backupPath, manifest); err != nil {
+		return fmt.Errorf("%w\nNothing was changed", err)
+	}
+	ui.Success(fmt.Sprintf("Backup written to %s", backupPath))
+	fmt.Println()
+
+	var fixedRepos []string
+	if !uninstallSkipRepos {
+		fmt.Println("Step 3: Restoring repositories...")
+		for _, c := range changes {
+			if err := setRepoRemoteURL(c.Path, c.Remote, c.NewURL); err != nil {
+				failedRepos = append(failedRepos, c.Path)
+			} else {
+				fixedRepos = append(fixedRepos, c.Path)
+			}
+		}
+		ui.Success(fmt.Sprintf("%d repo(s) restored", len(fixedRepos)))
+		fmt.Println()
+	}
+
+	fmt.Println("Step 4: Removing SSH config entries...")
 	if err := removeSSHConfigEntries(); err != nil {
 		ui.Error(fmt.Sprintf("Failed to remove SSH config: %v", err))
 	} else {
@@ -94,14 +210,31 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 
-	fmt.Println("Step 3: Removing bgit configuration...")
-	configDir, err := config.GetConfigDir()
-	if err == nil {
-		if err := os.RemoveAll(configDir); err != nil {
-			ui.Error(fmt.Sprintf("Failed to remove config: %v", err))
-		} else {
-			ui.Success(fmt.Sprintf("Removed %s", configDir))
+	fmt.Println("Step 5: Removing bgit-generated SSH keys...")
+	if uninstallKeepKeys {
+		ui.Info("Skipped (--keep-keys)")
+	} else if len(keys) == 0 {
+		ui.Info("No bgit-generated keys found")
+	}
+	for _, key := range keys {
+		for _, p := range []string{key, key + ".pub"} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				ui.Error(fmt.Sprintf("Failed to remove %s: %v", p, err))
+			}
 		}
+		ui.Success(fmt.Sprintf("Removed %s", key))
+	}
+	fmt.Println()
+
+	fmt.Println("Step 6: Reverting git config...")
+	revertGitConfig(configDir, localUsers)
+	fmt.Println()
+
+	fmt.Println("Step 7: Removing bgit configuration...")
+	if err := os.RemoveAll(configDir); err != nil {
+		ui.Error(fmt.Sprintf("Failed to remove config: %v", err))
+	} else {
+		ui.Success(fmt.Sprintf("Removed %s", configDir))
 	}
 	fmt.Println()
 
@@ -112,23 +245,26 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	if len(fixedRepos) > 0 {
 		fmt.Printf("\nRepositories restored (%d):\n", len(fixedRepos))
 		for _, repo := range fixedRepos {
-			fmt.Printf("  ✓ %s\n", repo)
+			ui.Printf("  %s %s\n", ui.Green("✓"), repo)
 		}
 	}
 
 	if len(failedRepos) > 0 {
 		fmt.Printf("\nRepositories failed (%d):\n", len(failedRepos))
 		for _, repo := range failedRepos {
-			fmt.Printf("  ✗ %s\n", repo)
+			ui.Printf("  %s %s\n", ui.Red("✗"), repo)
 		}
 	}
 
 	fmt.Println()
 	ui.Success("bgit uninstall complete!")
 	fmt.Println()
+	fmt.Printf("Backup: %s\n", backupPath)
+	fmt.Printf("To undo: bgit uninstall --undo %s\n", backupPath)
+	fmt.Println()
 	fmt.Println("Final step - manually remove the bgit binary:")
 	if runtime.GOOS == "windows" {
-		fmt.Println("  Option 1: Settings → Apps → bgit → Uninstall")
+		ui.Println("  Option 1: Settings → Apps → bgit → Uninstall")
 		fmt.Println("  Option 2: Remove-Item \"$env:LOCALAPPDATA\\bgit\" -Recurse -Force")
 	} else {
 		fmt.Println("  sudo rm /usr/local/bin/bgit")
@@ -138,122 +274,355 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func scanAndFixRepos(startPath string) (fixed []string, failed []string) {
-	scanDirs := []string{startPath}
+// printUninstallDryRun lists what uninstall would change: the remotes it
+// would rewrite, the repo-local git users it would remove, the SSH config
+// lines it would delete, and the files it would remove
+func printUninstallDryRun(changes []backup.RepoChange, localUsers []backup.RepoUser, failedRepos, keys []string) {
+	fmt.Println("Dry run: uninstall would make these changes")
+	fmt.Println()
 
-	commonDirs := []string{"Documents", "Projects", "repos", "src", "code", "work", "dev", "git"}
-	for _, dir := range commonDirs {
-		fullPath := filepath.Join(startPath, dir)
-		if info, err := os.Stat(fullPath); err == nil && info.IsDir() {
-			scanDirs = append(scanDirs, fullPath)
+	if uninstallSkipRepos {
+		fmt.Println("Repositories: not scanned (--skip-repos)")
+	} else {
+		fmt.Printf("Repositories rewritten (%d):\n", len(changes))
+		for _, c := range changes {
+			ui.Printf("  %s: %s %s → %s\n", shortenPath(c.Path), c.Remote, c.OldURL, c.NewURL)
+		}
+		if len(failedRepos) > 0 {
+			fmt.Printf("Repositories with a bgit remote that can't be converted, left alone (%d):\n", len(failedRepos))
+			for _, repo := range failedRepos {
+				fmt.Printf("  %s\n", shortenPath(repo))
+			}
+		}
+		if len(localUsers) > 0 {
+			fmt.Printf("Repo-local git users removed (%d):\n", len(localUsers))
+			for _, u := range localUsers {
+				fmt.Printf("  %s: %s <%s>\n", shortenPath(u.Path), u.Name, u.Email)
+			}
 		}
 	}
+	fmt.Println()
 
-	visited := make(map[string]bool)
-	bgitPattern := regexp.MustCompile(`github\.com-`)
-
-	for _, scanDir := range scanDirs {
-		filepath.Walk(scanDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
+	if sshConfigPath, err := platform.GetSSHConfigPath(); err == nil {
+		content, _ := os.ReadFile(sshConfigPath)
+		var removed []string
+		line := 0
+		for _, l := range diffLines(string(content), ssh.RemoveManagedSections(string(content))) {
+			if l.op == '+' {
+				continue
 			}
-
-			if info.IsDir() && strings.HasPrefix(info.Name(), ".") && info.Name() != ".git" {
-				return filepath.SkipDir
+			line++
+			if l.op == '-' {
+				removed = append(removed, fmt.Sprintf("  %4d  %s", line, l.text))
 			}
+		}
+		fmt.Printf("Lines removed from %s (%d):\n", shortenPath(sshConfigPath), len(removed))
+		for _, l := range removed {
+			fmt.Println(l)
+		}
+		fmt.Println()
+	}
 
-			skipDirs := []string{"node_modules", "vendor", ".cache", ".local", "snap", ".npm", ".cargo"}
-			for _, skip := range skipDirs {
-				if info.Name() == skip {
-					return filepath.SkipDir
-				}
+	fmt.Println("Files deleted:")
+	for _, key := range keys {
+		if _, err := os.Stat(key + ".pub"); err == nil {
+			fmt.Printf("  %s (+ .pub)\n", shortenPath(key))
+		} else {
+			fmt.Printf("  %s\n", shortenPath(key))
+		}
+	}
+	if uninstallKeepKeys {
+		fmt.Println("  (keys kept: --keep-keys)")
+	}
+	if configDir, err := config.GetConfigDir(); err == nil {
+		var names []string
+		if entries, err := os.ReadDir(configDir); err == nil {
+			for _, e := range entries {
+				names = append(names, e.Name())
 			}
+		}
+		fmt.Printf("  %s and everything in it: %s\n", shortenPath(configDir), strings.Join(names, ", "))
+	}
+	fmt.Println()
 
-			if info.IsDir() && info.Name() == ".git" {
-				repoPath := filepath.Dir(path)
+	fmt.Println("Git config reverted: the global user.name/user.email set back to their values")
+	fmt.Println("before bgit, author/committer emails bgit set, bgit's allowed signers entries,")
+	fmt.Println("and includes pointing into ~/.bgit.")
+	fmt.Println()
+	fmt.Println("A backup archive (for 'bgit uninstall --undo') is written to your home directory first.")
+	fmt.Println("No changes made. Run 'bgit uninstall' to apply.")
+}
 
-				if visited[repoPath] {
-					return filepath.SkipDir
-				}
-				visited[repoPath] = true
+// runUninstallUndo restores configuration, keys, SSH entries, and repository
+// remotes from an uninstall backup archive
+func runUninstallUndo(archivePath string) error {
+	manifest, err := backup.ReadManifest(archivePath)
+	if err != nil {
+		return err
+	}
 
-				url, err := getRepoRemoteURL(repoPath)
-				if err != nil || url == "" {
-					return filepath.SkipDir
-				}
+	exists, err := config.ConfigExists()
+	if err != nil {
+		return err
+	}
+	if exists && !uninstallForce {
+		return fmt.Errorf("bgit is already configured at %s\nMove it aside or use --force to overwrite it", manifest.ConfigDir)
+	}
 
-				if bgitPattern.MatchString(url) {
-					newURL, err := convertToStandardURL(url)
-					if err != nil {
-						failed = append(failed, repoPath)
-						return filepath.SkipDir
-					}
-
-					if err := setRepoRemoteURL(repoPath, "origin", newURL); err != nil {
-						failed = append(failed, repoPath)
-					} else {
-						fixed = append(fixed, repoPath)
-					}
-				}
+	fmt.Printf("Restoring from %s (created %s)\n\n", archivePath, manifest.CreatedAt.Format("2006-01-02 15:04:05"))
 
-				return filepath.SkipDir // Don't descend into .git
-			}
+	restoredKeys, err := backup.RestoreFiles(archivePath, manifest)
+	if err != nil {
+		return err
+	}
+	ui.Success(fmt.Sprintf("Restored %s", manifest.ConfigDir))
+	for _, key := range restoredKeys {
+		ui.Success(fmt.Sprintf("Restored key %s", key))
+	}
 
-			return nil
-		})
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load restored config: %w", err)
+	}
+	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+		ui.Error(fmt.Sprintf("Failed to restore SSH config entries: %v", err))
+	} else {
+		ui.Success("SSH config entries restored")
+	}
+
+	if manifest.GlobalName != "" || manifest.GlobalEmail != "" {
+		if err := git.SetGlobalUser(manifest.GlobalName, manifest.GlobalEmail); err != nil {
+			ui.Error(fmt.Sprintf("Failed to restore global git user: %v", err))
+		} else {
+			ui.Success(fmt.Sprintf("Global git user restored: %s <%s>", manifest.GlobalName, manifest.GlobalEmail))
+		}
+	}
+
+	for _, u := range manifest.LocalUsers {
+		if _, err := os.Stat(u.Path); err != nil {
+			continue
+		}
+		if err := git.SetRepoUser(u.Path, u.Name, u.Email); err != nil {
+			ui.Error(fmt.Sprintf("Failed to restore local git user in %s: %v", u.Path, err))
+		}
+	}
+	if len(manifest.LocalUsers) > 0 {
+		ui.Success(fmt.Sprintf("%d repo-local git user(s) restored", len(manifest.LocalUsers)))
 	}
 
-	return fixed, failed
+	restored, skipped := 0, 0
+	for _, c := range manifest.Repos {
+		current, err := git.GetRemoteURL(c.Path, c.Remote)
+		if err != nil || current != c.NewURL {
+			// The repo moved or its remote changed since uninstall; leave it alone
+			skipped++
+			continue
+		}
+		if err := setRepoRemoteURL(c.Path, c.Remote, c.OldURL); err != nil {
+			ui.Error(fmt.Sprintf("Failed to restore %s: %v", c.Path, err))
+			continue
+		}
+		restored++
+	}
+	if len(manifest.Repos) > 0 {
+		ui.Success(fmt.Sprintf("%d repo remote(s) restored", restored))
+	}
+	if skipped > 0 {
+		ui.Warning(fmt.Sprintf("%d repo(s) skipped (missing or remote changed since uninstall)", skipped))
+	}
+
+	fmt.Println()
+	ui.Success("Uninstall reversed")
+	return nil
 }
 
-func getRepoRemoteURL(repoPath string) (string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin")
-	output, err := cmd.Output()
+// planRepoRestores finds repositories whose origin uses a bgit host alias,
+// returning the standard URL each would be restored to, and repositories whose
+// local git user was written by bgit
+func planRepoRestores(roots, ignore []string) (changes []backup.RepoChange, localUsers []backup.RepoUser, failed []string, err error) {
+	bgitPattern := regexp.MustCompile(`github\.com-`)
+
+	repos, err := findRepos(roots, uninstallSkip, ignore, uninstallTimeout)
 	if err != nil {
-		return "", err
+		return nil, nil, nil, err
 	}
-	return strings.TrimSpace(string(output)), nil
-}
 
-func setRepoRemoteURL(repoPath, remote, url string) error {
-	cmd := exec.Command("git", "-C", repoPath, "remote", "set-url", remote, url)
-	return cmd.Run()
+	for _, repoPath := range repos {
+		if git.IsRepoUserManaged(repoPath) {
+			name, _ := git.GetRepoConfig(repoPath, "user.name")
+			email, _ := git.GetRepoConfig(repoPath, "user.email")
+			localUsers = append(localUsers, backup.RepoUser{Path: repoPath, Name: name, Email: email})
+		}
+
+		url, err := getRepoRemoteURL(repoPath)
+		if err != nil || url == "" {
+			continue
+		}
+
+		if !bgitPattern.MatchString(url) {
+			continue
+		}
+
+		newURL, err := convertToStandardURL(url)
+		if err != nil {
+			failed = append(failed, repoPath)
+			continue
+		}
+
+		changes = append(changes, backup.RepoChange{
+			Path:   repoPath,
+			Remote: "origin",
+			OldURL: url,
+			NewURL: newURL,
+		})
+	}
+
+	return changes, localUsers, failed, nil
 }
 
-func removeSSHConfigEntries() error {
-	sshConfigPath, err := platform.GetSSHConfigPath()
+// revertGitConfig undoes the git config bgit wrote: the global user is put back
+// to its pre-bgit values, bgit-managed repo-local users are removed, and
+// include entries pointing into the bgit config directory are dropped
+func revertGitConfig(configDir string, localUsers []backup.RepoUser) {
+	original, err := config.LoadOriginalGitUser()
 	if err != nil {
-		return err
+		ui.Error(err.Error())
 	}
 
-	content, err := os.ReadFile(sshConfigPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
+	switch {
+	case original != nil && original.Name == "" && original.Email == "":
+		if err := git.UnsetGlobalUser(); err != nil {
+			ui.Error(fmt.Sprintf("Failed to unset global git user: %v", err))
+		} else {
+			ui.Success("Global git user unset (it was not set before bgit)")
+		}
+	case original != nil:
+		if err := git.SetGlobalUser(original.Name, original.Email); err != nil {
+			ui.Error(fmt.Sprintf("Failed to restore global git user: %v", err))
+		} else {
+			ui.Success(fmt.Sprintf("Global git user restored: %s <%s>", original.Name, original.Email))
+		}
+	default:
+		// No record of the pre-bgit values; only clear the user if it is a bgit identity
+		_, email, _ := git.GetGlobalUser()
+		cfg, err := config.LoadConfig()
+		if err == nil && email != "" && cfg.FindUserByEmail(email) != nil {
+			if err := git.UnsetGlobalUser(); err != nil {
+				ui.Error(fmt.Sprintf("Failed to unset global git user: %v", err))
+			} else {
+				ui.Success("Global git user unset (it was set by bgit)")
+				fmt.Println("  Set it again with: git config --global user.name/user.email")
+			}
+		} else {
+			ui.Info("Global git user left unchanged")
 		}
-		return err
 	}
 
-	lines := strings.Split(string(content), "\n")
-	var newLines []string
-	inBgitSection := false
+	revertCommitEmails()
+	revertAllowedSigners()
+
+	for _, u := range localUsers {
+		if err := git.UnsetRepoUser(u.Path); err != nil {
+			ui.Error(fmt.Sprintf("Failed to remove local git user in %s: %v", u.Path, err))
+		}
+	}
+	if len(localUsers) > 0 {
+		ui.Success(fmt.Sprintf("Removed bgit-managed local git user from %d repo(s)", len(localUsers)))
+	}
 
-	for _, line := range lines {
-		if strings.Contains(line, "BEGIN BRGIT MANAGED") {
-			inBgitSection = true
+	removed, err := git.RemoveGlobalIncludes(configDir)
+	if err != nil {
+		ui.Error(err.Error())
+	}
+	for _, key := range removed {
+		ui.Success(fmt.Sprintf("Removed %s from global git config", key))
+	}
+}
+
+// revertCommitEmails removes the global author.email/committer.email if
+// 'bgit use' set them, i.e. they match an identity's override
+func revertCommitEmails() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return
+	}
+	for _, key := range []string{"author.email", "committer.email"} {
+		value, _ := git.GetGlobalConfig(key)
+		if value == "" {
 			continue
 		}
-		if strings.Contains(line, "END BRGIT MANAGED") {
-			inBgitSection = false
+		for _, u := range cfg.Users {
+			if value == u.AuthorEmail || value == u.CommitterEmail {
+				if err := git.UnsetGlobalConfig(key); err != nil {
+					ui.Error(fmt.Sprintf("Failed to unset global %s: %v", key, err))
+				} else {
+					ui.Success(fmt.Sprintf("Removed %s from global git config", key))
+				}
+				break
+			}
+		}
+	}
+}
+
+// revertAllowedSigners removes bgit's entries from the allowed_signers file
+// and unsets gpg.ssh.allowedSignersFile if the file is gone
+func revertAllowedSigners() {
+	path, changed, err := userpkg.RemoveAllowedSigners()
+	if err != nil {
+		ui.Error(err.Error())
+		return
+	}
+	if !changed {
+		return
+	}
+	ui.Success(fmt.Sprintf("Removed bgit entries from %s", path))
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+	current, _ := git.GetGlobalConfig("gpg.ssh.allowedSignersFile")
+	if expanded, err := platform.ExpandTilde(current); err == nil && current != "" && filepath.Clean(expanded) == path {
+		if err := git.UnsetGlobalConfig("gpg.ssh.allowedSignersFile"); err != nil {
+			ui.Error(fmt.Sprintf("Failed to unset gpg.ssh.allowedSignersFile: %v", err))
+		}
+	}
+}
+
+// bgitGeneratedKeys returns the configured SSH keys that bgit generated
+// (named bgit_<username> in the SSH directory); imported keys are never included
+func bgitGeneratedKeys(cfg *config.Config) []string {
+	sshDir, err := platform.GetSSHDir()
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	for _, u := range cfg.Users {
+		if u.SSHKeyPath == "" {
 			continue
 		}
-		if !inBgitSection {
-			newLines = append(newLines, line)
+		if filepath.Dir(u.SSHKeyPath) == sshDir && strings.HasPrefix(filepath.Base(u.SSHKeyPath), "bgit_") {
+			if _, err := os.Stat(u.SSHKeyPath); err == nil {
+				keys = append(keys, u.SSHKeyPath)
+			}
 		}
 	}
+	return keys
+}
 
-	newContent := strings.Join(newLines, "\n")
-	newContent = strings.TrimRight(newContent, "\n") + "\n"
+func getRepoRemoteURL(repoPath string) (string, error) {
+	return git.GetRemoteURL(repoPath, "origin")
+}
 
-	return os.WriteFile(sshConfigPath, []byte(newContent), 0600)
+func setRepoRemoteURL(repoPath, remote, url string) error {
+	return git.SetRemoteURL(repoPath, remote, url)
+}
+
+func removeSSHConfigEntries() error {
+	return ssh.RewriteSSHConfig(func(content string) (string, error) {
+		if content == "" {
+			return "", nil
+		}
+		return ssh.RemoveManagedSections(content) + "\n", nil
+	})
 }
@@ -2,32 +2,103 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/scanner"
 	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
 	"github.com/byterings/bgit/internal/user"
 )
 
 var (
-	updateSSHKey string
+	updateSSHKey    string
+	updateAgent     string
+	updateTemplate  string
+	updateTrailers  []string
+	updateAuthor    string
+	updateCommitter string
+	updateLifetime  string
+	updateTags      []string
+	updateName      string
+	updateEmail     string
+	updateGitHub    string
+	updatePropagate bool
 )
 
+// updateFlags are the flags that change an identity; without any, update
+// edits the identity interactively
+var updateFlags = []string{"name", "email", "github", "ssh-key", "identity-agent", "commit-template", "trailer", "author-email", "committer-email", "agent-lifetime", "tag"}
+
 var updateCmd = &cobra.Command{
 	Use:   "update <alias>",
-	Short: "Update a user's SSH key",
-	Long:  `Update the SSH key for an existing user.`,
-	Args:  cobra.ExactArgs(1),
-	Example: `  bgit update work --ssh-key ~/.ssh/id_ed25519
-  bgit update personal --ssh-key ~/.ssh/bgit_personal`,
+	Short: "Update a user's name, email, SSH key, commit template, commit emails, or agent lifetime",
+	Long: `Update the name, email, GitHub username, SSH key, external SSH agent, commit
+template, or author/committer emails for an existing user.
+
+Without flags, update asks for the name, email, GitHub username, and SSH key
+in a terminal, each pre-filled with its current value, shows what changed,
+and applies it after confirmation as the flags would. The SSH key is also
+the key the identity signs commits with.
+
+--name, --email, and --github change the identity itself. The global git
+config follows if the identity is active, and bgit lists the repositories
+bound to the identity or inside its workspaces whose local user.name,
+user.email, or origin remote still use the old values, and offers to update
+them. --propagate updates them without asking.
+
+With --identity-agent, ssh uses keys from that agent socket (for example the
+1Password agent) instead of a private key file; point --ssh-key at the public
+key so ssh offers the right one. Use --identity-agent none to go back to the
+default agent.
+
+--commit-template and --trailer replace the identity's commit template and
+trailers; pass 'none' to clear either. The new template takes effect right
+away if the identity is active or bound.
+
+--author-email and --committer-email set emails that git records instead of
+the identity's email (e.g. a corporate relay as committer); 'none' clears them.
+
+--agent-lifetime limits how long the SSH agent keeps the key after bgit loads
+it (ssh-add -t): a duration like 8h, a number of seconds, or "until 18:00" to
+drop it at the end of the working day. 'none' keeps it until the agent stops.
+Keys already loaded keep their old lifetime until they are added again.
+
+--tag replaces the identity's tags, which 'bgit list', 'bgit sync', and
+'bgit key rotate' can select identities by; 'none' clears them.`,
+	Args: cobra.ExactArgs(1),
+	Example: `  bgit update work   # Edit interactively
+  bgit update work --email john@newcorp.com --propagate
+  bgit update work --ssh-key ~/.ssh/id_ed25519
+  bgit update personal --ssh-key ~/.ssh/bgit_personal
+  bgit update work --identity-agent ~/.1password/agent.sock --ssh-key ~/.ssh/work.pub
+  bgit update work --trailer "Signed-off-by: John Doe <john@work.com>"
+  bgit update work --commit-template none --trailer none
+  bgit update work --committer-email john@relay.work.com
+  bgit update work --agent-lifetime "until 18:00"
+  bgit update acme --tag client-a,work`,
 	RunE: runUpdate,
 }
 
 func init() {
 	rootCmd.AddCommand(updateCmd)
-	updateCmd.Flags().StringVar(&updateSSHKey, "ssh-key", "", "Path to SSH private key")
-	updateCmd.MarkFlagRequired("ssh-key")
+	updateCmd.Flags().StringVar(&updateSSHKey, "ssh-key", "", "Path to SSH private key (or public key with --identity-agent)")
+	updateCmd.Flags().StringVar(&updateAgent, "identity-agent", "", "SSH agent socket holding the key, or 'none' to clear")
+	updateCmd.Flags().StringVar(&updateTemplate, "commit-template", "", "Commit message template file, or 'none' to clear")
+	updateCmd.Flags().StringArrayVar(&updateTrailers, "trailer", nil, "Trailer for the commit template (repeatable), or 'none' to clear")
+	updateCmd.Flags().StringVar(&updateAuthor, "author-email", "", "Author email, or 'none' to use the identity's email")
+	updateCmd.Flags().StringVar(&updateCommitter, "committer-email", "", "Committer email, or 'none' to use the identity's email")
+	updateCmd.Flags().StringVar(&updateLifetime, "agent-lifetime", "", "How long the SSH agent keeps the key (8h, seconds, \"until 18:00\"), or 'none'")
+	updateCmd.Flags().StringSliceVar(&updateTags, "tag", nil, "Tags for grouping identities (repeatable or comma-separated), or 'none' to clear")
+	updateCmd.Flags().StringVar(&updateName, "name", "", "Full name for Git commits")
+	updateCmd.Flags().StringVar(&updateEmail, "email", "", "Email address for Git commits")
+	updateCmd.Flags().StringVar(&updateGitHub, "github", "", "GitHub username")
+	updateCmd.Flags().BoolVar(&updatePropagate, "propagate", false, "Update bound repositories' git config and remotes without asking")
 }
 
 func runUpdate(cmd *cobra.Command, args []string) error {
@@ -50,15 +121,70 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("user '%s' not found\nRun: bgit list", identifier)
 	}
 
+	if !updateFlagsGiven(cmd) {
+		edited, err := editIdentity(foundUser)
+		if err != nil {
+			return err
+		}
+		if !edited {
+			return nil
+		}
+	}
+
 	// Validate SSH key path
-	if err := user.ValidateSSHKeyPath(updateSSHKey); err != nil {
+	if updateSSHKey != "" {
+		if err := user.ValidateSSHKeyPath(updateSSHKey); err != nil {
+			return err
+		}
+		updateSSHKey, err = checkDuplicateKey(cfg, updateSSHKey, foundUser.GitHubUsername, foundUser.Alias)
+		if err != nil {
+			return err
+		}
+	}
+	if updateAgent != "" && updateAgent != "none" {
+		warnMissingAgentSocket(updateAgent)
+	}
+	if updateTemplate != "" && updateTemplate != "none" {
+		if err := validateCommitTemplate(updateTemplate); err != nil {
+			return err
+		}
+	}
+	if updateLifetime != "" && updateLifetime != "none" {
+		if _, err := config.ParseAgentLifetime(updateLifetime, time.Now()); err != nil {
+			return err
+		}
+	}
+	if err := checkIdentityChange(cfg, foundUser); err != nil {
 		return err
 	}
+	templateChanged := updateTemplate != "" || len(updateTrailers) > 0
+	previous := *foundUser
 
 	// Update user's SSH key
 	for i := range cfg.Users {
 		if cfg.Users[i].Alias == foundUser.Alias {
-			cfg.Users[i].SSHKeyPath = updateSSHKey
+			cfg.Users[i].Name = updatedValue(cfg.Users[i].Name, updateName)
+			cfg.Users[i].Email = updatedValue(cfg.Users[i].Email, updateEmail)
+			cfg.Users[i].GitHubUsername = updatedValue(cfg.Users[i].GitHubUsername, updateGitHub)
+			if updateSSHKey != "" {
+				cfg.Users[i].SSHKeyPath = updateSSHKey
+			}
+			cfg.Users[i].IdentityAgent = updatedValue(cfg.Users[i].IdentityAgent, updateAgent)
+			cfg.Users[i].CommitTemplate = updatedValue(cfg.Users[i].CommitTemplate, updateTemplate)
+			if len(updateTrailers) == 1 && updateTrailers[0] == "none" {
+				cfg.Users[i].Trailers = nil
+			} else if len(updateTrailers) > 0 {
+				cfg.Users[i].Trailers = updateTrailers
+			}
+			cfg.Users[i].AuthorEmail = updatedValue(cfg.Users[i].AuthorEmail, updateAuthor)
+			cfg.Users[i].CommitterEmail = updatedValue(cfg.Users[i].CommitterEmail, updateCommitter)
+			cfg.Users[i].AgentLifetime = updatedValue(cfg.Users[i].AgentLifetime, updateLifetime)
+			if len(updateTags) == 1 && updateTags[0] == "none" {
+				cfg.Users[i].Tags = nil
+			} else if len(updateTags) > 0 {
+				cfg.Users[i].Tags = updateTags
+			}
+			foundUser = &cfg.Users[i]
 			break
 		}
 	}
@@ -67,15 +193,66 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	if err := config.SaveConfig(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
+	syncAllowedSigners(cfg)
 
 	// Update SSH config
 	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
 		return fmt.Errorf("failed to update SSH config: %w", err)
 	}
 
+	if identityChanged(&previous, foundUser) {
+		if err := propagateIdentityChange(cfg, &previous, foundUser); err != nil {
+			return err
+		}
+	}
+
+	if templateChanged {
+		// Refresh commit.template wherever the identity is in effect
+		if cfg.ActiveUser == foundUser.Alias {
+			applyCommitTemplate(foundUser, "")
+		}
+		for _, b := range cfg.GetBindings() {
+			if b.User == foundUser.Alias {
+				if _, err := os.Stat(b.Path); err == nil {
+					applyCommitTemplate(foundUser, b.Path)
+				}
+			}
+		}
+		ui.Success(fmt.Sprintf("Commit template updated for '%s'", foundUser.Alias))
+	}
+	if updateAuthor != "" || updateCommitter != "" {
+		if cfg.ActiveUser == foundUser.Alias {
+			if err := git.SetCommitEmails("", foundUser.AuthorEmail, foundUser.CommitterEmail); err != nil {
+				ui.Warning(fmt.Sprintf("Could not update git config: %v", err))
+			}
+		}
+		ui.Success(fmt.Sprintf("Commit emails updated for '%s'", foundUser.Alias))
+	}
+	if len(updateTags) > 0 {
+		if len(foundUser.Tags) == 0 {
+			ui.Success(fmt.Sprintf("Tags cleared for '%s'", foundUser.Alias))
+		} else {
+			ui.Success(fmt.Sprintf("Tags for '%s' set to %s", foundUser.Alias, strings.Join(foundUser.Tags, ", ")))
+		}
+	}
+	if updateLifetime != "" {
+		if foundUser.AgentLifetime == "" {
+			ui.Success(fmt.Sprintf("Agent lifetime cleared for '%s'", foundUser.Alias))
+		} else {
+			ui.Success(fmt.Sprintf("Agent lifetime for '%s' set to %s", foundUser.Alias, foundUser.AgentLifetime))
+		}
+		ui.Info("Takes effect the next time bgit loads the key into the agent")
+	}
+	if updateSSHKey == "" && updateAgent == "" {
+		return nil
+	}
+
 	ui.Success(fmt.Sprintf("SSH key updated for '%s'", foundUser.Alias))
 
 	// Show public key to add to GitHub
+	if updateSSHKey == "" {
+		return nil
+	}
 	pubKeyContent, err := user.GetPublicKeyContent(updateSSHKey)
 	if err == nil {
 		fmt.Println("\nAdd this public key to your GitHub account:")
@@ -87,3 +264,250 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// updateFlagsGiven reports whether any flag that changes the identity was set
+func updateFlagsGiven(cmd *cobra.Command) bool {
+	for _, name := range updateFlags {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// editIdentity asks for u's new name, email, GitHub username, and SSH key,
+// lists what changed, and after confirmation sets the flags for the changed
+// values, so the update goes on as if they had been given. It reports
+// whether there is anything to update.
+func editIdentity(u *config.User) (bool, error) {
+	if !ui.IsInteractive() {
+		return false, withExitCode(exitUsage, fmt.Errorf("nothing to update: pass a flag such as --email or --ssh-key, or run in a terminal to edit '%s' interactively", u.Alias))
+	}
+
+	current := ui.IdentityFields{Name: u.Name, Email: u.Email, GitHubUsername: u.GitHubUsername, SSHKeyPath: u.SSHKeyPath}
+	edited, err := ui.PromptIdentityEdit(current)
+	if err != nil {
+		return false, err
+	}
+
+	changes := []struct {
+		label      string
+		old, value string
+		flag       *string
+	}{
+		{"Name", current.Name, edited.Name, &updateName},
+		{"Email", current.Email, edited.Email, &updateEmail},
+		{"GitHub", current.GitHubUsername, edited.GitHubUsername, &updateGitHub},
+		{"SSH key", current.SSHKeyPath, edited.SSHKeyPath, &updateSSHKey},
+	}
+	changed := 0
+	fmt.Println()
+	for i := range changes {
+		c := &changes[i]
+		c.value = strings.TrimSpace(c.value)
+		if c.value == "" || c.value == c.old {
+			c.value = ""
+			continue
+		}
+		if changed == 0 {
+			fmt.Printf("Changes to '%s':\n", u.Alias)
+		}
+		changed++
+		ui.Printf("  %-9s %s → %s\n", c.label+":", orUnset(c.old), orUnset(c.value))
+	}
+	if changed == 0 {
+		ui.Info("Nothing changed")
+		return false, nil
+	}
+
+	fmt.Println()
+	confirmed, err := ui.PromptConfirmation("Save these changes?")
+	if err != nil {
+		return false, err
+	}
+	if !confirmed {
+		fmt.Println("No changes made")
+		return false, nil
+	}
+	for _, c := range changes {
+		if c.value != "" {
+			*c.flag = c.value
+		}
+	}
+	return true, nil
+}
+
+// updatedValue applies a flag to an optional field: empty leaves it
+// unchanged and "none" clears it
+func updatedValue(current, flag string) string {
+	switch flag {
+	case "":
+		return current
+	case "none":
+		return ""
+	default:
+		return flag
+	}
+}
+
+// checkIdentityChange validates --name, --email, and --github for u: none may
+// be cleared, and the email and GitHub username must stay unique
+func checkIdentityChange(cfg *config.Config, u *config.User) error {
+	for flag, value := range map[string]string{"name": updateName, "email": updateEmail, "github": updateGitHub} {
+		if value == "none" {
+			return withExitCode(exitUsage, fmt.Errorf("--%s can't be cleared", flag))
+		}
+	}
+	if updateEmail != "" && !strings.Contains(updateEmail, "@") {
+		return withExitCode(exitUsage, fmt.Errorf("invalid email address: %s", updateEmail))
+	}
+	for _, other := range cfg.Users {
+		if other.Alias == u.Alias {
+			continue
+		}
+		if updateEmail != "" && strings.EqualFold(other.Email, updateEmail) {
+			return fmt.Errorf("user '%s' already has email %s", other.Alias, other.Email)
+		}
+		if updateGitHub != "" && strings.EqualFold(other.GitHubUsername, updateGitHub) {
+			return fmt.Errorf("user '%s' already has GitHub username %s", other.Alias, other.GitHubUsername)
+		}
+	}
+	return nil
+}
+
+// identityChanged reports whether an update changed what repositories record
+// for the identity: its name, email, or GitHub username (the SSH host alias)
+func identityChanged(before, after *config.User) bool {
+	return before.Name != after.Name || before.Email != after.Email || before.GitHubUsername != after.GitHubUsername
+}
+
+// propagateIdentityChange brings the global git config (if the identity is
+// active) and the identity's repositories in line with its new name, email,
+// or GitHub username. Repository changes are listed and confirmed first.
+func propagateIdentityChange(cfg *config.Config, before, after *config.User) error {
+	ui.Success(fmt.Sprintf("Identity updated for '%s': %s <%s> (%s)", after.Alias, after.Name, after.Email, after.GitHubUsername))
+	if before.GitHubUsername != after.GitHubUsername {
+		ui.Info(fmt.Sprintf("SSH host is now %s", ssh.GetHostForUser(after.GitHubUsername)))
+	}
+	if cfg.ActiveUser == after.Alias {
+		if err := git.SetGlobalUser(after.Name, after.Email); err != nil {
+			ui.Warning(fmt.Sprintf("Could not update global git config: %v", err))
+		} else {
+			ui.Success("Global git config updated")
+		}
+	}
+
+	var plan changePlan
+	for _, repo := range identityRepos(cfg, after.Alias) {
+		planRepoIdentityUpdate(&plan, repo, before, after)
+	}
+	if len(plan) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("%d change(s) in repositories using '%s':\n", len(plan), after.Alias)
+	for _, c := range plan {
+		ui.Printf("  • %s\n", c.description)
+	}
+
+	apply := updatePropagate
+	if !apply {
+		if !ui.IsInteractive() {
+			fmt.Println("\nApply them with 'bgit sync --repo --fix' in each repository, or pass --propagate next time.")
+			return nil
+		}
+		fmt.Println()
+		confirmed, err := ui.PromptConfirmation("Update these repositories?")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Repositories left unchanged. 'bgit sync --repo --fix' updates one later.")
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, c := range plan {
+		if err := c.apply(); err != nil {
+			ui.Error(fmt.Sprintf("%s: %v", c.description, err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		ui.Warning(fmt.Sprintf("Updated %d of %d", len(plan)-failed, len(plan)))
+		exit(exitPartialFix)
+	}
+	ui.Success(fmt.Sprintf("Updated %d repository setting(s)", len(plan)))
+	return nil
+}
+
+// identityRepos returns the repositories that use an identity: the ones bound
+// to it and the ones inside its workspaces, skipping any a nested workspace
+// of another identity takes over
+func identityRepos(cfg *config.Config, alias string) []string {
+	var repos []string
+	seen := func(path string) bool {
+		for _, r := range repos {
+			if platform.SamePath(r, path) {
+				return true
+			}
+		}
+		return false
+	}
+	usesIdentity := func(path string) bool {
+		ws := cfg.FindWorkspaceByPath(path)
+		return ws == nil || ws.User == alias
+	}
+
+	for _, b := range cfg.GetBindings() {
+		if b.User != alias || !usesIdentity(b.Path) {
+			continue
+		}
+		if _, err := os.Stat(b.Path); err == nil && !seen(b.Path) {
+			repos = append(repos, b.Path)
+		}
+	}
+	var roots []string
+	for _, ws := range cfg.FindWorkspacesByUser(alias) {
+		roots = append(roots, ws.Path)
+	}
+	if len(roots) > 0 {
+		for _, repo := range scanner.FindRepos(roots) {
+			if usesIdentity(repo) && !seen(repo) {
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos
+}
+
+// planRepoIdentityUpdate plans updating a repository's local user.name and
+// user.email where they still hold the identity's old values, and its origin
+// remote where it still goes through the old SSH host
+func planRepoIdentityUpdate(plan *changePlan, repo string, before, after *config.User) {
+	name, _ := git.GetLocalConfig(repo, "user.name")
+	email, _ := git.GetLocalConfig(repo, "user.email")
+	if (name != "" && name == before.Name && before.Name != after.Name) ||
+		(email != "" && strings.EqualFold(email, before.Email) && before.Email != after.Email) {
+		plan.add(fmt.Sprintf("%s: user %s <%s> → %s <%s>", shortenPath(repo), name, email, after.Name, after.Email), func() error {
+			return git.SetRepoUser(repo, after.Name, after.Email)
+		})
+	}
+
+	if before.GitHubUsername == after.GitHubUsername {
+		return
+	}
+	url, _ := getRepoRemoteURL(repo)
+	if url == "" || !strings.Contains(url, ssh.GetHostForUser(before.GitHubUsername)+":") {
+		return
+	}
+	newURL, err := convertToBgitURL(url, after.GitHubUsername)
+	if err != nil || newURL == url {
+		return
+	}
+	plan.add(fmt.Sprintf("%s: origin %s → %s", shortenPath(repo), url, newURL), func() error {
+		return setRepoRemoteURL(repo, "origin", newURL)
+	})
+}
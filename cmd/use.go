@@ -3,38 +3,61 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"os/exec"
-	"runtime"
-	"strings"
+	"time"
 
+	"github.com/byterings/bgit/internal/agent"
 	"github.com/byterings/bgit/internal/config"
 	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/history"
 	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/platform"
 	"github.com/byterings/bgit/internal/ssh"
 	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
 	"github.com/spf13/cobra"
 )
 
 var (
-	useByUsername bool
-	useByEmail    bool
+	useByUsername  bool
+	useByEmail     bool
+	useDryRun      bool
+	useNoAgent     bool
+	useNoSSHConfig bool
 )
 
 var useCmd = &cobra.Command{
 	Use:   "use <alias>",
 	Short: "Switch to a different Git identity",
-	Long:  `Switch to a different Git identity by alias, username, or email.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Switch to a different Git identity by alias, username, or email.
+
+The global git config, SSH config, and bgit config change together: if any
+step fails, the steps already applied are rolled back.
+
+Commands listed in the [hooks] section of config.toml run around the switch:
+pre_use before anything changes (a failure aborts the switch) and post_use
+after it. They receive the new identity in BGIT_* environment variables (see
+'bgit plugins --help'), plus BGIT_HOOK and BGIT_PREVIOUS_ALIAS.
+
+If you manage ssh-agent or ~/.ssh/config yourself, --no-agent leaves the agent
+alone and --no-ssh-config leaves the SSH config alone; bgit then only changes
+the git identity.`,
+	Args: cobra.ExactArgs(1),
 	Example: `  bgit use work              # By alias (default)
   bgit use -u john-work      # By GitHub username
-  bgit use -m john@work.com  # By email`,
-	RunE: runUse,
+  bgit use -m john@work.com  # By email
+  bgit use work --dry-run    # Show what switching would change
+  bgit use work --no-agent --no-ssh-config  # Only switch the git identity`,
+	SilenceUsage: true,
+	RunE:         runUse,
 }
 
 func init() {
 	rootCmd.AddCommand(useCmd)
 	useCmd.Flags().BoolVarP(&useByUsername, "username", "u", false, "Find user by GitHub username")
 	useCmd.Flags().BoolVarP(&useByEmail, "email", "m", false, "Find user by email")
+	useCmd.Flags().BoolVar(&useDryRun, "dry-run", false, "Show the git, SSH, and file changes without making them")
+	useCmd.Flags().BoolVar(&useNoAgent, "no-agent", false, "Don't load the identity's key into the SSH agent")
+	useCmd.Flags().BoolVar(&useNoSSHConfig, "no-ssh-config", false, "Don't update the bgit section of the SSH config")
 }
 
 func runUse(cmd *cobra.Command, args []string) error {
@@ -66,26 +89,20 @@ func runUse(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("user '%s' not found\nRun: bgit list", identifier)
 	}
 
-	if err := git.SetGlobalUser(user.Name, user.Email); err != nil {
-		return fmt.Errorf("failed to update git config: %w", err)
-	}
-
-	if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
-		return fmt.Errorf("failed to update SSH config: %w", err)
+	plan := planUse(cfg, user)
+	if useDryRun {
+		plan.print("bgit use " + identifier)
+		return nil
 	}
-
-	cfg.ActiveUser = user.Alias
-	if err := config.SaveConfig(cfg); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
-	}
-
-	if user.SSHKeyPath != "" {
-		ensureSSHAgent(user)
+	undo := beginUndo("use", "switch to "+user.Alias, "")
+	if err := plan.runTransaction(undo); err != nil {
+		return err
 	}
 
 	ui.Success(fmt.Sprintf("Switched to identity: %s (%s)", user.Alias, user.Email))
 
 	cwd, err := os.Getwd()
+	recordHistory(history.ActionUse, user.Alias, cwd, user.Email)
 	if err == nil {
 		resolution, _ := identity.ResolveIdentity(cfg, cwd)
 		if resolution != nil && resolution.Alias != user.Alias {
@@ -97,11 +114,17 @@ func runUse(cmd *cobra.Command, args []string) error {
 			case identity.SourceBinding:
 				ui.Warning("Note: Current repository is bound to a different identity")
 				ui.Info(fmt.Sprintf("bgit commands here will use '%s' identity", resolution.Alias))
+			case identity.SourceRepoFile:
+				ui.Warning(fmt.Sprintf("Note: Current repository declares its identity in %s", resolution.Path))
+				ui.Info(fmt.Sprintf("bgit commands here will use '%s' identity", resolution.Alias))
+			case identity.SourceRule:
+				ui.Warning(fmt.Sprintf("Note: Current repository's owner '%s' is mapped to a different identity", resolution.Owner))
+				ui.Info(fmt.Sprintf("bgit commands here will use '%s' identity", resolution.Alias))
 			}
 		}
 	}
 
-	if user.SSHKeyPath != "" {
+	if user.HasSSHHost() {
 		fmt.Println("\nClone repos: bgit clone <url>")
 		fmt.Println("Fix existing: bgit remote fix")
 	}
@@ -109,28 +132,126 @@ func runUse(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// planUse lists the changes switching to user makes: global git config,
+// commit template, SSH config, bgit config, allowed signers, and the agent,
+// between the user's pre_use and post_use hooks
+func planUse(cfg *config.Config, user *config.User) changePlan {
+	var plan changePlan
+	previousAlias := cfg.ActiveUser
+	addUseHooks(&plan, hookPreUse, cfg.Hooks.PreUse, user, previousAlias)
+
+	currentName, currentEmail, _ := git.GetGlobalUser()
+	if currentName != user.Name || currentEmail != user.Email {
+		current := "(unset)"
+		if currentName != "" || currentEmail != "" {
+			current = fmt.Sprintf("'%s <%s>'", currentName, currentEmail)
+		}
+		plan.add(fmt.Sprintf("Set global git user: %s → '%s <%s>'", current, user.Name, user.Email), func() error {
+			if err := setGlobalUser(user.Name, user.Email); err != nil {
+				return fmt.Errorf("failed to update git config: %w", err)
+			}
+			ui.Verbose(fmt.Sprintf("Set global git user to %s <%s>", user.Name, user.Email))
+			return nil
+		})
+	}
+
+	authorEmail, _ := git.GetGlobalConfig("author.email")
+	committerEmail, _ := git.GetGlobalConfig("committer.email")
+	if authorEmail != user.AuthorEmail || committerEmail != user.CommitterEmail {
+		plan.add(fmt.Sprintf("Set global author.email %s → %s, committer.email %s → %s",
+			orUnset(authorEmail), orUnset(user.AuthorEmail), orUnset(committerEmail), orUnset(user.CommitterEmail)), func() error {
+			if err := git.SetCommitEmails("", user.AuthorEmail, user.CommitterEmail); err != nil {
+				return fmt.Errorf("failed to update git config: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if user.HasCommitTemplate() {
+		plan.add(fmt.Sprintf("Write the commit template for '%s' and set commit.template", user.Alias), func() error {
+			applyCommitTemplate(user, "")
+			return nil
+		})
+	} else if current, _ := git.GetGlobalConfig("commit.template"); userpkg.IsManagedTemplate(current) {
+		plan.add(fmt.Sprintf("Unset commit.template (%s)", current), func() error {
+			clearCommitTemplate("")
+			return nil
+		})
+	}
+
+	if useNoSSHConfig {
+		ui.Verbose("Leaving the SSH config alone (--no-ssh-config)")
+	} else if current, proposed, err := ssh.PreviewManagedSection(cfg.Users); err != nil || current != proposed {
+		sshConfigPath, _ := ssh.GetSSHConfigPath()
+		plan.add(fmt.Sprintf("Update the bgit section of %s", sshConfigPath), func() error {
+			if err := ssh.UpdateSSHConfig(cfg.Users); err != nil {
+				return fmt.Errorf("failed to update SSH config: %w", err)
+			}
+			ui.Verbose("Regenerated bgit SSH host aliases")
+			return nil
+		}, lineDiff(current, proposed)...)
+	}
+
+	configPath, _ := config.GetConfigPath()
+	plan.add(fmt.Sprintf("Set active_user to '%s' in %s", user.Alias, configPath), func() error {
+		cfg.ActiveUser = user.Alias
+		user.LastUsed = time.Now().Truncate(time.Second)
+		if err := config.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		return nil
+	})
+
+	if sc, err := git.GetSigningConfig(""); err == nil && sc.Format == "ssh" {
+		plan.add("Update the allowed signers file", func() error {
+			syncAllowedSigners(cfg)
+			return nil
+		})
+	}
+
+	// CI runners have no user agent to load keys into
+	if user.SSHKeyPath != "" && !user.UsesIdentityAgent() && !platform.IsCI() && !useNoAgent {
+		plan.add(fmt.Sprintf("Load %s into the SSH agent if it isn't loaded", user.SSHKeyPath), func() error {
+			ensureSSHAgent(user)
+			return nil
+		})
+	}
+
+	addUseHooks(&plan, hookPostUse, cfg.Hooks.PostUse, user, previousAlias)
+	return plan
+}
+
 // ensureSSHAgent checks if SSH agent is running and adds the user's key
 // This runs silently - only shows messages if there's an issue
 func ensureSSHAgent(user *config.User) {
-	if runtime.GOOS == "windows" {
+	if platform.UsesAgentService() {
 		// Start ssh-agent service silently
-		startCmd := exec.Command("powershell", "-Command", "Start-Service ssh-agent")
+		startCmd := ui.Command("powershell", "-Command", "Start-Service ssh-agent")
 		startCmd.Run() // Ignore errors - may already be running
 
 		// Set to automatic startup
-		autoCmd := exec.Command("powershell", "-Command", "Set-Service -Name ssh-agent -StartupType Automatic")
+		autoCmd := ui.Command("powershell", "-Command", "Set-Service -Name ssh-agent -StartupType Automatic")
 		autoCmd.Run() // Ignore errors - may require admin
 	}
 
-	// Check if key is already loaded
-	listCmd := exec.Command("ssh-add", "-l")
-	output, _ := listCmd.Output()
-
 	// If key not in agent, add it
-	if user.SSHKeyPath != "" && !strings.Contains(string(output), user.SSHKeyPath) {
-		addCmd := exec.Command("ssh-add", user.SSHKeyPath)
+	if user.SSHKeyPath != "" && !keyInAgent(user.SSHKeyPath) {
+		addCmd := ui.Command(platform.SSHAddCommand(), agent.AddKeyArgs(user.SSHKeyPath, agentLifetime(user))...).WithTimeout(0)
 		if err := addCmd.Run(); err == nil {
 			ui.Info("SSH key loaded into agent")
 		}
 	}
 }
+
+// keyInAgent reports whether the key at keyPath is loaded in the SSH agent.
+// ssh-add -l lists keys by fingerprint and comment, not path, so the key's
+// fingerprint is compared; a key that can't be read or an agent that can't be
+// reached counts as not loaded.
+func keyInAgent(keyPath string) bool {
+	fingerprint, err := userpkg.GetFingerprint(keyPath)
+	if err != nil {
+		return false
+	}
+	keys, err := agent.ListKeys()
+	return err == nil && agent.HasFingerprint(keys, fingerprint)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/history"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/remote"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the current repository matches its expected identity",
+	Long: `Check the current repository's origin remote, git user.email, and any
+committed .bgit.toml / .bgit identity file against the effective bgit identity.
+
+Exits with status 4 when a mismatch is found, so it can be used from
+scripts and git hooks. With --quiet, as the post-checkout hook runs it,
+only a mismatch is reported, as a short warning on stderr.`,
+	Example: `  bgit verify
+  bgit verify --quiet   # Print only a warning on mismatch (used by hooks)`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	repoRoot := identity.FindGitRoot(cwd)
+	if repoRoot == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+
+	if err := autoInit(); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	autoFixRemote(cfg, repoRoot)
+	report := inspectRepo(cfg, repoRoot)
+
+	// The post-checkout hook runs verify --quiet
+	if quietFlag {
+		if len(report.problems) == 0 {
+			return nil
+		}
+		fmt.Fprintln(os.Stderr, ui.Colorize(os.Stderr, ui.ColorYellow, ui.Text(fmt.Sprintf("⚠ bgit: identity mismatch in %s", shortenPath(repoRoot)))))
+		for _, p := range report.problems {
+			fmt.Fprintln(os.Stderr, ui.Colorize(os.Stderr, ui.ColorYellow, ui.Text("  → "+p)))
+		}
+		fmt.Fprintln(os.Stderr, "  Run 'bgit verify' for details")
+		exit(exitMismatch)
+	}
+
+	printRepoReport(report)
+	fmt.Println()
+
+	if len(report.problems) > 0 {
+		return withExitCode(exitMismatch, fmt.Errorf("identity mismatch in %s", repoRoot))
+	}
+
+	ui.Success("Repository identity verified")
+	return nil
+}
+
+// autoFixRemote points origin at the effective identity's host alias when the
+// repository's workspace sets auto_fix_remotes, so the post-checkout hook
+// repairs fresh clones instead of only warning. HTTPS remotes using bgit's
+// credential helper are left alone.
+func autoFixRemote(cfg *config.Config, repoRoot string) {
+	ws := cfg.FindWorkspaceByPath(repoRoot)
+	if ws == nil || !ws.AutoFixRemotes {
+		return
+	}
+	resolution, err := identity.ResolveIdentity(cfg, repoRoot)
+	if err != nil || resolution == nil || resolution.User == nil || !resolution.User.HasSSHHost() {
+		return
+	}
+	if isBgitCredentialHelper(git.GetCredentialHelper(repoRoot)) {
+		return
+	}
+
+	currentURL, err := git.GetRemoteURL(repoRoot, "origin")
+	if err != nil || currentURL == "" {
+		return
+	}
+	parsed, err := remote.Parse(currentURL)
+	if err != nil {
+		return
+	}
+	newURL := parsed.BgitURL(resolution.User.GitHubUsername)
+	if newURL == currentURL {
+		return
+	}
+	undo := beginUndo("remote fix", fmt.Sprintf("fix origin of %s for %s", shortenPath(repoRoot), resolution.Alias), repoRoot)
+	if err := git.SetRemoteURL(repoRoot, "origin", newURL); err != nil {
+		undo.Discard()
+		ui.Warning(fmt.Sprintf("Failed to fix origin remote: %v", err))
+		return
+	}
+	commitUndo(undo)
+	fmt.Fprintf(os.Stderr, "bgit: origin set to %s (workspace auto_fix_remotes)\n", newURL)
+	recordHistory(history.ActionRemoteFix, resolution.Alias, repoRoot, newURL)
+}
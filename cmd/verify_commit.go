@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/ui"
+	userpkg "github.com/byterings/bgit/internal/user"
+	"github.com/spf13/cobra"
+)
+
+var verifyCommitCmd = &cobra.Command{
+	Use:   "verify-commit [range]",
+	Short: "Verify commit signatures against your identities' keys",
+	Long: `Verify the signatures on a commit or range of commits (default HEAD) and
+report which identity signed each one.
+
+SSH signatures are checked against every identity's public key through the
+allowed_signers file bgit maintains; GPG signatures use your keyring. A commit
+passes when its signature is good and was made by the identity it was
+committed as.
+
+Exits with status 4 if any commit is unsigned, badly signed, or signed by a
+different identity.`,
+	Example: `  bgit verify-commit
+  bgit verify-commit main..HEAD
+  bgit verify-commit HEAD~5..`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runVerifyCommit,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCommitCmd)
+}
+
+// signatureStatus describes git's %G? signature status codes
+var signatureStatus = map[string]string{
+	"G": "good signature",
+	"U": "good signature (unknown trust)",
+	"B": "bad signature",
+	"X": "expired signature",
+	"Y": "signed with expired key",
+	"R": "signed with revoked key",
+	"E": "cannot verify (key not available)",
+	"N": "unsigned",
+}
+
+func runVerifyCommit(cmd *cobra.Command, args []string) error {
+	revRange := "HEAD"
+	if len(args) == 1 {
+		revRange = args[0]
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	repoRoot := identity.FindGitRoot(cwd)
+	if repoRoot == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	signers, cleanup, err := allowedSignersForVerify(cfg)
+	if err != nil {
+		return err
+	}
+
+	sigs, err := git.GetCommitSignatures(repoRoot, revRange, signers)
+	cleanup()
+	if err != nil {
+		return err
+	}
+	if len(sigs) == 0 {
+		ui.Info(fmt.Sprintf("No commits in %s", revRange))
+		return nil
+	}
+
+	fingerprints := identityFingerprints(cfg)
+
+	fmt.Printf("Verifying %d commit(s) in %s\n\n", len(sigs), revRange)
+
+	failed := 0
+	for _, sig := range sigs {
+		signer := signatureIdentity(cfg, sig, fingerprints)
+		committer := cfg.FindUserByCommitEmail(sig.CommitterEmail)
+
+		symbol := ui.Green("✓")
+		status := signatureStatus[sig.Status]
+		if status == "" {
+			status = fmt.Sprintf("unknown status '%s'", sig.Status)
+		}
+
+		var detail string
+		switch {
+		case !sig.Good():
+			symbol = ui.Red("✗")
+			failed++
+		case signer == nil:
+			symbol = ui.Yellow("⚠")
+			detail = fmt.Sprintf("signer %s is not a bgit identity", describeSigner(sig))
+		case committer != nil && committer.Alias != signer.Alias:
+			symbol = ui.Red("✗")
+			detail = fmt.Sprintf("signed by '%s' but committed as '%s'", signer.Alias, committer.Alias)
+			failed++
+		default:
+			detail = fmt.Sprintf("signed by '%s' (%s)", signer.Alias, describeSigner(sig))
+		}
+
+		ui.Printf("  %s %s  %s\n", symbol, sig.Hash[:7], sig.Subject)
+		fmt.Printf("      %s", status)
+		if detail != "" {
+			fmt.Printf(", %s", detail)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		fmt.Fprintln(os.Stderr, ui.Red(fmt.Sprintf("%d of %d commit(s) failed verification", failed, len(sigs))))
+		exit(exitMismatch)
+	}
+	ui.Success(fmt.Sprintf("All %d commit(s) verified", len(sigs)))
+	return nil
+}
+
+// allowedSignersForVerify returns the allowed_signers file to verify SSH
+// signatures with: the managed file, refreshed, if bgit maintains one, or
+// otherwise a temporary file with every identity's key
+func allowedSignersForVerify(cfg *config.Config) (string, func(), error) {
+	noop := func() {}
+
+	if path, err := userpkg.AllowedSignersPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			path, err := userpkg.WriteAllowedSigners(cfg.Users)
+			if err != nil {
+				return "", noop, err
+			}
+			return path, noop, nil
+		}
+	}
+
+	f, err := os.CreateTemp("", "bgit-allowed-signers-*")
+	if err != nil {
+		return "", noop, fmt.Errorf("failed to create allowed signers file: %w", err)
+	}
+	defer f.Close()
+	for i := range cfg.Users {
+		if line, ok := userpkg.AllowedSignerLine(&cfg.Users[i]); ok {
+			fmt.Fprintln(f, line)
+		}
+	}
+	return f.Name(), func() { os.Remove(f.Name()) }, nil
+}
+
+// identityFingerprints maps SSH key fingerprints to identity aliases
+func identityFingerprints(cfg *config.Config) map[string]string {
+	fingerprints := make(map[string]string)
+	for _, u := range cfg.Users {
+		if u.SSHKeyPath == "" {
+			continue
+		}
+		if fp, err := userpkg.GetFingerprint(u.SSHKeyPath); err == nil {
+			fingerprints[fp] = u.Alias
+		}
+	}
+	return fingerprints
+}
+
+// signatureIdentity returns the identity that made a signature, matched by
+// SSH key fingerprint or, for GPG, the email in the signer's user ID
+func signatureIdentity(cfg *config.Config, sig git.CommitSignature, fingerprints map[string]string) *config.User {
+	if alias, ok := fingerprints[sig.Fingerprint]; ok {
+		return cfg.FindUserByAlias(alias)
+	}
+	email := sig.Signer
+	if start, end := strings.LastIndex(email, "<"), strings.LastIndex(email, ">"); start >= 0 && end > start {
+		email = email[start+1 : end]
+	}
+	if email == "" || !strings.Contains(email, "@") {
+		return nil
+	}
+	return cfg.FindUserByCommitEmail(email)
+}
+
+// describeSigner returns the signer's user ID or principal, falling back to
+// the key fingerprint
+func describeSigner(sig git.CommitSignature) string {
+	if sig.Signer != "" {
+		return sig.Signer
+	}
+	if sig.Fingerprint != "" {
+		return sig.Fingerprint
+	}
+	return sig.Key
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/identity"
+	"github.com/byterings/bgit/internal/remote"
+	"github.com/byterings/bgit/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var verifyPushCmd = &cobra.Command{
+	Use:   "verify-push <remote> <url>",
+	Short: "Check that a push authenticates as the repository's identity",
+	Long: `Check the GitHub account a push to <url> would authenticate as against the
+effective identity of the current repository. The pre-push hook installed by
+'bgit hook install pre-push' runs this with the arguments git gives it.
+
+The account comes from the bgit host alias (git@github.com-<user>:...), the
+user in an HTTPS URL, or bgit's credential helper. Pushes whose account can't
+be told from the URL, such as plain git@github.com URLs, are allowed.
+
+Exits with status 4, blocking the push, when the accounts differ. Use
+'git push --no-verify' to push anyway.`,
+	Example:      `  bgit verify-push origin git@github.com-octocat:octocat/hello.git`,
+	Args:         cobra.ExactArgs(2),
+	Hidden:       true,
+	SilenceUsage: true,
+	RunE:         runVerifyPush,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyPushCmd)
+}
+
+func runVerifyPush(cmd *cobra.Command, args []string) error {
+	remoteName, pushURL := args[0], args[1]
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+	repoRoot := identity.FindGitRoot(cwd)
+	if repoRoot == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+
+	exists, err := config.ConfigExists()
+	if err != nil || !exists {
+		// bgit isn't set up, so there is no identity to enforce
+		return nil
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	resolution, err := identity.ResolveIdentity(cfg, repoRoot)
+	if err != nil || resolution == nil || resolution.User == nil || resolution.User.GitHubUsername == "" {
+		return nil
+	}
+
+	account, via := pushAccount(cfg, repoRoot, pushURL)
+	ui.Debugf("push to %s (%s) authenticates as '%s' via %s", remoteName, pushURL, account, via)
+	if account == "" || strings.EqualFold(account, resolution.User.GitHubUsername) {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, ui.Colorize(os.Stderr, ui.ColorRed, ui.Text(fmt.Sprintf("✗ bgit: push to %s blocked", remoteName))))
+	fmt.Fprintf(os.Stderr, "  %s %s authenticates as '%s' (%s)\n", ui.Text("→"), pushURL, account, via)
+	fmt.Fprintf(os.Stderr, "  %s this repository's identity is '%s' (%s) %s\n", ui.Text("→"), resolution.Alias, resolution.User.GitHubUsername, describeSource(resolution))
+	fmt.Fprintln(os.Stderr, "  Fix the remote with 'bgit remote fix', or push anyway with 'git push --no-verify'")
+	exit(exitMismatch)
+	return nil
+}
+
+// pushAccount returns the GitHub account a push to pushURL authenticates as
+// and how that was determined, or "" when the URL doesn't say
+func pushAccount(cfg *config.Config, repoRoot, pushURL string) (account, via string) {
+	if parsed, err := remote.Parse(pushURL); err == nil {
+		switch parsed.Kind {
+		case remote.KindBgit:
+			return parsed.HostUser, "host alias github.com-" + parsed.HostUser
+		case remote.KindHTTPS:
+			alias := credentialHelperAlias(git.GetCredentialHelper(repoRoot))
+			if u := cfg.FindUserByAlias(alias); u != nil {
+				return u.GitHubUsername, fmt.Sprintf("bgit credential helper for '%s'", alias)
+			}
+		}
+		return "", ""
+	}
+
+	// https://user@github.com/owner/repo.git
+	if u, err := url.Parse(pushURL); err == nil && u.Scheme == "https" && strings.EqualFold(u.Host, "github.com") && u.User != nil {
+		return u.User.Username(), "user in the URL"
+	}
+	return "", ""
+}
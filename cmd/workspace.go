@@ -7,15 +7,21 @@ import (
 	"strings"
 
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/scanner"
 	"github.com/byterings/bgit/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	workspacePath   string
-	workspaceUsers  string
-	workspaceList   bool
-	workspaceRemove string
+	workspacePath      string
+	workspaceUsers     string
+	workspaceList      bool
+	workspaceRemove    string
+	workspaceRemoveAll bool
+	workspaceAdd       string
+	workspaceAdopt     bool
 )
 
 var workspaceCmd = &cobra.Command{
@@ -24,23 +30,48 @@ var workspaceCmd = &cobra.Command{
 	Long: `Create organized workspace directories for each identity.
 
 All repositories cloned within a workspace folder automatically use that identity,
-regardless of the global active user.
+regardless of the global active user. An identity may have several workspaces.
 
 Examples:
-  bgit workspace                    # Create folders for all users in current directory
-  bgit workspace --path ~/code      # Create in specific location
-  bgit workspace --users work,oss   # Only specific users
-  bgit workspace --list             # Show configured workspaces
-  bgit workspace --remove work      # Remove workspace binding`,
+  bgit workspace                              # Create folders for all users in current directory
+  bgit workspace --path ~/code                # Create in specific location
+  bgit workspace --users work,oss             # Only specific users
+  bgit workspace --add ~/clients --users work # Register an existing folder for one user
+  bgit workspace --list                       # Show configured workspaces
+  bgit workspace --list --users work          # Show workspaces for one user
+  bgit workspace --remove ~/code/work         # Remove workspace by path
+  bgit workspace --remove work --all          # Remove every workspace for a user
+  bgit workspace --adopt --path ~/work        # Bind and fix all repos in an existing folder
+  bgit workspace move ~/code/work ~/src/work  # Move a workspace and its bindings`,
 	RunE: runWorkspace,
 }
 
+var workspaceMoveDir bool
+
+var workspaceMoveCmd = &cobra.Command{
+	Use:   "move <old-path> <new-path>",
+	Short: "Move or rename a workspace",
+	Long: `Update a workspace's path and every repository binding beneath it.
+
+By default only the bgit configuration is updated, for when you have already
+moved the folder yourself. Use --move-dir to also move the directory on disk.`,
+	Example: `  bgit workspace move ~/code/work ~/src/work             # Folder already moved
+  bgit workspace move ~/code/work ~/src/work --move-dir  # Move folder too`,
+	Args: cobra.ExactArgs(2),
+	RunE: runWorkspaceMove,
+}
+
 func init() {
 	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceMoveCmd)
+	workspaceMoveCmd.Flags().BoolVarP(&workspaceMoveDir, "move-dir", "m", false, "Also move the directory on disk")
 	workspaceCmd.Flags().StringVarP(&workspacePath, "path", "p", "", "Directory to create workspace folders in (default: current directory)")
 	workspaceCmd.Flags().StringVarP(&workspaceUsers, "users", "u", "", "Comma-separated list of user aliases to create folders for (default: all)")
 	workspaceCmd.Flags().BoolVarP(&workspaceList, "list", "l", false, "List configured workspaces")
-	workspaceCmd.Flags().StringVarP(&workspaceRemove, "remove", "r", "", "Remove workspace binding for the specified user alias")
+	workspaceCmd.Flags().StringVarP(&workspaceRemove, "remove", "r", "", "Remove workspace by path, or by user alias if it has only one")
+	workspaceCmd.Flags().BoolVar(&workspaceRemoveAll, "all", false, "With --remove <alias>, remove every workspace for that user")
+	workspaceCmd.Flags().StringVarP(&workspaceAdd, "add", "a", "", "Register an existing directory as a workspace (requires --users with one alias)")
+	workspaceCmd.Flags().BoolVar(&workspaceAdopt, "adopt", false, "Bind every repo in --path (or current directory) to its workspace identity and fix remotes and git config")
 }
 
 func runWorkspace(cmd *cobra.Command, args []string) error {
@@ -57,11 +88,89 @@ func runWorkspace(cmd *cobra.Command, args []string) error {
 		return removeWorkspace(cfg, workspaceRemove)
 	}
 
+	if workspaceAdd != "" {
+		return addWorkspace(cfg, workspaceAdd)
+	}
+
+	if workspaceAdopt {
+		return adoptWorkspace(cfg)
+	}
+
 	return createWorkspaces(cfg)
 }
 
+func runWorkspaceMove(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	oldPath, err := absWorkspacePath(args[0])
+	if err != nil {
+		return err
+	}
+	newPath, err := absWorkspacePath(args[1])
+	if err != nil {
+		return err
+	}
+
+	if workspaceMoveDir {
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("destination already exists: %s", newPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+	} else if _, err := os.Stat(newPath); os.IsNotExist(err) {
+		ui.Warning(fmt.Sprintf("%s does not exist yet. Use --move-dir to move the folder as well.", newPath))
+	}
+
+	updated, err := cfg.MoveWorkspace(oldPath, newPath)
+	if err != nil {
+		return err
+	}
+
+	if workspaceMoveDir {
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return fmt.Errorf("failed to move directory (config not changed): %w", err)
+		}
+		ui.Success(fmt.Sprintf("Moved %s → %s", oldPath, newPath))
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Workspace updated: %s → %s", shortenPath(oldPath), shortenPath(newPath)))
+	if updated > 0 {
+		ui.Info(fmt.Sprintf("Updated %d binding(s) under the workspace", updated))
+	}
+
+	return nil
+}
+
+// absWorkspacePath expands ~ and returns a cleaned absolute path
+func absWorkspacePath(path string) (string, error) {
+	expanded, err := platform.ExpandTilde(path)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return abs, nil
+}
+
 func listWorkspaces(cfg *config.Config) error {
 	workspaces := cfg.GetWorkspaces()
+	if workspaceUsers != "" {
+		var filtered []config.Workspace
+		for _, alias := range strings.Split(workspaceUsers, ",") {
+			filtered = append(filtered, cfg.FindWorkspacesByUser(strings.TrimSpace(alias))...)
+		}
+		workspaces = filtered
+	}
 
 	if len(workspaces) == 0 {
 		fmt.Println("No workspaces configured.")
@@ -85,37 +194,268 @@ func listWorkspaces(cfg *config.Config) error {
 			status = "✗ (missing)"
 		}
 
-		fmt.Printf("  %s %-20s → %s\n", status, userName, ws.Path)
+		ui.Printf("  %s %-20s → %s%s\n", status, userName, ws.Path, systemSuffix(cfg.IsSystemWorkspace(ws.Path)))
+		if settings := ws.Settings(); len(settings) > 0 {
+			fmt.Printf("      %s\n", describeWorkspaceSettings(ws))
+		}
 	}
 
 	fmt.Println()
 	return nil
 }
 
-func removeWorkspace(cfg *config.Config, userAlias string) error {
+func removeWorkspace(cfg *config.Config, target string) error {
+	if user := cfg.FindUserByAlias(target); user != nil {
+		return removeWorkspacesForUser(cfg, target)
+	}
+
+	path, err := absWorkspacePath(target)
+	if err != nil {
+		return err
+	}
+
 	var found *config.Workspace
 	for _, ws := range cfg.GetWorkspaces() {
-		if ws.User == userAlias {
+		if platform.SamePath(ws.Path, path) {
 			found = &ws
 			break
 		}
 	}
 
 	if found == nil {
-		return fmt.Errorf("no workspace found for user '%s'", userAlias)
+		return fmt.Errorf("no workspace found at '%s'", path)
+	}
+	if cfg.IsSystemWorkspace(found.Path) {
+		return config.ErrSystemEntry("workspace", found.Path)
 	}
 
-	if cfg.RemoveWorkspace(userAlias) {
+	if cfg.RemoveWorkspaceByPath(found.Path) {
 		if err := config.SaveConfig(cfg); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
-		ui.Success(fmt.Sprintf("Removed workspace binding for '%s' at %s", userAlias, found.Path))
+		ui.Success(fmt.Sprintf("Removed workspace binding for '%s' at %s", found.User, found.Path))
 		ui.Info("Note: The folder was not deleted. Remove it manually if needed.")
 	}
 
 	return nil
 }
 
+func removeWorkspacesForUser(cfg *config.Config, userAlias string) error {
+	workspaces := cfg.FindWorkspacesByUser(userAlias)
+	if len(workspaces) == 0 {
+		return fmt.Errorf("no workspace found for user '%s'", userAlias)
+	}
+
+	if len(workspaces) > 1 && !workspaceRemoveAll {
+		fmt.Printf("User '%s' has %d workspaces:\n", userAlias, len(workspaces))
+		for _, ws := range workspaces {
+			fmt.Printf("  %s\n", ws.Path)
+		}
+		return fmt.Errorf("specify a path with --remove <path>, or use --all to remove them all")
+	}
+	for _, ws := range workspaces {
+		if cfg.IsSystemWorkspace(ws.Path) {
+			return config.ErrSystemEntry("workspace", ws.Path)
+		}
+	}
+
+	cfg.RemoveWorkspace(userAlias)
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	for _, ws := range workspaces {
+		ui.Success(fmt.Sprintf("Removed workspace binding for '%s' at %s", userAlias, ws.Path))
+	}
+	ui.Info("Note: The folders were not deleted. Remove them manually if needed.")
+
+	return nil
+}
+
+func addWorkspace(cfg *config.Config, target string) error {
+	aliases := strings.Split(workspaceUsers, ",")
+	if workspaceUsers == "" || len(aliases) != 1 {
+		return fmt.Errorf("--add requires exactly one user alias via --users")
+	}
+	userAlias := strings.TrimSpace(aliases[0])
+
+	path, err := absWorkspacePath(target)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory does not exist: %s", path)
+	}
+
+	if parent := cfg.FindParentWorkspace(path); parent != nil && parent.User != userAlias {
+		ui.Info(fmt.Sprintf("Nested inside workspace %s (%s); this workspace takes precedence there", parent.Path, parent.User))
+	}
+
+	if err := cfg.AddWorkspace(path, userAlias); err != nil {
+		return err
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	ui.Success(fmt.Sprintf("Workspace added: %s/**  →  %s", path, userAlias))
+	if n := len(cfg.FindWorkspacesByUser(userAlias)); n > 1 {
+		ui.Info(fmt.Sprintf("'%s' now has %d workspaces", userAlias, n))
+	}
+
+	return nil
+}
+
+// createIdentityWorkspace creates dir if needed and registers it as a
+// workspace for alias, as part of adding the identity. Problems are reported
+// as warnings so they don't undo the add; the caller saves the config.
+// Returns the workspace's absolute path, or "" if it wasn't added.
+func createIdentityWorkspace(cfg *config.Config, dir, alias string) string {
+	path, err := absWorkspacePath(dir)
+	if err != nil {
+		ui.Warning(fmt.Sprintf("Could not set up workspace %s: %v", dir, err))
+		return ""
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		ui.Warning(fmt.Sprintf("Could not create %s: %v", path, err))
+		return ""
+	}
+	if ws := cfg.FindWorkspaceByPath(path); ws != nil && platform.SamePath(ws.Path, path) {
+		ui.Warning(fmt.Sprintf("%s is already a workspace for '%s'", path, ws.User))
+		return ""
+	}
+	if err := cfg.AddWorkspace(path, alias); err != nil {
+		ui.Warning(fmt.Sprintf("Could not add workspace: %v", err))
+		return ""
+	}
+	ui.Success(fmt.Sprintf("Workspace added: %s/**  →  %s", path, alias))
+	return path
+}
+
+// adoptWorkspace converts an existing folder of repositories to a bgit workspace:
+// every repo is bound to the workspace identity, its origin remote is rewritten to
+// the identity's host alias, and its local user.name/user.email are set
+func adoptWorkspace(cfg *config.Config) error {
+	basePath := workspacePath
+	if basePath == "" {
+		var err error
+		basePath, err = os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	basePath, err := absWorkspacePath(basePath)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(basePath); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory does not exist: %s", basePath)
+	}
+
+	userAlias := ""
+	registerWorkspace := false
+	if workspaceUsers != "" {
+		aliases := strings.Split(workspaceUsers, ",")
+		if len(aliases) != 1 {
+			return fmt.Errorf("--adopt accepts exactly one user alias via --users")
+		}
+		userAlias = strings.TrimSpace(aliases[0])
+		if ws := cfg.FindWorkspaceByPath(basePath); ws == nil || !platform.SamePath(ws.Path, basePath) {
+			registerWorkspace = true
+		}
+	} else if ws := cfg.FindWorkspaceByPath(basePath); ws != nil {
+		userAlias = ws.User
+	} else {
+		return fmt.Errorf("%s is not a workspace. Use --users <alias> to adopt it for an identity", basePath)
+	}
+
+	user := cfg.FindUserByAlias(userAlias)
+	if user == nil {
+		return fmt.Errorf("user '%s' not found", userAlias)
+	}
+
+	fmt.Printf("Scanning %s for repositories...\n", basePath)
+	repos := scanner.FindRepos([]string{basePath})
+	if len(repos) == 0 {
+		ui.Info("No git repositories found")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("Found %d repo(s). Each will be bound to '%s' (%s):\n", len(repos), userAlias, user.Email)
+	for _, repo := range repos {
+		fmt.Printf("  %s\n", shortenPath(repo))
+	}
+	fmt.Println()
+
+	confirmed, err := ui.PromptConfirmation("Adopt these repositories?")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Operation cancelled.")
+		return nil
+	}
+	fmt.Println()
+
+	if registerWorkspace {
+		if err := cfg.AddWorkspace(basePath, userAlias); err != nil {
+			return fmt.Errorf("failed to add workspace: %w", err)
+		}
+		ui.Success(fmt.Sprintf("Workspace added: %s/**  →  %s", basePath, userAlias))
+	}
+
+	adopted := 0
+	for _, repo := range repos {
+		if err := cfg.AddBinding(repo, userAlias); err != nil {
+			ui.Error(fmt.Sprintf("%s: failed to bind: %v", shortenPath(repo), err))
+			continue
+		}
+
+		if err := git.SetRepoUser(repo, user.Name, user.Email); err != nil {
+			ui.Error(fmt.Sprintf("%s: %v", shortenPath(repo), err))
+			continue
+		}
+		if err := git.SetCommitEmails(repo, user.AuthorEmail, user.CommitterEmail); err != nil {
+			ui.Error(fmt.Sprintf("%s: %v", shortenPath(repo), err))
+			continue
+		}
+		applyCommitTemplate(user, repo)
+
+		url, _ := getRepoRemoteURL(repo)
+		if url != "" && user.HasSSHHost() {
+			newURL, err := convertToBgitURL(url, user.GitHubUsername)
+			if err != nil {
+				ui.Warning(fmt.Sprintf("%s: bound, remote left unchanged (%s)", shortenPath(repo), url))
+				adopted++
+				continue
+			}
+			if newURL != url {
+				if err := setRepoRemoteURL(repo, "origin", newURL); err != nil {
+					ui.Error(fmt.Sprintf("%s: failed to update remote: %v", shortenPath(repo), err))
+					continue
+				}
+			}
+		}
+
+		ui.Success(shortenPath(repo))
+		adopted++
+	}
+
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Adopted %d of %d repo(s) for '%s'", adopted, len(repos), userAlias))
+
+	return nil
+}
+
 func createWorkspaces(cfg *config.Config) error {
 	basePath := workspacePath
 	if basePath == "" {
@@ -171,6 +511,10 @@ func createWorkspaces(cfg *config.Config) error {
 			ui.Info(fmt.Sprintf("Exists: %s/", user.Alias))
 		}
 
+		if parent := cfg.FindParentWorkspace(folderPath); parent != nil && parent.User != user.Alias {
+			ui.Info(fmt.Sprintf("Nested inside workspace %s (%s); %s/ takes precedence there", parent.Path, parent.User, user.Alias))
+		}
+
 		if err := cfg.AddWorkspace(folderPath, user.Alias); err != nil {
 			if !strings.Contains(err.Error(), "already exists") {
 				ui.Warning(fmt.Sprintf("Failed to bind %s: %v", user.Alias, err))
@@ -188,7 +532,7 @@ func createWorkspaces(cfg *config.Config) error {
 	fmt.Println("Auto-bound:")
 	for _, user := range users {
 		folderPath := filepath.Join(basePath, user.Alias)
-		fmt.Printf("  %s/**  →  %s (%s)\n", folderPath, user.Alias, user.GitHubUsername)
+		ui.Printf("  %s/**  →  %s (%s)\n", folderPath, user.Alias, user.GitHubUsername)
 	}
 
 	fmt.Println()
@@ -196,3 +540,12 @@ func createWorkspaces(cfg *config.Config) error {
 
 	return nil
 }
+
+// describeWorkspaceSettings lists a workspace's behavior settings for display
+func describeWorkspaceSettings(ws config.Workspace) string {
+	settings := ws.Settings()
+	if len(settings) == 0 {
+		return "none"
+	}
+	return strings.Join(settings, ", ")
+}
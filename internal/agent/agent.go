@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// Key is an identity loaded in the SSH agent
+type Key struct {
+	Fingerprint string // SHA256:... fingerprint
+	Comment     string
+	Type        string // e.g. ED25519, RSA
+}
+
+// ListKeys returns the keys currently loaded in the SSH agent, in the order
+// the agent offers them. An empty agent returns no keys and no error.
+func ListKeys() ([]Key, error) {
+	cmd := ui.Command(platform.SSHAddCommand(), "-l", "-E", "sha256")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// ssh-add exits 1 when the agent has no identities
+		if strings.Contains(string(output), "no identities") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var keys []Key
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if key, ok := parseListLine(line); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// parseListLine parses a line of `ssh-add -l` output:
+// "256 SHA256:abc... user@host (ED25519)"
+func parseListLine(line string) (Key, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.HasPrefix(fields[1], "SHA256:") {
+		return Key{}, false
+	}
+
+	key := Key{Fingerprint: fields[1]}
+	rest := fields[2:]
+	if n := len(rest); n > 0 && strings.HasPrefix(rest[n-1], "(") && strings.HasSuffix(rest[n-1], ")") {
+		key.Type = strings.Trim(rest[n-1], "()")
+		rest = rest[:n-1]
+	}
+	key.Comment = strings.Join(rest, " ")
+	return key, true
+}
+
+// HasFingerprint reports whether a fingerprint is among the loaded keys
+func HasFingerprint(keys []Key, fingerprint string) bool {
+	for _, k := range keys {
+		if k.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// AddKeyArgs returns the ssh-add arguments that load a key, storing its
+// passphrase in the macOS keychain so it survives reboots. A non-zero
+// lifetime makes the agent drop the key after that long (ssh-add -t).
+func AddKeyArgs(privateKeyPath string, lifetime time.Duration) []string {
+	var args []string
+	if platform.UsesAppleKeychain() {
+		args = append(args, "--apple-use-keychain")
+	}
+	if seconds := int(lifetime / time.Second); seconds > 0 {
+		args = append(args, "-t", strconv.Itoa(seconds))
+	}
+	return append(args, privateKeyPath)
+}
+
+// AddKey loads a private key into the SSH agent for lifetime (zero for no
+// limit), prompting on the terminal for a passphrase if the key needs one
+func AddKey(privateKeyPath string, lifetime time.Duration) error {
+	cmd := ui.Command(platform.SSHAddCommand(), AddKeyArgs(privateKeyPath, lifetime)...).WithTimeout(0)
+	cmd.Stdin = os.Stdin
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to add key to agent: %w", err)
+	}
+	return nil
+}
+
+// RemoveKey unloads a key from the SSH agent (ssh-add -d), identifying it by
+// its public half
+func RemoveKey(privateKeyPath string) error {
+	if err := ui.Command(platform.SSHAddCommand(), "-d", privateKeyPath).Run(); err != nil {
+		return fmt.Errorf("failed to remove key from agent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/byterings/bgit/internal/platform"
+)
+
+const (
+	manifestName  = "manifest.json"
+	configPrefix  = "bgit/"
+	sshConfigName = "ssh/config"
+	keysPrefix    = "keys/"
+)
+
+// RepoChange records a remote URL rewritten in a repository
+type RepoChange struct {
+	Path   string `json:"path"`
+	Remote string `json:"remote"`
+	OldURL string `json:"old_url"`
+	NewURL string `json:"new_url"`
+}
+
+// RepoUser records a bgit-managed local git user removed from a repository
+type RepoUser struct {
+	Path  string `json:"path"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Manifest describes what an archive contains and the changes made after it was taken
+type Manifest struct {
+	CreatedAt     time.Time    `json:"created_at"`
+	BgitVersion   string       `json:"bgit_version"`
+	ConfigDir     string       `json:"config_dir"`
+	SSHConfigPath string       `json:"ssh_config_path,omitempty"`
+	Keys          []string     `json:"keys,omitempty"` // Private key paths; public keys are stored alongside
+	Repos         []RepoChange `json:"repos,omitempty"`
+	GlobalName    string       `json:"global_name,omitempty"`  // Global git user.name before uninstall
+	GlobalEmail   string       `json:"global_email,omitempty"` // Global git user.email before uninstall
+	LocalUsers    []RepoUser   `json:"local_users,omitempty"`
+}
+
+// DefaultPath returns a timestamped archive path in dir
+func DefaultPath(dir string, now time.Time) string {
+	return filepath.Join(dir, fmt.Sprintf("bgit-backup-%s.zip", now.Format("20060102-150405")))
+}
+
+// Write creates an archive containing the manifest, the bgit config directory,
+// the SSH config, and the listed keys. The archive holds private keys, so it is
+// only readable by the owner.
+func Write(path string, m *Manifest) error {
+	f, err := platform.OpenFileSecure(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeEntry(zw, manifestName, data); err != nil {
+		return err
+	}
+
+	if m.ConfigDir != "" {
+		err := filepath.WalkDir(m.ConfigDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(m.ConfigDir, p)
+			if err != nil {
+				return err
+			}
+			return addFile(zw, configPrefix+filepath.ToSlash(rel), p)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to back up config: %w", err)
+		}
+	}
+
+	if m.SSHConfigPath != "" {
+		if err := addFile(zw, sshConfigName, m.SSHConfigPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to back up SSH config: %w", err)
+		}
+	}
+
+	for _, key := range m.Keys {
+		for _, p := range []string{key, key + ".pub"} {
+			if err := addFile(zw, keysPrefix+filepath.Base(p), p); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to back up key %s: %w", p, err)
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// ReadManifest returns the manifest of an archive
+func ReadManifest(path string) (*Manifest, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		if zf.Name != manifestName {
+			continue
+		}
+		data, err := readEntry(zf)
+		if err != nil {
+			return nil, err
+		}
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		return &m, nil
+	}
+	return nil, fmt.Errorf("%s is not a bgit backup (no manifest)", path)
+}
+
+// RestoreFiles extracts the bgit config directory and any keys that no longer
+// exist. Existing keys are never overwritten. Returns the restored key paths.
+func RestoreFiles(path string, m *Manifest) ([]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer zr.Close()
+
+	keyPaths := make(map[string]string)
+	for _, key := range m.Keys {
+		keyPaths[keysPrefix+filepath.Base(key)] = key
+		keyPaths[keysPrefix+filepath.Base(key)+".pub"] = key + ".pub"
+	}
+
+	var restoredKeys []string
+	for _, zf := range zr.File {
+		var dest string
+		switch {
+		case strings.HasPrefix(zf.Name, configPrefix):
+			rel := filepath.FromSlash(strings.TrimPrefix(zf.Name, configPrefix))
+			if rel == "" || strings.HasPrefix(filepath.Clean(rel), "..") {
+				continue
+			}
+			dest = filepath.Join(m.ConfigDir, rel)
+		case keyPaths[zf.Name] != "":
+			dest = keyPaths[zf.Name]
+			if _, err := os.Stat(dest); err == nil {
+				continue
+			}
+			if !strings.HasSuffix(dest, ".pub") {
+				restoredKeys = append(restoredKeys, dest)
+			}
+		default:
+			continue
+		}
+
+		data, err := readEntry(zf)
+		if err != nil {
+			return restoredKeys, err
+		}
+		if err := platform.MkdirSecure(filepath.Dir(dest)); err != nil {
+			return restoredKeys, fmt.Errorf("failed to create %s: %w", filepath.Dir(dest), err)
+		}
+		if err := platform.CreateFileSecure(dest, data); err != nil {
+			return restoredKeys, fmt.Errorf("failed to restore %s: %w", dest, err)
+		}
+	}
+
+	return restoredKeys, nil
+}
+
+func writeEntry(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+func addFile(zw *zip.Writer, name, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return writeEntry(zw, name, data)
+}
+
+func readEntry(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from backup: %w", zf.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
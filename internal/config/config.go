@@ -1,6 +1,8 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,6 +18,9 @@ const (
 	LegacyConfigDir   = ".bgit" // Old config directory name for migration
 )
 
+// ErrNotInitialized is returned when the config file does not exist yet
+var ErrNotInitialized = errors.New("bgit not initialized")
+
 // GetConfigDirName returns the config directory name
 func GetConfigDirName() string {
 	return platform.GetConfigDirName()
@@ -107,8 +112,9 @@ func MigrateFromLegacy() (bool, error) {
 	}
 
 	fmt.Println("Migration complete! Your bgit configuration has been migrated to bgit.")
-	fmt.Println("Note: Your existing SSH keys (bgit_*) will continue to work.")
-	fmt.Println("      New keys will be created with the bgit_* prefix.")
+	fmt.Println("Note: Your existing SSH keys (brgit_*) will continue to work.")
+	fmt.Println("      New keys will be created with the bgit_* prefix; rename the old")
+	fmt.Println("      ones with: bgit migrate keys")
 
 	return true, nil
 }
@@ -181,11 +187,16 @@ func CreateBackupDir() error {
 
 // NewConfig creates a new empty config
 func NewConfig() *Config {
-	return &Config{
-		Version:    "1.0",
+	cfg := &Config{
+		Version:    CurrentVersion,
 		ActiveUser: "",
 		Users:      []User{},
 	}
+	// A new config already has the current schema
+	for _, m := range migrations {
+		cfg.Migrations = append(cfg.Migrations, m.id)
+	}
+	return cfg
 }
 
 // LoadConfig loads the config from file
@@ -195,37 +206,48 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	var config Config
-	if _, err := toml.DecodeFile(configPath, &config); err != nil {
-		return nil, fmt.Errorf("failed to decode config: %w", err)
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s not found. Run 'bgit init' first", ErrNotInitialized, configPath)
 	}
 
-	// Migration: Set alias to GitHub username if missing
-	needsSave := false
-	for i := range config.Users {
-		if config.Users[i].Alias == "" {
-			config.Users[i].Alias = config.Users[i].GitHubUsername
-			needsSave = true
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var enc *encryption
+	if isEncryptedData(data) {
+		if data, enc, err = decryptConfig(data); err != nil {
+			return nil, err
 		}
 	}
 
-	// Migration: Update ActiveUser if it's a GitHub username instead of alias
-	if config.ActiveUser != "" {
-		// Check if ActiveUser is actually a GitHub username
-		user := config.FindUserByUsername(config.ActiveUser)
-		if user != nil && user.Alias != "" {
-			config.ActiveUser = user.Alias
-			needsSave = true
-		}
+	config := Config{encryption: enc}
+	if _, err := toml.Decode(string(data), &config); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
-	// Save migrated config
-	if needsSave {
+	if err := config.checkVersion(); err != nil {
+		return nil, err
+	}
+
+	// Upgrade older schemas, keeping a copy of the original file
+	fromVersion := config.Version
+	if len(config.pendingMigrations()) > 0 {
+		if err := backupBeforeMigration(configPath, fromVersion); err != nil {
+			return nil, fmt.Errorf("failed to back up config before migration: %w", err)
+		}
+		config.migrate()
 		if err := SaveConfig(&config); err != nil {
 			return nil, fmt.Errorf("failed to save migrated config: %w", err)
 		}
 	}
 
+	system, err := LoadSystemConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.mergeSystem(system)
+
 	return &config, nil
 }
 
@@ -236,15 +258,26 @@ func SaveConfig(config *Config) error {
 		return err
 	}
 
+	// System entries stay in the system config
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(config.userLayer()); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	data := buf.Bytes()
+	if config.encryption != nil {
+		if data, err = encryptConfig(data, config.encryption); err != nil {
+			return fmt.Errorf("failed to encrypt config: %w", err)
+		}
+	}
+
 	f, err := platform.OpenFileSecure(configPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
 	if err != nil {
 		return fmt.Errorf("failed to open config file: %w", err)
 	}
 	defer f.Close()
 
-	encoder := toml.NewEncoder(f)
-	if err := encoder.Encode(config); err != nil {
-		return fmt.Errorf("failed to encode config: %w", err)
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return nil
@@ -262,6 +295,17 @@ func (c *Config) FindUser(identifier string) *User {
 	return nil
 }
 
+// UsersWithTag returns the users carrying tag, in config order
+func (c *Config) UsersWithTag(tag string) []*User {
+	var users []*User
+	for i := range c.Users {
+		if c.Users[i].HasTag(tag) {
+			users = append(users, &c.Users[i])
+		}
+	}
+	return users
+}
+
 // FindUserByAlias finds a user by alias only
 func (c *Config) FindUserByAlias(alias string) *User {
 	for i := range c.Users {
@@ -292,6 +336,20 @@ func (c *Config) FindUserByEmail(email string) *User {
 	return nil
 }
 
+// FindUserByCommitEmail finds the user that commits as email: their email or
+// their author/committer override, compared case-insensitively
+func (c *Config) FindUserByCommitEmail(email string) *User {
+	for i := range c.Users {
+		u := &c.Users[i]
+		for _, e := range []string{u.Email, u.AuthorEmail, u.CommitterEmail} {
+			if e != "" && strings.EqualFold(e, email) {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
 // AddUser adds a new user to the config
 func (c *Config) AddUser(user User) error {
 	// Check for uniqueness
@@ -314,7 +372,7 @@ func (c *Config) AddUser(user User) error {
 func (c *Config) AddWorkspace(path, userAlias string) error {
 	// Check if workspace already exists
 	for _, ws := range c.Workspaces {
-		if ws.Path == path {
+		if platform.SamePath(ws.Path, path) {
 			return fmt.Errorf("workspace at '%s' already exists", path)
 		}
 	}
@@ -326,21 +384,26 @@ func (c *Config) AddWorkspace(path, userAlias string) error {
 	return nil
 }
 
-// RemoveWorkspace removes a workspace by user alias
-func (c *Config) RemoveWorkspace(userAlias string) bool {
-	for i, ws := range c.Workspaces {
+// RemoveWorkspace removes every workspace belonging to a user alias
+// Returns the number of workspaces removed
+func (c *Config) RemoveWorkspace(userAlias string) int {
+	kept := make([]Workspace, 0, len(c.Workspaces))
+	removed := 0
+	for _, ws := range c.Workspaces {
 		if ws.User == userAlias {
-			c.Workspaces = append(c.Workspaces[:i], c.Workspaces[i+1:]...)
-			return true
+			removed++
+			continue
 		}
+		kept = append(kept, ws)
 	}
-	return false
+	c.Workspaces = kept
+	return removed
 }
 
 // RemoveWorkspaceByPath removes a workspace by path
 func (c *Config) RemoveWorkspaceByPath(path string) bool {
 	for i, ws := range c.Workspaces {
-		if ws.Path == path {
+		if platform.SamePath(ws.Path, path) {
 			c.Workspaces = append(c.Workspaces[:i], c.Workspaces[i+1:]...)
 			return true
 		}
@@ -348,26 +411,100 @@ func (c *Config) RemoveWorkspaceByPath(path string) bool {
 	return false
 }
 
+// MoveWorkspace changes a workspace's path and rewrites every binding beneath it
+// Returns the number of bindings that were updated
+func (c *Config) MoveWorkspace(oldPath, newPath string) (int, error) {
+	oldPath = filepath.Clean(oldPath)
+	newPath = filepath.Clean(newPath)
+
+	index := -1
+	for i, ws := range c.Workspaces {
+		if platform.SamePath(ws.Path, oldPath) {
+			index = i
+		}
+		if platform.SamePath(ws.Path, newPath) {
+			return 0, fmt.Errorf("workspace at '%s' already exists", newPath)
+		}
+	}
+	if index == -1 {
+		return 0, fmt.Errorf("no workspace found at '%s'", oldPath)
+	}
+	c.Workspaces[index].Path = newPath
+
+	updated := 0
+	for i, b := range c.Bindings {
+		if !isPathInside(b.Path, oldPath) {
+			continue
+		}
+		rel, err := filepath.Rel(platform.CanonicalPath(oldPath), platform.CanonicalPath(b.Path))
+		if err != nil {
+			continue
+		}
+		c.Bindings[i].Path = filepath.Join(newPath, rel)
+		updated++
+	}
+
+	return updated, nil
+}
+
+// FindWorkspacesByUser returns all workspaces belonging to a user alias
+func (c *Config) FindWorkspacesByUser(userAlias string) []Workspace {
+	var workspaces []Workspace
+	for _, ws := range c.Workspaces {
+		if ws.User == userAlias {
+			workspaces = append(workspaces, ws)
+		}
+	}
+	return workspaces
+}
+
 // GetWorkspaces returns all configured workspaces
 func (c *Config) GetWorkspaces() []Workspace {
 	return c.Workspaces
 }
 
-// FindWorkspaceByPath finds a workspace that contains the given path
+// FindWorkspaceByPath finds the workspace that contains the given path
+// When workspaces are nested, the deepest (longest path) workspace wins
 func (c *Config) FindWorkspaceByPath(path string) *Workspace {
+	var best *Workspace
+	bestLen := -1
 	for i, ws := range c.Workspaces {
-		if isPathInside(path, ws.Path) {
-			return &c.Workspaces[i]
+		if !isPathInside(path, ws.Path) {
+			continue
+		}
+		wsLen := len(platform.CanonicalPath(ws.Path))
+		if wsLen > bestLen {
+			best = &c.Workspaces[i]
+			bestLen = wsLen
 		}
 	}
-	return nil
+	return best
+}
+
+// FindParentWorkspace finds the deepest workspace strictly containing the given path
+// Used to detect nesting when a new workspace is created inside an existing one
+func (c *Config) FindParentWorkspace(path string) *Workspace {
+	var best *Workspace
+	bestLen := -1
+	cleaned := filepath.Clean(path)
+	for i, ws := range c.Workspaces {
+		wsPath := platform.CanonicalPath(ws.Path)
+		if platform.SamePath(wsPath, cleaned) || !isPathInside(cleaned, wsPath) {
+			continue
+		}
+		if len(wsPath) > bestLen {
+			best = &c.Workspaces[i]
+			bestLen = len(wsPath)
+		}
+	}
+	return best
 }
 
 // AddBinding adds a new repo binding to the config
 func (c *Config) AddBinding(path, userAlias string) error {
 	// Check if binding already exists, update if so
 	for i, b := range c.Bindings {
-		if b.Path == path {
+		if platform.SamePath(b.Path, path) {
 			c.Bindings[i].User = userAlias
 			return nil
 		}
@@ -383,7 +520,7 @@ func (c *Config) AddBinding(path, userAlias string) error {
 // RemoveBinding removes a binding by path
 func (c *Config) RemoveBinding(path string) bool {
 	for i, b := range c.Bindings {
-		if b.Path == path {
+		if platform.SamePath(b.Path, path) {
 			c.Bindings = append(c.Bindings[:i], c.Bindings[i+1:]...)
 			return true
 		}
@@ -399,58 +536,94 @@ func (c *Config) GetBindings() []Binding {
 // FindBindingByPath finds a binding for the given path
 func (c *Config) FindBindingByPath(path string) *Binding {
 	for i, b := range c.Bindings {
-		if b.Path == path {
+		if platform.SamePath(b.Path, path) {
 			return &c.Bindings[i]
 		}
 	}
 	return nil
 }
 
-// CleanupInvalidPaths removes workspaces and bindings for non-existent paths
-func (c *Config) CleanupInvalidPaths() int {
-	removed := 0
+// AddRule adds or updates the rule for a repository owner
+func (c *Config) AddRule(owner, userAlias string) error {
+	if c.FindUserByAlias(userAlias) == nil {
+		return fmt.Errorf("user '%s' not found", userAlias)
+	}
+	for i, r := range c.Rules {
+		if strings.EqualFold(r.Owner, owner) {
+			c.Rules[i].User = userAlias
+			return nil
+		}
+	}
+	c.Rules = append(c.Rules, Rule{Owner: owner, User: userAlias})
+	return nil
+}
 
-	// Clean workspaces
-	validWorkspaces := make([]Workspace, 0, len(c.Workspaces))
+// RemoveRule removes the rule for a repository owner
+func (c *Config) RemoveRule(owner string) bool {
+	for i, r := range c.Rules {
+		if strings.EqualFold(r.Owner, owner) {
+			c.Rules = append(c.Rules[:i], c.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// FindRuleByOwner finds the rule matching a repository owner
+func (c *Config) FindRuleByOwner(owner string) *Rule {
+	if owner == "" {
+		return nil
+	}
+	for i, r := range c.Rules {
+		if strings.EqualFold(r.Owner, owner) {
+			return &c.Rules[i]
+		}
+	}
+	return nil
+}
+
+// PruneWorkspaces removes workspaces whose directories no longer exist
+// Returns the removed workspaces
+func (c *Config) PruneWorkspaces() []Workspace {
+	var removed []Workspace
+	valid := make([]Workspace, 0, len(c.Workspaces))
 	for _, ws := range c.Workspaces {
 		if _, err := os.Stat(ws.Path); err == nil {
-			validWorkspaces = append(validWorkspaces, ws)
+			valid = append(valid, ws)
 		} else {
-			removed++
+			removed = append(removed, ws)
 		}
 	}
-	c.Workspaces = validWorkspaces
+	c.Workspaces = valid
+	return removed
+}
+
+// BindingExists reports whether b's repository still exists: its path has a
+// .git directory or file. Listings mark the others missing and PruneBindings
+// removes them.
+func BindingExists(b Binding) bool {
+	_, err := os.Stat(filepath.Join(b.Path, ".git"))
+	return err == nil
+}
 
-	// Clean bindings
-	validBindings := make([]Binding, 0, len(c.Bindings))
+// PruneBindings removes bindings whose repositories no longer exist
+// Returns the removed bindings
+func (c *Config) PruneBindings() []Binding {
+	var removed []Binding
+	valid := make([]Binding, 0, len(c.Bindings))
 	for _, b := range c.Bindings {
-		if _, err := os.Stat(b.Path); err == nil {
-			validBindings = append(validBindings, b)
+		if BindingExists(b) {
+			valid = append(valid, b)
 		} else {
-			removed++
+			removed = append(removed, b)
 		}
 	}
-	c.Bindings = validBindings
-
+	c.Bindings = valid
 	return removed
 }
 
-// isPathInside checks if childPath is inside parentPath
+// isPathInside checks if childPath is inside parentPath, resolving symlinks
+// and ignoring case where the platform's filesystems do
 func isPathInside(childPath, parentPath string) bool {
-	// Clean and get absolute paths
-	child, err := filepath.Abs(childPath)
-	if err != nil {
-		return false
-	}
-	parent, err := filepath.Abs(parentPath)
-	if err != nil {
-		return false
-	}
-
-	// Ensure parent ends with separator for proper prefix matching
-	if !strings.HasSuffix(parent, string(filepath.Separator)) {
-		parent = parent + string(filepath.Separator)
-	}
-
-	return strings.HasPrefix(child+string(filepath.Separator), parent) || child == strings.TrimSuffix(parent, string(filepath.Separator))
+	return platform.IsPathInside(childPath, parentPath)
 }
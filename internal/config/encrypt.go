@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/byterings/bgit/internal/platform"
+	"golang.org/x/crypto/scrypt"
+)
+
+// An encrypted config.toml is itself a small TOML file holding the real
+// config sealed with AES-256-GCM. The key is derived from a passphrase with
+// scrypt, or is a random key kept in the OS keychain. It is decrypted in
+// memory on load and sealed again, with a fresh nonce, on every save.
+
+// Encryption methods
+const (
+	EncryptionPassphrase = "passphrase"
+	EncryptionKeychain   = "keychain"
+)
+
+// PassphraseEnv supplies the passphrase for an encrypted config without a
+// prompt, e.g. in scripts
+const PassphraseEnv = "BGIT_CONFIG_PASSPHRASE"
+
+// keychainAccount names the config key in the OS keychain
+const keychainAccount = "config-key"
+
+// encryptedHeader starts every encrypted config so it is recognized cheaply
+const encryptedHeader = "# bgit encrypted config; run 'bgit config decrypt' to restore plain TOML\n"
+
+// encryptedVersion is the format version of encrypted config files
+const encryptedVersion = 1
+
+// scrypt parameters for passphrase keys
+const (
+	scryptN  = 1 << 15
+	scryptR  = 8
+	scryptP  = 1
+	keySize  = 32
+	saltSize = 16
+)
+
+// ErrWrongPassphrase is returned when an encrypted config can't be opened
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted config")
+
+// PassphraseFunc asks for the passphrase of an encrypted config. It is set
+// by the CLI to an interactive prompt; without it, or when it fails, only
+// BGIT_CONFIG_PASSPHRASE can open the config.
+var PassphraseFunc func(confirm bool) (string, error)
+
+// encryptedFile is the on-disk form of an encrypted config
+type encryptedFile struct {
+	Encrypted int    `toml:"bgit_encrypted"`
+	Method    string `toml:"method"`
+	Salt      string `toml:"salt,omitempty"`
+	Data      string `toml:"data"` // base64 of nonce followed by ciphertext
+}
+
+// encryption is how a loaded config was sealed, reused when it is saved
+type encryption struct {
+	method string
+	salt   []byte
+	key    []byte
+}
+
+// IsEncrypted reports whether the config file is encrypted
+func IsEncrypted() bool {
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(configPath)
+	return err == nil && isEncryptedData(data)
+}
+
+func isEncryptedData(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedHeader))
+}
+
+// EncryptionMethod returns how the loaded config is encrypted, or "" if it
+// is stored as plain TOML
+func (c *Config) EncryptionMethod() string {
+	if c.encryption == nil {
+		return ""
+	}
+	return c.encryption.method
+}
+
+// Encrypt makes SaveConfig store c encrypted with method. A passphrase is
+// read from BGIT_CONFIG_PASSPHRASE or asked for twice; a keychain key is
+// generated and stored in the OS keychain, unless c already uses one.
+func (c *Config) Encrypt(method string) error {
+	if method == EncryptionKeychain && c.EncryptionMethod() == EncryptionKeychain {
+		return nil
+	}
+
+	enc := &encryption{method: method}
+	switch method {
+	case EncryptionPassphrase:
+		passphrase, err := readPassphrase(true)
+		if err != nil {
+			return err
+		}
+		enc.salt = make([]byte, saltSize)
+		if _, err := rand.Read(enc.salt); err != nil {
+			return err
+		}
+		if enc.key, err = deriveKey(passphrase, enc.salt); err != nil {
+			return err
+		}
+	case EncryptionKeychain:
+		enc.key = make([]byte, keySize)
+		if _, err := rand.Read(enc.key); err != nil {
+			return err
+		}
+		if err := platform.KeychainSet(keychainAccount, base64.StdEncoding.EncodeToString(enc.key), "bgit config key"); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown encryption method '%s' (use %s or %s)", method, EncryptionPassphrase, EncryptionKeychain)
+	}
+
+	c.encryption = enc
+	return nil
+}
+
+// Decrypt makes SaveConfig store c as plain TOML again
+func (c *Config) Decrypt() {
+	c.encryption = nil
+}
+
+// RemoveKeychainKey deletes the config key from the OS keychain. Call it
+// only after the config has been saved without it.
+func RemoveKeychainKey() error {
+	return platform.KeychainDelete(keychainAccount)
+}
+
+// decryptConfig opens an encrypted config file's contents
+func decryptConfig(data []byte) ([]byte, *encryption, error) {
+	var file encryptedFile
+	if _, err := toml.Decode(string(data), &file); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode encrypted config: %w", err)
+	}
+	if file.Encrypted != encryptedVersion {
+		return nil, nil, fmt.Errorf("encrypted config format %d is not supported by this bgit; upgrade bgit", file.Encrypted)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(file.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode encrypted config: %w", err)
+	}
+
+	enc := &encryption{method: file.Method}
+	switch file.Method {
+	case EncryptionPassphrase:
+		if enc.salt, err = base64.StdEncoding.DecodeString(file.Salt); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode encrypted config: %w", err)
+		}
+		passphrase, err := readPassphrase(false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if enc.key, err = deriveKey(passphrase, enc.salt); err != nil {
+			return nil, nil, err
+		}
+	case EncryptionKeychain:
+		encoded, err := platform.KeychainGet(keychainAccount)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config is encrypted with a keychain key: %w", err)
+		}
+		if enc.key, err = base64.StdEncoding.DecodeString(encoded); err != nil || len(enc.key) != keySize {
+			return nil, nil, fmt.Errorf("config key in the keychain is malformed")
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown encryption method '%s' in config", file.Method)
+	}
+
+	plain, err := open(enc.key, sealed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plain, enc, nil
+}
+
+// encryptConfig seals plain TOML into the encrypted file format
+func encryptConfig(plain []byte, enc *encryption) ([]byte, error) {
+	sealed, err := seal(enc.key, plain)
+	if err != nil {
+		return nil, err
+	}
+	file := encryptedFile{
+		Encrypted: encryptedVersion,
+		Method:    enc.method,
+		Data:      base64.StdEncoding.EncodeToString(sealed),
+	}
+	if enc.salt != nil {
+		file.Salt = base64.StdEncoding.EncodeToString(enc.salt)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(encryptedHeader)
+	if err := toml.NewEncoder(&buf).Encode(file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readPassphrase returns the passphrase from the environment or a prompt
+func readPassphrase(confirm bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if PassphraseFunc == nil {
+		return "", fmt.Errorf("config passphrase required; set %s", PassphraseEnv)
+	}
+	passphrase, err := PassphraseFunc(confirm)
+	if err != nil {
+		return "", fmt.Errorf("config passphrase required; set %s or run bgit interactively: %w", PassphraseEnv, err)
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+	return passphrase, nil
+}
+
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+}
+
+// seal encrypts plain with AES-256-GCM, prefixing a random nonce
+func seal(key, plain []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, nil), nil
+}
+
+// open reverses seal
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
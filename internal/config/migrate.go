@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// CurrentVersion is the config schema version written by this build
+const CurrentVersion = "1.1"
+
+// migration upgrades a config to a newer schema. Migrations run once, in
+// order; their IDs are recorded in Config.Migrations so each is applied only
+// once even if version numbers are later reused by hand edits. To change the
+// schema, append a migration and bump CurrentVersion to its version. Never
+// reorder or remove entries.
+type migration struct {
+	id      string
+	version string // Schema version after this migration
+	apply   func(c *Config)
+}
+
+var migrations = []migration{
+	{
+		id:      "alias-backfill",
+		version: "1.1",
+		apply: func(c *Config) {
+			// Users from before aliases existed are identified by GitHub username
+			for i := range c.Users {
+				if c.Users[i].Alias == "" {
+					c.Users[i].Alias = c.Users[i].GitHubUsername
+				}
+			}
+		},
+	},
+	{
+		id:      "active-user-alias",
+		version: "1.1",
+		apply: func(c *Config) {
+			// active_user used to store the GitHub username
+			if c.ActiveUser == "" || c.FindUserByAlias(c.ActiveUser) != nil {
+				return
+			}
+			if user := c.FindUserByUsername(c.ActiveUser); user != nil && user.Alias != "" {
+				c.ActiveUser = user.Alias
+			}
+		},
+	},
+}
+
+// pendingMigrations returns the migrations not yet recorded in the config
+func (c *Config) pendingMigrations() []migration {
+	applied := make(map[string]bool)
+	for _, id := range c.Migrations {
+		applied[id] = true
+	}
+
+	var pending []migration
+	for _, m := range migrations {
+		if !applied[m.id] {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// checkVersion refuses configs written by a newer bgit, whose fields this
+// build would silently drop when saving
+func (c *Config) checkVersion() error {
+	if c.Version == "" {
+		return nil
+	}
+	have, err := platform.ParseVersion(c.Version)
+	if err != nil {
+		return fmt.Errorf("invalid config version %q", c.Version)
+	}
+	current, _ := platform.ParseVersion(CurrentVersion)
+	if !current.AtLeast(have.Major, have.Minor) {
+		return fmt.Errorf("config version %s is newer than this bgit supports (%s); upgrade bgit", c.Version, CurrentVersion)
+	}
+	return nil
+}
+
+// migrate applies pending migrations in order and reports whether any ran
+func (c *Config) migrate() bool {
+	pending := c.pendingMigrations()
+	for _, m := range pending {
+		m.apply(c)
+		c.Migrations = append(c.Migrations, m.id)
+		c.Version = m.version
+	}
+	return len(pending) > 0
+}
+
+// backupBeforeMigration copies the config file into the backup directory
+// before a migration rewrites it
+func backupBeforeMigration(configPath, fromVersion string) error {
+	if err := CreateBackupDir(); err != nil {
+		return err
+	}
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return err
+	}
+	if fromVersion == "" {
+		fromVersion = "unversioned"
+	}
+	name := fmt.Sprintf("config-%s-%s.toml", fromVersion, time.Now().Format("20060102-150405"))
+	return copyFile(configPath, filepath.Join(backupDir, name))
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// originalGitUserFile records the global git identity from before bgit first changed it
+const originalGitUserFile = "original-gitconfig.toml"
+
+// GitUser is a git user.name / user.email pair
+type GitUser struct {
+	Name  string `toml:"name"`
+	Email string `toml:"email"`
+}
+
+func originalGitUserPath() (string, error) {
+	backupDir, err := GetBackupDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(backupDir, originalGitUserFile), nil
+}
+
+// SaveOriginalGitUser records the global git identity bgit is about to replace
+// Only the first call has an effect, so the record always holds the pre-bgit values
+func SaveOriginalGitUser(u GitUser) error {
+	path, err := originalGitUserPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := CreateBackupDir(); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to record original git user: %w", err)
+	}
+	defer f.Close()
+
+	if err := toml.NewEncoder(f).Encode(u); err != nil {
+		return fmt.Errorf("failed to record original git user: %w", err)
+	}
+	return nil
+}
+
+// LoadOriginalGitUser returns the recorded pre-bgit global git identity,
+// or nil if bgit never changed it
+func LoadOriginalGitUser() (*GitUser, error) {
+	path, err := originalGitUserPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var u GitUser
+	if _, err := toml.DecodeFile(path, &u); err != nil {
+		return nil, fmt.Errorf("failed to read original git user: %w", err)
+	}
+	return &u, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Preset is an organization's template for an identity, published as a TOML
+// file or URL so members only fill in their personal values
+// ('bgit add --preset')
+type Preset struct {
+	Name         string   `toml:"name"`          // Organization name shown while adding
+	Alias        string   `toml:"alias"`         // Suggested alias
+	Host         string   `toml:"host"`          // Git host; only github.com is supported
+	EmailPattern string   `toml:"email_pattern"` // Required email shape, e.g. "*@acme.com"
+	KeyType      string   `toml:"key_type"`      // Required key type: ed25519, ed25519-sk, or ecdsa-sk
+	Workspace    string   `toml:"workspace"`     // Workspace directory created for the identity, e.g. ~/acme
+	Owners       []string `toml:"owners"`        // GitHub organizations routed to the identity
+	Trailers     []string `toml:"trailers"`      // Commit trailers; {name} and {email} are filled in
+}
+
+// ParsePreset decodes and validates a preset. Unknown keys are rejected so a
+// preset written for a newer bgit doesn't silently lose requirements.
+func ParsePreset(data []byte, source string) (*Preset, error) {
+	var preset Preset
+	meta, err := toml.Decode(string(data), &preset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode preset %s: %w", source, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("unknown keys in preset %s: %s", source, strings.Join(keys, ", "))
+	}
+
+	if preset.Host != "" && !strings.EqualFold(preset.Host, "github.com") {
+		return nil, fmt.Errorf("preset %s targets %s; bgit supports github.com only", source, preset.Host)
+	}
+	if preset.EmailPattern != "" {
+		if _, err := path.Match(strings.ToLower(preset.EmailPattern), ""); err != nil {
+			return nil, fmt.Errorf("invalid email_pattern in preset %s: %w", source, err)
+		}
+	}
+	return &preset, nil
+}
+
+// MatchesEmail reports whether an email satisfies the preset's email_pattern
+func (p *Preset) MatchesEmail(email string) bool {
+	if p.EmailPattern == "" {
+		return true
+	}
+	ok, _ := path.Match(strings.ToLower(p.EmailPattern), strings.ToLower(email))
+	return ok
+}
+
+// ExpandTrailers returns the preset's trailers with {name} and {email}
+// replaced by the member's values
+func (p *Preset) ExpandTrailers(name, email string) []string {
+	var trailers []string
+	for _, t := range p.Trailers {
+		t = strings.ReplaceAll(t, "{name}", name)
+		t = strings.ReplaceAll(t, "{email}", email)
+		trailers = append(trailers, t)
+	}
+	return trailers
+}
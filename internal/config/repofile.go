@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// RepoFileNames are the per-repository identity files, in order of preference
+var RepoFileNames = []string{".bgit.toml", ".bgit"}
+
+// RepoFile is a committed file at a repository root declaring the expected identity
+// Teams use it to standardize which identity contributors commit with
+type RepoFile struct {
+	User   string `toml:"user"`   // Expected user alias
+	Email  string `toml:"email"`  // Expected commit email
+	GitHub string `toml:"github"` // Expected GitHub username
+	Path   string `toml:"-"`      // File the declaration was read from
+}
+
+// LoadRepoFile reads the identity file at a repository root
+// Returns nil without error if the repository has none
+func LoadRepoFile(repoRoot string) (*RepoFile, error) {
+	for _, name := range RepoFileNames {
+		path := filepath.Join(repoRoot, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			// ~/.bgit is the config directory, so a directory here is never a repo file
+			continue
+		}
+
+		var rf RepoFile
+		if _, err := toml.DecodeFile(path, &rf); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		rf.Path = path
+		return &rf, nil
+	}
+	return nil, nil
+}
+
+// FindUserForRepoFile finds the configured user matching a repo file declaration
+// The alias is checked first, then email, then GitHub username
+func (c *Config) FindUserForRepoFile(rf *RepoFile) *User {
+	if rf.User != "" {
+		if user := c.FindUserByAlias(rf.User); user != nil {
+			return user
+		}
+	}
+	if rf.Email != "" {
+		if user := c.FindUserByEmail(rf.Email); user != nil {
+			return user
+		}
+	}
+	if rf.GitHub != "" {
+		if user := c.FindUserByUsername(rf.GitHub); user != nil {
+			return user
+		}
+	}
+	return nil
+}
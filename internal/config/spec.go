@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Spec is a declarative description of identities, workspaces, and rules
+// that 'bgit apply' converges local state to, for dotfiles repos and team
+// onboarding scripts
+type Spec struct {
+	Identities []SpecIdentity `toml:"identities"`
+	Workspaces []Workspace    `toml:"workspaces"`
+	Rules      []Rule         `toml:"rules"`
+}
+
+// SpecIdentity declares an identity. Without ssh_key_path or identity_agent,
+// the key is ~/.ssh/bgit_<github_username>, generated with key_type if missing;
+// key_type "none" declares an identity without a key.
+type SpecIdentity struct {
+	Alias          string   `toml:"alias"`
+	Name           string   `toml:"name"`
+	Email          string   `toml:"email"`
+	GitHubUsername string   `toml:"github_username"`
+	SSHKeyPath     string   `toml:"ssh_key_path"`
+	KeyType        string   `toml:"key_type"` // ed25519 (default), ed25519-sk, ecdsa-sk, or none
+	IdentityAgent  string   `toml:"identity_agent"`
+	CommitTemplate string   `toml:"commit_template"`
+	Trailers       []string `toml:"trailers"`
+	AuthorEmail    string   `toml:"author_email"`
+	CommitterEmail string   `toml:"committer_email"`
+}
+
+// LoadSpec reads and validates a spec file. Unknown keys are rejected so a
+// typo doesn't silently drop a setting.
+func LoadSpec(path string) (*Spec, error) {
+	var spec Spec
+	meta, err := toml.DecodeFile(path, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("unknown keys in %s: %s", path, strings.Join(keys, ", "))
+	}
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// validate checks required fields and duplicate aliases
+func (s *Spec) validate() error {
+	aliases := make(map[string]bool)
+	for i, id := range s.Identities {
+		if id.Alias == "" || id.Name == "" || id.Email == "" || id.GitHubUsername == "" {
+			return fmt.Errorf("identity %d: alias, name, email, and github_username are required", i+1)
+		}
+		if aliases[id.Alias] {
+			return fmt.Errorf("identity '%s' is declared twice", id.Alias)
+		}
+		aliases[id.Alias] = true
+	}
+	for _, ws := range s.Workspaces {
+		if ws.Path == "" || ws.User == "" {
+			return fmt.Errorf("workspaces need a path and a user")
+		}
+		if err := ws.ValidateSettings(); err != nil {
+			return err
+		}
+	}
+	for _, r := range s.Rules {
+		if r.Owner == "" || r.User == "" {
+			return fmt.Errorf("rules need an owner and a user")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SSHOverridesFileName is the user-edited file of extra SSH directives merged
+// into bgit's managed section of ~/.ssh/config
+const SSHOverridesFileName = "ssh_overrides.toml"
+
+// SSHDirective is one line of an ssh_config Host block
+type SSHDirective struct {
+	Key   string
+	Value string
+}
+
+// SSHOverrides maps identity aliases ("*" for every identity) to directives
+// that replace or extend the ones bgit generates for the identity's host
+type SSHOverrides map[string][]SSHDirective
+
+// forbiddenSSHKeys would break the structure of the managed section
+var forbiddenSSHKeys = []string{"host", "match", "include"}
+
+// sshManagedKey is the table key that freezes an identity's host block
+// ("managed = false"); it is not written as a directive
+const sshManagedKey = "managed"
+
+// GetSSHOverridesPath returns the path to the SSH overrides file
+func GetSSHOverridesPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, SSHOverridesFileName), nil
+}
+
+// LoadSSHOverrides reads the SSH overrides file. A missing file means no
+// overrides. Each table is an identity alias or "*":
+//
+//	[work]
+//	ProxyCommand = "ssh -W %h:%p bastion.example.com"
+//
+//	["*"]
+//	ServerAliveInterval = 60
+//
+// "managed = false" in an identity's table freezes its host block: bgit keeps
+// the block as it is in ~/.ssh/config, hand edits included, instead of
+// regenerating it. An array value writes the directive once per element. An ssh_overrides.toml
+// next to the system config is read first; a key the user's file sets in
+// the same table replaces the system's.
+func LoadSSHOverrides() (SSHOverrides, error) {
+	path, err := GetSSHOverridesPath()
+	if err != nil {
+		return nil, err
+	}
+	system, err := readSSHOverrides(filepath.Join(GetSystemConfigDir(), SSHOverridesFileName))
+	if err != nil {
+		return nil, err
+	}
+	user, err := readSSHOverrides(path)
+	if err != nil {
+		return nil, err
+	}
+	if system == nil {
+		return user, nil
+	}
+
+	for host, directives := range user {
+		userKeys := make(map[string]bool)
+		for _, d := range directives {
+			userKeys[strings.ToLower(d.Key)] = true
+		}
+		var kept []SSHDirective
+		for _, d := range system[host] {
+			if !userKeys[strings.ToLower(d.Key)] {
+				kept = append(kept, d)
+			}
+		}
+		system[host] = append(kept, directives...)
+	}
+	return system, nil
+}
+
+// readSSHOverrides parses one overrides file, returning nil if it is missing
+func readSSHOverrides(path string) (SSHOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", SSHOverridesFileName, err)
+	}
+
+	var raw map[string]map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", SSHOverridesFileName, err)
+	}
+
+	overrides := make(SSHOverrides)
+	for host, table := range raw {
+		keys := make([]string, 0, len(table))
+		for key := range table {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			for _, forbidden := range forbiddenSSHKeys {
+				if strings.EqualFold(key, forbidden) {
+					return nil, fmt.Errorf("%s: [%s] cannot set %s", SSHOverridesFileName, host, key)
+				}
+			}
+			if strings.EqualFold(key, sshManagedKey) {
+				if _, ok := table[key].(bool); !ok {
+					return nil, fmt.Errorf("%s: [%s] %s must be true or false", SSHOverridesFileName, host, key)
+				}
+			}
+			values, err := sshOverrideValues(table[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: [%s] %s: %w", SSHOverridesFileName, host, key, err)
+			}
+			for _, value := range values {
+				overrides[host] = append(overrides[host], SSHDirective{Key: key, Value: value})
+			}
+		}
+	}
+	return overrides, nil
+}
+
+// sshOverrideValues converts a TOML value into directive values
+func sshOverrideValues(v interface{}) ([]string, error) {
+	switch value := v.(type) {
+	case string:
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("value cannot span lines")
+		}
+		return []string{value}, nil
+	case int64, float64:
+		return []string{fmt.Sprint(value)}, nil
+	case bool:
+		if value {
+			return []string{"yes"}, nil
+		}
+		return []string{"no"}, nil
+	case []interface{}:
+		var values []string
+		for _, element := range value {
+			converted, err := sshOverrideValues(element)
+			if err != nil {
+				return nil, err
+			}
+			if len(converted) != 1 {
+				return nil, fmt.Errorf("arrays cannot be nested")
+			}
+			values = append(values, converted...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// Apply merges the overrides for an identity into its generated directives.
+// An override replaces every generated directive with the same (case-
+// insensitive) key at the position of the first; the rest are appended.
+// The identity's own table wins over "*".
+func (o SSHOverrides) Apply(alias string, generated []SSHDirective) []SSHDirective {
+	var overrides []SSHDirective
+	for _, d := range o.forAlias(alias) {
+		if !strings.EqualFold(d.Key, sshManagedKey) {
+			overrides = append(overrides, d)
+		}
+	}
+	if len(overrides) == 0 {
+		return generated
+	}
+
+	byKey := make(map[string][]SSHDirective)
+	var order []string
+	for _, d := range overrides {
+		key := strings.ToLower(d.Key)
+		if _, seen := byKey[key]; !seen {
+			order = append(order, key)
+		}
+		byKey[key] = append(byKey[key], d)
+	}
+
+	var merged []SSHDirective
+	used := make(map[string]bool)
+	for _, d := range generated {
+		key := strings.ToLower(d.Key)
+		replacement, ok := byKey[key]
+		if !ok {
+			merged = append(merged, d)
+			continue
+		}
+		if !used[key] {
+			merged = append(merged, replacement...)
+			used[key] = true
+		}
+	}
+	for _, key := range order {
+		if !used[key] {
+			merged = append(merged, byKey[key]...)
+		}
+	}
+	return merged
+}
+
+// Frozen reports whether "managed = false" freezes the identity's host
+// block, in its own table or in "*"
+func (o SSHOverrides) Frozen(alias string) bool {
+	frozen := false
+	for _, d := range o.forAlias(alias) {
+		if strings.EqualFold(d.Key, sshManagedKey) {
+			frozen = d.Value == "no"
+		}
+	}
+	return frozen
+}
+
+// forAlias returns the "*" directives overlaid with the alias's own, keyed
+// case-insensitively
+func (o SSHOverrides) forAlias(alias string) []SSHDirective {
+	own := o[alias]
+	ownKeys := make(map[string]bool)
+	for _, d := range own {
+		ownKeys[strings.ToLower(d.Key)] = true
+	}
+
+	var directives []SSHDirective
+	for _, d := range o["*"] {
+		if !ownKeys[strings.ToLower(d.Key)] {
+			directives = append(directives, d)
+		}
+	}
+	return append(directives, own...)
+}
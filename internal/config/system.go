@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+
+	"github.com/BurntSushi/toml"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// The system config is an optional, admin-managed config.toml that every
+// user's config is layered over. It pre-seeds identities, workspaces, rules,
+// scan settings, and hooks; a user entry with the same alias, path, or owner
+// replaces the system one. bgit never writes to it: system entries are kept
+// in memory only and left out when the user config is saved.
+
+// SystemConfigEnv overrides the system config path, e.g. to try a config
+// before deploying it
+const SystemConfigEnv = "BGIT_SYSTEM_CONFIG"
+
+// GetSystemConfigDir returns the machine-wide bgit directory: /etc/bgit, or
+// %ProgramData%\bgit on Windows
+func GetSystemConfigDir() string {
+	if path := os.Getenv(SystemConfigEnv); path != "" {
+		return filepath.Dir(path)
+	}
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "bgit")
+	}
+	return "/etc/bgit"
+}
+
+// GetSystemConfigPath returns the path to the system config file
+func GetSystemConfigPath() string {
+	if path := os.Getenv(SystemConfigEnv); path != "" {
+		return path
+	}
+	return filepath.Join(GetSystemConfigDir(), ConfigFileName)
+}
+
+// LoadSystemConfig reads the system config, returning nil if there is none
+func LoadSystemConfig() (*Config, error) {
+	path := GetSystemConfigPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var system Config
+	if _, err := toml.DecodeFile(path, &system); err != nil {
+		return nil, fmt.Errorf("failed to decode system config %s: %w", path, err)
+	}
+	if err := system.checkVersion(); err != nil {
+		return nil, fmt.Errorf("system config %s: %w", path, err)
+	}
+	// Upgrade in memory only; the file belongs to the administrator
+	system.migrate()
+	for _, ws := range system.Workspaces {
+		if err := ws.ValidateSettings(); err != nil {
+			return nil, fmt.Errorf("system config %s: %w", path, err)
+		}
+	}
+	return &system, nil
+}
+
+// mergeSystem layers c over the system config. Identities, workspaces,
+// bindings, and rules the user also defines keep the user's version; scan
+// roots, scan ignores, and hooks are combined with the system's first.
+func (c *Config) mergeSystem(system *Config) {
+	if system == nil {
+		return
+	}
+	c.system = system
+
+	for _, u := range system.Users {
+		if c.FindUserByAlias(u.Alias) == nil {
+			c.Users = append(c.Users, u)
+		}
+	}
+	for _, ws := range system.Workspaces {
+		if !c.hasWorkspace(ws.Path) {
+			c.Workspaces = append(c.Workspaces, ws)
+		}
+	}
+	for _, b := range system.Bindings {
+		if c.FindBindingByPath(b.Path) == nil {
+			c.Bindings = append(c.Bindings, b)
+		}
+	}
+	for _, r := range system.Rules {
+		if c.FindRuleByOwner(r.Owner) == nil {
+			c.Rules = append(c.Rules, r)
+		}
+	}
+
+	c.ScanRoots = unionStrings(system.ScanRoots, c.ScanRoots)
+	c.ScanIgnore = unionStrings(system.ScanIgnore, c.ScanIgnore)
+	c.Hooks.PreUse = unionStrings(system.Hooks.PreUse, c.Hooks.PreUse)
+	c.Hooks.PostUse = unionStrings(system.Hooks.PostUse, c.Hooks.PostUse)
+}
+
+// hasWorkspace reports whether a workspace is registered at exactly path
+func (c *Config) hasWorkspace(path string) bool {
+	for _, ws := range c.Workspaces {
+		if platform.SamePath(ws.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// userLayer returns the config as it should be saved: without the entries
+// that came unchanged from the system config
+func (c *Config) userLayer() *Config {
+	if c.system == nil {
+		return c
+	}
+	user := *c
+	user.system = nil
+	user.Users = withoutSystem(c.Users, c.system.Users)
+	user.Workspaces = withoutSystem(c.Workspaces, c.system.Workspaces)
+	user.Bindings = withoutSystem(c.Bindings, c.system.Bindings)
+	user.Rules = withoutSystem(c.Rules, c.system.Rules)
+	user.ScanRoots = withoutSystem(c.ScanRoots, c.system.ScanRoots)
+	user.ScanIgnore = withoutSystem(c.ScanIgnore, c.system.ScanIgnore)
+	user.Hooks.PreUse = withoutSystem(c.Hooks.PreUse, c.system.Hooks.PreUse)
+	user.Hooks.PostUse = withoutSystem(c.Hooks.PostUse, c.system.Hooks.PostUse)
+	return &user
+}
+
+// HasSystemConfig reports whether a system config was merged into c
+func (c *Config) HasSystemConfig() bool {
+	return c.system != nil
+}
+
+// IsSystemUser reports whether an identity comes from the system config
+// and hasn't been redefined by the user
+func (c *Config) IsSystemUser(alias string) bool {
+	u := c.FindUserByAlias(alias)
+	return u != nil && c.system != nil && containsEntry(c.system.Users, *u)
+}
+
+// IsSystemWorkspace reports whether the workspace at path comes from the
+// system config
+func (c *Config) IsSystemWorkspace(path string) bool {
+	if c.system == nil {
+		return false
+	}
+	for _, ws := range c.Workspaces {
+		if platform.SamePath(ws.Path, path) {
+			return containsEntry(c.system.Workspaces, ws)
+		}
+	}
+	return false
+}
+
+// IsSystemBinding reports whether the binding for path comes from the
+// system config
+func (c *Config) IsSystemBinding(path string) bool {
+	b := c.FindBindingByPath(path)
+	return b != nil && c.system != nil && containsEntry(c.system.Bindings, *b)
+}
+
+// IsSystemRule reports whether the rule for owner comes from the system
+// config
+func (c *Config) IsSystemRule(owner string) bool {
+	r := c.FindRuleByOwner(owner)
+	return r != nil && c.system != nil && containsEntry(c.system.Rules, *r)
+}
+
+// ErrSystemEntry explains that an entry can't be removed because an
+// administrator manages it
+func ErrSystemEntry(kind, name string) error {
+	return fmt.Errorf("%s '%s' is defined in the system config %s and can't be removed here; override it with your own %s or ask your administrator",
+		kind, name, GetSystemConfigPath(), kind)
+}
+
+// withoutSystem returns entries minus those equal to a system entry
+func withoutSystem[T any](entries, system []T) []T {
+	var kept []T
+	for _, e := range entries {
+		if !containsEntry(system, e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+func containsEntry[T any](entries []T, entry T) bool {
+	for _, e := range entries {
+		if reflect.DeepEqual(e, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// unionStrings returns first followed by the values of second not in first
+func unionStrings(first, second []string) []string {
+	result := append([]string(nil), first...)
+	for _, s := range second {
+		if !containsEntry(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -1,12 +1,111 @@
 package config
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // User represents a Git identity
 type User struct {
-	Alias          string `toml:"alias"` // Short name for easy switching (e.g., work, personal)
-	Name           string `toml:"name"`
-	Email          string `toml:"email"`
-	GitHubUsername string `toml:"github_username"`
-	SSHKeyPath     string `toml:"ssh_key_path"`
+	Alias          string    `toml:"alias"` // Short name for easy switching (e.g., work, personal)
+	Name           string    `toml:"name"`
+	Email          string    `toml:"email"`
+	GitHubUsername string    `toml:"github_username"`
+	SSHKeyPath     string    `toml:"ssh_key_path"`
+	IdentityAgent  string    `toml:"identity_agent,omitempty"`  // External agent socket (e.g. 1Password); ssh_key_path may then be the public key
+	LastUsed       time.Time `toml:"last_used,omitempty"`       // Last time 'bgit use' activated this identity
+	CommitTemplate string    `toml:"commit_template,omitempty"` // Commit message template file
+	Trailers       []string  `toml:"trailers,omitempty"`        // Trailers appended to the template, e.g. "Signed-off-by: Name <email>"
+	AuthorEmail    string    `toml:"author_email,omitempty"`    // Overrides email as the commit author (author.email)
+	CommitterEmail string    `toml:"committer_email,omitempty"` // Overrides email as the committer, e.g. a corporate relay (committer.email)
+	AgentLifetime  string    `toml:"agent_lifetime,omitempty"`  // How long ssh-add keeps the key: "8h", seconds, or "until 18:00"
+	Tags           []string  `toml:"tags,omitempty"`            // Groups for bulk commands, e.g. a client name
+}
+
+// HasSSHHost reports whether bgit generates a github.com-<username> host for the user
+func (u *User) HasSSHHost() bool {
+	return u.SSHKeyPath != "" || u.IdentityAgent != ""
+}
+
+// EffectiveAuthorEmail returns the email git records as the commit author
+func (u *User) EffectiveAuthorEmail() string {
+	if u.AuthorEmail != "" {
+		return u.AuthorEmail
+	}
+	return u.Email
+}
+
+// EffectiveCommitterEmail returns the email git records as the committer
+func (u *User) EffectiveCommitterEmail() string {
+	if u.CommitterEmail != "" {
+		return u.CommitterEmail
+	}
+	return u.Email
+}
+
+// HasCommitTemplate reports whether bgit writes a commit.template for the user
+func (u *User) HasCommitTemplate() bool {
+	return u.CommitTemplate != "" || len(u.Trailers) > 0
+}
+
+// UsesIdentityAgent reports whether the user's key lives in an external agent
+// rather than a private key file bgit manages
+func (u *User) UsesIdentityAgent() bool {
+	return u.IdentityAgent != ""
+}
+
+// HasTag reports whether the user carries tag, ignoring case
+func (u *User) HasTag(tag string) bool {
+	for _, t := range u.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyLifetime returns how long ssh-add should keep the user's key loaded when
+// it is added at now; zero means until the agent stops
+func (u *User) KeyLifetime(now time.Time) (time.Duration, error) {
+	return ParseAgentLifetime(u.AgentLifetime, now)
+}
+
+// ParseAgentLifetime reads an agent_lifetime value: a duration ("8h", "90m"),
+// a number of seconds, or "until HH:MM" for the next time the local clock
+// shows HH:MM, e.g. the end of the working day. The result is whole seconds,
+// as ssh-add -t takes them.
+func ParseAgentLifetime(value string, now time.Time) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	var lifetime time.Duration
+	if clock, ok := strings.CutPrefix(value, "until "); ok {
+		t, err := time.ParseInLocation("15:04", strings.TrimSpace(clock), now.Location())
+		if err != nil {
+			return 0, fmt.Errorf("agent_lifetime '%s': expected a time like 'until 18:00'", value)
+		}
+		end := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+		if !end.After(now) {
+			end = end.AddDate(0, 0, 1)
+		}
+		lifetime = end.Sub(now)
+	} else if seconds, err := strconv.Atoi(value); err == nil {
+		lifetime = time.Duration(seconds) * time.Second
+	} else if d, err := time.ParseDuration(value); err == nil {
+		lifetime = d
+	} else {
+		return 0, fmt.Errorf("agent_lifetime '%s': expected a duration like '8h', seconds, or 'until 18:00'", value)
+	}
+
+	if lifetime <= 0 {
+		return 0, fmt.Errorf("agent_lifetime '%s' must be positive", value)
+	}
+	// Round up so a key never expires before the requested time
+	return (lifetime + time.Second - 1).Truncate(time.Second), nil
 }
 
 // Workspace represents a directory that auto-binds to a user identity
@@ -14,6 +113,42 @@ type User struct {
 type Workspace struct {
 	Path string `toml:"path"` // Absolute path to the workspace directory
 	User string `toml:"user"` // User alias
+
+	// Behavior settings for repositories inside the workspace
+	AutoFixRemotes bool   `toml:"auto_fix_remotes,omitempty"` // sync and the post-checkout hook fix origin without asking
+	RequireSigning bool   `toml:"require_signing,omitempty"`  // verify, scan, and sync flag repos that don't sign commits
+	CloneProtocol  string `toml:"clone_protocol,omitempty"`   // Default protocol for bgit clone: ssh or https
+}
+
+// Clone protocols a workspace can default to
+const (
+	CloneProtocolSSH   = "ssh"
+	CloneProtocolHTTPS = "https"
+)
+
+// Settings lists the workspace's behavior settings that are turned on, as
+// they appear in the config
+func (w Workspace) Settings() []string {
+	var settings []string
+	if w.AutoFixRemotes {
+		settings = append(settings, "auto_fix_remotes")
+	}
+	if w.RequireSigning {
+		settings = append(settings, "require_signing")
+	}
+	if w.CloneProtocol != "" {
+		settings = append(settings, "clone_protocol="+w.CloneProtocol)
+	}
+	return settings
+}
+
+// ValidateSettings rejects unknown setting values
+func (w Workspace) ValidateSettings() error {
+	switch w.CloneProtocol {
+	case "", CloneProtocolSSH, CloneProtocolHTTPS:
+		return nil
+	}
+	return fmt.Errorf("workspace %s: clone_protocol must be ssh or https, not '%s'", w.Path, w.CloneProtocol)
 }
 
 // Binding represents a specific repository bound to a user identity
@@ -22,11 +157,33 @@ type Binding struct {
 	User string `toml:"user"` // User alias
 }
 
+// Rule maps a GitHub repository owner (user or organization) to a user identity
+// Repositories owned by Owner use the associated user unless a workspace or binding applies
+type Rule struct {
+	Owner string `toml:"owner"` // GitHub owner or organization (case-insensitive)
+	User  string `toml:"user"`  // User alias
+}
+
+// Hooks are user commands run around identity switches, e.g. to switch npm,
+// GPG, or gh configuration along with git. Each entry runs in a shell.
+type Hooks struct {
+	PreUse  []string `toml:"pre_use,omitempty"`  // Run before 'bgit use' changes anything; a failure aborts the switch
+	PostUse []string `toml:"post_use,omitempty"` // Run after a successful switch; failures are only reported
+}
+
 // Config represents the bgit configuration
 type Config struct {
 	Version    string      `toml:"version"`
+	Migrations []string    `toml:"migrations"`  // IDs of applied schema migrations (see migrate.go)
 	ActiveUser string      `toml:"active_user"` // Stores the alias
 	Users      []User      `toml:"users"`
-	Workspaces []Workspace `toml:"workspaces"` // Phase 2: workspace directories
-	Bindings   []Binding   `toml:"bindings"`   // Phase 2: repo-specific bindings
+	Workspaces []Workspace `toml:"workspaces"`  // Phase 2: workspace directories
+	Bindings   []Binding   `toml:"bindings"`    // Phase 2: repo-specific bindings
+	Rules      []Rule      `toml:"rules"`       // Owner/org to identity mapping
+	ScanRoots  []string    `toml:"scan_roots"`  // Extra directories searched by scan and uninstall
+	ScanIgnore []string    `toml:"scan_ignore"` // Patterns the scanner skips (see .bgitignore)
+	Hooks      Hooks       `toml:"hooks,omitempty"`
+
+	system     *Config     // Machine-wide config merged under this one (see system.go)
+	encryption *encryption // How the file is encrypted, nil for plain TOML (see encrypt.go)
 }
@@ -0,0 +1,423 @@
+package execx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/byterings/bgit/internal/redact"
+)
+
+// Every external program bgit runs (git, ssh, ssh-add, ssh-keygen,
+// powershell, icacls) goes through Cmd. It gives each one a deadline, puts
+// the program's stderr into the error it returns, traces it for
+// --show-commands with secrets masked, and lets tests substitute a fake. The
+// package depends only on redact so the lowest-level packages can use it too.
+
+// DefaultTimeout bounds a command that wasn't given a deadline. Commands that
+// wait on the user (passphrase prompts, security key touches, clones) opt out
+// with WithTimeout(0).
+const DefaultTimeout = 30 * time.Second
+
+// waitDelay bounds how long Wait blocks on output pipes after the process
+// exits or is killed, e.g. when ssh leaves a ControlMaster holding them open
+const waitDelay = 2 * time.Second
+
+// maxCapturedStderr is how much of a command's stderr is kept for its error
+const maxCapturedStderr = 4096
+
+// TraceEnv enables tracing when set to a non-empty value other than "0"
+const TraceEnv = "BGIT_TRACE"
+
+var (
+	tracing            = os.Getenv(TraceEnv) != "" && os.Getenv(TraceEnv) != "0"
+	traceOut io.Writer = os.Stderr
+)
+
+// startObserver, when set, is told the command line of every command started
+var startObserver func(command string)
+
+// SetStartObserver registers a function told about every command as it starts,
+// including faked ones, so callers such as the --debug log see commands run
+// from any package
+func SetStartObserver(fn func(command string)) {
+	startObserver = fn
+}
+
+// SetTracing turns command tracing on or off
+func SetTracing(enabled bool) {
+	tracing = enabled
+}
+
+// Tracing reports whether commands are being traced
+func Tracing() bool {
+	return tracing
+}
+
+// Cmd is an exec.Cmd with a deadline. Fields such as Stdin, Stdout, Env, and
+// Dir are set as on exec.Cmd.
+type Cmd struct {
+	*exec.Cmd
+
+	parent   context.Context
+	cancel   context.CancelFunc
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+	started  time.Time
+	stderr   *tailBuffer  // Captured stderr when the caller didn't set Stderr
+	faked    *fakedResult // Result of a fake started with Start
+}
+
+// Command returns a Cmd running name with args, limited to DefaultTimeout
+func Command(name string, args ...string) *Cmd {
+	return CommandContext(context.Background(), name, args...)
+}
+
+// CommandContext returns a Cmd that is killed when ctx is done. A ctx with a
+// deadline replaces DefaultTimeout.
+func CommandContext(ctx context.Context, name string, args ...string) *Cmd {
+	runCtx, cancel := context.WithCancel(ctx)
+	c := &Cmd{
+		Cmd:     exec.CommandContext(runCtx, name, args...),
+		parent:  ctx,
+		cancel:  cancel,
+		timeout: DefaultTimeout,
+	}
+	if _, ok := ctx.Deadline(); ok {
+		c.timeout = 0
+	}
+	c.WaitDelay = waitDelay
+	return c
+}
+
+// WithTimeout sets how long the command may run once started; 0 means no
+// limit beyond the Cmd's context
+func (c *Cmd) WithTimeout(timeout time.Duration) *Cmd {
+	c.timeout = timeout
+	return c
+}
+
+// String returns the command line as it would be typed in a shell
+func (c *Cmd) String() string {
+	words := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		words[i] = shellQuote(arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// Run starts the command and waits for it to finish
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("execx: Stdout already set")
+	}
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its standard output and error
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil || c.Stderr != nil {
+		return nil, errors.New("execx: Stdout or Stderr already set")
+	}
+	var output bytes.Buffer
+	c.stderr = &tailBuffer{}
+	c.Stdout = &output
+	c.Stderr = io.MultiWriter(&output, c.stderr)
+	err := c.Run()
+	return output.Bytes(), err
+}
+
+// Start starts the command without waiting for it; call Wait to finish it
+func (c *Cmd) Start() error {
+	c.started = time.Now()
+	if startObserver != nil {
+		startObserver(c.String())
+	}
+	if tracing {
+		fmt.Fprintf(traceOut, "$ %s\n", redact.String(c.String()))
+	}
+
+	if fake := currentFake(); fake != nil {
+		c.faked = c.runFake(fake)
+		if c.faked.startErr != nil {
+			return c.finish(c.faked.startErr)
+		}
+		return nil
+	}
+
+	if c.Stderr == nil {
+		c.stderr = &tailBuffer{}
+		c.Stderr = c.stderr
+	}
+	if err := c.Cmd.Start(); err != nil {
+		return c.finish(err)
+	}
+	if c.timeout > 0 {
+		c.timer = time.AfterFunc(c.timeout, func() {
+			c.timedOut.Store(true)
+			c.cancel()
+		})
+	}
+	return nil
+}
+
+// Wait waits for a command started with Start
+func (c *Cmd) Wait() error {
+	if c.faked != nil {
+		return c.finish(c.faked.err)
+	}
+	err := c.Cmd.Wait()
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	return c.finish(err)
+}
+
+// finish releases the command's context, wraps err, and traces the result
+func (c *Cmd) finish(err error) error {
+	c.cancel()
+	if err != nil {
+		err = c.wrap(err)
+	}
+	c.traceExit(err)
+	return err
+}
+
+// wrap turns a failure into an *Error carrying the exit code and stderr
+func (c *Cmd) wrap(err error) error {
+	var existing *Error
+	if errors.As(err, &existing) {
+		return err
+	}
+	e := &Error{
+		Program:  filepath.Base(c.Path),
+		Command:  c.String(),
+		ExitCode: -1,
+		Err:      err,
+		TimedOut: c.timedOut.Load(),
+		Timeout:  c.timeout,
+	}
+	if c.stderr != nil {
+		e.Stderr = strings.TrimSpace(c.stderr.String())
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.ExitCode = exitErr.ExitCode()
+	}
+	if !e.TimedOut && c.parent.Err() != nil {
+		e.Err = fmt.Errorf("%w (%v)", err, c.parent.Err())
+	}
+	return e
+}
+
+func (c *Cmd) traceExit(err error) {
+	if !tracing {
+		return
+	}
+	elapsed := time.Since(c.started).Round(time.Millisecond)
+
+	var e *Error
+	switch {
+	case err == nil:
+		fmt.Fprintf(traceOut, "  → exit 0 (%s)\n", elapsed)
+	case errors.As(err, &e) && e.TimedOut:
+		fmt.Fprintf(traceOut, "  → timed out after %s\n", e.Timeout)
+	case errors.As(err, &e) && e.ExitCode >= 0:
+		fmt.Fprintf(traceOut, "  → exit %d (%s)\n", e.ExitCode, elapsed)
+	default:
+		fmt.Fprintf(traceOut, "  → failed: %s (%s)\n", redact.String(fmt.Sprint(errors.Unwrap(err))), elapsed)
+	}
+}
+
+// Error is returned when a command can't be started, exits non-zero, or runs
+// past its deadline
+type Error struct {
+	Program  string        // Base name of the program, e.g. "git"
+	Command  string        // Full command line
+	ExitCode int           // -1 if the command didn't exit normally
+	Stderr   string        // Trimmed tail of the command's stderr
+	TimedOut bool          // Killed for running longer than Timeout
+	Timeout  time.Duration // The deadline the command ran under
+	Err      error         // Underlying error, usually an *exec.ExitError
+}
+
+func (e *Error) Error() string {
+	var msg string
+	switch {
+	case e.TimedOut:
+		msg = fmt.Sprintf("%s timed out after %s", e.Program, e.Timeout)
+	case e.ExitCode >= 0:
+		msg = fmt.Sprintf("%s exited with status %d", e.Program, e.ExitCode)
+	default:
+		msg = fmt.Sprintf("%s failed: %v", e.Program, e.Err)
+	}
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the command that produced err: 0 for nil
+// and -1 if the command didn't exit normally
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.ExitCode
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// Stderr returns the captured stderr of the command that produced err
+func Stderr(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Stderr
+	}
+	return ""
+}
+
+// IsTimeout reports whether err is from a command killed by its deadline
+func IsTimeout(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.TimedOut
+}
+
+// Result is what a Fake reports for one command
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Err      error // Returned as a start failure, e.g. exec.ErrNotFound
+}
+
+// Fake stands in for the external programs in tests. It receives each
+// command (with Args, Dir, Env, and Stdin as the caller set them) instead of
+// the command being run.
+type Fake func(c *Cmd) Result
+
+type fakedResult struct {
+	startErr error
+	err      error
+}
+
+var (
+	fakeMu sync.Mutex
+	fake   Fake
+)
+
+// SetFake routes every command to f until the returned function is called
+func SetFake(f Fake) (restore func()) {
+	fakeMu.Lock()
+	previous := fake
+	fake = f
+	fakeMu.Unlock()
+	return func() {
+		fakeMu.Lock()
+		fake = previous
+		fakeMu.Unlock()
+	}
+}
+
+func currentFake() Fake {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return fake
+}
+
+// runFake runs f for the command, writing its output where the real
+// program's would have gone
+func (c *Cmd) runFake(f Fake) *fakedResult {
+	r := f(c)
+	if r.Err != nil {
+		return &fakedResult{startErr: r.Err}
+	}
+	if c.Stdout != nil {
+		io.WriteString(c.Stdout, r.Stdout)
+	}
+	if c.Stderr != nil {
+		io.WriteString(c.Stderr, r.Stderr)
+	} else {
+		c.stderr = &tailBuffer{}
+		c.stderr.Write([]byte(r.Stderr))
+	}
+	if r.ExitCode != 0 {
+		e := &Error{
+			Program:  filepath.Base(c.Path),
+			Command:  c.String(),
+			ExitCode: r.ExitCode,
+			Timeout:  c.timeout,
+			Err:      fmt.Errorf("exit status %d", r.ExitCode),
+		}
+		// Like a real command's, only the captured tail of stderr is kept
+		if c.stderr != nil {
+			e.Stderr = strings.TrimSpace(c.stderr.String())
+		}
+		return &fakedResult{err: e}
+	}
+	return &fakedResult{}
+}
+
+// tailBuffer keeps the last maxCapturedStderr bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - maxCapturedStderr; over > 0 {
+		b.buf = b.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// shellQuote single-quotes an argument when a shell would split or expand it
+func shellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
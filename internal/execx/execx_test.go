@@ -0,0 +1,187 @@
+package execx
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakeExitCodeAndStderr(t *testing.T) {
+	var got *Cmd
+	defer SetFake(func(c *Cmd) Result {
+		got = c
+		return Result{Stdout: "out\n", Stderr: "fatal: boom\n", ExitCode: 3}
+	})()
+
+	cmd := Command("git", "-C", "/repo", "status")
+	cmd.Dir = "/work"
+	output, err := cmd.Output()
+
+	if string(output) != "out\n" {
+		t.Errorf("stdout = %q, want %q", output, "out\n")
+	}
+	if got == nil || strings.Join(got.Args, " ") != "git -C /repo status" || got.Dir != "/work" {
+		t.Errorf("fake saw %+v, want the command as configured", got)
+	}
+	if code := ExitCode(err); code != 3 {
+		t.Errorf("ExitCode = %d, want 3", code)
+	}
+	if stderr := Stderr(err); stderr != "fatal: boom" {
+		t.Errorf("Stderr = %q, want %q", stderr, "fatal: boom")
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %T, want *Error", err)
+	}
+	if e.Program != "git" || e.TimedOut {
+		t.Errorf("Error = %+v, want Program git and not timed out", e)
+	}
+	if want := "git exited with status 3: fatal: boom"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestFakeStderrKeepsTail(t *testing.T) {
+	tail := strings.Repeat("b", maxCapturedStderr)
+	defer SetFake(func(c *Cmd) Result {
+		return Result{Stderr: strings.Repeat("a", 100) + tail, ExitCode: 1}
+	})()
+
+	err := Command("ssh", "-T", "git@github.com").Run()
+	if stderr := Stderr(err); stderr != tail {
+		t.Errorf("Stderr kept %d bytes starting %q, want the last %d", len(stderr), stderr[:1], maxCapturedStderr)
+	}
+}
+
+func TestFakeCallerStderr(t *testing.T) {
+	defer SetFake(func(c *Cmd) Result {
+		return Result{Stderr: "warning\n", ExitCode: 2}
+	})()
+
+	var stderr bytes.Buffer
+	cmd := Command("ssh-add", "-l")
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	if stderr.String() != "warning\n" {
+		t.Errorf("caller's Stderr = %q, want %q", stderr.String(), "warning\n")
+	}
+	if got := Stderr(err); got != "" {
+		t.Errorf("Stderr(err) = %q, want nothing captured when the caller set Stderr", got)
+	}
+	if code := ExitCode(err); code != 2 {
+		t.Errorf("ExitCode = %d, want 2", code)
+	}
+}
+
+func TestFakeCombinedOutput(t *testing.T) {
+	defer SetFake(func(c *Cmd) Result {
+		return Result{Stdout: "out ", Stderr: "err", ExitCode: 1}
+	})()
+
+	output, err := Command("icacls", "file").CombinedOutput()
+	if string(output) != "out err" {
+		t.Errorf("output = %q, want %q", output, "out err")
+	}
+	if got := Stderr(err); got != "err" {
+		t.Errorf("Stderr = %q, want %q", got, "err")
+	}
+}
+
+func TestFakeStartError(t *testing.T) {
+	defer SetFake(func(c *Cmd) Result {
+		return Result{Err: exec.ErrNotFound}
+	})()
+
+	cmd := Command("secret-tool", "lookup")
+	err := cmd.Start()
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("Start err = %v, want exec.ErrNotFound", err)
+	}
+	if code := ExitCode(err); code != -1 {
+		t.Errorf("ExitCode = %d, want -1", code)
+	}
+	if err := Command("secret-tool", "lookup").Run(); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("Run err = %v, want exec.ErrNotFound", err)
+	}
+}
+
+func TestSetFakeRestore(t *testing.T) {
+	restore := SetFake(func(c *Cmd) Result { return Result{} })
+	if currentFake() == nil {
+		t.Fatal("fake not installed")
+	}
+	restore()
+	if currentFake() != nil {
+		t.Error("fake still installed after restore")
+	}
+}
+
+func TestStartObserver(t *testing.T) {
+	defer SetFake(func(c *Cmd) Result { return Result{} })()
+	var started []string
+	SetStartObserver(func(command string) { started = append(started, command) })
+	defer SetStartObserver(nil)
+
+	Command("git", "commit", "-m", "two words").Run()
+	if want := []string{"git commit -m 'two words'"}; strings.Join(started, "\n") != strings.Join(want, "\n") {
+		t.Errorf("observed %q, want %q", started, want)
+	}
+}
+
+func TestExitCodeAndStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	err := Command("sh", "-c", "echo oops >&2; exit 4").Run()
+	if code := ExitCode(err); code != 4 {
+		t.Errorf("ExitCode = %d, want 4", code)
+	}
+	if stderr := Stderr(err); stderr != "oops" {
+		t.Errorf("Stderr = %q, want %q", stderr, "oops")
+	}
+	if ExitCode(nil) != 0 {
+		t.Error("ExitCode(nil) != 0")
+	}
+}
+
+func TestWithTimeoutKills(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sleep")
+	}
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not found")
+	}
+
+	start := time.Now()
+	err := Command("sleep", "10").WithTimeout(100 * time.Millisecond).Run()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command ran %s, want it killed after 100ms", elapsed)
+	}
+	if !IsTimeout(err) {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if want := "sleep timed out after 100ms"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"git", "status"}, "git status"},
+		{[]string{"git", "commit", "-m", "it's"}, `git commit -m 'it'\''s'`},
+		{[]string{"ssh", "-i", "~/.ssh/id", ""}, "ssh -i '~/.ssh/id' ''"},
+	}
+	for _, tt := range tests {
+		if got := Command(tt.args[0], tt.args[1:]...).String(); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// credentialSection scopes bgit's credential settings to GitHub over HTTPS
+const credentialSection = "credential.https://github.com"
+
+// SetCredentialHelper makes a repository authenticate to GitHub over HTTPS
+// with the given helper command and username only. The empty helper entry
+// first resets helpers inherited from global config, so another account's
+// token stored there is never offered.
+func SetCredentialHelper(repoPath, helper, username string) error {
+	key := credentialSection + ".helper"
+	ui.Command("git", "-C", repoPath, "config", "--local", "--unset-all", key).Run()
+	for _, value := range []string{"", helper} {
+		cmd := ui.Command("git", "-C", repoPath, "config", "--local", "--add", key, value)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	if err := runRepoConfig(repoPath, credentialSection+".username", username); err != nil {
+		return fmt.Errorf("failed to set %s.username: %w", credentialSection, err)
+	}
+	return nil
+}
+
+// GetCredentialHelper returns the last credential helper configured locally
+// for GitHub, or "" if none
+func GetCredentialHelper(repoPath string) string {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--local", "--get-all", credentialSection+".helper")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	values := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	return values[len(values)-1]
+}
+
+// UnsetCredentialHelper removes the GitHub credential settings from a
+// repository's local config; a repository without them is left unchanged
+func UnsetCredentialHelper(repoPath string) error {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--local", "--remove-section", credentialSection)
+	if err := cmd.Run(); err != nil {
+		// Exit code 128 means the section doesn't exist
+		if execx.ExitCode(err) == 128 {
+			return nil
+		}
+		return fmt.Errorf("failed to remove %s: %w", credentialSection, err)
+	}
+	return nil
+}
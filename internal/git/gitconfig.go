@@ -2,8 +2,14 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
 )
 
 // SetGlobalUser sets the global Git user name and email
@@ -38,21 +44,49 @@ func GetGlobalUser() (name, email string, err error) {
 
 // runGitConfig runs git config --global to set a value
 func runGitConfig(key, value string) error {
-	cmd := exec.Command("git", "config", "--global", key, value)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("git config failed: %s: %w", string(output), err)
+	cmd := ui.Command("git", "config", "--global", key, value)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git config failed: %w", err)
 	}
 	return nil
 }
 
 // getGitConfig gets a git config value
 func getGitConfig(key string) (string, error) {
-	cmd := exec.Command("git", "config", "--global", "--get", key)
+	cmd := ui.Command("git", "config", "--global", "--get", key)
 	output, err := cmd.Output()
 	if err != nil {
 		// If key doesn't exist, return empty string
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
+		if execx.ExitCode(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetGlobalConfig returns a global git config value, or an empty string if unset
+func GetGlobalConfig(key string) (string, error) {
+	return getGitConfig(key)
+}
+
+// SetGlobalConfig sets a global git config value
+func SetGlobalConfig(key, value string) error {
+	return runGitConfig(key, value)
+}
+
+// UnsetGlobalConfig removes a key from the global git config; unset keys are ignored
+func UnsetGlobalConfig(key string) error {
+	return setOrUnsetConfig("", key, "")
+}
+
+// GetLocalConfig returns a value from a repository's local config only,
+// or an empty string if unset
+func GetLocalConfig(repoPath, key string) (string, error) {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--local", "--get", key)
+	output, err := cmd.Output()
+	if err != nil {
+		if execx.ExitCode(err) == 1 {
 			return "", nil
 		}
 		return "", err
@@ -62,6 +96,274 @@ func getGitConfig(key string) (string, error) {
 
 // IsGitInstalled checks if git is installed
 func IsGitInstalled() bool {
-	cmd := exec.Command("git", "--version")
+	cmd := ui.Command("git", "--version")
 	return cmd.Run() == nil
 }
+
+// GetRepoConfig returns the effective git config value for a repository
+// (local config layered over global), or an empty string if unset
+func GetRepoConfig(repoPath, key string) (string, error) {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--get", key)
+	output, err := cmd.Output()
+	if err != nil {
+		if execx.ExitCode(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetRepoConfigBool returns the effective git config value for a repository
+// as a boolean, read with --type=bool so yes, on, 1, and a bare key count as
+// true the way git counts them. An unset key is false.
+func GetRepoConfigBool(repoPath, key string) (bool, error) {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--type=bool", "--get", key)
+	output, err := cmd.Output()
+	if err != nil {
+		if execx.ExitCode(err) == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.TrimSpace(string(output)) == "true", nil
+}
+
+// ManagedUserKey marks a repository whose local user.name/email were written by bgit
+const ManagedUserKey = "bgit.manageduser"
+
+// SetRepoUser sets the local user.name and user.email for a repository
+// and marks them as bgit-managed so uninstall can remove them
+func SetRepoUser(repoPath, name, email string) error {
+	if err := runRepoConfig(repoPath, "user.name", name); err != nil {
+		return fmt.Errorf("failed to set git user.name: %w", err)
+	}
+	if err := runRepoConfig(repoPath, "user.email", email); err != nil {
+		return fmt.Errorf("failed to set git user.email: %w", err)
+	}
+	if err := runRepoConfig(repoPath, ManagedUserKey, "true"); err != nil {
+		return fmt.Errorf("failed to mark git user as bgit-managed: %w", err)
+	}
+	return nil
+}
+
+// IsRepoUserManaged reports whether a repository's local user was written by bgit
+func IsRepoUserManaged(repoPath string) bool {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--local", "--get", ManagedUserKey)
+	output, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// UnsetRepoUser removes the local user.name, user.email, author/committer
+// emails, and bgit marker from a repository
+func UnsetRepoUser(repoPath string) error {
+	for _, key := range []string{"user.name", "user.email", "author.email", "committer.email", ManagedUserKey} {
+		cmd := ui.Command("git", "-C", repoPath, "config", "--local", "--unset", key)
+		if err := cmd.Run(); err != nil {
+			// Exit code 5 means the key was not set
+			if execx.ExitCode(err) == 5 {
+				continue
+			}
+			return fmt.Errorf("failed to unset %s: %w", key, err)
+		}
+	}
+	// Drop the now-empty [bgit] section; it may not exist
+	ui.Command("git", "-C", repoPath, "config", "--local", "--remove-section", "bgit").Run()
+	return nil
+}
+
+// UnsetGlobalUser removes the global user.name and user.email
+func UnsetGlobalUser() error {
+	for _, key := range []string{"user.name", "user.email"} {
+		cmd := ui.Command("git", "config", "--global", "--unset", key)
+		if err := cmd.Run(); err != nil {
+			if execx.ExitCode(err) == 5 {
+				continue
+			}
+			return fmt.Errorf("failed to unset git %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// RemoveGlobalIncludes removes global include and includeIf entries whose path
+// lies inside dir, returning the removed keys
+func RemoveGlobalIncludes(dir string) ([]string, error) {
+	cmd := ui.Command("git", "config", "--global", "--get-regexp", `^include(if\..*)?\.path$`)
+	output, err := cmd.Output()
+	if err != nil {
+		if execx.ExitCode(err) == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read git includes: %w", err)
+	}
+
+	var removed []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		path, err := platform.ExpandTilde(value)
+		if err != nil {
+			continue
+		}
+		path, dir := filepath.Clean(path), filepath.Clean(dir)
+		if path != dir && !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			continue
+		}
+
+		unset := ui.Command("git", "config", "--global", "--unset", key, "^"+regexp.QuoteMeta(value)+"$")
+		if err := unset.Run(); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+		removed = append(removed, key)
+
+		// Remove the section too if nothing else is left in it
+		section := strings.TrimSuffix(key, ".path")
+		if check := ui.Command("git", "config", "--global", "--get-regexp", "^"+regexp.QuoteMeta(section)+`\.`); check.Run() != nil {
+			ui.Command("git", "config", "--global", "--remove-section", section).Run()
+		}
+	}
+	return removed, nil
+}
+
+// runRepoConfig runs git config --local in a repository to set a value
+func runRepoConfig(repoPath, key, value string) error {
+	cmd := ui.Command("git", "-C", repoPath, "config", "--local", key, value)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git config failed: %w", err)
+	}
+	return nil
+}
+
+// SetCommitEmails sets author.email and committer.email, which take precedence
+// over user.email, in a repository's local config or, with an empty repoPath,
+// the global config. An empty email removes the key so user.email applies.
+func SetCommitEmails(repoPath, authorEmail, committerEmail string) error {
+	for _, kv := range [][2]string{{"author.email", authorEmail}, {"committer.email", committerEmail}} {
+		if err := setOrUnsetConfig(repoPath, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setOrUnsetConfig sets a key in local (or, with an empty repoPath, global)
+// config, or removes it when value is empty
+func setOrUnsetConfig(repoPath, key, value string) error {
+	if value != "" {
+		var err error
+		if repoPath == "" {
+			err = runGitConfig(key, value)
+		} else {
+			err = runRepoConfig(repoPath, key, value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		return nil
+	}
+
+	args := []string{"config", "--global", "--unset", key}
+	if repoPath != "" {
+		args = []string{"-C", repoPath, "config", "--local", "--unset", key}
+	}
+	if err := ui.Command("git", args...).Run(); err != nil {
+		// Exit code 5 means the key was not set
+		if execx.ExitCode(err) == 5 {
+			return nil
+		}
+		return fmt.Errorf("failed to unset %s: %w", key, err)
+	}
+	return nil
+}
+
+// SetSSHCommand sets core.sshCommand, the ssh command git runs for a
+// repository's SSH remotes
+func SetSSHCommand(repoPath, command string) error {
+	return runRepoConfig(repoPath, "core.sshCommand", command)
+}
+
+// SetCommitTemplate points commit.template at a file, in a repository's local
+// config or, with an empty repoPath, the global config
+func SetCommitTemplate(repoPath, path string) error {
+	if repoPath == "" {
+		return runGitConfig("commit.template", path)
+	}
+	return runRepoConfig(repoPath, "commit.template", path)
+}
+
+// UnsetCommitTemplate removes commit.template from a repository's local
+// config or, with an empty repoPath, the global config
+func UnsetCommitTemplate(repoPath string) error {
+	return setOrUnsetConfig(repoPath, "commit.template", "")
+}
+
+// GetHooksDir returns the absolute hooks directory for a repository,
+// honoring core.hooksPath
+func GetHooksDir(repoPath string) (string, error) {
+	cmd := ui.Command("git", "-C", repoPath, "rev-parse", "--git-path", "hooks")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate hooks directory: %w", err)
+	}
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return dir, nil
+}
+
+// GetGlobalConfigPath returns the file 'git config --global' writes to:
+// $GIT_CONFIG_GLOBAL, else ~/.gitconfig, unless only the XDG file exists
+func GetGlobalConfigPath() (string, error) {
+	if path := os.Getenv("GIT_CONFIG_GLOBAL"); path != "" {
+		return platform.ExpandTilde(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	path := filepath.Join(home, ".gitconfig")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdgPath := filepath.Join(xdg, "git", "config"); fileExists(xdgPath) {
+		return xdgPath, nil
+	}
+	return path, nil
+}
+
+// GetRepoConfigPath returns a repository's local config file, which linked
+// worktrees share with their main repository
+func GetRepoConfigPath(repoPath string) (string, error) {
+	cmd := ui.Command("git", "-C", repoPath, "rev-parse", "--git-common-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git directory: %w", err)
+	}
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return filepath.Join(dir, "config"), nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// GetVersion returns the installed git version
+func GetVersion() (platform.Version, error) {
+	output, err := ui.Command("git", "--version").Output()
+	if err != nil {
+		return platform.Version{}, err
+	}
+	return platform.ParseVersion(string(output))
+}
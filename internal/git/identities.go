@@ -0,0 +1,134 @@
+package git
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// ConfigIdentity is a user.name/user.email pair found in the global git
+// config or in a file it includes
+type ConfigIdentity struct {
+	Name       string
+	Email      string
+	SSHKeyPath string // -i key from core.sshCommand, if any
+	Source     string // "global" or the include file path
+	GitDir     string // Directory from an includeIf "gitdir:..." condition, if any
+}
+
+// FindConfigIdentities returns the identity set in the global git config
+// followed by those set in its include and includeIf files, skipping files
+// without a user.email
+func FindConfigIdentities() ([]ConfigIdentity, error) {
+	var identities []ConfigIdentity
+
+	name, email, err := GetGlobalUser()
+	if err != nil {
+		return nil, err
+	}
+	if email != "" {
+		sshCommand, _ := getGitConfig("core.sshCommand")
+		identities = append(identities, ConfigIdentity{
+			Name:       name,
+			Email:      email,
+			SSHKeyPath: sshCommandKey(sshCommand),
+			Source:     "global",
+		})
+	}
+
+	cmd := ui.Command("git", "config", "--global", "--get-regexp", `^include(if\..*)?\.path$`)
+	output, err := cmd.Output()
+	if err != nil {
+		if execx.ExitCode(err) == 1 {
+			return identities, nil
+		}
+		return identities, err
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		path, err := platform.ExpandTilde(value)
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			// Relative include paths are relative to the including file
+			if globalPath, err := platform.ExpandTilde("~/.gitconfig"); err == nil {
+				path = filepath.Join(filepath.Dir(globalPath), path)
+			}
+		}
+
+		email := getFileConfig(path, "user.email")
+		if email == "" {
+			continue
+		}
+		identities = append(identities, ConfigIdentity{
+			Name:       getFileConfig(path, "user.name"),
+			Email:      email,
+			SSHKeyPath: sshCommandKey(getFileConfig(path, "core.sshCommand")),
+			Source:     path,
+			GitDir:     includeGitDir(key),
+		})
+	}
+	return identities, nil
+}
+
+// getFileConfig returns a value from a single git config file, or an empty
+// string if the file or key is missing
+func getFileConfig(path, key string) string {
+	output, err := ui.Command("git", "config", "--file", path, "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// includeGitDir returns the directory named by an includeIf "gitdir:<dir>/"
+// key, or "" for plain includes and other conditions
+func includeGitDir(key string) string {
+	condition := strings.TrimSuffix(strings.TrimPrefix(key, "includeif."), ".path")
+	if condition == key {
+		return ""
+	}
+	var dir string
+	switch {
+	case strings.HasPrefix(condition, "gitdir:"):
+		dir = strings.TrimPrefix(condition, "gitdir:")
+	case strings.HasPrefix(condition, "gitdir/i:"):
+		dir = strings.TrimPrefix(condition, "gitdir/i:")
+	default:
+		return ""
+	}
+
+	// "~/work/" matches ~/work/**; a pattern with wildcards elsewhere has no
+	// single directory
+	dir = strings.TrimSuffix(strings.TrimSuffix(dir, "**"), "/")
+	if dir == "" || strings.ContainsAny(dir, "*?[") {
+		return ""
+	}
+	expanded, err := platform.ExpandTilde(dir)
+	if err != nil || !filepath.IsAbs(expanded) {
+		return ""
+	}
+	return filepath.Clean(expanded)
+}
+
+// sshCommandKey returns the key passed with -i in a core.sshCommand value
+func sshCommandKey(sshCommand string) string {
+	fields := strings.Fields(sshCommand)
+	for i, field := range fields {
+		if field == "-i" && i+1 < len(fields) {
+			return strings.Trim(fields[i+1], `"'`)
+		}
+		if strings.HasPrefix(field, "-i") && len(field) > 2 {
+			return strings.Trim(field[2:], `"'`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// CommitEmailCounts returns the number of commits per author email reachable
+// from any ref in a repository, limited to commits newer than since (any date
+// git log --since accepts, e.g. "90 days ago"). Emails are lowercased.
+func CommitEmailCounts(repoPath, since string) (map[string]int, error) {
+	args := []string{"-C", repoPath, "log", "--all", "--format=%ae"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+
+	// Walking all history can take a while in large repositories
+	output, err := ui.Command("git", args...).WithTimeout(0).Output()
+	if err != nil {
+		// A repository without commits has nothing to count
+		if strings.Contains(execx.Stderr(err), "does not have any commits") {
+			return map[string]int{}, nil
+		}
+		return nil, fmt.Errorf("git log failed in %s: %w", repoPath, err)
+	}
+
+	counts := make(map[string]int)
+	for _, line := range strings.Split(string(output), "\n") {
+		email := strings.ToLower(strings.TrimSpace(line))
+		if email != "" {
+			counts[email]++
+		}
+	}
+	return counts, nil
+}
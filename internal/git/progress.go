@@ -0,0 +1,94 @@
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// progressLine matches the progress git prints on stderr with --progress, with
+// or without the "remote: " prefix of server-side phases:
+//
+//	Receiving objects:  45% (450/1000), 1.20 MiB | 3.40 MiB/s
+//	remote: Enumerating objects: 1234, done.
+var progressLine = regexp.MustCompile(`^(?:remote: )?([A-Z][a-z]+(?: [a-z]+)*):\s+(?:(\d+)% \((\d+)/(\d+)\)|(\d+))(?:,\s*(.*?))?(?:,?\s*done\.)?\s*$`)
+
+// Progress is one update of a git transfer phase
+type Progress struct {
+	Phase   string // e.g. "Receiving objects"
+	Percent int    // -1 for phases that only count, like enumerating objects
+	Current int
+	Total   int
+	Detail  string // transfer size and rate, e.g. "1.20 MiB | 3.40 MiB/s"
+	Done    bool
+}
+
+// ParseProgress parses a line of git's progress output
+func ParseProgress(line string) (Progress, bool) {
+	line = strings.TrimSpace(line)
+	m := progressLine.FindStringSubmatch(line)
+	if m == nil {
+		return Progress{}, false
+	}
+	p := Progress{Phase: m[1], Percent: -1, Detail: m[6], Done: strings.HasSuffix(line, "done.")}
+	if m[2] != "" {
+		p.Percent, _ = strconv.Atoi(m[2])
+		p.Current, _ = strconv.Atoi(m[3])
+		p.Total, _ = strconv.Atoi(m[4])
+	} else {
+		p.Current, _ = strconv.Atoi(m[5])
+		p.Total = p.Current
+	}
+	return p, true
+}
+
+// ProgressWriter splits git's stderr into progress updates, which git ends
+// with \r while a phase runs, and the other messages it prints
+type ProgressWriter struct {
+	onProgress func(Progress)
+	onLine     func(string)
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewProgressWriter returns a writer calling onProgress for each progress
+// update and onLine for every other line
+func NewProgressWriter(onProgress func(Progress), onLine func(string)) *ProgressWriter {
+	return &ProgressWriter{onProgress: onProgress, onLine: onLine}
+}
+
+// Write implements io.Writer
+func (w *ProgressWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, b := range data {
+		if b != '\r' && b != '\n' {
+			w.buf = append(w.buf, b)
+			continue
+		}
+		w.emit()
+	}
+	return len(data), nil
+}
+
+// Flush handles output left without a line ending
+func (w *ProgressWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emit()
+}
+
+func (w *ProgressWriter) emit() {
+	line := strings.TrimRight(string(w.buf), " ")
+	w.buf = w.buf[:0]
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if p, ok := ParseProgress(line); ok {
+		w.onProgress(p)
+		return
+	}
+	w.onLine(line)
+}
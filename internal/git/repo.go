@@ -0,0 +1,123 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/byterings/bgit/internal/ui"
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+// Repository introspection reads .git directly with go-git, so read-only
+// operations like resolving identities and scanning don't need a git binary or
+// a process per repository. Remote URLs are read the way git reports them,
+// with url.<base>.insteadOf rules from the system, global, and repository
+// config applied. Writes go through git itself, because go-git rewrites the
+// whole config file when saving, dropping comments and keys it doesn't model.
+
+// openRepo opens the repository containing path
+func openRepo(path string) (*gogit.Repository, error) {
+	return gogit.PlainOpenWithOptions(path, &gogit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+}
+
+// IsRepo reports whether path is inside a git repository
+func IsRepo(path string) bool {
+	_, err := openRepo(path)
+	return err == nil
+}
+
+// GetRemoteURL returns the (first) URL of a remote in the given repository,
+// as git remote get-url reports it
+func GetRemoteURL(repoPath, remote string) (string, error) {
+	remotes, err := ListRemotes(repoPath)
+	if err != nil {
+		return "", err
+	}
+	url, ok := remotes[remote]
+	if !ok {
+		return "", fmt.Errorf("no such remote '%s'", remote)
+	}
+	return url, nil
+}
+
+// ListRemotes returns the (first) URL of each remote in the given repository,
+// keyed by remote name, as git remote get-url reports them. Remotes without a
+// URL are left out.
+func ListRemotes(repoPath string) (map[string]string, error) {
+	repo, err := openRepo(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+	rules := insteadOfRules(cfg)
+	remotes := make(map[string]string)
+	// go-git has already applied the repository's own rules to cfg.Remotes;
+	// start from the raw values so rules from every scope apply once
+	for _, sub := range cfg.Raw.Section("remote").Subsections {
+		if urls := sub.OptionAll("url"); len(urls) > 0 {
+			remotes[sub.Name] = applyInsteadOf(urls[0], rules)
+		}
+	}
+	return remotes, nil
+}
+
+// insteadOfRules returns the url.<base>.insteadOf rules that apply to a
+// repository, mapping each prefix to its replacement base. Repository rules
+// override global ones, which override system ones, as in git. A global or
+// system config go-git can't read contributes no rules.
+func insteadOfRules(local *gitconfig.Config) map[string]string {
+	rules := make(map[string]string)
+	var configs []*gitconfig.Config
+	for _, scope := range []gitconfig.Scope{gitconfig.SystemScope, gitconfig.GlobalScope} {
+		if cfg, err := gitconfig.LoadConfig(scope); err == nil {
+			configs = append(configs, cfg)
+		}
+	}
+	configs = append(configs, local)
+
+	for _, cfg := range configs {
+		for _, sub := range cfg.Raw.Section("url").Subsections {
+			for _, prefix := range sub.OptionAll("insteadOf") {
+				if prefix != "" {
+					rules[prefix] = sub.Name
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// applyInsteadOf rewrites url with the rule whose prefix is the longest match,
+// as git does
+func applyInsteadOf(url string, rules map[string]string) string {
+	prefixes := make([]string, 0, len(rules))
+	for prefix := range rules {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(url, prefix) {
+			return rules[prefix] + strings.TrimPrefix(url, prefix)
+		}
+	}
+	return url
+}
+
+// SetRemoteURL replaces the URL of an existing remote with git remote set-url,
+// which edits only that line of .git/config
+func SetRemoteURL(repoPath, remote, url string) error {
+	cmd := ui.Command("git", "-C", repoPath, "remote", "set-url", remote, url)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git remote set-url failed: %w", err)
+	}
+	return nil
+}
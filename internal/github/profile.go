@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// apiURL is the GitHub REST API root
+const apiURL = "https://api.github.com"
+
+// ErrUserNotFound is returned when GitHub has no account with the username
+var ErrUserNotFound = errors.New("GitHub user not found")
+
+// Profile is the public part of a GitHub account bgit uses to prefill an identity
+type Profile struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+	Email string `json:"email"` // Public profile email, often empty
+}
+
+// NoReplyEmail returns the account's private commit email,
+// e.g. 12345+octocat@users.noreply.github.com
+func (p *Profile) NoReplyEmail() string {
+	return fmt.Sprintf("%d+%s@users.noreply.github.com", p.ID, p.Login)
+}
+
+// CommitEmail returns the public profile email, or the noreply email if the
+// account keeps its email private
+func (p *Profile) CommitEmail() string {
+	if p.Email != "" {
+		return p.Email
+	}
+	return p.NoReplyEmail()
+}
+
+// FetchProfile looks up a GitHub account by username. GITHUB_TOKEN, if set,
+// is sent to raise the unauthenticated rate limit. The request is abandoned
+// when ctx ends.
+func FetchProfile(ctx context.Context, username string) (*Profile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"/users/"+url.PathEscape(username), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	ui.Debugf("GET %s", req.URL)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		return nil, fmt.Errorf("GitHub API rate limit reached (set GITHUB_TOKEN to raise it)")
+	default:
+		return nil, fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+
+	var profile Profile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,103 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// FileName is the history log inside the bgit config directory
+const FileName = "history.log"
+
+// Actions recorded in the history log
+const (
+	ActionUse           = "use"
+	ActionBind          = "bind"
+	ActionUnbind        = "unbind"
+	ActionRemoteFix     = "remote-fix"
+	ActionRemoteRestore = "remote-restore"
+	ActionUndo          = "undo"
+)
+
+// Entry is one line of the history log
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	User   string    `json:"user,omitempty"`   // Identity alias
+	Path   string    `json:"path,omitempty"`   // Working directory or repository
+	Detail string    `json:"detail,omitempty"` // e.g. the new remote URL
+}
+
+// Path returns the history log path
+func Path() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, FileName), nil
+}
+
+// Record appends an entry to the history log, stamping it with the current time
+func Record(e Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now().Truncate(time.Second)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	f, err := platform.OpenFileSecure(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history log: %w", err)
+	}
+	return nil
+}
+
+// Load returns every entry in the history log, oldest first
+// Lines that cannot be parsed are skipped
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read history log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,120 @@
+package hooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// managedMarker identifies hook scripts written by bgit
+const managedMarker = "# bgit-managed hook"
+
+// Hook names bgit can install
+const (
+	PostCheckout = "post-checkout"
+	PrePush      = "pre-push"
+)
+
+// scripts holds the body of every hook bgit knows how to install
+var scripts = map[string]string{
+	PostCheckout: `command -v bgit >/dev/null 2>&1 || exit 0
+bgit verify --quiet || true
+`,
+	// git passes the remote name and push URL; a non-zero exit blocks the push
+	PrePush: `command -v bgit >/dev/null 2>&1 || exit 0
+exec bgit verify-push "$1" "$2"
+`,
+}
+
+// Names returns the hook names bgit can install
+func Names() []string {
+	return []string{PostCheckout, PrePush}
+}
+
+// Path returns the path of a hook script in a repository
+func Path(repoPath, name string) (string, error) {
+	dir, err := git.GetHooksDir(repoPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// IsInstalled reports whether a bgit-managed hook is installed
+func IsInstalled(repoPath, name string) bool {
+	path, err := Path(repoPath, name)
+	if err != nil {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(content), managedMarker)
+}
+
+// IsCurrent reports whether an installed bgit-managed hook matches the script
+// this version of bgit would write
+func IsCurrent(repoPath, name string) bool {
+	path, err := Path(repoPath, name)
+	if err != nil {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return string(content) == script(name)
+}
+
+// script returns the full hook file contents for a known hook
+func script(name string) string {
+	return fmt.Sprintf("#!/bin/sh\n%s: %s\n%s", managedMarker, name, scripts[name])
+}
+
+// Install writes a bgit-managed hook script into a repository
+// An existing hook not written by bgit is only replaced when force is set
+func Install(repoPath, name string, force bool) error {
+	if _, ok := scripts[name]; !ok {
+		return fmt.Errorf("unknown hook: %s", name)
+	}
+
+	path, err := Path(repoPath, name)
+	if err != nil {
+		return err
+	}
+
+	if content, err := os.ReadFile(path); err == nil && !strings.Contains(string(content), managedMarker) && !force {
+		return fmt.Errorf("%s already exists and was not created by bgit (use --force to replace it)", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	ui.Debugf("write %s", path)
+	if err := os.WriteFile(path, []byte(script(name)), 0755); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+	return nil
+}
+
+// Uninstall removes a bgit-managed hook, leaving hooks written by others untouched
+// Returns true if a hook was removed
+func Uninstall(repoPath, name string) (bool, error) {
+	if !IsInstalled(repoPath, name) {
+		return false, nil
+	}
+	path, err := Path(repoPath, name)
+	if err != nil {
+		return false, err
+	}
+	if err := os.Remove(path); err != nil {
+		return false, fmt.Errorf("failed to remove hook: %w", err)
+	}
+	return true, nil
+}
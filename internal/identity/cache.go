@@ -0,0 +1,226 @@
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/byterings/bgit/internal/config"
+)
+
+// CacheFileName holds recent resolutions inside the bgit config directory so
+// frequent callers (shell prompts running 'bgit active') skip decoding the
+// config and opening the repository
+const CacheFileName = "resolve-cache.json"
+
+// maxCacheEntries bounds the cache; the least recently stored paths are dropped
+const maxCacheEntries = 64
+
+// cacheEntry is one cached resolution. An entry is valid while its stamps
+// match: the bgit executable, the config file, and the repository files that
+// repo-file and rule resolution read.
+type cacheEntry struct {
+	Path        string       `json:"path"`
+	BgitStamp   string       `json:"bgit_stamp"`
+	ConfigStamp string       `json:"config_stamp"`
+	RepoStamp   string       `json:"repo_stamp"`
+	Found       bool         `json:"found"`
+	Alias       string       `json:"alias,omitempty"`
+	Source      string       `json:"source,omitempty"`
+	MatchPath   string       `json:"match_path,omitempty"`
+	Owner       string       `json:"owner,omitempty"`
+	User        *config.User `json:"user,omitempty"`
+}
+
+// ResolveCached resolves the identity for a path like ResolveIdentity, reusing
+// the cached result while neither the config nor the repository changed
+func ResolveCached(currentPath string) (*Resolution, error) {
+	absPath, err := filepath.Abs(currentPath)
+	if err != nil {
+		absPath = currentPath
+	}
+
+	// The cache holds identities in plain text, which an encrypted config
+	// is meant to avoid
+	if config.IsEncrypted() {
+		_ = ClearCache()
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		return ResolveIdentity(cfg, absPath)
+	}
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	bgitStamp := executableStamp()
+	// The system config is merged into the user's, so it invalidates too
+	configStamp := fileStamp(configPath) + "|" + fileStamp(config.GetSystemConfigPath())
+	repoStamp := repoStamp(findGitRoot(absPath))
+
+	entries := loadCache()
+	for _, e := range entries {
+		if e.Path == absPath && e.BgitStamp == bgitStamp && e.ConfigStamp == configStamp && e.RepoStamp == repoStamp {
+			return e.resolution(), nil
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	resolution, err := ResolveIdentity(cfg, absPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := cacheEntry{Path: absPath, BgitStamp: bgitStamp, ConfigStamp: configStamp, RepoStamp: repoStamp}
+	if resolution != nil {
+		entry.Found = true
+		entry.Alias = resolution.Alias
+		entry.Source = string(resolution.Source)
+		entry.MatchPath = resolution.Path
+		entry.Owner = resolution.Owner
+		entry.User = resolution.User
+	}
+	// A cache that can't be written only costs speed
+	_ = storeCache(entries, entry)
+
+	return resolution, nil
+}
+
+func (e cacheEntry) resolution() *Resolution {
+	if !e.Found || e.User == nil {
+		return nil
+	}
+	return &Resolution{
+		User:   e.User,
+		Alias:  e.Alias,
+		Source: ResolutionSource(e.Source),
+		Path:   e.MatchPath,
+		Owner:  e.Owner,
+	}
+}
+
+func cachePath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, CacheFileName), nil
+}
+
+// ClearCache removes the resolution cache
+func ClearCache() error {
+	path, err := cachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// loadCache returns the cached entries; a missing or unreadable cache is empty
+func loadCache() []cacheEntry {
+	path, err := cachePath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries []cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// storeCache replaces any entry for the same path with entry and writes the
+// cache atomically, so concurrent prompts never read a partial file
+func storeCache(entries []cacheEntry, entry cacheEntry) error {
+	kept := make([]cacheEntry, 0, len(entries)+1)
+	for _, e := range entries {
+		if e.Path != entry.Path {
+			kept = append(kept, e)
+		}
+	}
+	kept = append(kept, entry)
+	if len(kept) > maxCacheEntries {
+		kept = kept[len(kept)-maxCacheEntries:]
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	path, err := cachePath()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), CacheFileName+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// repoStamp fingerprints the repository files resolution reads beyond the
+// config: the git config (origin remote for rules) and the repo identity file
+func repoStamp(repoRoot string) string {
+	if repoRoot == "" {
+		return ""
+	}
+	stamp := fileStamp(filepath.Join(gitDirFor(MainRepoRoot(repoRoot)), "config"))
+	for _, name := range config.RepoFileNames {
+		stamp += "|" + fileStamp(filepath.Join(repoRoot, name))
+	}
+	return stamp
+}
+
+// gitDirFor returns the git directory of a repository root, following a .git file
+func gitDirFor(repoRoot string) string {
+	gitDir := filepath.Join(repoRoot, ".git")
+	if target := readGitFile(gitDir); target != "" {
+		return target
+	}
+	return gitDir
+}
+
+// executableStamp identifies the running bgit build, so an upgrade that
+// changes resolution never serves results cached by the old version
+func executableStamp() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "-"
+	}
+	return fileStamp(exe)
+}
+
+// fileStamp identifies a file's current version by modification time and size
+func fileStamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/remote"
 )
 
 // ResolutionSource indicates how the identity was resolved
@@ -14,6 +17,9 @@ type ResolutionSource string
 const (
 	SourceWorkspace ResolutionSource = "workspace"
 	SourceBinding   ResolutionSource = "binding"
+	SourceRepoFile  ResolutionSource = "repo-file"
+	SourceRule      ResolutionSource = "rule"
+	SourceOwner     ResolutionSource = "owner" // Suggested only: remote owner matches a GitHub username
 	SourceGlobal    ResolutionSource = "global"
 )
 
@@ -22,11 +28,13 @@ type Resolution struct {
 	User   *config.User
 	Alias  string
 	Source ResolutionSource
-	Path   string // The workspace or binding path that matched (empty for global)
+	Path   string // The workspace, binding, or repo file path that matched (empty for global)
+	Owner  string // The repository owner that matched a rule (rule source only)
 }
 
 // ResolveIdentity resolves the effective identity for the given path
-// Priority: 1. Workspace (if path is inside) 2. Binding (exact match) 3. Global active user
+// Priority: 1. Workspace (deepest one containing the path) 2. Binding (exact match)
+// 3. Repo file (.bgit.toml at the repo root) 4. Rule (origin remote owner) 5. Global active user
 func ResolveIdentity(cfg *config.Config, currentPath string) (*Resolution, error) {
 	// Get absolute path
 	absPath, err := filepath.Abs(currentPath)
@@ -49,9 +57,10 @@ func ResolveIdentity(cfg *config.Config, currentPath string) (*Resolution, error
 	}
 
 	// 2. Check for repo binding (walk up to find git root, then check binding)
+	// Linked worktrees share their main repository's binding
 	repoRoot := findGitRoot(absPath)
 	if repoRoot != "" {
-		binding := cfg.FindBindingByPath(repoRoot)
+		binding := cfg.FindBindingByPath(MainRepoRoot(repoRoot))
 		if binding != nil {
 			user := cfg.FindUserByAlias(binding.User)
 			if user != nil {
@@ -65,7 +74,30 @@ func ResolveIdentity(cfg *config.Config, currentPath string) (*Resolution, error
 		}
 	}
 
-	// 3. Fall back to global active user
+	// 3. Check for a committed repo identity file
+	if repoRoot != "" {
+		if rf, err := config.LoadRepoFile(repoRoot); err == nil && rf != nil {
+			if user := cfg.FindUserForRepoFile(rf); user != nil {
+				return &Resolution{
+					User:   user,
+					Alias:  user.Alias,
+					Source: SourceRepoFile,
+					Path:   rf.Path,
+				}, nil
+			}
+		}
+	}
+
+	// 4. Check owner rules against the repo's origin remote
+	if repoRoot != "" && len(cfg.Rules) > 0 {
+		if url, err := git.GetRemoteURL(repoRoot, "origin"); err == nil {
+			if resolution := ResolveRule(cfg, url); resolution != nil {
+				return resolution, nil
+			}
+		}
+	}
+
+	// 5. Fall back to global active user
 	if cfg.ActiveUser != "" {
 		user := cfg.FindUserByAlias(cfg.ActiveUser)
 		if user != nil {
@@ -81,6 +113,53 @@ func ResolveIdentity(cfg *config.Config, currentPath string) (*Resolution, error
 	return nil, nil
 }
 
+// ResolveRule returns the rule-based resolution for a remote URL, or nil if no rule matches
+func ResolveRule(cfg *config.Config, url string) *Resolution {
+	parsed, err := remote.Parse(url)
+	if err != nil {
+		return nil
+	}
+	rule := cfg.FindRuleByOwner(parsed.Owner)
+	if rule == nil {
+		return nil
+	}
+	user := cfg.FindUserByAlias(rule.User)
+	if user == nil {
+		return nil
+	}
+	return &Resolution{
+		User:   user,
+		Alias:  rule.User,
+		Source: SourceRule,
+		Owner:  parsed.Owner,
+	}
+}
+
+// SuggestIdentity proposes an identity for a remote URL based on its owner
+// Rules are checked first, then identities whose GitHub username matches the owner
+// Returns nil if nothing matches
+func SuggestIdentity(cfg *config.Config, url string) *Resolution {
+	if resolution := ResolveRule(cfg, url); resolution != nil {
+		return resolution
+	}
+
+	parsed, err := remote.Parse(url)
+	if err != nil {
+		return nil
+	}
+	for i := range cfg.Users {
+		if strings.EqualFold(cfg.Users[i].GitHubUsername, parsed.Owner) {
+			return &Resolution{
+				User:   &cfg.Users[i],
+				Alias:  cfg.Users[i].Alias,
+				Source: SourceOwner,
+				Owner:  parsed.Owner,
+			}
+		}
+	}
+	return nil
+}
+
 // GetEffectiveUser returns the effective user for the current directory
 func GetEffectiveUser(cfg *config.Config) (*config.User, error) {
 	cwd, err := os.Getwd()
@@ -143,10 +222,11 @@ func IsRepoBound(cfg *config.Config, path string) bool {
 	if repoRoot == "" {
 		return false
 	}
-	return cfg.FindBindingByPath(repoRoot) != nil
+	return cfg.FindBindingByPath(MainRepoRoot(repoRoot)) != nil
 }
 
-// findGitRoot walks up from path to find the git repository root
+// findGitRoot walks up from path to find the git repository root. A .git
+// file (linked worktree or submodule) counts when it points at a git directory.
 func findGitRoot(path string) string {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -156,8 +236,10 @@ func findGitRoot(path string) string {
 	current := absPath
 	for {
 		gitDir := filepath.Join(current, ".git")
-		if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
-			return current
+		if info, err := os.Stat(gitDir); err == nil {
+			if info.IsDir() || readGitFile(gitDir) != "" {
+				return current
+			}
 		}
 
 		parent := filepath.Dir(current)
@@ -169,22 +251,61 @@ func findGitRoot(path string) string {
 	}
 }
 
-// IsInsidePath checks if childPath is inside parentPath
-func IsInsidePath(childPath, parentPath string) bool {
-	child, err := filepath.Abs(childPath)
+// readGitFile returns the git directory a .git file points at ("gitdir: <path>"),
+// or "" if the file isn't a valid pointer
+func readGitFile(gitFile string) string {
+	content, err := os.ReadFile(gitFile)
 	if err != nil {
-		return false
+		return ""
+	}
+	line := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+	if !strings.HasPrefix(line, "gitdir:") {
+		return ""
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if gitDir == "" {
+		return ""
 	}
-	parent, err := filepath.Abs(parentPath)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(gitFile), gitDir)
+	}
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return filepath.Clean(gitDir)
+}
+
+// MainRepoRoot returns the main working tree for a repository root. For a
+// linked worktree (created by 'git worktree add') this is the checkout that
+// owns the shared .git directory; any other root is returned unchanged.
+func MainRepoRoot(repoRoot string) string {
+	gitDir := readGitFile(filepath.Join(repoRoot, ".git"))
+	if gitDir == "" {
+		return repoRoot
+	}
+
+	// Linked worktrees record the shared git directory in a commondir file;
+	// submodules have none and are repositories of their own
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
 	if err != nil {
-		return false
+		return repoRoot
+	}
+	commonDir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
 	}
+	commonDir = filepath.Clean(commonDir)
 
-	if !strings.HasSuffix(parent, string(filepath.Separator)) {
-		parent = parent + string(filepath.Separator)
+	// A bare repository has no main working tree to bind
+	if filepath.Base(commonDir) != ".git" {
+		return repoRoot
 	}
+	return filepath.Dir(commonDir)
+}
 
-	return strings.HasPrefix(child+string(filepath.Separator), parent) || child == strings.TrimSuffix(parent, string(filepath.Separator))
+// IsInsidePath checks if childPath is inside parentPath
+func IsInsidePath(childPath, parentPath string) bool {
+	return platform.IsPathInside(childPath, parentPath)
 }
 
 // FindGitRoot is exported version of findGitRoot
@@ -0,0 +1,306 @@
+package journal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// The journal keeps copies of the files a mutating operation is about to
+// change, so 'bgit undo' can put them back. Each entry is a directory under
+// ~/.bgit/journal holding a manifest and the original file contents.
+
+// DirName is the journal directory inside the bgit config directory
+const DirName = "journal"
+
+// maxEntries bounds how many operations can be undone
+const maxEntries = 20
+
+const manifestName = "manifest.json"
+
+// File is one file snapshotted by an entry
+type File struct {
+	Path      string `json:"path"`
+	Existed   bool   `json:"existed"`              // Whether the file existed before the operation
+	Copy      string `json:"copy,omitempty"`       // Name of the saved copy inside the entry directory
+	AfterHash string `json:"after_hash,omitempty"` // Content hash once the operation finished ("" if removed)
+}
+
+// Entry is one undoable operation
+type Entry struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Operation   string    `json:"operation"`   // e.g. "use", "bind", "remote fix"
+	Description string    `json:"description"` // e.g. "switch to work"
+	Files       []File    `json:"files"`
+
+	dir string
+}
+
+// Dir returns the journal directory
+func Dir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, DirName), nil
+}
+
+// Begin snapshots paths before an operation changes them. The entry only
+// becomes undoable once Commit records the result.
+func Begin(operation, description string, paths ...string) (*Entry, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := platform.MkdirSecure(root); err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+
+	now := time.Now()
+	e := &Entry{
+		// Zero-padded so IDs from the same second sort in order
+		ID:          fmt.Sprintf("%s-%09d", now.Format("20060102-150405"), now.Nanosecond()),
+		Time:        now.Truncate(time.Second),
+		Operation:   operation,
+		Description: description,
+	}
+	e.dir = filepath.Join(root, e.ID)
+	if err := platform.MkdirSecure(e.dir); err != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, path := range paths {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		f := File{Path: path}
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			f.Existed = true
+			f.Copy = fmt.Sprintf("%d-%s", i, filepath.Base(path))
+			if err := platform.CreateFileSecure(filepath.Join(e.dir, f.Copy), data); err != nil {
+				e.Discard()
+				return nil, fmt.Errorf("failed to save %s: %w", path, err)
+			}
+		case !os.IsNotExist(err):
+			// Without a snapshot the file couldn't be restored, so there is
+			// no undo (or rollback) for the operation at all
+			e.Discard()
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		e.Files = append(e.Files, f)
+	}
+	return e, nil
+}
+
+// Commit records the state the operation left behind. An operation that
+// changed none of the snapshotted files leaves nothing to undo, so its entry
+// is discarded. A nil entry is a no-op.
+func (e *Entry) Commit() error {
+	if e == nil {
+		return nil
+	}
+
+	changed := false
+	for i := range e.Files {
+		f := &e.Files[i]
+		f.AfterHash = hashFile(f.Path)
+		if f.AfterHash != e.beforeHash(*f) {
+			changed = true
+		}
+	}
+	if !changed {
+		return e.Discard()
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	if err := platform.CreateFileSecure(filepath.Join(e.dir, manifestName), data); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return prune()
+}
+
+// Discard removes an entry that won't be committed. A nil entry is a no-op.
+func (e *Entry) Discard() error {
+	if e == nil || e.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(e.dir)
+}
+
+// Latest returns the most recent committed entry, or nil if there is none
+func Latest() (*Entry, error) {
+	entries, err := List()
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return entries[len(entries)-1], nil
+}
+
+// List returns the committed entries, oldest first. Entries without a
+// manifest (interrupted operations) are skipped.
+func List() ([]*Entry, error) {
+	root, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	dirents, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var entries []*Entry
+	for _, d := range dirents {
+		if !d.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, d.Name())
+		data, err := os.ReadFile(filepath.Join(dir, manifestName))
+		if err != nil {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+		e.dir = dir
+		entries = append(entries, &e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Time.Before(entries[j].Time)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+// ModifiedSince returns the files that changed after the operation finished,
+// which undo would otherwise silently overwrite
+func (e *Entry) ModifiedSince() []string {
+	var modified []string
+	for _, f := range e.Files {
+		if hashFile(f.Path) != f.AfterHash {
+			modified = append(modified, f.Path)
+		}
+	}
+	return modified
+}
+
+// Pending returns the files that currently differ from their state before
+// the operation, i.e. the ones Restore would write or remove
+func (e *Entry) Pending() []File {
+	var pending []File
+	for _, f := range e.Files {
+		if hashFile(f.Path) != e.beforeHash(f) {
+			pending = append(pending, f)
+		}
+	}
+	return pending
+}
+
+// Restore puts every snapshotted file back as it was before the operation:
+// saved contents are rewritten and files the operation created are removed
+func (e *Entry) Restore() error {
+	var failed []string
+	for _, f := range e.Pending() {
+		if !f.Existed {
+			if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+				failed = append(failed, fmt.Sprintf("%s: %v", f.Path, err))
+			}
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(e.dir, f.Copy))
+		if err == nil {
+			err = platform.CreateFileSecure(f.Path, data)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", f.Path, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restore %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// beforeHash returns the hash of a file's saved copy ("" if it didn't exist)
+func (e *Entry) beforeHash(f File) string {
+	if !f.Existed {
+		return ""
+	}
+	return hashFile(filepath.Join(e.dir, f.Copy))
+}
+
+// hashFile returns the SHA-256 of a file's contents, or "" if it doesn't exist
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// prune removes the oldest entries beyond maxEntries, along with leftovers of
+// interrupted operations
+func prune() error {
+	root, err := Dir()
+	if err != nil {
+		return err
+	}
+	entries, err := List()
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]bool)
+	start := 0
+	if len(entries) > maxEntries {
+		start = len(entries) - maxEntries
+	}
+	for _, e := range entries[start:] {
+		keep[filepath.Base(e.dir)] = true
+	}
+
+	dirents, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-time.Hour)
+	for _, d := range dirents {
+		if keep[d.Name()] {
+			continue
+		}
+		// An entry without a manifest may belong to an operation still running
+		if info, err := d.Info(); err == nil && info.ModTime().After(cutoff) && !hasManifest(filepath.Join(root, d.Name())) {
+			continue
+		}
+		os.RemoveAll(filepath.Join(root, d.Name()))
+	}
+	return nil
+}
+
+func hasManifest(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, manifestName))
+	return err == nil
+}
@@ -0,0 +1,124 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+)
+
+// OpenSSH for Windows refuses private keys that anyone other than the owner,
+// SYSTEM, and Administrators can access, the ACL equivalent of chmod 600.
+// ACLs are read and fixed with icacls, which ships with every Windows version.
+
+// broadPrincipalSIDs are the well-known groups removed by the ACL fix:
+// Everyone, Authenticated Users, and Users
+var broadPrincipalSIDs = []string{"*S-1-1-0", "*S-1-5-11", "*S-1-5-32-545"}
+
+// ACLEntry is one access control entry as printed by icacls
+type ACLEntry struct {
+	Principal   string // e.g. BUILTIN\Users
+	Permissions string // e.g. (I)(RX)
+}
+
+// ReadACL returns the access control entries of a file (Windows only)
+func ReadACL(path string) ([]ACLEntry, error) {
+	output, err := execx.Command("icacls", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("icacls failed: %w", err)
+	}
+	return parseICACLS(string(output), path), nil
+}
+
+// parseICACLS parses icacls output: the first line starts with the path, then
+// one "PRINCIPAL:(perms)" entry per line, then a summary line
+func parseICACLS(output, path string) []ACLEntry {
+	var entries []ACLEntry
+	for i, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if i == 0 {
+			line = strings.TrimPrefix(line, path)
+		}
+		line = strings.TrimSpace(line)
+		sep := strings.Index(line, ":(")
+		if sep < 0 {
+			continue
+		}
+		entries = append(entries, ACLEntry{
+			Principal:   line[:sep],
+			Permissions: line[sep+1:],
+		})
+	}
+	return entries
+}
+
+// currentWindowsUser returns DOMAIN\user for the current user
+func currentWindowsUser() string {
+	user := os.Getenv("USERNAME")
+	if domain := os.Getenv("USERDOMAIN"); domain != "" {
+		return domain + `\` + user
+	}
+	return user
+}
+
+// isAllowedKeyPrincipal reports whether OpenSSH accepts a principal having
+// access to a private key: the owner, SYSTEM, or Administrators
+func isAllowedKeyPrincipal(principal string) bool {
+	p := strings.ToLower(principal)
+	user := strings.ToLower(currentWindowsUser())
+	switch {
+	case p == user, p == strings.ToLower(os.Getenv("USERNAME")):
+		return true
+	case p == "system", strings.HasSuffix(p, `\system`):
+		return true
+	case strings.HasSuffix(p, `\administrators`):
+		return true
+	}
+	return false
+}
+
+// BroadACLPrincipals returns the principals other than the owner, SYSTEM, and
+// Administrators that can access a file (Windows only)
+func BroadACLPrincipals(path string) ([]string, error) {
+	entries, err := ReadACL(path)
+	if err != nil {
+		return nil, err
+	}
+	var broad []string
+	for _, e := range entries {
+		if !isAllowedKeyPrincipal(e.Principal) {
+			broad = append(broad, e.Principal)
+		}
+	}
+	return broad, nil
+}
+
+// windowsACLFixArgs returns icacls arguments that restrict a file to the
+// current user: inherited entries are dropped, the user gets full control, and
+// the broad built-in groups are removed
+func windowsACLFixArgs(path string) []string {
+	args := []string{path, "/inheritance:r", "/grant:r", currentWindowsUser() + ":F", "/remove:g"}
+	return append(args, broadPrincipalSIDs...)
+}
+
+// fixWindowsACL restricts a file's ACL to the current user
+func fixWindowsACL(path string) error {
+	output, err := execx.Command("icacls", windowsACLFixArgs(path)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("icacls failed: %s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// windowsACLFixCommand returns the icacls command line that fixes a key's ACL
+func windowsACLFixCommand(path string) string {
+	args := windowsACLFixArgs(path)
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, ` `) {
+			arg = `"` + arg + `"`
+		}
+		quoted[i] = arg
+	}
+	return "icacls " + strings.Join(quoted, " ")
+}
@@ -0,0 +1,51 @@
+package platform
+
+import (
+	"os"
+	"strings"
+)
+
+// ciVendors maps environment variables set by CI services to their names.
+// Most services also set CI=true, but not all of them do.
+var ciVendors = []struct {
+	env  string
+	name string
+}{
+	{"GITHUB_ACTIONS", "GitHub Actions"},
+	{"GITLAB_CI", "GitLab CI"},
+	{"CIRCLECI", "CircleCI"},
+	{"BUILDKITE", "Buildkite"},
+	{"TRAVIS", "Travis CI"},
+	{"JENKINS_URL", "Jenkins"},
+	{"TF_BUILD", "Azure Pipelines"},
+	{"BITBUCKET_BUILD_NUMBER", "Bitbucket Pipelines"},
+	{"TEAMCITY_VERSION", "TeamCity"},
+	{"APPVEYOR", "AppVeyor"},
+	{"DRONE", "Drone"},
+	{"CODEBUILD_BUILD_ID", "AWS CodeBuild"},
+}
+
+// CIName returns the name of the CI service bgit is running in, "CI" for an
+// unrecognized one that sets CI, or "" outside CI. CI=false or CI=0 turns
+// detection off, e.g. to test prompts inside a pipeline container.
+func CIName() string {
+	ci := strings.ToLower(os.Getenv("CI"))
+	if ci == "false" || ci == "0" {
+		return ""
+	}
+	for _, v := range ciVendors {
+		if os.Getenv(v.env) != "" {
+			return v.name
+		}
+	}
+	if ci != "" {
+		return "CI"
+	}
+	return ""
+}
+
+// IsCI reports whether bgit is running in a CI pipeline, where nobody can
+// answer prompts and there is no user SSH agent to manage
+func IsCI() bool {
+	return CIName() != ""
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package platform
+
+// EnableConsoleUTF8 prepares the Windows console for bgit's output; other
+// terminals need nothing
+func EnableConsoleUTF8() (legacy bool, restore func()) {
+	return false, func() {}
+}
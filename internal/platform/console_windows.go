@@ -0,0 +1,53 @@
+package platform
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// cpUTF8 is the UTF-8 console code page
+const cpUTF8 = 65001
+
+// EnableConsoleUTF8 switches the console bgit writes to to UTF-8 (code page
+// 65001), so the symbols in its own output and the output of git and ssh
+// render instead of turning into mojibake, and turns on virtual terminal
+// processing so ANSI colors aren't printed as escape codes. It reports
+// whether the console is a legacy one that can't process VT sequences
+// (before Windows 10, or with "Use legacy console" set), and returns a
+// function that puts back the console's code page and modes. Output that
+// isn't a console, such as a pipe or mintty, is left alone.
+func EnableConsoleUTF8() (legacy bool, restore func()) {
+	var restores []func()
+	isConsole := false
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(f.Fd())
+		var mode uint32
+		if windows.GetConsoleMode(handle, &mode) != nil {
+			continue
+		}
+		isConsole = true
+		if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+			continue
+		}
+		if windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) != nil {
+			legacy = true
+			continue
+		}
+		restores = append(restores, func() { windows.SetConsoleMode(handle, mode) })
+	}
+
+	if isConsole {
+		if cp, err := windows.GetConsoleOutputCP(); err == nil && cp != cpUTF8 {
+			if windows.SetConsoleOutputCP(cpUTF8) == nil {
+				restores = append(restores, func() { windows.SetConsoleOutputCP(cp) })
+			}
+		}
+	}
+
+	return legacy, func() {
+		for _, r := range restores {
+			r()
+		}
+	}
+}
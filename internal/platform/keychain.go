@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/byterings/bgit/internal/execx"
+)
+
+// keychainService groups bgit's secrets in the OS keychain
+const keychainService = "bgit"
+
+// ErrKeychainUnsupported is returned where bgit has no keychain to use
+var ErrKeychainUnsupported = errors.New("no supported OS keychain (macOS Keychain or Secret Service via secret-tool)")
+
+// KeychainGet returns the secret stored for account in the OS keychain
+func KeychainGet(account string) (string, error) {
+	var cmd *execx.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = execx.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	case runtime.GOOS == "linux":
+		cmd = execx.Command("secret-tool", "lookup", "service", keychainService, "account", account)
+	default:
+		return "", ErrKeychainUnsupported
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s' from the keychain: %w", account, err)
+	}
+	secret := strings.TrimRight(string(output), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("no '%s' secret in the keychain", account)
+	}
+	return secret, nil
+}
+
+// KeychainSet stores secret for account in the OS keychain, replacing any
+// previous value
+func KeychainSet(account, secret, label string) error {
+	var cmd *execx.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		// security only takes the secret as an argument, so pass the whole
+		// command on stdin to keep it out of ps and --show-commands
+		cmd = execx.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -w %s\n", keychainService, account, label, secret))
+	case runtime.GOOS == "linux":
+		cmd = execx.Command("secret-tool", "store", "--label="+label, "service", keychainService, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return ErrKeychainUnsupported
+	}
+
+	// Unlocking the keychain may ask the user to confirm
+	if err := cmd.WithTimeout(0).Run(); err != nil {
+		return fmt.Errorf("failed to store '%s' in the keychain: %w", account, err)
+	}
+	return nil
+}
+
+// KeychainDelete removes the secret stored for account, if any
+func KeychainDelete(account string) error {
+	var cmd *execx.Cmd
+	switch {
+	case runtime.GOOS == "darwin":
+		cmd = execx.Command("security", "delete-generic-password", "-s", keychainService, "-a", account)
+	case runtime.GOOS == "linux":
+		cmd = execx.Command("secret-tool", "clear", "service", keychainService, "account", account)
+	default:
+		return ErrKeychainUnsupported
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to remove '%s' from the keychain: %w", account, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	// lockWait is how long LockFile waits for another process to finish
+	lockWait = 5 * time.Second
+	// lockStale is the age after which a lock is assumed to be left over
+	// from a crashed process and taken over
+	lockStale = 10 * time.Minute
+)
+
+// FileLock is an exclusive lock on a file, held by creating <file>.lock the
+// way git locks its config. The new content is written to the lock file and
+// renamed over the original, so other readers never see a partial file.
+type FileLock struct {
+	path string // file being replaced, with symlinks resolved
+	file *os.File
+	done bool
+}
+
+// LockFile locks path for replacement, waiting briefly if another process
+// holds the lock. A symlinked path locks and replaces the link's target, so
+// dotfile managers keep their links.
+func LockFile(path string) (*FileLock, error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	lockPath := path + ".lock"
+
+	perm := os.FileMode(0600)
+	if runtime.GOOS == "windows" {
+		perm = 0644
+	}
+	if info, err := os.Stat(path); err == nil {
+		// Keep the mode of the file being replaced
+		perm = info.Mode().Perm()
+	}
+
+	deadline := time.Now().Add(lockWait)
+	for {
+		notifyWrite("lock", lockPath)
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if err == nil {
+			return &FileLock{path: path, file: file}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another process; if none is running, remove %s", path, lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Path returns the file the lock replaces
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// Commit writes data to the lock file and renames it over the locked file,
+// releasing the lock
+func (l *FileLock) Commit(data []byte) error {
+	if l.done {
+		return fmt.Errorf("lock on %s already released", l.path)
+	}
+	l.done = true
+	lockPath := l.file.Name()
+
+	notifyWrite("write", l.path)
+	if _, err := l.file.Write(data); err != nil {
+		l.file.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		os.Remove(lockPath)
+		return err
+	}
+	if err := l.file.Close(); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	if err := os.Rename(lockPath, l.path); err != nil {
+		os.Remove(lockPath)
+		return err
+	}
+	return nil
+}
+
+// Unlock releases the lock without changing the file; it does nothing after
+// Commit, so it can be deferred
+func (l *FileLock) Unlock() {
+	if l.done {
+		return
+	}
+	l.done = true
+	l.file.Close()
+	os.Remove(l.file.Name())
+}
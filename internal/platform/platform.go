@@ -6,10 +6,19 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // GetSSHDir returns the SSH directory path for the current platform
 func GetSSHDir() (string, error) {
+	// Git for Windows' ssh reads $HOME/.ssh, which differs from
+	// %USERPROFILE%\.ssh when HOME is set
+	if runtime.GOOS == "windows" && DetectSSHEnvironment().Stack == SSHStackGitForWindows {
+		if home := os.Getenv("HOME"); home != "" {
+			return filepath.Join(home, ".ssh"), nil
+		}
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get home directory: %w", err)
@@ -26,8 +35,24 @@ func GetSSHConfigPath() (string, error) {
 	return filepath.Join(sshDir, "config"), nil
 }
 
+// writeObserver, when set, is called with every path written through this package
+var writeObserver func(op, path string)
+
+// SetWriteObserver registers a function told about every directory created and
+// file written by the platform helpers, so callers can log file system changes
+func SetWriteObserver(fn func(op, path string)) {
+	writeObserver = fn
+}
+
+func notifyWrite(op, path string) {
+	if writeObserver != nil {
+		writeObserver(op, path)
+	}
+}
+
 // MkdirSecure creates a directory with appropriate permissions for the platform
 func MkdirSecure(path string) error {
+	notifyWrite("mkdir", path)
 	if runtime.GOOS == "windows" {
 		// Windows doesn't use Unix permissions
 		return os.MkdirAll(path, 0755)
@@ -38,6 +63,7 @@ func MkdirSecure(path string) error {
 
 // CreateFileSecure creates a file with appropriate permissions for the platform
 func CreateFileSecure(path string, data []byte) error {
+	notifyWrite("write", path)
 	if runtime.GOOS == "windows" {
 		// Windows doesn't use Unix permissions
 		return os.WriteFile(path, data, 0644)
@@ -48,6 +74,7 @@ func CreateFileSecure(path string, data []byte) error {
 
 // OpenFileSecure opens a file for writing with appropriate permissions
 func OpenFileSecure(path string, flag int) (*os.File, error) {
+	notifyWrite("open", path)
 	if runtime.GOOS == "windows" {
 		return os.OpenFile(path, flag, 0644)
 	}
@@ -55,12 +82,17 @@ func OpenFileSecure(path string, flag int) (*os.File, error) {
 	return os.OpenFile(path, flag, 0600)
 }
 
-// CheckFilePermissions checks if a file has secure permissions (Unix only)
-// Returns true if permissions are OK, false if they need fixing
+// CheckFilePermissions checks if a file has secure permissions: no access for
+// group/other on Unix, no ACL entries beyond the owner, SYSTEM, and
+// Administrators on Windows. Returns true if permissions are OK, false if they
+// need fixing
 func CheckFilePermissions(path string) (bool, error) {
 	if runtime.GOOS == "windows" {
-		// Windows doesn't use Unix permissions, always return true
-		return true, nil
+		broad, err := BroadACLPrincipals(path)
+		if err != nil {
+			return false, err
+		}
+		return len(broad) == 0, nil
 	}
 
 	info, err := os.Stat(path)
@@ -76,11 +108,11 @@ func CheckFilePermissions(path string) (bool, error) {
 	return true, nil
 }
 
-// FixFilePermissions sets secure permissions on a file (Unix only)
+// FixFilePermissions sets secure permissions on a file: 600 on Unix, an ACL
+// granting only the current user on Windows
 func FixFilePermissions(path string) error {
 	if runtime.GOOS == "windows" {
-		// Windows doesn't use Unix permissions, no-op
-		return nil
+		return fixWindowsACL(path)
 	}
 	return os.Chmod(path, 0600)
 }
@@ -88,7 +120,7 @@ func FixFilePermissions(path string) error {
 // GetPermissionFixCommand returns the appropriate command to fix file permissions
 func GetPermissionFixCommand(path string) string {
 	if runtime.GOOS == "windows" {
-		return "File permissions are not applicable on Windows"
+		return windowsACLFixCommand(path)
 	}
 	return fmt.Sprintf("chmod 600 %s", path)
 }
@@ -151,6 +183,16 @@ func GetConfigDirName() string {
 	return ".bgit"
 }
 
+// UsesAppleKeychain reports whether ssh-add is Apple's build, which can keep
+// key passphrases in the macOS keychain (Homebrew's OpenSSH can't)
+func UsesAppleKeychain() bool {
+	if runtime.GOOS != "darwin" {
+		return false
+	}
+	path, err := exec.LookPath("ssh-add")
+	return err == nil && path == "/usr/bin/ssh-add"
+}
+
 // GetPlatformName returns a user-friendly platform name
 func GetPlatformName() string {
 	switch runtime.GOOS {
@@ -188,3 +230,62 @@ func GetConfigFilePath() string {
 	}
 	return filepath.Join(home, GetConfigDirName(), "config.toml")
 }
+
+// CanonicalPath returns an absolute, symlink-free form of path for comparison.
+// Components that don't exist yet are kept as written after the deepest
+// existing ancestor is resolved.
+func CanonicalPath(path string) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	rest := ""
+	current := absPath
+	for {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return absPath
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
+
+// CaseInsensitivePaths reports whether the platform's default filesystems
+// ignore case (macOS and Windows)
+func CaseInsensitivePaths() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
+
+// PathKey returns the form of path used to compare paths: canonical, and
+// case-folded where the filesystem ignores case
+func PathKey(path string) string {
+	key := CanonicalPath(path)
+	if CaseInsensitivePaths() {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+// SamePath reports whether two paths refer to the same location
+func SamePath(a, b string) bool {
+	return PathKey(a) == PathKey(b)
+}
+
+// IsPathInside reports whether child is parent or lies beneath it, after
+// resolving symlinks and (on macOS and Windows) ignoring case
+func IsPathInside(child, parent string) bool {
+	childKey := PathKey(child)
+	parentKey := PathKey(parent)
+	if childKey == parentKey {
+		return true
+	}
+	if !strings.HasSuffix(parentKey, string(filepath.Separator)) {
+		parentKey += string(filepath.Separator)
+	}
+	return strings.HasPrefix(childKey, parentKey)
+}
@@ -0,0 +1,162 @@
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/byterings/bgit/internal/execx"
+)
+
+// SSHStack identifies which OpenSSH build git runs. On Windows, Git for
+// Windows bundles its own ssh (an MSYS build that reads $HOME/.ssh and uses an
+// agent reached through SSH_AUTH_SOCK) alongside the Windows OpenSSH client
+// (which reads %USERPROFILE%\.ssh and uses the ssh-agent service).
+type SSHStack string
+
+const (
+	SSHStackOpenSSH        SSHStack = "OpenSSH"
+	SSHStackWindowsOpenSSH SSHStack = "Windows OpenSSH"
+	SSHStackGitForWindows  SSHStack = "Git for Windows OpenSSH"
+	SSHStackCustom         SSHStack = "custom"
+)
+
+// SSHEnvironment describes the ssh client git uses and its tools
+type SSHEnvironment struct {
+	Stack  SSHStack
+	SSH    string // ssh client command or path
+	SSHAdd string // ssh-add from the same installation
+	Source string // how git picks it: GIT_SSH_COMMAND, GIT_SSH, core.sshCommand, bundled, or PATH
+}
+
+var (
+	sshEnvOnce sync.Once
+	sshEnv     SSHEnvironment
+)
+
+// DetectSSHEnvironment returns the ssh stack git uses, following git's own
+// order: GIT_SSH_COMMAND, GIT_SSH, core.sshCommand, then the client on PATH
+// (Git for Windows prefers its bundled ssh). The result is cached.
+func DetectSSHEnvironment() SSHEnvironment {
+	sshEnvOnce.Do(func() {
+		sshEnv = detectSSHEnvironment()
+	})
+	return sshEnv
+}
+
+// SSHCommand returns the OpenSSH client git uses, for running ssh the way git
+// would. With a custom (non-OpenSSH) client it falls back to ssh on PATH.
+func SSHCommand() string {
+	env := DetectSSHEnvironment()
+	if env.Stack == SSHStackCustom {
+		return "ssh"
+	}
+	return env.SSH
+}
+
+// SSHAddCommand returns the ssh-add that talks to the same agent as git's ssh
+func SSHAddCommand() string {
+	return DetectSSHEnvironment().SSHAdd
+}
+
+// UsesAgentService reports whether git's ssh uses the Windows ssh-agent
+// service (Windows OpenSSH) rather than an agent found via SSH_AUTH_SOCK
+func UsesAgentService() bool {
+	return DetectSSHEnvironment().Stack == SSHStackWindowsOpenSSH
+}
+
+func detectSSHEnvironment() SSHEnvironment {
+	if cmd := os.Getenv("GIT_SSH_COMMAND"); cmd != "" {
+		return sshEnvironmentFor(firstCommandWord(cmd), "GIT_SSH_COMMAND")
+	}
+	if cmd := os.Getenv("GIT_SSH"); cmd != "" {
+		return sshEnvironmentFor(cmd, "GIT_SSH")
+	}
+	if output, err := execx.Command("git", "config", "--get", "core.sshCommand").Output(); err == nil {
+		if cmd := strings.TrimSpace(string(output)); cmd != "" {
+			return sshEnvironmentFor(firstCommandWord(cmd), "core.sshCommand")
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		if bundled := bundledGitSSH(); bundled != "" {
+			return sshEnvironmentFor(bundled, "bundled")
+		}
+	}
+	if path, err := exec.LookPath("ssh"); err == nil {
+		return sshEnvironmentFor(path, "PATH")
+	}
+	return SSHEnvironment{Stack: SSHStackOpenSSH, SSH: "ssh", SSHAdd: "ssh-add", Source: "PATH"}
+}
+
+// sshEnvironmentFor classifies an ssh client path and finds its ssh-add
+func sshEnvironmentFor(sshPath, source string) SSHEnvironment {
+	env := SSHEnvironment{Stack: classifySSH(sshPath), SSH: sshPath, SSHAdd: "ssh-add", Source: source}
+
+	name := strings.ToLower(filepath.Base(sshPath))
+	if name != "ssh" && name != "ssh.exe" {
+		// plink, a wrapper script, etc.: keep the default ssh-add
+		return env
+	}
+	if filepath.IsAbs(sshPath) {
+		sshAdd := filepath.Join(filepath.Dir(sshPath), "ssh-add"+filepath.Ext(sshPath))
+		if _, err := os.Stat(sshAdd); err == nil {
+			env.SSHAdd = sshAdd
+		}
+	}
+	return env
+}
+
+// classifySSH identifies the OpenSSH build an ssh client path belongs to
+func classifySSH(sshPath string) SSHStack {
+	name := strings.ToLower(filepath.Base(sshPath))
+	if name != "ssh" && name != "ssh.exe" {
+		return SSHStackCustom
+	}
+	if runtime.GOOS != "windows" {
+		return SSHStackOpenSSH
+	}
+
+	lower := strings.ToLower(filepath.ToSlash(sshPath))
+	switch {
+	case strings.Contains(lower, "/system32/openssh/"), strings.Contains(lower, "/program files/openssh"):
+		return SSHStackWindowsOpenSSH
+	case strings.Contains(lower, "/usr/bin/"):
+		return SSHStackGitForWindows
+	}
+	return SSHStackOpenSSH
+}
+
+// bundledGitSSH returns the ssh.exe shipped with Git for Windows, found
+// relative to git's exec path (<root>\mingw64\libexec\git-core), or ""
+func bundledGitSSH() string {
+	output, err := execx.Command("git", "--exec-path").Output()
+	if err != nil {
+		return ""
+	}
+	execPath := filepath.FromSlash(strings.TrimSpace(string(output)))
+	root := filepath.Dir(filepath.Dir(filepath.Dir(execPath)))
+	ssh := filepath.Join(root, "usr", "bin", "ssh.exe")
+	if _, err := os.Stat(ssh); err != nil {
+		return ""
+	}
+	return ssh
+}
+
+// firstCommandWord returns the program of a shell command line, allowing a
+// quoted path such as "C:/Program Files/OpenSSH/ssh.exe" -i key
+func firstCommandWord(cmd string) string {
+	cmd = strings.TrimSpace(cmd)
+	if strings.HasPrefix(cmd, `"`) || strings.HasPrefix(cmd, `'`) {
+		if end := strings.IndexByte(cmd[1:], cmd[0]); end >= 0 {
+			return cmd[1 : end+1]
+		}
+	}
+	if fields := strings.Fields(cmd); len(fields) > 0 {
+		return fields[0]
+	}
+	return cmd
+}
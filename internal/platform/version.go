@@ -0,0 +1,45 @@
+package platform
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Version is a parsed major.minor.patch tool version
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion extracts the first dotted version number from a string,
+// e.g. "git version 2.39.2" or "OpenSSH_9.2p1 Debian-2"
+func ParseVersion(s string) (Version, error) {
+	matches := versionPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return Version{}, fmt.Errorf("no version found in %q", s)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	if matches[3] != "" {
+		v.Patch, _ = strconv.Atoi(matches[3])
+	}
+	return v, nil
+}
+
+// AtLeast reports whether v is greater than or equal to major.minor
+func (v Version) AtLeast(major, minor int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.Minor >= minor
+}
+
+// String returns the version as major.minor.patch
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
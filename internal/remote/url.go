@@ -0,0 +1,67 @@
+package remote
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind identifies the format of a GitHub remote URL
+type Kind string
+
+const (
+	KindHTTPS Kind = "https"
+	KindSSH   Kind = "ssh"
+	KindBgit  Kind = "bgit" // git@github.com-<user>:owner/repo.git
+)
+
+var (
+	// Pattern for HTTPS: https://github.com/owner/repo.git
+	httpsPattern = regexp.MustCompile(`^https?://github\.com/([^/]+)/(.+?)(?:\.git)?/?$`)
+
+	// Pattern for SSH: git@github.com:owner/repo.git
+	sshPattern = regexp.MustCompile(`^git@github\.com:([^/]+)/(.+?)(?:\.git)?$`)
+
+	// Pattern for bgit format: git@github.com-user:owner/repo.git
+	bgitPattern = regexp.MustCompile(`^git@github\.com-([^:]+):([^/]+)/(.+?)(?:\.git)?$`)
+)
+
+// URL is a parsed GitHub remote URL
+type URL struct {
+	Kind     Kind
+	Owner    string // Repository owner (user or organization)
+	Repo     string // Repository name without .git
+	HostUser string // GitHub username from the bgit host alias (KindBgit only)
+}
+
+// Parse parses a GitHub HTTPS, SSH, or bgit host-alias URL
+func Parse(url string) (*URL, error) {
+	url = strings.TrimSpace(url)
+
+	if matches := bgitPattern.FindStringSubmatch(url); matches != nil {
+		return &URL{Kind: KindBgit, HostUser: matches[1], Owner: matches[2], Repo: strings.TrimSuffix(matches[3], ".git")}, nil
+	}
+	if matches := sshPattern.FindStringSubmatch(url); matches != nil {
+		return &URL{Kind: KindSSH, Owner: matches[1], Repo: strings.TrimSuffix(matches[2], ".git")}, nil
+	}
+	if matches := httpsPattern.FindStringSubmatch(url); matches != nil {
+		return &URL{Kind: KindHTTPS, Owner: matches[1], Repo: strings.TrimSuffix(matches[2], ".git")}, nil
+	}
+
+	return nil, fmt.Errorf("unrecognized URL format: %s", url)
+}
+
+// BgitURL returns the SSH URL using the host alias for the given GitHub username
+func (u *URL) BgitURL(hostUser string) string {
+	return fmt.Sprintf("git@github.com-%s:%s/%s.git", hostUser, u.Owner, u.Repo)
+}
+
+// StandardURL returns the standard GitHub SSH URL
+func (u *URL) StandardURL() string {
+	return fmt.Sprintf("git@github.com:%s/%s.git", u.Owner, u.Repo)
+}
+
+// HTTPSURL returns the GitHub HTTPS URL
+func (u *URL) HTTPSURL() string {
+	return fmt.Sprintf("https://github.com/%s/%s.git", u.Owner, u.Repo)
+}
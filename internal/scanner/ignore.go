@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the per-directory ignore file read during scans
+const IgnoreFileName = ".bgitignore"
+
+// IgnoreRule is a single ignore pattern
+//
+// A pattern without a slash matches a directory name at any depth, like
+// "build" or "*.bak". In a .bgitignore (Base set), a pattern containing a slash
+// is matched against the path relative to that directory, like "mirrors/old"
+// or "/archive". In config (Base empty), absolute patterns such as "/mnt/*"
+// match the full path and relative ones match its trailing components.
+type IgnoreRule struct {
+	Base    string
+	Pattern string
+}
+
+// ParseIgnore builds rules from pattern lines. Blank lines and lines starting
+// with # are skipped, a trailing slash is dropped, and ~ is expanded.
+func ParseIgnore(base string, lines []string) []IgnoreRule {
+	var rules []IgnoreRule
+	for _, line := range lines {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+
+		if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				pattern = filepath.ToSlash(home) + strings.TrimPrefix(pattern, "~")
+			}
+		}
+
+		rule := IgnoreRule{Pattern: pattern}
+		if base != "" && strings.Contains(pattern, "/") {
+			rule.Base = base
+			rule.Pattern = strings.TrimPrefix(pattern, "/")
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// LoadIgnoreFile reads the .bgitignore in dir, if there is one
+func LoadIgnoreFile(dir string) ([]IgnoreRule, error) {
+	f, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ParseIgnore(dir, lines), nil
+}
+
+// Ignored reports whether any rule matches the directory at path
+func Ignored(rules []IgnoreRule, path string) bool {
+	for _, rule := range rules {
+		if rule.matches(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r IgnoreRule) matches(path string) bool {
+	path = filepath.ToSlash(path)
+	switch {
+	case !strings.Contains(r.Pattern, "/"):
+		ok, _ := filepath.Match(r.Pattern, filepath.Base(path))
+		return ok
+	case r.Base != "":
+		rel, err := filepath.Rel(r.Base, filepath.FromSlash(path))
+		if err != nil {
+			return false
+		}
+		ok, _ := filepath.Match(r.Pattern, filepath.ToSlash(rel))
+		return ok
+	case filepath.IsAbs(filepath.FromSlash(r.Pattern)):
+		ok, _ := filepath.Match(r.Pattern, path)
+		return ok
+	default:
+		// Match the pattern against the same number of trailing path components
+		parts := strings.Split(path, "/")
+		n := strings.Count(r.Pattern, "/") + 1
+		if n > len(parts) {
+			return false
+		}
+		ok, _ := filepath.Match(r.Pattern, strings.Join(parts[len(parts)-n:], "/"))
+		return ok
+	}
+}
@@ -0,0 +1,254 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// commonDirs are directories under $HOME that usually contain repositories
+var commonDirs = []string{"Documents", "Projects", "repos", "src", "code", "work", "dev", "git"}
+
+// skipDirs are directory names that are never descended into
+var skipDirs = []string{"node_modules", "vendor", ".cache", ".local", "snap", ".npm", ".cargo"}
+
+// ErrTimeout is returned with partial results when a scan exceeds its timeout
+var ErrTimeout = errors.New("scan timed out")
+
+// Default scan settings
+const (
+	DefaultWorkers   = 8
+	DefaultTimeout   = 2 * time.Minute
+	progressInterval = 200 * time.Millisecond
+)
+
+// Options control a repository scan
+type Options struct {
+	Workers  int           // Concurrent directory readers (default DefaultWorkers)
+	Timeout  time.Duration // Overall limit; zero means no limit
+	Skip     []string      // Extra directory names to skip, in addition to the built-in list
+	Ignore   []string      // Ignore patterns applied everywhere (see ParseIgnore)
+	Progress func(dirs, repos int)
+}
+
+// DefaultRoots returns the home directory plus common code directories that exist
+func DefaultRoots(home string) []string {
+	roots := []string{home}
+	for _, dir := range commonDirs {
+		fullPath := filepath.Join(home, dir)
+		if info, err := os.Stat(fullPath); err == nil && info.IsDir() {
+			roots = append(roots, fullPath)
+		}
+	}
+	return roots
+}
+
+// FindRepos walks the given roots and returns the root path of every git repository found
+// Each repository is reported once even if roots overlap
+func FindRepos(roots []string) []string {
+	repos, _ := Find(roots, Options{})
+	return repos
+}
+
+// Find walks the given roots with a pool of workers and returns the sorted
+// root paths of every git repository found. When the timeout is reached the
+// repositories found so far are returned along with ErrTimeout.
+func Find(roots []string, opts Options) ([]string, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), opts.Timeout)
+	}
+	defer cancel()
+
+	w := &walker{
+		ctx:     ctx,
+		skip:    make(map[string]bool),
+		visited: make(map[string]bool),
+		found:   make(map[string]bool),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	for _, name := range append(append([]string{}, skipDirs...), opts.Skip...) {
+		w.skip[name] = true
+	}
+	rules := ParseIgnore("", opts.Ignore)
+	for _, root := range roots {
+		w.push(dirTask{path: filepath.Clean(root), rules: rules})
+	}
+
+	// Wake idle workers when the timeout fires; exits on cancel once the walk is done
+	go func() {
+		<-ctx.Done()
+		w.mu.Lock()
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}()
+
+	stopProgress := make(chan struct{})
+	var progressDone sync.WaitGroup
+	if opts.Progress != nil {
+		progressDone.Add(1)
+		go func() {
+			defer progressDone.Done()
+			ticker := time.NewTicker(progressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					opts.Progress(int(w.dirs.Load()), w.repoCount())
+				case <-stopProgress:
+					return
+				}
+			}
+		}()
+	}
+
+	var workers sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			w.work()
+		}()
+	}
+	workers.Wait()
+
+	close(stopProgress)
+	progressDone.Wait()
+	if opts.Progress != nil {
+		opts.Progress(int(w.dirs.Load()), w.repoCount())
+	}
+
+	repos := make([]string, 0, len(w.found))
+	for repo := range w.found {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return repos, ErrTimeout
+	}
+	return repos, nil
+}
+
+// walker holds the shared state of a concurrent directory walk
+type walker struct {
+	ctx  context.Context
+	skip map[string]bool
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []dirTask
+	pending int // Directories queued or being read
+	visited map[string]bool
+	found   map[string]bool
+
+	dirs atomic.Int64
+}
+
+// dirTask is a queued directory with the ignore rules that apply inside it
+type dirTask struct {
+	path  string
+	rules []IgnoreRule
+}
+
+// push queues a directory unless it was already queued
+func (w *walker) push(task dirTask) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visited[task.path] {
+		return
+	}
+	w.visited[task.path] = true
+	w.queue = append(w.queue, task)
+	w.pending++
+	w.cond.Signal()
+}
+
+// work reads directories from the queue until the walk finishes or times out
+func (w *walker) work() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && w.pending > 0 && w.ctx.Err() == nil {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 || w.ctx.Err() != nil {
+			w.mu.Unlock()
+			return
+		}
+		task := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		w.readDir(task)
+
+		w.mu.Lock()
+		w.pending--
+		if w.pending == 0 {
+			w.cond.Broadcast()
+		}
+		w.mu.Unlock()
+	}
+}
+
+// readDir records a directory as a repository if it has a .git directory and
+// queues its subdirectories that are not skipped or ignored
+func (w *walker) readDir(task dirTask) {
+	w.dirs.Add(1)
+	dir := task.path
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	rules := task.rules
+	if local, err := LoadIgnoreFile(dir); err == nil && len(local) > 0 {
+		// Copy so sibling directories don't share the appended rules
+		rules = append(rules[:len(rules):len(rules)], local...)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+
+		if name == ".git" {
+			w.mu.Lock()
+			w.found[dir] = true
+			w.mu.Unlock()
+			continue // Don't descend into .git
+		}
+
+		if w.skip[name] {
+			continue
+		}
+
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if Ignored(rules, path) {
+			continue
+		}
+
+		w.push(dirTask{path: path, rules: rules})
+	}
+}
+
+func (w *walker) repoCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.found)
+}
@@ -0,0 +1,117 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// ConnectionStatus classifies the outcome of an authentication test
+type ConnectionStatus int
+
+const (
+	ConnectionAuthenticated ConnectionStatus = iota
+	ConnectionPermissionDenied
+	ConnectionHostKeyFailed // github.com's host key is unknown or doesn't match known_hosts
+	ConnectionFailed        // Refused, unreachable, or DNS failure
+	ConnectionTimedOut
+	ConnectionCanceled // The context was canceled, e.g. by Ctrl-C
+	ConnectionUnknown
+)
+
+// ConnectionResult is the outcome of TestConnection
+type ConnectionResult struct {
+	Status  ConnectionStatus
+	Account string // GitHub login that accepted the key (authenticated only)
+	Output  string // ssh's combined output
+}
+
+// GitHubHostKeyFingerprints are the SHA256 fingerprints GitHub publishes for
+// github.com, for checking an unknown host key by hand
+var GitHubHostKeyFingerprints = []string{
+	"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU (ED25519)",
+	"SHA256:p2QAMXNIC1TJYWeIOttrVc98/R1BUFWu3/LiyKgUfQM (ECDSA)",
+	"SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s (RSA)",
+}
+
+// defaultConnectTimeout is ssh's ConnectTimeout, in seconds, when the context
+// has no deadline
+const defaultConnectTimeout = 10
+
+// greetingPattern matches GitHub's reply to ssh -T, e.g.
+// "Hi octocat! You've successfully authenticated, but GitHub does not provide shell access."
+var greetingPattern = regexp.MustCompile(`Hi ([A-Za-z0-9-]+)! You've successfully authenticated`)
+
+// TestConnection runs an authenticated 'ssh -T' against a host alias (e.g.
+// github.com-octocat) and reports which GitHub account answered. Host keys
+// are verified against known_hosts and nothing is prompted for, so an
+// unknown or changed github.com key fails instead of being accepted.
+//
+// ssh is killed when ctx ends; its ConnectTimeout is set from ctx's deadline
+// so a slow connect is reported by ssh itself where possible.
+func TestConnection(ctx context.Context, host string) ConnectionResult {
+	connectTimeout := defaultConnectTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		connectTimeout = int(time.Until(deadline) / time.Second)
+		if connectTimeout < 1 {
+			connectTimeout = 1
+		}
+	}
+
+	args := []string{
+		"-T",
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "ConnectTimeout=" + strconv.Itoa(connectTimeout),
+		"git@" + host,
+	}
+	if configPath, err := platform.GetSSHConfigPath(); err == nil && fileExists(configPath) {
+		args = append([]string{"-F", configPath}, args...)
+	}
+	output, _ := ui.CommandContext(ctx, platform.SSHCommand(), args...).CombinedOutput()
+	result := ConnectionResult{Output: strings.TrimSpace(string(output))}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Status = ConnectionTimedOut
+		return result
+	case errors.Is(ctx.Err(), context.Canceled):
+		result.Status = ConnectionCanceled
+		return result
+	}
+	if m := greetingPattern.FindStringSubmatch(result.Output); m != nil {
+		result.Status = ConnectionAuthenticated
+		result.Account = m[1]
+		return result
+	}
+
+	switch {
+	case strings.Contains(result.Output, "Host key verification failed"),
+		strings.Contains(result.Output, "REMOTE HOST IDENTIFICATION HAS CHANGED"):
+		result.Status = ConnectionHostKeyFailed
+	case strings.Contains(result.Output, "Permission denied"):
+		result.Status = ConnectionPermissionDenied
+	case strings.Contains(result.Output, "timed out"):
+		result.Status = ConnectionTimedOut
+	case strings.Contains(result.Output, "Connection refused"),
+		strings.Contains(result.Output, "Could not resolve hostname"),
+		strings.Contains(result.Output, "Network is unreachable"),
+		strings.Contains(result.Output, "Connection closed"):
+		result.Status = ConnectionFailed
+	default:
+		result.Status = ConnectionUnknown
+	}
+	return result
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,51 @@
+package ssh
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// skHelperDirs are where OpenSSH packages install ssh-sk-helper, the
+// middleware that talks to FIDO2 authenticators via libfido2
+var skHelperDirs = []string{
+	"/usr/lib/openssh",
+	"/usr/libexec/openssh",
+	"/usr/libexec",
+	"/usr/local/libexec",
+	"/opt/homebrew/libexec",
+}
+
+// SupportsSecurityKeys reports whether the ssh client knows the FIDO2
+// (sk-*) key types
+func SupportsSecurityKeys() bool {
+	output, err := ui.Command(platform.SSHCommand(), "-Q", "key").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "sk-ssh-ed25519@openssh.com")
+}
+
+// SecurityKeyMiddleware returns the FIDO2 middleware ssh will use and
+// whether one was found. SSH_SK_PROVIDER overrides the built-in helper
+// (Apple's OpenSSH ships without one); Windows OpenSSH uses Windows Hello.
+func SecurityKeyMiddleware() (string, bool) {
+	if provider := os.Getenv("SSH_SK_PROVIDER"); provider != "" {
+		_, err := os.Stat(provider)
+		return provider, err == nil
+	}
+	if runtime.GOOS == "windows" {
+		return "Windows Hello", true
+	}
+	for _, dir := range skHelperDirs {
+		path := filepath.Join(dir, "ssh-sk-helper")
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hostBlockMarker matches the markers around one identity's host entry inside
+// the managed section: "# ---- BEGIN BGIT HOST work (frozen) ----"
+var hostBlockMarker = regexp.MustCompile(`(?i)^#\s*-*\s*(BEGIN|END)\s+BGIT\s+HOST\s+(\S+)(\s+\(frozen\))?\s*-*$`)
+
+// hostBlockStart returns the marker that opens an identity's host block
+func hostBlockStart(alias string, frozen bool) string {
+	if frozen {
+		return "# ---- BEGIN BGIT HOST " + alias + " (frozen) ----"
+	}
+	return "# ---- BEGIN BGIT HOST " + alias + " ----"
+}
+
+// hostBlockEnd returns the marker that closes an identity's host block
+func hostBlockEnd(alias string) string {
+	return "# ---- END BGIT HOST " + alias + " ----"
+}
+
+// hostBlocks returns the body of each identity's marked host block in a
+// managed section, keyed by alias
+func hostBlocks(section string) map[string]string {
+	blocks := make(map[string]string)
+	var alias string
+	var body []string
+	for _, line := range strings.Split(section, "\n") {
+		m := hostBlockMarker.FindStringSubmatch(strings.TrimSpace(line))
+		switch {
+		case m != nil && strings.EqualFold(m[1], "BEGIN"):
+			alias, body = m[2], nil
+		case m != nil && alias != "" && m[2] == alias:
+			blocks[alias] = trimBlock(body)
+			alias = ""
+		case alias != "":
+			body = append(body, line)
+		}
+	}
+	return blocks
+}
+
+// unmarkedHostEntry returns the entry for host in a managed section written
+// before host blocks were marked, from its Host line up to the next entry
+func unmarkedHostEntry(section, host string) string {
+	var body []string
+	for _, line := range strings.Split(section, "\n") {
+		trimmed := strings.TrimSpace(line)
+		key, value := splitDirective(trimmed)
+		isHost := strings.EqualFold(key, "host")
+		if body == nil {
+			if patterns := strings.Fields(value); isHost && len(patterns) > 0 && patterns[0] == host {
+				body = []string{line}
+			}
+			continue
+		}
+		if isHost || isManagedEnd(trimmed) || hostBlockMarker.MatchString(trimmed) {
+			break
+		}
+		body = append(body, line)
+	}
+	return trimBlock(body)
+}
+
+// trimBlock joins a block's lines without trailing blank lines
+func trimBlock(lines []string) string {
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n \t")
+}
@@ -0,0 +1,158 @@
+package ssh
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// HostEntry is a Host block in the SSH config outside the bgit-managed section
+type HostEntry struct {
+	Line          int               // 1-based line number of the Host directive
+	Patterns      []string          // Host patterns, including negated ones
+	Options       map[string]string // First value of each option, keyed by lowercase name
+	IdentityFiles []string          // All IdentityFile values, in order
+}
+
+// Matches reports whether this entry applies to the given host alias,
+// following ssh_config pattern rules (* and ? wildcards, ! negation)
+func (e HostEntry) Matches(host string) bool {
+	matched := false
+	for _, pattern := range e.Patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		ok, err := filepath.Match(strings.ToLower(pattern), strings.ToLower(host))
+		if err != nil || !ok {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// FindUnmanagedHostEntries parses Host blocks that are not inside a bgit-managed section
+// Directives before the first Host line apply to every host and are returned with pattern "*"
+func FindUnmanagedHostEntries(content string) []HostEntry {
+	var entries []HostEntry
+	var current *HostEntry
+	managed := managedLines(content, FindManagedSections(content))
+
+	global := HostEntry{Line: 0, Patterns: []string{"*"}, Options: map[string]string{}}
+	current = &global
+
+	for i, rawLine := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		line := strings.TrimSpace(rawLine)
+
+		if managed[i] {
+			if current != nil && isManagedStart(line) {
+				entries = appendEntry(entries, current)
+				current = nil
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value := splitDirective(line)
+		switch strings.ToLower(key) {
+		case "host":
+			entries = appendEntry(entries, current)
+			current = &HostEntry{Line: lineNum, Patterns: strings.Fields(value), Options: map[string]string{}}
+		case "match":
+			// Match blocks are conditional; they are not analyzed
+			entries = appendEntry(entries, current)
+			current = nil
+		default:
+			if current == nil {
+				continue
+			}
+			lower := strings.ToLower(key)
+			if lower == "identityfile" {
+				current.IdentityFiles = append(current.IdentityFiles, value)
+			}
+			if _, exists := current.Options[lower]; !exists {
+				current.Options[lower] = value
+			}
+		}
+	}
+	entries = appendEntry(entries, current)
+
+	return entries
+}
+
+func appendEntry(entries []HostEntry, entry *HostEntry) []HostEntry {
+	if entry == nil || (entry.Line == 0 && len(entry.Options) == 0) {
+		return entries
+	}
+	return append(entries, *entry)
+}
+
+// splitDirective splits an ssh_config line into keyword and argument
+// Both "Key value" and "Key=value" forms are accepted
+func splitDirective(line string) (string, string) {
+	line = strings.Replace(line, "=", " ", 1)
+	fields := strings.SplitN(line, " ", 2)
+	if len(fields) < 2 {
+		return fields[0], ""
+	}
+	return fields[0], strings.Trim(strings.TrimSpace(fields[1]), `"`)
+}
+
+// EffectiveHost is the configuration ssh resolves for a host alias
+type EffectiveHost struct {
+	HostName       string   // Real host name; the alias itself if no entry sets one
+	IdentityFiles  []string // Identity files in the order they would be tried
+	IdentitiesOnly bool     // Whether agent keys not listed in IdentityFiles are excluded
+}
+
+// GetEffectiveHost asks ssh how it would connect to a host alias.
+// Requires an OpenSSH client supporting -G.
+func GetEffectiveHost(host string) (*EffectiveHost, error) {
+	args := []string{"-G", host}
+	if configPath, err := platform.GetSSHConfigPath(); err == nil && fileExists(configPath) {
+		args = append([]string{"-F", configPath}, args...)
+	}
+	cmd := ui.Command(platform.SSHCommand(), args...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	eh := &EffectiveHost{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "hostname":
+			eh.HostName = fields[1]
+		case "identityfile":
+			path, err := platform.ExpandTilde(fields[1])
+			if err != nil {
+				path = fields[1]
+			}
+			eh.IdentityFiles = append(eh.IdentityFiles, path)
+		case "identitiesonly":
+			eh.IdentitiesOnly = fields[1] == "yes"
+		}
+	}
+	return eh, nil
+}
+
+// GetClientVersion returns the installed OpenSSH client version
+// ssh -V prints to stderr, e.g. "OpenSSH_9.2p1 Debian-2, OpenSSL 3.0.11"
+func GetClientVersion() (platform.Version, error) {
+	output, err := ui.Command(platform.SSHCommand(), "-V").CombinedOutput()
+	if err != nil {
+		return platform.Version{}, err
+	}
+	return platform.ParseVersion(string(output))
+}
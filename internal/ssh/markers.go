@@ -0,0 +1,168 @@
+package ssh
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+)
+
+// managedMarker matches every spelling of the markers bgit has written around
+// its section: BGIT and the older BRGIT, in any case, with any dashes or spacing
+var managedMarker = regexp.MustCompile(`(?i)^#\s*-*\s*(BEGIN|END)\s+(BGIT|BRGIT)\s+MANAGED\s*-*$`)
+
+// isManagedStart reports whether a line opens a current or legacy managed section
+func isManagedStart(line string) bool {
+	m := managedMarker.FindStringSubmatch(strings.TrimSpace(line))
+	return m != nil && strings.EqualFold(m[1], "BEGIN")
+}
+
+// isManagedEnd reports whether a line closes a current or legacy managed section
+func isManagedEnd(line string) bool {
+	m := managedMarker.FindStringSubmatch(strings.TrimSpace(line))
+	return m != nil && strings.EqualFold(m[1], "END")
+}
+
+// ManagedSection is a block of the SSH config between bgit markers
+type ManagedSection struct {
+	Start       int      // 1-based line of the BEGIN marker
+	End         int      // 1-based last line of the block
+	StartMarker string   // BEGIN marker as written
+	EndMarker   string   // END marker as written; empty if the block has none
+	Hosts       []string // Host patterns inside the block
+}
+
+// Terminated reports whether the block has its END marker
+func (s ManagedSection) Terminated() bool {
+	return s.EndMarker != ""
+}
+
+// Legacy reports whether the block uses the older BRGIT markers
+func (s ManagedSection) Legacy() bool {
+	return strings.Contains(strings.ToUpper(s.StartMarker), "BRGIT")
+}
+
+// Canonical reports whether the block uses the markers bgit writes today
+func (s ManagedSection) Canonical() bool {
+	return strings.TrimSpace(s.StartMarker) == bgitManagedStart && strings.TrimSpace(s.EndMarker) == bgitManagedEnd
+}
+
+// MarkerReport describes the bgit markers found in an SSH config
+type MarkerReport struct {
+	Sections  []ManagedSection
+	StrayEnds []int // 1-based lines of END markers with no BEGIN
+}
+
+// NeedsMigration reports whether the config has anything other than at most
+// one block with the current markers
+func (r MarkerReport) NeedsMigration() bool {
+	if len(r.StrayEnds) > 0 || len(r.Sections) > 1 {
+		return true
+	}
+	return len(r.Sections) == 1 && !r.Sections[0].Canonical()
+}
+
+// FindManagedSections locates every bgit block in an SSH config. A BEGIN
+// marker without a matching END marker ends before the next marker or the
+// first Host or Match entry bgit wouldn't have written, so a damaged block
+// never swallows the user's own entries after it.
+func FindManagedSections(content string) MarkerReport {
+	var report MarkerReport
+	var current *ManagedSection
+
+	closeUnterminated := func() {
+		if current != nil {
+			report.Sections = append(report.Sections, *current)
+			current = nil
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+
+	// endAhead[i] reports whether an END marker follows line i before the
+	// next BEGIN, i.e. whether a block open at line i is terminated
+	endAhead := make([]bool, len(lines)+1)
+	for i := len(lines) - 1; i >= 0; i-- {
+		switch {
+		case isManagedStart(lines[i]):
+			endAhead[i] = false
+		case isManagedEnd(lines[i]):
+			endAhead[i] = true
+		default:
+			endAhead[i] = endAhead[i+1]
+		}
+	}
+
+	for i, line := range lines {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case isManagedStart(trimmed):
+			closeUnterminated()
+			current = &ManagedSection{Start: lineNum, End: lineNum, StartMarker: trimmed}
+			continue
+		case isManagedEnd(trimmed):
+			if current == nil {
+				report.StrayEnds = append(report.StrayEnds, lineNum)
+				continue
+			}
+			current.End = lineNum
+			current.EndMarker = trimmed
+			report.Sections = append(report.Sections, *current)
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		key, value := splitDirective(trimmed)
+		switch strings.ToLower(key) {
+		case "host":
+			if !strings.HasPrefix(value, "github.com-") && !endAhead[i] {
+				// Not an entry bgit writes; an unterminated block ends here
+				closeUnterminated()
+				continue
+			}
+			current.Hosts = append(current.Hosts, value)
+		case "match":
+			if !endAhead[i] {
+				closeUnterminated()
+				continue
+			}
+		}
+		if trimmed != "" {
+			// Blank lines after an unterminated block stay outside it
+			current.End = lineNum
+		}
+	}
+	closeUnterminated()
+	return report
+}
+
+// managedLines marks the lines of content that belong to bgit blocks or are
+// stray END markers, indexed from 0
+func managedLines(content string, report MarkerReport) []bool {
+	managed := make([]bool, strings.Count(content, "\n")+1)
+	for _, s := range report.Sections {
+		for n := s.Start; n <= s.End; n++ {
+			managed[n-1] = true
+		}
+	}
+	for _, n := range report.StrayEnds {
+		managed[n-1] = true
+	}
+	return managed
+}
+
+// ManagedHosts returns the host aliases bgit generates for users
+func ManagedHosts(users []config.User) []string {
+	var hosts []string
+	for _, u := range users {
+		if u.HasSSHHost() {
+			hosts = append(hosts, GetHostForUser(u.GitHubUsername))
+		}
+	}
+	return hosts
+}
@@ -1,11 +1,12 @@
 package ssh
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/byterings/bgit/internal/config"
 	"github.com/byterings/bgit/internal/platform"
@@ -14,9 +15,6 @@ import (
 const (
 	bgitManagedStart = "# ---- BEGIN BGIT MANAGED ----"
 	bgitManagedEnd   = "# ---- END BGIT MANAGED ----"
-	// Legacy markers for migration from bgit
-	legacyManagedStart = "# ---- BEGIN BRGIT MANAGED ----"
-	legacyManagedEnd   = "# ---- END BRGIT MANAGED ----"
 )
 
 // GetSSHConfigPath returns the path to the SSH config file
@@ -26,46 +24,52 @@ func GetSSHConfigPath() (string, error) {
 
 // UpdateSSHConfig updates the SSH config with bgit-managed entries
 func UpdateSSHConfig(users []config.User) error {
-	configPath, err := GetSSHConfigPath()
+	overrides, err := config.LoadSSHOverrides()
 	if err != nil {
 		return err
 	}
 
-	// Ensure .ssh directory exists
-	sshDir := filepath.Dir(configPath)
-	if err := platform.MkdirSecure(sshDir); err != nil {
-		return fmt.Errorf("failed to create .ssh directory: %w", err)
-	}
-
-	// Read existing config
-	existingContent, err := readSSHConfig(configPath)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to read SSH config: %w", err)
-	}
-
-	// Remove old bgit-managed section
-	cleanedContent := removeBgitSection(existingContent)
+	return RewriteSSHConfig(func(existingContent string) (string, error) {
+		// Generate new bgit section, keeping frozen host blocks
+		bgitSection := generateBgitSection(users, overrides, ExtractManagedSection(existingContent))
 
-	// Generate new bgit section
-	bgitSection := generateBgitSection(users)
+		// Remove old bgit-managed section
+		cleanedContent := RemoveManagedSections(existingContent)
 
-	// Combine content
-	var newContent strings.Builder
-	if cleanedContent != "" {
-		newContent.WriteString(cleanedContent)
-		if !strings.HasSuffix(cleanedContent, "\n") {
+		// Combine content
+		var newContent strings.Builder
+		if cleanedContent != "" {
+			newContent.WriteString(cleanedContent)
+			if !strings.HasSuffix(cleanedContent, "\n") {
+				newContent.WriteString("\n")
+			}
 			newContent.WriteString("\n")
 		}
-		newContent.WriteString("\n")
+		newContent.WriteString(bgitSection)
+		return newContent.String(), nil
+	})
+}
+
+// PreviewManagedSection returns the current bgit-managed section of the SSH
+// config and the section UpdateSSHConfig would write, without changing anything
+func PreviewManagedSection(users []config.User) (current, proposed string, err error) {
+	configPath, err := GetSSHConfigPath()
+	if err != nil {
+		return "", "", err
 	}
-	newContent.WriteString(bgitSection)
 
-	// Write updated config
-	if err := platform.CreateFileSecure(configPath, []byte(newContent.String())); err != nil {
-		return fmt.Errorf("failed to write SSH config: %w", err)
+	existingContent, err := readSSHConfig(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	overrides, err := config.LoadSSHOverrides()
+	if err != nil {
+		return "", "", err
 	}
 
-	return nil
+	current = ExtractManagedSection(existingContent)
+	return current, generateBgitSection(users, overrides, current), nil
 }
 
 // readSSHConfig reads the SSH config file
@@ -77,57 +81,86 @@ func readSSHConfig(path string) (string, error) {
 	return string(content), nil
 }
 
-// removeBgitSection removes the bgit-managed section from SSH config
-// Also removes legacy bgit-managed sections for migration
-func removeBgitSection(content string) string {
-	scanner := bufio.NewScanner(strings.NewReader(content))
+// RemoveManagedSections removes every bgit block from an SSH config, with
+// current or legacy markers, along with stray END markers
+func RemoveManagedSections(content string) string {
+	managed := managedLines(content, FindManagedSections(content))
 	var result strings.Builder
-	inManagedSection := false
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmedLine := strings.TrimSpace(line)
-
-		// Check for current or legacy start markers
-		if trimmedLine == bgitManagedStart || trimmedLine == legacyManagedStart {
-			inManagedSection = true
+	previousBlank, removed := true, false
+	for i, line := range strings.Split(content, "\n") {
+		if managed[i] {
+			removed = true
 			continue
 		}
-
-		// Check for current or legacy end markers
-		if trimmedLine == bgitManagedEnd || trimmedLine == legacyManagedEnd {
-			inManagedSection = false
+		// Don't leave a double gap where a block was cut out
+		blank := strings.TrimSpace(line) == ""
+		if blank && previousBlank && removed {
 			continue
 		}
+		previousBlank, removed = blank, false
+		result.WriteString(line)
+		result.WriteString("\n")
+	}
+
+	return strings.TrimRight(result.String(), "\n")
+}
 
-		if !inManagedSection {
+// ExtractManagedSection returns only the bgit-managed lines of an SSH config,
+// including current and legacy markers
+func ExtractManagedSection(content string) string {
+	managed := managedLines(content, FindManagedSections(content))
+	var result strings.Builder
+	for i, line := range strings.Split(content, "\n") {
+		if managed[i] {
 			result.WriteString(line)
 			result.WriteString("\n")
 		}
 	}
 
-	return strings.TrimRight(result.String(), "\n")
+	return result.String()
 }
 
-// generateBgitSection generates the bgit-managed SSH config section
-func generateBgitSection(users []config.User) string {
+// generateBgitSection generates the bgit-managed SSH config section, merging
+// each identity's entries from the SSH overrides file. Every identity gets its
+// own marked host block; blocks frozen with "managed = false" are copied from
+// the current section instead of being regenerated.
+func generateBgitSection(users []config.User, overrides config.SSHOverrides, current string) string {
 	var section strings.Builder
 
 	section.WriteString(bgitManagedStart + "\n")
 	section.WriteString("# DO NOT EDIT THIS SECTION MANUALLY\n")
 	section.WriteString("# This section is managed by bgit\n")
+	section.WriteString("# Add your own directives in ~/.bgit/" + config.SSHOverridesFileName + ",\n")
+	section.WriteString("# or set managed = false there to freeze a host block and edit it here\n")
 	section.WriteString("\n")
 
+	existing := hostBlocks(current)
 	for _, user := range users {
-		if user.SSHKeyPath == "" {
+		if !user.HasSSHHost() {
 			continue // Skip users without SSH keys
 		}
 
-		section.WriteString(fmt.Sprintf("Host github.com-%s\n", user.GitHubUsername))
-		section.WriteString("  HostName github.com\n")
-		section.WriteString("  User git\n")
-		section.WriteString(fmt.Sprintf("  IdentityFile %s\n", platform.NormalizePathForSSHConfig(user.SSHKeyPath)))
-		section.WriteString("  IdentitiesOnly yes\n")
+		frozen := overrides.Frozen(user.Alias)
+		body := ""
+		if frozen {
+			body = existing[user.Alias]
+			if body == "" {
+				// Written before host blocks were marked
+				body = unmarkedHostEntry(current, GetHostForUser(user.GitHubUsername))
+			}
+		}
+		if body == "" {
+			var entry strings.Builder
+			entry.WriteString(fmt.Sprintf("Host github.com-%s", user.GitHubUsername))
+			for _, d := range overrides.Apply(user.Alias, hostDirectives(user)) {
+				entry.WriteString(fmt.Sprintf("\n  %s %s", d.Key, d.Value))
+			}
+			body = entry.String()
+		}
+
+		section.WriteString(hostBlockStart(user.Alias, frozen) + "\n")
+		section.WriteString(body + "\n")
+		section.WriteString(hostBlockEnd(user.Alias) + "\n")
 		section.WriteString("\n")
 	}
 
@@ -136,6 +169,74 @@ func generateBgitSection(users []config.User) string {
 	return section.String()
 }
 
+// hostDirectives returns the directives bgit generates for a user's host alias
+func hostDirectives(user config.User) []config.SSHDirective {
+	directives := []config.SSHDirective{
+		{Key: "HostName", Value: "github.com"},
+		{Key: "User", Value: "git"},
+	}
+	if user.IdentityAgent != "" {
+		directives = append(directives, config.SSHDirective{Key: "IdentityAgent", Value: quoteSSHConfigValue(platform.NormalizePathForSSHConfig(user.IdentityAgent))})
+	}
+	if user.SSHKeyPath != "" {
+		// With an external agent this is usually the public key, which
+		// tells ssh which of the agent's keys to offer
+		directives = append(directives,
+			config.SSHDirective{Key: "IdentityFile", Value: platform.NormalizePathForSSHConfig(user.SSHKeyPath)},
+			config.SSHDirective{Key: "IdentitiesOnly", Value: "yes"},
+		)
+		if runtime.GOOS == "darwin" && !user.UsesIdentityAgent() {
+			// Keep passphrases in the keychain; IgnoreUnknown keeps
+			// non-Apple OpenSSH builds (e.g. Homebrew) from rejecting UseKeychain
+			directives = append(directives,
+				config.SSHDirective{Key: "IgnoreUnknown", Value: "UseKeychain"},
+				config.SSHDirective{Key: "UseKeychain", Value: "yes"},
+			)
+			if value, ok := addKeysToAgentValue(user); ok {
+				directives = append(directives, config.SSHDirective{Key: "AddKeysToAgent", Value: value})
+			}
+		}
+	}
+	return directives
+}
+
+// addKeysToAgentValue returns the AddKeysToAgent setting matching the user's
+// agent_lifetime, so keys ssh loads itself expire like the ones bgit loads.
+// An "until HH:MM" lifetime has no fixed length to write, so ssh is left to
+// not add those keys at all.
+func addKeysToAgentValue(user config.User) (string, bool) {
+	if strings.HasPrefix(strings.TrimSpace(user.AgentLifetime), "until ") {
+		return "", false
+	}
+	lifetime, err := user.KeyLifetime(time.Now())
+	if err != nil || lifetime == 0 {
+		return "yes", true
+	}
+	return strconv.Itoa(int(lifetime / time.Second)), true
+}
+
+// quoteSSHConfigValue quotes a value containing spaces, such as the 1Password
+// agent socket under "~/Library/Group Containers"
+func quoteSSHConfigValue(value string) string {
+	if strings.ContainsAny(value, " \t") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// GitHub accepts SSH on port 443 of ssh.github.com for networks that block
+// port 22
+const (
+	GitHubSSHOver443Host = "ssh.github.com"
+	GitHubSSHOver443Port = "443"
+)
+
+// SSHOver443Command is a core.sshCommand that reaches GitHub through
+// ssh.github.com:443 while keeping the rest of a bgit host's settings
+func SSHOver443Command() string {
+	return "ssh -o HostName=" + GitHubSSHOver443Host + " -o Port=" + GitHubSSHOver443Port
+}
+
 // GetHostForUser returns the SSH host alias for a user
 func GetHostForUser(username string) string {
 	return fmt.Sprintf("github.com-%s", username)
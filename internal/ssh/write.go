@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+const (
+	// sshConfigBackupPrefix names copies of the SSH config in ~/.bgit/backups
+	sshConfigBackupPrefix = "ssh_config-"
+	// maxSSHConfigBackups is how many copies are kept; older ones are removed
+	maxSSHConfigBackups = 20
+)
+
+// RewriteSSHConfig replaces the SSH config with edit's result while holding
+// a lock on it, so concurrent bgit runs can't interleave their changes. The
+// previous version is copied to ~/.bgit/backups first. edit receives the
+// current content, empty if the file doesn't exist; nothing is written when
+// it returns the content unchanged.
+func RewriteSSHConfig(edit func(content string) (string, error)) error {
+	configPath, err := GetSSHConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := platform.MkdirSecure(filepath.Dir(configPath)); err != nil {
+		return fmt.Errorf("failed to create .ssh directory: %w", err)
+	}
+
+	lock, err := platform.LockFile(configPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	current, err := readSSHConfig(lock.Path())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read SSH config: %w", err)
+	}
+
+	updated, err := edit(current)
+	if err != nil {
+		return err
+	}
+	if updated == current {
+		return nil
+	}
+
+	if current != "" {
+		if err := backupSSHConfig(current); err != nil {
+			return fmt.Errorf("failed to back up SSH config: %w", err)
+		}
+	}
+	if err := lock.Commit([]byte(updated)); err != nil {
+		return fmt.Errorf("failed to write SSH config: %w", err)
+	}
+	return nil
+}
+
+// backupSSHConfig saves content as ~/.bgit/backups/ssh_config-<timestamp>
+// and prunes the oldest copies
+func backupSSHConfig(content string) error {
+	if err := config.CreateBackupDir(); err != nil {
+		return err
+	}
+	backupDir, err := config.GetBackupDir()
+	if err != nil {
+		return err
+	}
+
+	name := sshConfigBackupPrefix + time.Now().Format("20060102-150405")
+	file, err := platform.OpenFileSecure(filepath.Join(backupDir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if errors.Is(err, os.ErrExist) {
+		// Already backed up this second; that copy is the older one
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	// Timestamps sort by name, oldest first
+	backups, _ := filepath.Glob(filepath.Join(backupDir, sshConfigBackupPrefix+"*"))
+	sort.Strings(backups)
+	for len(backups) > maxSSHConfigBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// asciiReplacer swaps the symbols bgit prints for ASCII stand-ins
+var asciiReplacer = strings.NewReplacer(
+	"✓", "+",
+	"✗", "x",
+	"⚠", "!",
+	"ℹ", "i",
+	"→", "->",
+	"•", "*",
+	"█", "#",
+	"░", "-",
+	"─", "-",
+)
+
+var asciiMode = !unicodeTerminal()
+
+// SetASCII turns ASCII mode on or off. --ascii turns it on; otherwise it is
+// on for non-UTF-8 locales and legacy Windows consoles.
+func SetASCII(enabled bool) {
+	asciiMode = enabled
+}
+
+// ASCII reports whether symbols are printed as ASCII
+func ASCII() bool {
+	return asciiMode
+}
+
+// Text returns s with bgit's symbols (✓ ✗ ⚠ ℹ → • ─) replaced by ASCII in
+// ASCII mode
+func Text(s string) string {
+	if !asciiMode {
+		return s
+	}
+	return asciiReplacer.Replace(s)
+}
+
+// Printf is fmt.Printf for output with symbols
+func Printf(format string, args ...interface{}) {
+	fmt.Print(Text(fmt.Sprintf(format, args...)))
+}
+
+// Println is fmt.Println for output with symbols
+func Println(s string) {
+	fmt.Println(Text(s))
+}
+
+// unicodeTerminal reports whether the terminal is likely to show non-ASCII
+// symbols. On Unix that is a UTF-8 locale; an unset locale is assumed to be
+// UTF-8, as on most systems that don't set one. Windows consoles are switched
+// to UTF-8 by SetupConsole, which turns ASCII mode on for legacy ones.
+func unicodeTerminal() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return true
+}
+
+// ActiveMarker returns the mark for the active identity in lists: → or, in
+// ASCII mode, > so the columns stay aligned
+func ActiveMarker() string {
+	if asciiMode {
+		return ">"
+	}
+	return "→"
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// Color is an ANSI SGR color code
+type Color string
+
+// Colors used by bgit output
+const (
+	ColorRed    Color = "31"
+	ColorGreen  Color = "32"
+	ColorYellow Color = "33"
+	ColorCyan   Color = "36"
+)
+
+// Color modes accepted by --color
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+var colorMode = ColorAuto
+
+// SetColorMode sets when output is colored: "auto" colors terminals unless
+// NO_COLOR is set, TERM is dumb, or bgit runs in CI; "always" and "never"
+// force it on or off
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		colorMode = mode
+		return nil
+	default:
+		return fmt.Errorf("invalid color mode '%s' (use auto, always, or never)", mode)
+	}
+}
+
+// colorEnabled reports whether text written to f should be colored. A legacy
+// Windows console would print the escape codes, so "auto" leaves it plain.
+func colorEnabled(f *os.File) bool {
+	switch colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	// https://no-color.org: any non-empty value disables color
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || platform.IsCI() || legacyConsole {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// Colorize wraps s in the given color if output to f should be colored
+func Colorize(f *os.File, c Color, s string) string {
+	if !colorEnabled(f) {
+		return s
+	}
+	return "\033[" + string(c) + "m" + s + "\033[0m"
+}
+
+// Red colors text for stdout
+func Red(s string) string {
+	return Colorize(os.Stdout, ColorRed, s)
+}
+
+// Green colors text for stdout
+func Green(s string) string {
+	return Colorize(os.Stdout, ColorGreen, s)
+}
+
+// Yellow colors text for stdout
+func Yellow(s string) string {
+	return Colorize(os.Stdout, ColorYellow, s)
+}
+
+// Cyan colors text for stdout
+func Cyan(s string) string {
+	return Colorize(os.Stdout, ColorCyan, s)
+}
@@ -0,0 +1,27 @@
+package ui
+
+import "github.com/byterings/bgit/internal/platform"
+
+var (
+	legacyConsole  bool
+	restoreConsole = func() {}
+)
+
+// SetupConsole prepares the terminal before bgit prints anything. On Windows
+// it switches the console to UTF-8 and turns on VT processing; a legacy
+// console that can't process VT sequences gets no colors and ASCII symbols.
+// Call RestoreConsole before exiting.
+func SetupConsole() {
+	legacy, restore := platform.EnableConsoleUTF8()
+	restoreConsole = restore
+	if legacy {
+		legacyConsole = true
+		asciiMode = true
+	}
+}
+
+// RestoreConsole puts back the console settings SetupConsole changed
+func RestoreConsole() {
+	restoreConsole()
+	restoreConsole = func() {}
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the language bgit's messages are written in, and the one
+// used when the environment names no language bgit has a catalog for
+const DefaultLocale = "en"
+
+// catalogs maps a language to its translations, keyed by the English message.
+// A message missing from a catalog is printed in English.
+var catalogs = map[string]map[string]string{}
+
+var (
+	locale     string
+	localeOnce sync.Once
+)
+
+// registerCatalog adds the translations for a language; each catalog file
+// calls it from init
+func registerCatalog(lang string, messages map[string]string) {
+	catalogs[lang] = messages
+}
+
+// DetectLocale returns the language named by the environment, checking
+// LC_ALL, LC_MESSAGES, and LANG in the order POSIX gives them precedence:
+// "de_DE.UTF-8" is "de". "C", "POSIX", and an unset environment are English.
+func DetectLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return parseLocale(value)
+		}
+	}
+	return DefaultLocale
+}
+
+// parseLocale reduces a POSIX locale name (language[_territory][.codeset][@modifier])
+// to its lowercase language
+func parseLocale(value string) string {
+	lang := value
+	if i := strings.IndexAny(lang, "_.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == "c" || lang == "posix" {
+		return DefaultLocale
+	}
+	return lang
+}
+
+// Locale returns the language bgit prints messages in: the detected one when
+// a catalog exists for it, English otherwise
+func Locale() string {
+	localeOnce.Do(func() {
+		if locale == "" {
+			locale = DetectLocale()
+		}
+		if _, ok := catalogs[locale]; !ok {
+			locale = DefaultLocale
+		}
+	})
+	return locale
+}
+
+// SetLocale overrides the detected language; a language without a catalog
+// falls back to English
+func SetLocale(lang string) {
+	localeOnce = sync.Once{}
+	locale = parseLocale(lang)
+}
+
+// T translates an English message into the current language and, given
+// args, formats it like fmt.Sprintf. Translations keep the message's verbs
+// in the same order.
+func T(message string, args ...any) string {
+	if translated, ok := catalogs[Locale()][message]; ok {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
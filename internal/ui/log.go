@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/execx"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/redact"
+)
+
+// Level controls how much bgit prints
+type Level int
+
+// Output levels, from least to most output
+const (
+	LevelQuiet   Level = iota // Errors and requested output only
+	LevelNormal               // Default
+	LevelVerbose              // Extra detail about what bgit is doing
+	LevelDebug                // Verbose plus external commands and file writes, also logged to ~/.bgit/logs
+)
+
+// logDirName is the directory inside the bgit config directory holding debug logs
+const logDirName = "logs"
+
+var (
+	level    = LevelNormal
+	debugLog *os.File
+)
+
+// SetLevel sets the output level. At LevelDebug, file writes made through the
+// platform helpers and every external command execx starts, including those
+// platform runs itself, are logged as well.
+func SetLevel(l Level) {
+	level = l
+	if l >= LevelDebug {
+		platform.SetWriteObserver(func(op, path string) {
+			Debugf("%s %s", op, path)
+		})
+		execx.SetStartObserver(func(command string) {
+			Debugf("exec %s", command)
+		})
+	} else {
+		platform.SetWriteObserver(nil)
+		execx.SetStartObserver(nil)
+	}
+}
+
+// GetLevel returns the current output level
+func GetLevel() Level {
+	return level
+}
+
+// IsVerbose reports whether verbose (or debug) output is enabled
+func IsVerbose() bool {
+	return level >= LevelVerbose
+}
+
+// Verbose prints a detail message when --verbose or --debug is set
+func Verbose(message string) {
+	if level >= LevelVerbose {
+		fmt.Printf("  %s\n", message)
+	}
+}
+
+// Debugf prints a debug message to stderr and appends it to the debug log
+// when --debug is set, with tokens and key material masked
+func Debugf(format string, args ...interface{}) {
+	if level < LevelDebug {
+		return
+	}
+	message := redact.String(fmt.Sprintf(format, args...))
+	fmt.Fprintf(os.Stderr, "[debug] %s\n", message)
+
+	if f := openDebugLog(); f != nil {
+		fmt.Fprintf(f, "%s [%d] %s\n", time.Now().Format(time.RFC3339), os.Getpid(), message)
+	}
+}
+
+// Command returns an execx.Cmd for an external program. It is logged at
+// debug level when it starts (and traced with --show-commands).
+func Command(name string, args ...string) *execx.Cmd {
+	return execx.Command(name, args...)
+}
+
+// CommandContext is Command bound to a context, which kills the process when
+// the context is done
+func CommandContext(ctx context.Context, name string, args ...string) *execx.Cmd {
+	return execx.CommandContext(ctx, name, args...)
+}
+
+// CloseLog closes the debug log if it was opened
+func CloseLog() {
+	if debugLog != nil {
+		debugLog.Close()
+		debugLog = nil
+	}
+}
+
+// LogDir returns the directory --debug writes its daily logs to, ~/.bgit/logs
+func LogDir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, logDirName), nil
+}
+
+// openDebugLog opens ~/.bgit/logs/bgit-YYYYMMDD.log for appending on first use
+// Files are opened directly rather than through platform so opening the log
+// isn't itself logged
+func openDebugLog() *os.File {
+	if debugLog != nil {
+		return debugLog
+	}
+
+	dir, err := LogDir()
+	if err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("bgit-%s.log", time.Now().Format("20060102")))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil
+	}
+	debugLog = f
+	return debugLog
+}
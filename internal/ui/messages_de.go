@@ -0,0 +1,60 @@
+package ui
+
+func init() {
+	registerCatalog("de", map[string]string{
+		// Identity list
+		"No users configured yet.":                               "Noch keine Benutzer eingerichtet.",
+		"Add your first user with: bgit add":                     "Den ersten Benutzer hinzufügen mit: bgit add",
+		"Configured users:":                                      "Eingerichtete Benutzer:",
+		"No active user set. Use 'bgit use <alias>' to set one.": "Kein aktiver Benutzer. Mit 'bgit use <alias>' einen wählen.",
+		"No identities tagged '%s'.":                             "Keine Identitäten mit dem Tag '%s'.",
+
+		// Identity details (bgit list -v)
+		"GitHub:":                 "GitHub:",
+		"Host alias:":             "Host-Alias:",
+		"Tags:":                   "Tags:",
+		"SSH key:":                "SSH-Schlüssel:",
+		"Fingerprint:":            "Fingerabdruck:",
+		"In agent:":               "Im Agent:",
+		"Workspaces:":             "Workspaces:",
+		"Bindings:":               "Bindungen:",
+		"Last used:":              "Zuletzt verwendet:",
+		"(any key in %s)":         "(beliebiger Schlüssel in %s)",
+		"(none)":                  "(keiner)",
+		"(unreadable public key)": "(öffentlicher Schlüssel nicht lesbar)",
+		"agent not running":       "Agent läuft nicht",
+		"external agent (%s)":     "externer Agent (%s)",
+		"yes":                     "ja",
+		"no":                      "nein",
+		"never":                   "nie",
+		"just now":                "gerade eben",
+		"%d min ago":              "vor %d Min.",
+		"%d hours ago":            "vor %d Stunden",
+		"%d days ago":             "vor %d Tagen",
+
+		// Prompts
+		"Alias (e.g., work, personal, freelance):":                       "Alias (z. B. work, personal, freelance):",
+		"Short name for switching identities - use lowercase, no spaces": "Kurzname zum Wechseln der Identität – Kleinbuchstaben, keine Leerzeichen",
+		"Full name:": "Vollständiger Name:",
+		"Your full name for Git commits (e.g., John Doe)": "Dein vollständiger Name für Git-Commits (z. B. Max Mustermann)",
+		"Email address:": "E-Mail-Adresse:",
+		"Your email for Git commits (e.g., john@example.com)":                      "Deine E-Mail-Adresse für Git-Commits (z. B. max@example.com)",
+		"invalid email format":                                                     "ungültiges E-Mail-Format",
+		"GitHub username:":                                                         "GitHub-Benutzername:",
+		"Your GitHub username (e.g., johndoe)":                                     "Dein GitHub-Benutzername (z. B. maxmustermann)",
+		"How do you want to set up SSH key?":                                       "Wie soll der SSH-Schlüssel eingerichtet werden?",
+		"Generate new key pair (Recommended)":                                      "Neues Schlüsselpaar erzeugen (empfohlen)",
+		"Generate new FIDO2 security key (YubiKey, etc.)":                          "Neuen FIDO2-Sicherheitsschlüssel erzeugen (YubiKey usw.)",
+		"Import existing key":                                                      "Vorhandenen Schlüssel importieren",
+		"Skip for now (add manually later)":                                        "Vorerst überspringen (später manuell hinzufügen)",
+		"Path to existing SSH private key:":                                        "Pfad zum vorhandenen privaten SSH-Schlüssel:",
+		"Full path to your private key file (e.g., ~/.ssh/id_ed25519)":             "Vollständiger Pfad zur privaten Schlüsseldatei (z. B. ~/.ssh/id_ed25519)",
+		"Workspace folder:":                                                        "Workspace-Ordner:",
+		"Repositories cloned into this folder use the new identity automatically":  "In diesen Ordner geklonte Repositories verwenden automatisch die neue Identität",
+		"SSH key (also used to sign commits):":                                     "SSH-Schlüssel (signiert auch die Commits):",
+		"Path to the private key, or the public key if an external agent holds it": "Pfad zum privaten Schlüssel, oder zum öffentlichen, wenn ein externer Agent ihn hält",
+		"Config passphrase:":                                                       "Passphrase der Konfiguration:",
+		"Repeat passphrase:":                                                       "Passphrase wiederholen:",
+		"passphrases do not match":                                                 "die Passphrasen stimmen nicht überein",
+	})
+}
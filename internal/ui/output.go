@@ -2,57 +2,110 @@ package ui
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
 )
 
-// PrintUsersList prints the list of users in a formatted way
-func PrintUsersList(users []config.User, activeUser string) {
+// PrintUsersList prints the list of users in a formatted way, with the SHA256
+// fingerprint of each identity's key from fingerprints (keyed by alias)
+func PrintUsersList(users []config.User, activeUser string, fingerprints map[string]string) {
 	if len(users) == 0 {
-		fmt.Println("No users configured yet.")
-		fmt.Println("\nAdd your first user with: bgit add")
+		fmt.Println(T("No users configured yet."))
+		fmt.Println("\n" + T("Add your first user with: bgit add"))
 		return
 	}
 
-	fmt.Println("\nConfigured users:")
+	fmt.Println("\n" + T("Configured users:"))
 	fmt.Println()
 
 	for _, user := range users {
 		indicator := " "
 		if user.Alias == activeUser {
-			indicator = "→"
+			indicator = ActiveMarker()
 		}
 
-		fmt.Printf("%s %-20s %-30s %s\n",
+		fmt.Printf("%s %-20s %-30s %-24s %s\n",
 			indicator,
 			user.Alias,
 			user.Email,
 			user.Name,
+			fingerprints[user.Alias],
 		)
 	}
 
 	fmt.Println()
 	if activeUser == "" {
-		fmt.Println("No active user set. Use 'bgit use <alias>' to set one.")
+		fmt.Println(T("No active user set. Use 'bgit use <alias>' to set one."))
 	}
 }
 
 // Success prints a success message with checkmark
+// Success, Info, and Warning are silenced by --quiet; Error never is
 func Success(message string) {
-	fmt.Printf("✓ %s\n", message)
+	if level == LevelQuiet {
+		return
+	}
+	Printf("%s %s\n", Green("✓"), message)
 }
 
 // Error prints an error message
 func Error(message string) {
-	fmt.Printf("✗ %s\n", message)
+	Printf("%s %s\n", Red("✗"), message)
 }
 
 // Info prints an info message
 func Info(message string) {
-	fmt.Printf("ℹ %s\n", message)
+	if level == LevelQuiet {
+		return
+	}
+	Printf("%s %s\n", Cyan("ℹ"), message)
 }
 
 // Warning prints a warning message
 func Warning(message string) {
-	fmt.Printf("⚠ %s\n", message)
+	if level == LevelQuiet {
+		return
+	}
+	Printf("%s %s\n", Yellow("⚠"), message)
+}
+
+// Progress overwrites the current terminal line on stderr with a status message
+// Nothing is printed when stderr is not a terminal, so logs and pipes stay clean
+func Progress(message string) {
+	if ShowsProgress() {
+		fmt.Fprintf(os.Stderr, "\r\033[K%s", message)
+	}
+}
+
+// ShowsProgress reports whether Progress draws anything
+func ShowsProgress() bool {
+	return level > LevelQuiet && isTerminal(os.Stderr)
+}
+
+// ProgressBar renders a bar width cells wide, filled to percent
+func ProgressBar(percent, width int) string {
+	percent = max(0, min(percent, 100))
+	filled := width * percent / 100
+	return Text("[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]")
+}
+
+// ClearProgress erases the line written by Progress
+func ClearProgress() {
+	if isTerminal(os.Stderr) {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
+
+// IsInteractive reports whether prompts can be answered: stdin is a terminal
+// and bgit isn't running in CI
+func IsInteractive() bool {
+	return isTerminal(os.Stdin) && !platform.IsCI()
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
@@ -1,18 +1,33 @@
 package ui
 
 import (
-	"fmt"
+	"errors"
+	"os"
 	"regexp"
 
 	"github.com/AlecAivazis/survey/v2"
 )
 
+// ErrNotInteractive is returned by prompts when nobody can answer them
+var ErrNotInteractive = errors.New("cannot prompt: not running interactively (stdin is not a terminal or CI was detected)")
+
 // PromptUserInfo prompts for user information interactively
 func PromptUserInfo() (alias, name, email, githubUsername string, err error) {
+	return PromptUserInfoWithDefaults("", "", "", "")
+}
+
+// PromptUserInfoWithDefaults prompts for user information, suggesting the
+// given values (e.g. fetched from GitHub or set by a team preset)
+func PromptUserInfoWithDefaults(defaultAlias, defaultName, defaultEmail, defaultGitHub string) (alias, name, email, githubUsername string, err error) {
+	if !IsInteractive() {
+		return "", "", "", "", ErrNotInteractive
+	}
+
 	// Prompt for alias
 	aliasPrompt := &survey.Input{
-		Message: "Alias (e.g., work, personal, freelance):",
-		Help:    "Short name for switching identities - use lowercase, no spaces",
+		Message: T("Alias (e.g., work, personal, freelance):"),
+		Default: defaultAlias,
+		Help:    T("Short name for switching identities - use lowercase, no spaces"),
 	}
 	if err := survey.AskOne(aliasPrompt, &alias, survey.WithValidator(survey.Required)); err != nil {
 		return "", "", "", "", err
@@ -20,8 +35,9 @@ func PromptUserInfo() (alias, name, email, githubUsername string, err error) {
 
 	// Prompt for name
 	namePrompt := &survey.Input{
-		Message: "Full name:",
-		Help:    "Your full name for Git commits (e.g., John Doe)",
+		Message: T("Full name:"),
+		Default: defaultName,
+		Help:    T("Your full name for Git commits (e.g., John Doe)"),
 	}
 	if err := survey.AskOne(namePrompt, &name, survey.WithValidator(survey.Required)); err != nil {
 		return "", "", "", "", err
@@ -29,13 +45,14 @@ func PromptUserInfo() (alias, name, email, githubUsername string, err error) {
 
 	// Prompt for email
 	emailPrompt := &survey.Input{
-		Message: "Email address:",
-		Help:    "Your email for Git commits (e.g., john@example.com)",
+		Message: T("Email address:"),
+		Default: defaultEmail,
+		Help:    T("Your email for Git commits (e.g., john@example.com)"),
 	}
 	emailValidator := func(val interface{}) error {
 		if str, ok := val.(string); ok {
 			if !isValidEmail(str) {
-				return fmt.Errorf("invalid email format")
+				return errors.New(T("invalid email format"))
 			}
 		}
 		return nil
@@ -46,8 +63,9 @@ func PromptUserInfo() (alias, name, email, githubUsername string, err error) {
 
 	// Prompt for GitHub username
 	githubPrompt := &survey.Input{
-		Message: "GitHub username:",
-		Help:    "Your GitHub username (e.g., johndoe)",
+		Message: T("GitHub username:"),
+		Default: defaultGitHub,
+		Help:    T("Your GitHub username (e.g., johndoe)"),
 	}
 	if err := survey.AskOne(githubPrompt, &githubUsername, survey.WithValidator(survey.Required)); err != nil {
 		return "", "", "", "", err
@@ -56,29 +74,149 @@ func PromptUserInfo() (alias, name, email, githubUsername string, err error) {
 	return alias, name, email, githubUsername, nil
 }
 
-// PromptSSHKeyOption prompts for SSH key setup option
+// PromptImportedUser prompts for the alias and GitHub username of an identity
+// whose name and email came from git config, suggesting defaults
+func PromptImportedUser(defaultAlias, defaultGitHub string) (alias, githubUsername string, err error) {
+	if !IsInteractive() {
+		return "", "", ErrNotInteractive
+	}
+	aliasPrompt := &survey.Input{
+		Message: T("Alias (e.g., work, personal, freelance):"),
+		Default: defaultAlias,
+		Help:    T("Short name for switching identities - use lowercase, no spaces"),
+	}
+	if err := survey.AskOne(aliasPrompt, &alias, survey.WithValidator(survey.Required)); err != nil {
+		return "", "", err
+	}
+
+	githubPrompt := &survey.Input{
+		Message: T("GitHub username:"),
+		Default: defaultGitHub,
+		Help:    T("Your GitHub username (e.g., johndoe)"),
+	}
+	if err := survey.AskOne(githubPrompt, &githubUsername, survey.WithValidator(survey.Required)); err != nil {
+		return "", "", err
+	}
+
+	return alias, githubUsername, nil
+}
+
+// sshKeyOptions are the choices PromptSSHKeyOption offers, in English
+var sshKeyOptions = []string{
+	"Generate new key pair (Recommended)",
+	"Generate new FIDO2 security key (YubiKey, etc.)",
+	"Import existing key",
+	"Skip for now (add manually later)",
+}
+
+// IdentityFields are the values of an identity PromptIdentityEdit edits
+type IdentityFields struct {
+	Name           string
+	Email          string
+	GitHubUsername string
+	SSHKeyPath     string
+}
+
+// PromptIdentityEdit asks for an identity's name, email, GitHub username, and
+// SSH key, each pre-filled with its current value, and returns the answers
+func PromptIdentityEdit(current IdentityFields) (IdentityFields, error) {
+	if !IsInteractive() {
+		return IdentityFields{}, ErrNotInteractive
+	}
+	var edited IdentityFields
+
+	namePrompt := &survey.Input{
+		Message: T("Full name:"),
+		Default: current.Name,
+		Help:    T("Your full name for Git commits (e.g., John Doe)"),
+	}
+	if err := survey.AskOne(namePrompt, &edited.Name, survey.WithValidator(survey.Required)); err != nil {
+		return IdentityFields{}, err
+	}
+
+	emailPrompt := &survey.Input{
+		Message: T("Email address:"),
+		Default: current.Email,
+		Help:    T("Your email for Git commits (e.g., john@example.com)"),
+	}
+	emailValidator := func(val interface{}) error {
+		if str, ok := val.(string); ok && !isValidEmail(str) {
+			return errors.New(T("invalid email format"))
+		}
+		return nil
+	}
+	if err := survey.AskOne(emailPrompt, &edited.Email, survey.WithValidator(survey.Required), survey.WithValidator(emailValidator)); err != nil {
+		return IdentityFields{}, err
+	}
+
+	githubPrompt := &survey.Input{
+		Message: T("GitHub username:"),
+		Default: current.GitHubUsername,
+		Help:    T("Your GitHub username (e.g., johndoe)"),
+	}
+	if err := survey.AskOne(githubPrompt, &edited.GitHubUsername, survey.WithValidator(survey.Required)); err != nil {
+		return IdentityFields{}, err
+	}
+
+	keyPrompt := &survey.Input{
+		Message: T("SSH key (also used to sign commits):"),
+		Default: current.SSHKeyPath,
+		Help:    T("Path to the private key, or the public key if an external agent holds it"),
+	}
+	if err := survey.AskOne(keyPrompt, &edited.SSHKeyPath); err != nil {
+		return IdentityFields{}, err
+	}
+
+	return edited, nil
+}
+
+// PromptSSHKeyOption prompts for SSH key setup option. The options are shown
+// translated, but the English one is returned for callers to match.
 func PromptSSHKeyOption() (string, error) {
-	var choice string
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+	options := make([]string, len(sshKeyOptions))
+	for i, option := range sshKeyOptions {
+		options[i] = T(option)
+	}
+	var choice int
 	prompt := &survey.Select{
-		Message: "How do you want to set up SSH key?",
-		Options: []string{
-			"Generate new key pair (Recommended)",
-			"Import existing key",
-			"Skip for now (add manually later)",
-		},
+		Message: T("How do you want to set up SSH key?"),
+		Options: options,
 	}
 	if err := survey.AskOne(prompt, &choice); err != nil {
 		return "", err
 	}
-	return choice, nil
+	return sshKeyOptions[choice], nil
 }
 
 // PromptExistingKeyPath prompts for existing SSH key path
 func PromptExistingKeyPath() (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
 	var path string
 	prompt := &survey.Input{
-		Message: "Path to existing SSH private key:",
-		Help:    "Full path to your private key file (e.g., ~/.ssh/id_ed25519)",
+		Message: T("Path to existing SSH private key:"),
+		Help:    T("Full path to your private key file (e.g., ~/.ssh/id_ed25519)"),
+	}
+	if err := survey.AskOne(prompt, &path, survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// PromptWorkspacePath asks where to create a new identity's workspace folder
+func PromptWorkspacePath(defaultPath string) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+	var path string
+	prompt := &survey.Input{
+		Message: T("Workspace folder:"),
+		Default: defaultPath,
+		Help:    T("Repositories cloned into this folder use the new identity automatically"),
 	}
 	if err := survey.AskOne(prompt, &path, survey.WithValidator(survey.Required)); err != nil {
 		return "", err
@@ -88,6 +226,9 @@ func PromptExistingKeyPath() (string, error) {
 
 // PromptConfirmation prompts for yes/no confirmation
 func PromptConfirmation(message string) (bool, error) {
+	if !IsInteractive() {
+		return false, ErrNotInteractive
+	}
 	var confirmed bool
 	prompt := &survey.Confirm{
 		Message: message,
@@ -99,6 +240,34 @@ func PromptConfirmation(message string) (bool, error) {
 	return confirmed, nil
 }
 
+// PromptPassphrase prompts for the config passphrase without echoing it,
+// asking twice when confirm is set. It draws on stderr so it also works
+// under command substitution, e.g. in shell prompts.
+func PromptPassphrase(confirm bool) (string, error) {
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+	stdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+
+	var passphrase string
+	prompt := &survey.Password{Message: T("Config passphrase:")}
+	if err := survey.AskOne(prompt, &passphrase, survey.WithValidator(survey.Required), stdio); err != nil {
+		return "", err
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+
+	var again string
+	if err := survey.AskOne(&survey.Password{Message: T("Repeat passphrase:")}, &again, stdio); err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", errors.New(T("passphrases do not match"))
+	}
+	return passphrase, nil
+}
+
 // isValidEmail checks if email format is valid
 func isValidEmail(email string) bool {
 	// Simple email validation regex
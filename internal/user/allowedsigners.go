@@ -0,0 +1,156 @@
+package user
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+const (
+	signersManagedStart = "# ---- BEGIN BGIT MANAGED ----"
+	signersManagedEnd   = "# ---- END BGIT MANAGED ----"
+)
+
+// AllowedSignersPath returns the allowed_signers file bgit maintains,
+// under $XDG_CONFIG_HOME/git (default ~/.config/git) like git's own config
+func AllowedSignersPath() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "git", "allowed_signers"), nil
+}
+
+// AllowedSignerLine returns the allowed_signers entry for a user: every email
+// the user commits as, trusted for git signatures with their public key.
+// ok is false if the user has no readable public key.
+func AllowedSignerLine(u *config.User) (line string, ok bool) {
+	if u.SSHKeyPath == "" {
+		return "", false
+	}
+	content, err := GetPublicKeyContent(u.SSHKeyPath)
+	if err != nil {
+		return "", false
+	}
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		return "", false
+	}
+
+	principals := []string{u.Email}
+	for _, email := range []string{u.AuthorEmail, u.CommitterEmail} {
+		if email != "" && !containsString(principals, email) {
+			principals = append(principals, email)
+		}
+	}
+	return fmt.Sprintf("%s namespaces=\"git\" %s %s", strings.Join(principals, ","), fields[0], fields[1]), true
+}
+
+// WriteAllowedSigners rewrites the bgit-managed section of the allowed_signers
+// file with an entry per identity, keeping any entries outside it
+func WriteAllowedSigners(users []config.User) (string, error) {
+	path, err := AllowedSignersPath()
+	if err != nil {
+		return "", err
+	}
+
+	kept, err := unmanagedSigners(path)
+	if err != nil {
+		return "", err
+	}
+
+	var content strings.Builder
+	for _, line := range kept {
+		content.WriteString(line + "\n")
+	}
+	if len(kept) > 0 {
+		content.WriteString("\n")
+	}
+	content.WriteString(signersManagedStart + "\n")
+	for i := range users {
+		if line, ok := AllowedSignerLine(&users[i]); ok {
+			content.WriteString(line + "\n")
+		}
+	}
+	content.WriteString(signersManagedEnd + "\n")
+
+	if err := platform.MkdirSecure(filepath.Dir(path)); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := platform.CreateFileSecure(path, []byte(content.String())); err != nil {
+		return "", fmt.Errorf("failed to write allowed signers: %w", err)
+	}
+	return path, nil
+}
+
+// RemoveAllowedSigners removes the bgit-managed section from the
+// allowed_signers file, deleting the file if nothing else is left.
+// It returns the file's path and whether it was changed.
+func RemoveAllowedSigners() (string, bool, error) {
+	path, err := AllowedSignersPath()
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path, false, nil
+	}
+
+	kept, err := unmanagedSigners(path)
+	if err != nil {
+		return path, false, err
+	}
+	if len(kept) == 0 {
+		if err := os.Remove(path); err != nil {
+			return path, false, fmt.Errorf("failed to remove allowed signers: %w", err)
+		}
+		return path, true, nil
+	}
+	content := strings.Join(kept, "\n") + "\n"
+	if err := platform.CreateFileSecure(path, []byte(content)); err != nil {
+		return path, false, fmt.Errorf("failed to write allowed signers: %w", err)
+	}
+	return path, true, nil
+}
+
+// unmanagedSigners returns the non-empty lines of an allowed_signers file
+// outside the bgit-managed section
+func unmanagedSigners(path string) ([]string, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read allowed signers: %w", err)
+	}
+
+	var kept []string
+	inManaged := false
+	for _, line := range strings.Split(strings.TrimRight(string(existing), "\n"), "\n") {
+		switch strings.TrimSpace(line) {
+		case signersManagedStart:
+			inManaged = true
+			continue
+		case signersManagedEnd:
+			inManaged = false
+			continue
+		}
+		if !inManaged && line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return kept, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// credentialsDirName holds HTTPS tokens saved by 'bgit credential', one file
+// per GitHub account
+const credentialsDirName = "credentials"
+
+// GetCredentialsDir returns the directory holding saved HTTPS tokens
+func GetCredentialsDir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, credentialsDirName), nil
+}
+
+// LoadToken returns the saved HTTPS token for a GitHub account, or "" if none
+func LoadToken(githubUsername string) (string, error) {
+	dir, err := GetCredentialsDir()
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(filepath.Join(dir, githubUsername))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read saved token: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// SaveToken stores an HTTPS token for a GitHub account with owner-only
+// permissions. Like git's credential-store, the token is kept unencrypted.
+func SaveToken(githubUsername, token string) error {
+	dir, err := GetCredentialsDir()
+	if err != nil {
+		return err
+	}
+	if err := platform.MkdirSecure(dir); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+	path := filepath.Join(dir, githubUsername)
+	if err := platform.CreateFileSecure(path, []byte(token+"\n")); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+	return platform.FixFilePermissions(path)
+}
+
+// DeleteToken removes the saved HTTPS token for a GitHub account
+func DeleteToken(githubUsername string) error {
+	dir, err := GetCredentialsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, githubUsername)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete saved token: %w", err)
+	}
+	return nil
+}
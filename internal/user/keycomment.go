@@ -0,0 +1,100 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+	"golang.org/x/crypto/ssh"
+)
+
+// keyCommentSuffix marks comments written by 'bgit key comment'
+const keyCommentSuffix = " (bgit)"
+
+// CanonicalKeyComment returns the comment 'bgit key comment' gives an
+// identity's key: alias@hostname (bgit)
+func CanonicalKeyComment(alias string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "localhost"
+	}
+	// Keep the short name; domains make the comment long in ssh-add -l
+	host, _, _ = strings.Cut(host, ".")
+	return alias + "@" + host + keyCommentSuffix
+}
+
+// commentAlias returns the alias in a canonical key comment
+func commentAlias(comment string) (string, bool) {
+	if !strings.HasSuffix(comment, keyCommentSuffix) {
+		return "", false
+	}
+	alias, _, ok := strings.Cut(strings.TrimSuffix(comment, keyCommentSuffix), "@")
+	return alias, ok
+}
+
+// SetKeyComment rewrites the comment of a key pair. ssh-keygen updates both
+// files, asking on the terminal for the passphrase of a protected key. For
+// keys held by an external agent only the .pub file is rewritten.
+func SetKeyComment(keyPath, comment string) error {
+	if strings.HasSuffix(keyPath, ".pub") {
+		return setPublicKeyComment(keyPath, comment)
+	}
+
+	cmd := ui.Command(platform.GetSSHKeygenPath(), "-c", "-C", comment, "-f", keyPath).WithTimeout(0)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to change key comment: %w", err)
+	}
+
+	// ssh-keygen leaves the .pub alone when the private key format has no
+	// comment field (PEM); keep the two in step anyway
+	if _, current, err := ReadPublicKeyFile(keyPath + ".pub"); err == nil && current != comment {
+		return setPublicKeyComment(keyPath+".pub", comment)
+	}
+	return nil
+}
+
+// setPublicKeyComment rewrites the comment of a .pub file
+func setPublicKeyComment(path, comment string) error {
+	key, _, err := ReadPublicKeyFile(path)
+	if err != nil {
+		return err
+	}
+	line := bytes.TrimSpace(ssh.MarshalAuthorizedKey(key))
+	content := fmt.Sprintf("%s %s\n", line, comment)
+	ui.Debugf("write %s", path)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// CommentNames reports whether a public key comment names u: its email,
+// its GitHub username (as in the "<username>@bgit" comment of keys bgit
+// generates), or its alias in a comment written by 'bgit key comment'
+func CommentNames(comment string, u *config.User) bool {
+	if alias, ok := commentAlias(comment); ok {
+		return alias == u.Alias
+	}
+
+	comment = strings.ToLower(comment)
+	if u.Email != "" && strings.Contains(comment, strings.ToLower(u.Email)) {
+		return true
+	}
+	if u.GitHubUsername == "" {
+		return false
+	}
+	for _, token := range strings.FieldsFunc(comment, func(r rune) bool {
+		return !(r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if token == strings.ToLower(u.GitHubUsername) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/byterings/bgit/internal/ui"
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrPublicKeyUnknown is returned when the public half of a private key
+// can't be read without its passphrase (legacy encrypted PEM keys)
+var ErrPublicKeyUnknown = errors.New("public key can't be read from this private key without its passphrase")
+
+// opensshKeyMagic starts the body of an OpenSSH-format private key
+const opensshKeyMagic = "openssh-key-v1\x00"
+
+// PublicKeyFromPrivate reads the public key from a private key file. The
+// OpenSSH format stores it unencrypted, so this works for passphrase-
+// protected and FIDO2 keys without prompting.
+func PublicKeyFromPrivate(privateKeyPath string) (ssh.PublicKey, error) {
+	data, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	if block, _ := pem.Decode(data); block != nil && block.Type == "OPENSSH PRIVATE KEY" {
+		if key, err := opensshPublicKey(block.Bytes); err == nil {
+			return key, nil
+		}
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+	if err == nil {
+		return signer.PublicKey(), nil
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if missing.PublicKey != nil {
+			return missing.PublicKey, nil
+		}
+		return nil, ErrPublicKeyUnknown
+	}
+	return nil, fmt.Errorf("failed to parse private key: %w", err)
+}
+
+// opensshPublicKey reads the first public key from the header of an
+// OpenSSH private key: magic, cipher, KDF, KDF options, key count, keys
+func opensshPublicKey(body []byte) (ssh.PublicKey, error) {
+	if !bytes.HasPrefix(body, []byte(opensshKeyMagic)) {
+		return nil, errors.New("not an OpenSSH private key")
+	}
+	rest := body[len(opensshKeyMagic):]
+	for i := 0; i < 3; i++ {
+		if _, rest = readSSHString(rest); rest == nil {
+			return nil, errors.New("truncated OpenSSH private key")
+		}
+	}
+	if len(rest) < 4 || binary.BigEndian.Uint32(rest) < 1 {
+		return nil, errors.New("OpenSSH private key holds no keys")
+	}
+	blob, rest := readSSHString(rest[4:])
+	if rest == nil {
+		return nil, errors.New("truncated OpenSSH private key")
+	}
+	return ssh.ParsePublicKey(blob)
+}
+
+// readSSHString splits a length-prefixed string off data; rest is nil if
+// data is too short
+func readSSHString(data []byte) (value, rest []byte) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	n := binary.BigEndian.Uint32(data)
+	if uint64(len(data)-4) < uint64(n) {
+		return nil, nil
+	}
+	return data[4 : 4+n], data[4+n:]
+}
+
+// ReadPublicKeyFile parses a .pub file, returning the key and its comment
+func ReadPublicKeyFile(path string) (ssh.PublicKey, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	key, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return key, comment, nil
+}
+
+// KeyPairMismatchError reports a .pub file that isn't the public half of
+// its private key
+type KeyPairMismatchError struct {
+	PrivateKeyPath     string
+	PublicKeyPath      string
+	PrivateFingerprint string // SHA256 fingerprint of the private key
+	PublicFingerprint  string // SHA256 fingerprint of the key in the .pub file
+}
+
+func (e *KeyPairMismatchError) Error() string {
+	return fmt.Sprintf("%s does not match %s (private key is %s, .pub file has %s)",
+		e.PublicKeyPath, e.PrivateKeyPath, e.PrivateFingerprint, e.PublicFingerprint)
+}
+
+// VerifyKeyPair checks that privateKeyPath's .pub file holds its public key.
+// A missing .pub file is not an error. A private key whose public half can't
+// be read returns ErrPublicKeyUnknown, unless prompt allows asking
+// ssh-keygen to derive it, which needs the key's passphrase.
+func VerifyKeyPair(privateKeyPath string, prompt bool) error {
+	publicKeyPath := privateKeyPath + ".pub"
+	public, _, err := ReadPublicKeyFile(publicKeyPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	private, err := PublicKeyFromPrivate(privateKeyPath)
+	if errors.Is(err, ErrPublicKeyUnknown) && prompt {
+		private, err = derivePublicKey(privateKeyPath)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(private.Marshal(), public.Marshal()) {
+		return &KeyPairMismatchError{
+			PrivateKeyPath:     privateKeyPath,
+			PublicKeyPath:      publicKeyPath,
+			PrivateFingerprint: ssh.FingerprintSHA256(private),
+			PublicFingerprint:  ssh.FingerprintSHA256(public),
+		}
+	}
+	return nil
+}
+
+// derivePublicKey asks ssh-keygen for a private key's public key; it prompts
+// for the passphrase on the terminal
+func derivePublicKey(privateKeyPath string) (ssh.PublicKey, error) {
+	fmt.Printf("Enter the passphrase for %s to check its .pub file\n", privateKeyPath)
+	cmd := ui.Command("ssh-keygen", "-y", "-f", privateKeyPath).WithTimeout(0)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive public key: %w", err)
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse derived public key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,98 @@
+package user
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/git"
+	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
+)
+
+// SigningKeyBelongsTo reports whether the configured signing key belongs to a user.
+// known is false when ownership could not be determined (e.g. gpg not installed).
+func SigningKeyBelongsTo(sc git.SigningConfig, u *config.User) (belongs bool, known bool) {
+	if sc.Key == "" {
+		return false, false
+	}
+
+	switch sc.Format {
+	case "ssh":
+		return sshSigningKeyBelongsTo(sc.Key, u)
+	case "openpgp":
+		return gpgKeyBelongsTo(sc.Key, u.Email)
+	default:
+		return false, false
+	}
+}
+
+// sshSigningKeyBelongsTo compares an SSH signing key (literal or path) with the user's key pair
+func sshSigningKeyBelongsTo(key string, u *config.User) (bool, bool) {
+	if u.SSHKeyPath == "" {
+		return false, true
+	}
+
+	userPub, err := GetPublicKeyContent(u.SSHKeyPath)
+	if err != nil {
+		return false, false
+	}
+
+	var signingPub string
+	if strings.HasPrefix(key, "key::") {
+		signingPub = strings.TrimPrefix(key, "key::")
+	} else if strings.HasPrefix(key, "ssh-") || strings.HasPrefix(key, "ecdsa-") || strings.HasPrefix(key, "sk-") {
+		signingPub = key
+	} else {
+		path, err := platform.ExpandTilde(key)
+		if err != nil {
+			return false, false
+		}
+		if path == u.SSHKeyPath {
+			return true, true
+		}
+		if !strings.HasSuffix(path, ".pub") {
+			path += ".pub"
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false, false
+		}
+		signingPub = string(content)
+	}
+
+	return sameAuthorizedKey(signingPub, userPub), true
+}
+
+// sameAuthorizedKey compares the type and base64 blob of two authorized_keys lines, ignoring comments
+func sameAuthorizedKey(a, b string) bool {
+	fa := strings.Fields(a)
+	fb := strings.Fields(b)
+	if len(fa) < 2 || len(fb) < 2 {
+		return false
+	}
+	return fa[0] == fb[0] && fa[1] == fb[1]
+}
+
+// gpgKeyBelongsTo checks whether any uid on a GPG key carries the given email
+func gpgKeyBelongsTo(keyID, email string) (bool, bool) {
+	if !platform.HasCommand("gpg") {
+		return false, false
+	}
+
+	cmd := ui.Command("gpg", "--list-keys", "--with-colons", keyID)
+	output, err := cmd.Output()
+	if err != nil {
+		return false, false
+	}
+
+	want := strings.ToLower(fmt.Sprintf("<%s>", email))
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) > 9 && fields[0] == "uid" && strings.Contains(strings.ToLower(fields[9]), want) {
+			return true, true
+		}
+	}
+	return false, true
+}
@@ -4,12 +4,15 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/byterings/bgit/internal/platform"
+	"github.com/byterings/bgit/internal/ui"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -64,6 +67,7 @@ func GenerateSSHKey(username string) (privateKeyPath, publicKeyPath string, err
 
 	// Write public key
 	publicKeyBytes := ssh.MarshalAuthorizedKey(sshPubKey)
+	ui.Debugf("write %s", publicKeyPath)
 	if err := os.WriteFile(publicKeyPath, publicKeyBytes, 0644); err != nil {
 		return "", "", fmt.Errorf("failed to write public key: %w", err)
 	}
@@ -98,6 +102,11 @@ func ValidateSSHKeyPath(path string) error {
 		return fmt.Errorf("path is a directory, not a file: %s", path)
 	}
 
+	// Public keys (used with an external agent) are meant to be readable
+	if strings.HasSuffix(path, ".pub") {
+		return nil
+	}
+
 	// Check permissions (Unix only)
 	ok, err := platform.CheckFilePermissions(path)
 	if err != nil {
@@ -105,10 +114,20 @@ func ValidateSSHKeyPath(path string) error {
 	}
 	if !ok {
 		mode := info.Mode()
-		fmt.Printf("⚠ Warning: Key file has insecure permissions: %s\n", mode)
+		ui.Printf("⚠ Warning: Key file has insecure permissions: %s\n", mode)
 		fmt.Printf("  Run: %s\n", platform.GetPermissionFixCommand(path))
 	}
 
+	// A stale .pub gets uploaded to GitHub, which then rejects the private key
+	var mismatch *KeyPairMismatchError
+	err = VerifyKeyPair(path, ui.IsInteractive())
+	if errors.As(err, &mismatch) {
+		return fmt.Errorf("%w\n  Regenerate it with: ssh-keygen -y -f %s > %s", err, path, mismatch.PublicKeyPath)
+	}
+	if err != nil {
+		ui.Debugf("key pair check skipped for %s: %v", path, err)
+	}
+
 	return nil
 }
 
@@ -139,20 +158,241 @@ func GenerateSSHKeySystem(username string) (privateKeyPath, publicKeyPath string
 	}
 
 	// Use ssh-keygen to generate the key
-	cmd := exec.Command("ssh-keygen", "-t", "ed25519", "-f", privateKeyPath, "-N", "", "-C", username+"@bgit")
+	cmd := ui.Command("ssh-keygen", "-t", "ed25519", "-f", privateKeyPath, "-N", "", "-C", username+"@bgit")
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("failed to generate SSH key: %w", err)
+	}
+
+	return privateKeyPath, publicKeyPath, nil
+}
+
+// GenerateRotatedKey creates a replacement ed25519 key for username at
+// ~/.ssh/bgit_<username>_<YYYYMMDD>, leaving the current key in place
+func GenerateRotatedKey(username, comment string) (privateKeyPath, publicKeyPath string, err error) {
+	if !platform.HasCommand("ssh-keygen") {
+		return "", "", fmt.Errorf("ssh-keygen not found; it is needed to generate the new key")
+	}
+
+	sshDir, err := platform.GetSSHDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := platform.MkdirSecure(sshDir); err != nil {
+		return "", "", fmt.Errorf("failed to create .ssh directory: %w", err)
+	}
+
+	privateKeyPath = RotatedKeyPath(sshDir, username, time.Now())
+	publicKeyPath = privateKeyPath + ".pub"
+	if _, err := os.Stat(privateKeyPath); err == nil {
+		return "", "", fmt.Errorf("key already exists at %s", privateKeyPath)
+	}
+
+	cmd := ui.Command("ssh-keygen", "-t", "ed25519", "-f", privateKeyPath, "-N", "", "-C", comment)
 	if err := cmd.Run(); err != nil {
 		return "", "", fmt.Errorf("failed to generate SSH key: %w", err)
 	}
+	return privateKeyPath, publicKeyPath, nil
+}
+
+// RotatedKeyPath returns where GenerateRotatedKey writes a key made on day
+func RotatedKeyPath(sshDir, username string, day time.Time) string {
+	return filepath.Join(sshDir, fmt.Sprintf("bgit_%s_%s", username, day.Format("20060102")))
+}
+
+// SecurityKeyTypes are the FIDO2 key types ssh-keygen can generate
+var SecurityKeyTypes = []string{"ed25519-sk", "ecdsa-sk"}
+
+// IsSecurityKeyType reports whether keyType is a FIDO2 key type
+func IsSecurityKeyType(keyType string) bool {
+	for _, t := range SecurityKeyTypes {
+		if t == keyType {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSecurityKey generates a FIDO2 resident key backed by a hardware
+// authenticator. ssh-keygen talks to the user directly (touch the key, enter
+// its PIN), so it runs attached to the terminal. The key is stored on the
+// authenticator under ssh:bgit-<username>, so 'ssh-keygen -K' can restore the
+// key handle on another machine.
+func GenerateSecurityKey(username, keyType string) (privateKeyPath, publicKeyPath string, err error) {
+	if !IsSecurityKeyType(keyType) {
+		return "", "", fmt.Errorf("unsupported security key type '%s' (use %s)", keyType, strings.Join(SecurityKeyTypes, " or "))
+	}
+	if !platform.HasCommand("ssh-keygen") {
+		return "", "", fmt.Errorf("ssh-keygen not found; security keys need OpenSSH 8.2+")
+	}
+
+	sshDir, err := platform.GetSSHDir()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := platform.MkdirSecure(sshDir); err != nil {
+		return "", "", fmt.Errorf("failed to create .ssh directory: %w", err)
+	}
+
+	privateKeyPath = filepath.Join(sshDir, fmt.Sprintf("bgit_%s", username))
+	publicKeyPath = privateKeyPath + ".pub"
+
+	if _, err := os.Stat(privateKeyPath); err == nil {
+		return "", "", fmt.Errorf("key already exists at %s", privateKeyPath)
+	}
+
+	fmt.Println("Touch your security key when it blinks...")
+	cmd := ui.Command("ssh-keygen", "-t", keyType,
+		"-O", "resident",
+		"-O", "application=ssh:bgit-"+username,
+		"-f", privateKeyPath, "-N", "", "-C", username+"@bgit").WithTimeout(0)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("failed to generate security key: %w", err)
+	}
+
+	return privateKeyPath, publicKeyPath, nil
+}
+
+// AdoptKey copies an existing key pair to ~/.ssh/bgit_<username>, so the
+// identity owns a bgit-namespaced copy that other tooling won't rename or
+// delete. The private key is written with owner-only permissions; the source
+// files are left in place.
+func AdoptKey(sourcePath, username string) (privateKeyPath, publicKeyPath string, err error) {
+	sourcePath, err = platform.ExpandTilde(sourcePath)
+	if err != nil {
+		return "", "", err
+	}
+	sourcePath = strings.TrimSuffix(sourcePath, ".pub")
+
+	privateKey, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read private key: %w", err)
+	}
+	publicKey, err := os.ReadFile(sourcePath + ".pub")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	sshDir, err := platform.GetSSHDir()
+	if err != nil {
+		return "", "", err
+	}
+	if err := platform.MkdirSecure(sshDir); err != nil {
+		return "", "", fmt.Errorf("failed to create .ssh directory: %w", err)
+	}
+
+	privateKeyPath = filepath.Join(sshDir, fmt.Sprintf("bgit_%s", username))
+	publicKeyPath = privateKeyPath + ".pub"
+	if filepath.Clean(sourcePath) == privateKeyPath {
+		return "", "", fmt.Errorf("%s already follows bgit's naming", sourcePath)
+	}
+	if _, err := os.Stat(privateKeyPath); err == nil {
+		return "", "", fmt.Errorf("key already exists at %s", privateKeyPath)
+	}
+
+	if err := platform.CreateFileSecure(privateKeyPath, privateKey); err != nil {
+		return "", "", fmt.Errorf("failed to write private key: %w", err)
+	}
+	// CreateFileSecure leaves inherited ACLs on Windows
+	if err := platform.FixFilePermissions(privateKeyPath); err != nil {
+		return "", "", fmt.Errorf("failed to restrict private key permissions: %w", err)
+	}
+	ui.Debugf("write %s", publicKeyPath)
+	if err := os.WriteFile(publicKeyPath, publicKey, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write public key: %w", err)
+	}
 
 	return privateKeyPath, publicKeyPath, nil
 }
 
+// IsSecurityKey reports whether a key pair is backed by a FIDO2 authenticator
+func IsSecurityKey(privateKeyPath string) bool {
+	content, err := GetPublicKeyContent(privateKeyPath)
+	if err != nil {
+		return false
+	}
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content))
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(pubKey.Type(), "sk-")
+}
+
+// keyTypeNames maps ssh public key types to the names bgit accepts for --key-type
+var keyTypeNames = map[string]string{
+	"ssh-ed25519":                        "ed25519",
+	"sk-ssh-ed25519@openssh.com":         "ed25519-sk",
+	"sk-ecdsa-sha2-nistp256@openssh.com": "ecdsa-sk",
+}
+
+// KeyType returns a key pair's type as a --key-type name (ed25519,
+// ed25519-sk, ecdsa-sk), or the ssh type name for other keys (e.g. ssh-rsa)
+func KeyType(privateKeyPath string) (string, error) {
+	content, err := GetPublicKeyContent(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+	if name, ok := keyTypeNames[pubKey.Type()]; ok {
+		return name, nil
+	}
+	return pubKey.Type(), nil
+}
+
 // GetPublicKeyContent reads and returns the public key content
 func GetPublicKeyContent(privateKeyPath string) (string, error) {
-	publicKeyPath := privateKeyPath + ".pub"
-	content, err := os.ReadFile(publicKeyPath)
+	content, err := os.ReadFile(PublicKeyPath(privateKeyPath))
 	if err != nil {
 		return "", fmt.Errorf("failed to read public key: %w", err)
 	}
 	return string(content), nil
 }
+
+// PublicKeyPath returns the public key for a key path, which may already be
+// a public key (identities whose private key lives in an external agent)
+func PublicKeyPath(keyPath string) string {
+	if strings.HasSuffix(keyPath, ".pub") {
+		return keyPath
+	}
+	return keyPath + ".pub"
+}
+
+// GetFingerprint returns the SHA256 fingerprint of a key pair, as GitHub
+// shows it on the SSH keys settings page. It is read from the private key,
+// which is what authenticates, falling back to the .pub file.
+func GetFingerprint(privateKeyPath string) (string, error) {
+	if !strings.HasSuffix(privateKeyPath, ".pub") {
+		if key, err := PublicKeyFromPrivate(privateKeyPath); err == nil {
+			return ssh.FingerprintSHA256(key), nil
+		}
+	}
+
+	content, err := GetPublicKeyContent(privateKeyPath)
+	if err != nil {
+		return "", err
+	}
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return ssh.FingerprintSHA256(pubKey), nil
+}
+
+// ChangePassphrase runs ssh-keygen -p on a private key, which asks on the
+// terminal for the old passphrase and the new one (empty removes it)
+func ChangePassphrase(privateKeyPath string) error {
+	cmd := ui.Command(platform.GetSSHKeygenPath(), "-p", "-f", privateKeyPath).WithTimeout(0)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to change passphrase: %w", err)
+	}
+	return nil
+}
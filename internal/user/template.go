@@ -0,0 +1,80 @@
+package user
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/byterings/bgit/internal/config"
+	"github.com/byterings/bgit/internal/platform"
+)
+
+// templatesDirName holds the commit templates bgit generates, one per identity
+const templatesDirName = "templates"
+
+// GetTemplatesDir returns the directory holding generated commit templates
+func GetTemplatesDir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, templatesDirName), nil
+}
+
+// IsManagedTemplate reports whether a commit.template value is one bgit generated
+func IsManagedTemplate(path string) bool {
+	dir, err := GetTemplatesDir()
+	if err != nil || path == "" {
+		return false
+	}
+	expanded, err := platform.ExpandTilde(path)
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(filepath.Clean(expanded)) == filepath.Clean(dir)
+}
+
+// WriteCommitTemplate generates the commit template for a user: their
+// template file (if any) followed by their trailers. It returns the path
+// to use as commit.template, or "" if the user has neither.
+func WriteCommitTemplate(u *config.User) (string, error) {
+	if !u.HasCommitTemplate() {
+		return "", nil
+	}
+
+	var content strings.Builder
+	if u.CommitTemplate != "" {
+		path, err := platform.ExpandTilde(u.CommitTemplate)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read commit template: %w", err)
+		}
+		content.WriteString(strings.TrimRight(string(data), "\n"))
+		content.WriteString("\n")
+	}
+	if len(u.Trailers) > 0 {
+		// Leave a blank line above the trailers for the subject and body
+		content.WriteString("\n")
+		for _, trailer := range u.Trailers {
+			content.WriteString(trailer + "\n")
+		}
+	}
+
+	dir, err := GetTemplatesDir()
+	if err != nil {
+		return "", err
+	}
+	if err := platform.MkdirSecure(dir); err != nil {
+		return "", fmt.Errorf("failed to create templates directory: %w", err)
+	}
+
+	path := filepath.Join(dir, u.Alias+".txt")
+	if err := platform.CreateFileSecure(path, []byte(content.String())); err != nil {
+		return "", fmt.Errorf("failed to write commit template: %w", err)
+	}
+	return path, nil
+}